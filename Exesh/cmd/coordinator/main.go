@@ -6,6 +6,8 @@ import (
 	executeAPI "exesh/internal/api/execute"
 	heartbeatAPI "exesh/internal/api/heartbeat"
 	messagesAPI "exesh/internal/api/messages"
+	streamAPI "exesh/internal/api/stream"
+	workersAPI "exesh/internal/api/workers"
 	"exesh/internal/calculator"
 	"exesh/internal/config"
 	"exesh/internal/dispatcher"
@@ -16,6 +18,7 @@ import (
 	executeUC "exesh/internal/usecase/execute"
 	heartbeatUC "exesh/internal/usecase/heartbeat"
 	messagesUC "exesh/internal/usecase/messages"
+	workersUC "exesh/internal/usecase/workers"
 	"fmt"
 	flog "log"
 	"log/slog"
@@ -50,7 +53,7 @@ func main() {
 
 	mux := chi.NewRouter()
 
-	unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, eventStorage, err := setupStorage(log, cfg.Storage)
+	unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, tenantUsageStorage, eventStorage, err := setupStorage(log, cfg.Storage, cfg.Calculator)
 	if err != nil {
 		log.Error("failed to setup storage", slog.String("error", err.Error()))
 		return
@@ -68,7 +71,14 @@ func main() {
 	workerPool.StartObserver(ctx)
 
 	filestorageAdapter := adapter.NewFilestorageAdapter(fs)
-	calc := calculator.NewCalculator(categoryHistogramStorage)
+	estimator, err := calculator.NewEstimator(cfg.Calculator, categoryHistogramStorage)
+	if err != nil {
+		log.Error("failed to create estimator", slog.String("error", err.Error()))
+		return
+	}
+	calc := calculator.NewCalculator(estimator)
+	histogramRecorder := calculator.NewHistogramRecorder(log, cfg.Calculator.PriorFlushInterval, unitOfWork, categoryHistogramStorage)
+	histogramRecorder.Start(ctx)
 	executionFactory := factory.NewExecutionFactory(cfg.JobFactory, filestorageAdapter, calc)
 
 	messageFactory := factory.NewMessageFactory()
@@ -83,7 +93,7 @@ func main() {
 	promCoordinatorRegistry := prometheus.WrapRegistererWithPrefix("coduels_exesh_coordinator_", promRegistry)
 
 	executionScheduler := schedule.NewExecutionScheduler(log, cfg.ExecutionScheduler,
-		unitOfWork, executionStorage, categoryHistogramStorage,
+		unitOfWork, executionStorage, histogramRecorder, messageStorage,
 		executionFactory, workerPool, messageFactory, messageDispatcher, eventStorage)
 	jobScheduler := schedule.NewJobScheduler(log, cfg.JobScheduler, workerPool, executionScheduler, eventStorage)
 
@@ -92,17 +102,24 @@ func main() {
 		return
 	}
 
-	executionScheduler.Start(ctx)
-
-	executeUseCase := executeUC.NewUseCase(log, unitOfWork, executionStorage, calc)
+	executeUseCase := executeUC.NewUseCase(log, cfg.Admission, unitOfWork, executionStorage, tenantUsageStorage, messageStorage, calc, executionFactory)
 	executeAPI.NewHandler(log, executeUseCase).Register(mux)
 
 	heartbeatUseCase := heartbeatUC.NewUseCase(log, workerPool, jobScheduler)
 	heartbeatAPI.NewHandler(log, heartbeatUseCase).Register(mux)
 
+	streamHandler := streamAPI.NewHandler(log, cfg.Stream, heartbeatUseCase)
+	jobScheduler.OnJobsReady(streamHandler.Wake)
+	streamHandler.Register(mux)
+
+	executionScheduler.Start(ctx)
+
 	messagesUseCase := messagesUC.NewUseCase(log, unitOfWork, messageStorage)
 	messagesAPI.NewHandler(log, messagesUseCase).Register(mux)
 
+	workersUseCase := workersUC.NewUseCase(log, cfg.WorkerPool.DrainAPIKeys, workerPool)
+	workersAPI.NewHandler(log, workersUseCase).Register(mux)
+
 	log.Info("starting server", slog.String("address", cfg.HttpServer.Addr))
 
 	stop := make(chan os.Signal, 1)
@@ -156,12 +173,13 @@ func setupLogger(env string) (log *slog.Logger, err error) {
 	return log, err
 }
 
-func setupStorage(log *slog.Logger, cfg config.StorageConfig) (
+func setupStorage(log *slog.Logger, cfg config.StorageConfig, calculatorCfg config.CalculatorConfig) (
 	unitOfWork *postgres.UnitOfWork,
 	executionStorage *postgres.ExecutionStorage,
 	outboxStorage *postgres.OutboxStorage,
 	messageStorage *postgres.MessageStorage,
 	categoryHistogramStorage *postgres.CategoryHistogramStorage,
+	tenantUsageStorage *postgres.TenantUsageStorage,
 	eventStorage *postgres.SchedulerEventStorage,
 	err error,
 ) {
@@ -171,7 +189,7 @@ func setupStorage(log *slog.Logger, cfg config.StorageConfig) (
 	unitOfWork, err = postgres.NewUnitOfWork(cfg)
 	if err != nil {
 		err = fmt.Errorf("failed to create unit of work: %w", err)
-		return unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, eventStorage, err
+		return unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, tenantUsageStorage, eventStorage, err
 	}
 
 	err = unitOfWork.Do(ctx, func(ctx context.Context) error {
@@ -184,16 +202,19 @@ func setupStorage(log *slog.Logger, cfg config.StorageConfig) (
 		if messageStorage, err = postgres.NewMessageStorage(ctx, log); err != nil {
 			return fmt.Errorf("failed to create message storage: %w", err)
 		}
-		if categoryHistogramStorage, err = postgres.NewCategoryHistogramStorage(ctx, log); err != nil {
+		if categoryHistogramStorage, err = postgres.NewCategoryHistogramStorage(ctx, log, calculatorCfg.HalfLife); err != nil {
 			return fmt.Errorf("failed to create category histogram storage: %w", err)
 		}
+		if tenantUsageStorage, err = postgres.NewTenantUsageStorage(ctx, log); err != nil {
+			return fmt.Errorf("failed to create tenant usage storage: %w", err)
+		}
 		return nil
 	})
 	if err != nil {
-		return unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, eventStorage, err
+		return unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, tenantUsageStorage, eventStorage, err
 	}
 
 	eventStorage, err = postgres.NewSchedulerEventStorage(ctx, log, unitOfWork.DB())
 
-	return unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, eventStorage, err
+	return unitOfWork, executionStorage, outboxStorage, messageStorage, categoryHistogramStorage, tenantUsageStorage, eventStorage, err
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"exesh/internal/calculator"
+	"exesh/internal/config"
+	"exesh/internal/storage/postgres"
+	"flag"
+	"fmt"
+	flog "log"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// estimator-eval replays the jobs measured by the coordinator through every estimator
+// and reports how well each of them predicts the time and memory the jobs used.
+// It reads the coordinator config from CONFIG_PATH, the flags override the estimator settings.
+func main() {
+	cfg := config.MustLoadCoordinatorConfig()
+
+	period := flag.Duration("period", 7*24*time.Hour, "replay the jobs measured during this period")
+	flag.DurationVar(&cfg.Calculator.HalfLife, "half-life", cfg.Calculator.HalfLife, "half-life of the observations")
+	flag.Float64Var(&cfg.Calculator.Quantile.TimeQuantile, "time-quantile", cfg.Calculator.Quantile.TimeQuantile, "time quantile of the quantile estimator")
+	flag.Float64Var(&cfg.Calculator.Quantile.MemoryQuantile, "memory-quantile", cfg.Calculator.Quantile.MemoryQuantile, "memory quantile of the quantile estimator")
+	flag.Float64Var(&cfg.Calculator.Quantile.PriorWeight, "prior-weight", cfg.Calculator.Quantile.PriorWeight, "weight of the prior of the quantile estimator")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Storage.InitTimeout+time.Minute)
+	defer cancel()
+
+	unitOfWork, err := postgres.NewUnitOfWork(cfg.Storage)
+	if err != nil {
+		flog.Fatalf("failed to create unit of work: %v", err)
+	}
+	eventStorage, err := postgres.NewSchedulerEventStorage(ctx, log, unitOfWork.DB())
+	if err != nil {
+		flog.Fatalf("failed to create scheduler event storage: %v", err)
+	}
+
+	samples, err := eventStorage.GetJobSamples(ctx, time.Now().Add(-*period))
+	if err != nil {
+		flog.Fatalf("failed to get job samples: %v", err)
+	}
+	log.Info("replaying job samples", slog.Int("samples", len(samples)))
+
+	estimators := map[string]calculator.Estimator{
+		calculator.MaxMedianEstimatorName: calculator.NewMaxMedianEstimator(nil),
+		calculator.QuantileEstimatorName:  calculator.NewQuantileEstimator(cfg.Calculator.Quantile, nil),
+	}
+	reports := calculator.Replay(samples, estimators, cfg.Calculator.HalfLife)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "estimator\tsamples\ttime under\ttime over\tmemory under\tmemory over\tpacking")
+	for _, report := range reports {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%.2fx\t%.1f%%\t%.2fx\t%.1f%%\n",
+			report.Estimator,
+			report.Samples,
+			100*report.TimeUnderestimated,
+			report.TimeOverestimation,
+			100*report.MemoryUnderestimated,
+			report.MemoryOverestimation,
+			100*report.PackingEfficiency,
+		)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"exesh/internal/config"
+	"exesh/internal/simulator"
+	"exesh/internal/storage/postgres"
+	"flag"
+	"fmt"
+	flog "log"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// simulate runs the coordinator schedulers on a virtual clock against simulated workers,
+// so that the scheduler settings and the capacity can be tuned before deploying them.
+// It reads the coordinator config from CONFIG_PATH, the flags override the scheduler settings.
+// The workload is either synthetic or recorded from the job events of the coordinator database.
+func main() {
+	cfg := config.MustLoadCoordinatorConfig()
+
+	simCfg := simulator.Config{
+		ExecutionScheduler: cfg.ExecutionScheduler,
+		JobScheduler:       cfg.JobScheduler,
+		WorkerPool:         cfg.WorkerPool,
+	}
+	synthetic := simulator.SyntheticConfig{}
+
+	workloadKind := flag.String("workload", "synthetic", "workload to simulate: synthetic or recorded")
+	period := flag.Duration("period", 24*time.Hour, "replay the jobs recorded during this period for the recorded workload")
+	verbose := flag.Bool("v", false, "log the schedulers")
+
+	flag.IntVar(&simCfg.Workers, "workers", 4, "number of simulated workers")
+	flag.IntVar(&simCfg.WorkerSlots, "slots", 2, "slots of a worker")
+	flag.IntVar(&simCfg.WorkerMemory, "memory", 1024, "memory of a worker in megabytes")
+	flag.DurationVar(&simCfg.HeartbeatInterval, "heartbeat", 100*time.Millisecond, "heartbeat interval of the workers")
+	flag.DurationVar(&simCfg.MaxDuration, "max-duration", 24*time.Hour, "stop the simulation after this virtual time")
+	flag.IntVar(&simCfg.JobScheduler.PromisedJobsLimit, "promised-jobs-limit", cfg.JobScheduler.PromisedJobsLimit, "promised jobs limit of the job scheduler")
+	flag.Int64Var(&simCfg.ExecutionScheduler.Capacity, "capacity", cfg.ExecutionScheduler.Capacity, "capacity of the execution scheduler")
+
+	flag.IntVar(&synthetic.Executions, "executions", 100, "number of synthetic executions")
+	flag.Float64Var(&synthetic.Rate, "rate", 1, "synthetic executions submitted per second")
+	flag.IntVar(&synthetic.MinJobs, "min-jobs", 10, "minimal number of jobs of a synthetic execution")
+	flag.IntVar(&synthetic.MaxJobs, "max-jobs", 50, "maximal number of jobs of a synthetic execution")
+	flag.IntVar(&synthetic.MeanTime, "mean-time", 300, "mean expected time of a synthetic job in milliseconds")
+	flag.IntVar(&synthetic.MeanMemory, "mean-memory", 64, "mean expected memory of a synthetic job in megabytes")
+	flag.IntVar(&synthetic.TimeLimit, "time-limit", 2000, "time limit of a synthetic job in milliseconds")
+	flag.IntVar(&synthetic.MemoryLimit, "memory-limit", 256, "memory limit of a synthetic job in megabytes")
+	flag.Float64Var(&synthetic.Spread, "spread", 0.3, "spread of the actual synthetic job time and memory around the expected ones")
+	flag.Uint64Var(&synthetic.Seed, "seed", 1, "seed of the synthetic workload")
+	flag.Parse()
+
+	level := slog.LevelError
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	ctx := context.Background()
+
+	var workload simulator.Workload
+	switch *workloadKind {
+	case "synthetic":
+		workload = simulator.SyntheticWorkload(synthetic)
+	case "recorded":
+		var err error
+		if workload, err = loadRecordedWorkload(ctx, log, cfg.Storage, *period); err != nil {
+			flog.Fatalf("failed to load recorded workload: %v", err)
+		}
+	default:
+		flog.Fatalf("unknown workload %s", *workloadKind)
+	}
+
+	report, err := simulator.New(log, simCfg).Run(ctx, workload)
+	if err != nil {
+		flog.Fatalf("failed to run simulation: %v", err)
+	}
+
+	printReport(report)
+}
+
+func loadRecordedWorkload(ctx context.Context, log *slog.Logger, cfg config.StorageConfig, period time.Duration) (simulator.Workload, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.InitTimeout+time.Minute)
+	defer cancel()
+
+	unitOfWork, err := postgres.NewUnitOfWork(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create unit of work: %w", err)
+	}
+	eventStorage, err := postgres.NewSchedulerEventStorage(ctx, log, unitOfWork.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduler event storage: %w", err)
+	}
+
+	events, err := eventStorage.GetJobEvents(ctx, []string{"finished", "measured"}, time.Now().Add(-period))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job events: %w", err)
+	}
+
+	return simulator.RecordedWorkload(events), nil
+}
+
+func printReport(report simulator.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "simulated time\t%s\n", report.Duration)
+	_, _ = fmt.Fprintf(w, "executions\t%d submitted, %d finished, %d failed, %d expired\n",
+		report.Submitted, report.Finished, report.Failed, report.Expired)
+	_, _ = fmt.Fprintf(w, "queue latency\t%s\n", formatPercentiles(report.QueueLatency))
+	_, _ = fmt.Fprintf(w, "execution latency\t%s\n", formatPercentiles(report.ExecutionLatency))
+	_, _ = fmt.Fprintf(w, "slot utilisation\t%.1f%%\n", 100*report.SlotUtilisation)
+	_, _ = fmt.Fprintf(w, "memory utilisation\t%.1f%% used, %.1f%% reserved\n",
+		100*report.MemoryUtilisation, 100*report.ReservedMemoryUtilisation)
+	_, _ = fmt.Fprintf(w, "promised jobs\t%d, %.1f%% late\n", report.PromisedJobs, 100*report.PromisesLate)
+	_, _ = fmt.Fprintf(w, "promise error\t%s\n", formatPercentiles(report.PromiseError))
+	_ = w.Flush()
+}
+
+func formatPercentiles(p simulator.Percentiles) string {
+	return fmt.Sprintf("p50 %s, p90 %s, p99 %s, max %s", p.P50, p.P90, p.P99, p.Max)
+}
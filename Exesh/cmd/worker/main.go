@@ -5,6 +5,7 @@ import (
 	"errors"
 	"exesh/internal/config"
 	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/placement"
 	"exesh/internal/executor"
 	"exesh/internal/executor/executors"
 	"exesh/internal/provider"
@@ -19,7 +20,10 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/DIvanCode/filestorage/pkg/filestorage"
 	"github.com/go-chi/chi/v5"
@@ -59,9 +63,11 @@ func main() {
 	sourceProvider := provider.NewSourceProvider(cfg.SourceProvider, filestorageAdapter)
 	outputProvider := provider.NewOutputProvider(cfg.OutputProvider, filestorageAdapter)
 
-	executorFactory := setupExecutorFactory(log, sourceProvider, outputProvider)
+	toolchainRegistry := executor.NewToolchainRegistry(cfg.Worker.Toolchains)
+	executorFactory := setupExecutorFactory(log, sourceProvider, outputProvider, toolchainRegistry)
 
-	worker.NewWorker(log, cfg.Worker, sourceProvider, executorFactory).Start(ctx)
+	wrk := worker.NewWorker(log, cfg.Worker, setupLabels(cfg), sourceProvider, executorFactory)
+	wrk.Start(ctx)
 
 	promRegistry := prometheus.NewRegistry()
 	promRegistry.MustRegister(
@@ -96,7 +102,19 @@ func main() {
 
 	log.Info("server started")
 
-	<-stop
+	select {
+	case <-stop:
+		log.Info("draining worker", slog.Duration("timeout", cfg.Worker.DrainTimeout))
+		wrk.Drain()
+		select {
+		case <-wrk.Drained():
+		case <-time.After(cfg.Worker.DrainTimeout):
+			log.Warn("worker drain timed out")
+		}
+	case <-wrk.Drained():
+		log.Info("worker decommissioned by coordinator")
+	}
+
 	log.Info("stopping server")
 
 	if err := errors.Join(srv.Shutdown(ctx), msrv.Shutdown(ctx)); err != nil {
@@ -122,17 +140,46 @@ func setupLogger(env string) (log *slog.Logger, err error) {
 	return log, err
 }
 
+func setupLabels(cfg *config.WorkerConfig) placement.Labels {
+	labels := make(placement.Labels, len(cfg.Worker.Labels)+2)
+	for key, values := range cfg.Worker.Labels {
+		labels[key] = slices.Clone(values)
+	}
+	if cfg.Runtime != "" {
+		labels[placement.LabelRuntime] = []string{cfg.Runtime}
+	}
+	if cpu := detectCPUModel(); cpu != "" {
+		labels[placement.LabelCPU] = []string{cpu}
+	}
+	return labels
+}
+
+func detectCPUModel() string {
+	content, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
 func setupExecutorFactory(
 	log *slog.Logger,
 	sourceProvider *provider.SourceProvider,
 	outputProvider *provider.OutputProvider,
+	toolchainRegistry *executor.ToolchainRegistry,
 ) *executor.ExecutorFactory {
 	localRuntimeFactory := local.NewRuntimeFactory(job.CompileCpp, job.CompileGo)
 	isolateRuntimeFactory := isolate.NewRuntimeFactory(job.RunCpp, job.RunPy, job.RunGo, job.CheckCpp)
 	runtimeFactory := runtime.NewJobRuntimeFactory(localRuntimeFactory, isolateRuntimeFactory)
 
-	compileCppExecutorFactory := executors.NewCompileCppExecutorFactory(log, sourceProvider, outputProvider, localRuntimeFactory)
-	compileGoExecutorFactory := executors.NewCompileGoExecutorFactory(log, sourceProvider, outputProvider, localRuntimeFactory)
+	compileCppExecutorFactory := executors.NewCompileCppExecutorFactory(log, sourceProvider, outputProvider, localRuntimeFactory, toolchainRegistry)
+	compileGoExecutorFactory := executors.NewCompileGoExecutorFactory(log, sourceProvider, outputProvider, localRuntimeFactory, toolchainRegistry)
 	runCppExecutorFactory := executors.NewRunCppExecutorFactory(log, sourceProvider, outputProvider, isolateRuntimeFactory)
 	runPyExecutorFactory := executors.NewRunPyExecutorFactory(log, sourceProvider, outputProvider, isolateRuntimeFactory)
 	runGoExecutorFactory := executors.NewRunGoExecutorFactory(log, sourceProvider, outputProvider, isolateRuntimeFactory)
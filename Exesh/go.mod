@@ -4,6 +4,7 @@ go 1.24.0
 
 require (
 	github.com/DIvanCode/filestorage v1.7.1
+	github.com/coder/websocket v1.8.14
 	github.com/go-chi/chi/v5 v5.2.3
 	github.com/go-chi/render v1.0.3
 	github.com/google/uuid v1.6.0
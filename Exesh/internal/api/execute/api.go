@@ -4,16 +4,30 @@ import (
 	"exesh/internal/api"
 	"exesh/internal/domain/execution"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
+	"time"
 )
 
 type (
 	Request struct {
 		Sources sources.Definitions        `json:"sources"`
 		Stages  execution.StageDefinitions `json:"stages"`
+
+		Constraints placement.Constraints `json:"constraints,omitempty"`
+
+		PriorityClass execution.PriorityClass `json:"priority_class,omitempty"`
+		Tenant        string                  `json:"tenant,omitempty"`
+
+		Deadline *time.Time `json:"deadline,omitempty"`
+		MaxTries int        `json:"max_tries,omitempty"`
+
+		IdempotencyKey string `json:"idempotency_key,omitempty"`
 	}
 
 	Response struct {
 		api.Response
-		ExecutionID *execution.ID `json:"execution_id,omitempty"`
+		ExecutionID       *execution.ID `json:"execution_id,omitempty"`
+		Deduplicated      bool          `json:"deduplicated,omitempty"`
+		RetryAfterSeconds *int          `json:"retry_after_seconds,omitempty"`
 	}
 )
@@ -2,10 +2,14 @@ package execute
 
 import (
 	"encoding/json"
+	"errors"
 	"exesh/internal/api"
+	"exesh/internal/domain/tenant"
 	"exesh/internal/usecase/execute"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -23,6 +27,8 @@ func NewHandler(log *slog.Logger, uc *execute.UseCase) *Handler {
 	}
 }
 
+const apiKeyHeader = "X-Api-Key"
+
 func (h *Handler) Register(r chi.Router) {
 	r.Post("/execute", h.Handle)
 }
@@ -36,8 +42,40 @@ func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	command := execute.Command{Sources: req.Sources, Stages: req.Stages}
+	command := execute.Command{
+		Sources:       req.Sources,
+		Stages:        req.Stages,
+		Constraints:   req.Constraints,
+		PriorityClass: req.PriorityClass,
+		Tenant:        req.Tenant,
+		Deadline:      req.Deadline,
+		MaxTries:      req.MaxTries,
+		APIKey:        r.Header.Get(apiKeyHeader),
+
+		IdempotencyKey: req.IdempotencyKey,
+	}
 	result, err := h.uc.Execute(r.Context(), command)
+	if errors.Is(err, execute.ErrInvalidCommand) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	if errors.Is(err, execute.ErrUnauthorized) {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+	var quotaErr *tenant.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		retryAfter := int(math.Ceil(quotaErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, Response{
+			Response:          api.Error(quotaErr.Error()),
+			RetryAfterSeconds: &retryAfter,
+		})
+		return
+	}
 	if err != nil {
 		h.log.Error("failed to execute", slog.Any("err", err))
 		render.Status(r, http.StatusInternalServerError)
@@ -52,8 +90,9 @@ func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 
 func okResponse(result execute.Result) Response {
 	return Response{
-		Response:    api.OK(),
-		ExecutionID: &result.ExecutionID,
+		Response:     api.OK(),
+		ExecutionID:  &result.ExecutionID,
+		Deduplicated: result.Deduplicated,
 	}
 }
 
@@ -2,9 +2,11 @@ package heartbeat
 
 import (
 	"exesh/internal/api"
+	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
 	"exesh/internal/domain/execution/result/results"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
 )
 
 type (
@@ -15,11 +17,17 @@ type (
 		TotalMemory     int              `json:"total_memory_mb"`
 		FreeSlots       int              `json:"free_slots"`
 		AvailableMemory int              `json:"available_memory_mb"`
+		Toolchains      []string         `json:"toolchains,omitempty"`
+		Labels          placement.Labels `json:"labels,omitempty"`
+		Draining        bool             `json:"draining,omitempty"`
+		ReturnedJobs    []job.ID         `json:"returned_jobs,omitempty"`
+		Deregister      bool             `json:"deregister,omitempty"`
 	}
 
 	Response struct {
 		api.Response
 		Jobs    []jobs.Job       `json:"jobs,omitempty"`
 		Sources []sources.Source `json:"sources,omitempty"`
+		Drain   bool             `json:"drain,omitempty"`
 	}
 )
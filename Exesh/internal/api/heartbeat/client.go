@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"exesh/internal/api"
+	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
 	"exesh/internal/domain/execution/result/results"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
 	"fmt"
 	"io"
 	"net/http"
@@ -31,7 +33,12 @@ func (c *Client) Heartbeat(
 	totalMemory int,
 	freeSlots int,
 	availableMemory int,
-) ([]jobs.Job, []sources.Source, error) {
+	toolchains []string,
+	labels placement.Labels,
+	draining bool,
+	returnedJobs []job.ID,
+	deregister bool,
+) ([]jobs.Job, []sources.Source, bool, error) {
 	req := Request{
 		WorkerID:        workerID,
 		DoneJobs:        doneJobs,
@@ -39,10 +46,15 @@ func (c *Client) Heartbeat(
 		TotalMemory:     totalMemory,
 		FreeSlots:       freeSlots,
 		AvailableMemory: availableMemory,
+		Toolchains:      toolchains,
+		Labels:          labels,
+		Draining:        draining,
+		ReturnedJobs:    returnedJobs,
+		Deregister:      deregister,
 	}
 	jsonReq, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, false, err
 	}
 	httpReq, err := http.NewRequestWithContext(
 		ctx,
@@ -50,31 +62,31 @@ func (c *Client) Heartbeat(
 		c.endpoint+"/heartbeat",
 		bytes.NewBuffer(jsonReq))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create heartheat request: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to create heartheat request: %w", err)
 	}
 
 	httpClient := http.Client{}
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to send heartheat request: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to send heartheat request: %w", err)
 	}
 	defer func() { _ = httpResp.Body.Close() }()
 
 	if httpResp.StatusCode != http.StatusOK {
 		content, err := io.ReadAll(httpResp.Body)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read heartheat response: %w", err)
+			return nil, nil, false, fmt.Errorf("failed to read heartheat response: %w", err)
 		}
-		return nil, nil, fmt.Errorf("heartbeat got response error (status %d): %s", httpResp.StatusCode, string(content))
+		return nil, nil, false, fmt.Errorf("heartbeat got response error (status %d): %s", httpResp.StatusCode, string(content))
 	}
 
 	var resp Response
 	if err = json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
-		return nil, nil, fmt.Errorf("failed to decode heartheat response: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to decode heartheat response: %w", err)
 	}
 	if resp.Status != api.StatusOK {
-		return nil, nil, fmt.Errorf("heartbeat got response error: %s", resp.Error)
+		return nil, nil, false, fmt.Errorf("heartbeat got response error: %s", resp.Error)
 	}
 
-	return resp.Jobs, resp.Sources, nil
+	return resp.Jobs, resp.Sources, resp.Drain, nil
 }
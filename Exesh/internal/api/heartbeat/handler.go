@@ -38,9 +38,9 @@ func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	command := buildCommand(req)
-	jbs, srcs := h.uc.Heartbeat(r.Context(), command)
+	jbs, srcs, drain := h.uc.Heartbeat(r.Context(), command)
 
-	render.JSON(w, r, okResponse(jbs, srcs))
+	render.JSON(w, r, okResponse(jbs, srcs, drain))
 	return
 }
 
@@ -52,14 +52,20 @@ func buildCommand(req Request) heartbeat.Command {
 		TotalMemory:     req.TotalMemory,
 		FreeSlots:       req.FreeSlots,
 		AvailableMemory: req.AvailableMemory,
+		Toolchains:      req.Toolchains,
+		Labels:          req.Labels,
+		Draining:        req.Draining,
+		ReturnedJobs:    req.ReturnedJobs,
+		Deregister:      req.Deregister,
 	}
 }
 
-func okResponse(jbs []jobs.Job, srcs []sources.Source) Response {
+func okResponse(jbs []jobs.Job, srcs []sources.Source, drain bool) Response {
 	return Response{
 		Response: api.OK(),
 		Jobs:     jbs,
 		Sources:  srcs,
+		Drain:    drain,
 	}
 }
 
@@ -0,0 +1,23 @@
+package stream
+
+import (
+	"exesh/internal/api/heartbeat"
+)
+
+type (
+	// Request is the state a worker reports over the stream, the same as in a heartbeat.
+	// Ack is the sequence number of the last response the worker has taken the jobs from,
+	// so the coordinator knows which of the pushed jobs the reported free slots and memory account for.
+	Request struct {
+		heartbeat.Request
+		Ack uint64 `json:"ack"`
+	}
+
+	// Response is the jobs and the sources pushed to a worker over the stream.
+	// Every response of a stream has the next sequence number.
+	Response struct {
+		heartbeat.Response
+		Seq          uint64 `json:"seq"`
+		Deregistered bool   `json:"deregistered,omitempty"`
+	}
+)
@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+type (
+	Client struct {
+		endpoint string
+	}
+
+	Conn struct {
+		conn *websocket.Conn
+	}
+)
+
+const dialTimeout = 5 * time.Second
+
+func NewStreamClient(endpoint string) *Client {
+	return &Client{
+		endpoint: endpoint,
+	}
+}
+
+func (c *Client) Connect(ctx context.Context) (*Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, c.endpoint+"/stream", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial stream: %w", err)
+	}
+	conn.SetReadLimit(-1)
+
+	return &Conn{conn: conn}, nil
+}
+
+func (c *Conn) Send(ctx context.Context, req Request) error {
+	if err := wsjson.Write(ctx, c.conn, req); err != nil {
+		return fmt.Errorf("failed to write stream request: %w", err)
+	}
+	return nil
+}
+
+func (c *Conn) Receive(ctx context.Context) (Response, error) {
+	var resp Response
+	if err := wsjson.Read(ctx, c.conn, &resp); err != nil {
+		return Response{}, fmt.Errorf("failed to read stream response: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,286 @@
+package stream
+
+import (
+	"context"
+	"exesh/internal/api"
+	"exesh/internal/api/heartbeat"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution/job/jobs"
+	"exesh/internal/domain/execution/source/sources"
+	heartbeatUC "exesh/internal/usecase/heartbeat"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/go-chi/chi/v5"
+)
+
+type (
+	// Handler keeps a stream to every worker connected for the push dispatch.
+	// The jobs are pushed to the worker as soon as the job scheduler has them,
+	// the worker reports the done jobs right away and stays alive while the stream does.
+	Handler struct {
+		log *slog.Logger
+		cfg config.StreamConfig
+		uc  *heartbeatUC.UseCase
+
+		mu       sync.Mutex
+		sessions map[*session]struct{}
+	}
+
+	session struct {
+		wake chan struct{}
+
+		// state is the last state reported by the worker, nil until the first report.
+		state  *heartbeatUC.Command
+		seq    uint64
+		pushed []pushedJobs
+		drain  bool
+	}
+
+	// pushedJobs are the jobs of a response the worker has not acknowledged yet,
+	// so the free slots and memory it reported do not account for them.
+	pushedJobs struct {
+		seq    uint64
+		slots  int
+		memory int
+	}
+)
+
+func NewHandler(log *slog.Logger, cfg config.StreamConfig, useCase *heartbeatUC.UseCase) *Handler {
+	return &Handler{
+		log: log,
+		cfg: cfg,
+		uc:  useCase,
+
+		mu:       sync.Mutex{},
+		sessions: make(map[*session]struct{}),
+	}
+}
+
+func (h *Handler) Register(r chi.Router) {
+	r.Get("/stream", h.Handle)
+}
+
+// Wake makes every stream try to push jobs to its worker. It does not block.
+func (h *Handler) Wake() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.sessions {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		h.log.Info("failed to accept stream", slog.Any("err", err))
+		return
+	}
+	defer func() { _ = conn.CloseNow() }()
+	conn.SetReadLimit(-1)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	s := &session{
+		wake:   make(chan struct{}, 1),
+		pushed: make([]pushedJobs, 0),
+	}
+	h.addSession(s)
+	defer h.removeSession(s)
+
+	requests := make(chan Request)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			var req Request
+			if err := wsjson.Read(ctx, conn, &req); err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	keepalive := time.NewTicker(h.cfg.KeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err = <-readErr:
+			if websocket.CloseStatus(err) == -1 {
+				h.log.Warn("stream broken", slog.String("worker", s.workerID()), slog.Any("err", err))
+			}
+			return
+		case req := <-requests:
+			if req.WorkerID == "" {
+				h.log.Info("stream request without worker id")
+				return
+			}
+			if !h.report(ctx, conn, s, req) {
+				return
+			}
+		case <-s.wake:
+			if slots, _ := s.free(); slots <= 0 {
+				continue
+			}
+			if !h.push(ctx, conn, s) {
+				return
+			}
+		case <-keepalive.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, h.cfg.KeepaliveInterval)
+			err = conn.Ping(pingCtx)
+			pingCancel()
+			if err != nil {
+				h.log.Warn("stream worker does not respond", slog.String("worker", s.workerID()), slog.Any("err", err))
+				return
+			}
+			if s.state != nil && !h.push(ctx, conn, s) {
+				return
+			}
+		}
+	}
+}
+
+// report handles the state reported by the worker and pushes the jobs it can take now.
+func (h *Handler) report(ctx context.Context, conn *websocket.Conn, s *session, req Request) bool {
+	s.acknowledge(req.Ack)
+
+	command := buildCommand(req.Request)
+	state := command
+	state.DoneJobs, state.ReturnedJobs = nil, nil
+	s.state = &state
+	command.FreeSlots, command.AvailableMemory = s.free()
+
+	if req.Deregister {
+		h.uc.Heartbeat(ctx, command)
+		h.log.Info("worker deregistered over stream", slog.String("worker", req.WorkerID))
+		s.seq++
+		_ = h.send(ctx, conn, Response{Response: heartbeat.Response{Response: api.OK()}, Seq: s.seq, Deregistered: true})
+		_ = conn.Close(websocket.StatusNormalClosure, "deregistered")
+		return false
+	}
+
+	jbs, srcs, drain := h.uc.Heartbeat(ctx, command)
+	return h.respond(ctx, conn, s, jbs, srcs, drain)
+}
+
+// push picks the jobs for the last reported state of the worker, which also keeps the worker alive in the pool.
+func (h *Handler) push(ctx context.Context, conn *websocket.Conn, s *session) bool {
+	command := *s.state
+	command.FreeSlots, command.AvailableMemory = s.free()
+
+	jbs, srcs, drain := h.uc.Heartbeat(ctx, command)
+	return h.respond(ctx, conn, s, jbs, srcs, drain)
+}
+
+func (h *Handler) respond(ctx context.Context, conn *websocket.Conn, s *session, jbs []jobs.Job, srcs []sources.Source, drain bool) bool {
+	if len(jbs) == 0 && (!drain || s.drain) {
+		return true
+	}
+
+	s.seq++
+	resp := Response{
+		Response: heartbeat.Response{
+			Response: api.OK(),
+			Jobs:     jbs,
+			Sources:  srcs,
+			Drain:    drain,
+		},
+		Seq: s.seq,
+	}
+	if err := h.send(ctx, conn, resp); err != nil {
+		h.log.Warn("failed to push jobs", slog.String("worker", s.workerID()), slog.Any("err", err))
+		h.uc.ReturnJobs(context.WithoutCancel(ctx), s.workerID(), jbs)
+		return false
+	}
+
+	s.drain = s.drain || drain
+	if len(jbs) > 0 {
+		pushed := pushedJobs{seq: s.seq, slots: len(jbs)}
+		for _, jb := range jbs {
+			pushed.memory += jb.GetExpectedMemory()
+		}
+		s.pushed = append(s.pushed, pushed)
+	}
+	return true
+}
+
+func (h *Handler) send(ctx context.Context, conn *websocket.Conn, resp Response) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.WriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, resp)
+}
+
+func (h *Handler) addSession(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessions[s] = struct{}{}
+}
+
+func (h *Handler) removeSession(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, s)
+}
+
+// acknowledge forgets the pushed jobs the worker has taken.
+func (s *session) acknowledge(ack uint64) {
+	pushed := make([]pushedJobs, 0, len(s.pushed))
+	for _, p := range s.pushed {
+		if p.seq > ack {
+			pushed = append(pushed, p)
+		}
+	}
+	s.pushed = pushed
+}
+
+// free returns the free slots and memory of the worker, taking the unacknowledged pushed jobs off its last reported state.
+func (s *session) free() (int, int) {
+	if s.state == nil {
+		return 0, 0
+	}
+	slots, memory := s.state.FreeSlots, s.state.AvailableMemory
+	for _, p := range s.pushed {
+		slots -= p.slots
+		memory -= p.memory
+	}
+	return max(slots, 0), max(memory, 0)
+}
+
+func (s *session) workerID() string {
+	if s.state == nil {
+		return ""
+	}
+	return s.state.WorkerID
+}
+
+func buildCommand(req heartbeat.Request) heartbeatUC.Command {
+	return heartbeatUC.Command{
+		WorkerID:        req.WorkerID,
+		DoneJobs:        req.DoneJobs,
+		TotalSlots:      req.TotalSlots,
+		TotalMemory:     req.TotalMemory,
+		FreeSlots:       req.FreeSlots,
+		AvailableMemory: req.AvailableMemory,
+		Toolchains:      req.Toolchains,
+		Labels:          req.Labels,
+		Draining:        req.Draining,
+		ReturnedJobs:    req.ReturnedJobs,
+		Deregister:      req.Deregister,
+	}
+}
@@ -0,0 +1,216 @@
+package stream
+
+import (
+	"context"
+	"exesh/internal/api/heartbeat"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution/input"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
+	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/execution/result/results"
+	"exesh/internal/domain/execution/source"
+	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
+	heartbeatUC "exesh/internal/usecase/heartbeat"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type stubWorkerPool struct{}
+
+func (stubWorkerPool) Heartbeat(string, int, int, []string, placement.Labels, bool) {}
+
+func (stubWorkerPool) PutArtifact(string, job.ID, time.Time) {}
+
+func (stubWorkerPool) IsDraining(string) bool { return false }
+
+func (stubWorkerPool) Deregister(string) {}
+
+type pick struct {
+	slots  int
+	memory int
+}
+
+// stubJobScheduler reports every pick and gives the worker the queued jobs it has the slots for.
+type stubJobScheduler struct {
+	picks chan pick
+	queue chan jobs.Job
+}
+
+func (s *stubJobScheduler) PickJobs(_ context.Context, _ string, slots int, memory int) ([]jobs.Job, []sources.Source) {
+	s.picks <- pick{slots: slots, memory: memory}
+
+	picked := make([]jobs.Job, 0)
+	for len(picked) < slots {
+		select {
+		case jb := <-s.queue:
+			picked = append(picked, jb)
+		default:
+			return picked, nil
+		}
+	}
+	return picked, nil
+}
+
+func (s *stubJobScheduler) DoneJob(context.Context, string, results.Result) {}
+
+func (s *stubJobScheduler) ReturnJob(context.Context, string, job.ID) {}
+
+func newTestJob(t *testing.T, n int, expectedMemory int) jobs.Job {
+	t.Helper()
+
+	var id job.ID
+	if err := id.FromString(strings.Repeat(fmt.Sprint(n), len(id))); err != nil {
+		t.Fatalf("job id: %v", err)
+	}
+	var sourceID source.ID
+	if err := sourceID.FromString(strings.Repeat(fmt.Sprint(n), len(sourceID))); err != nil {
+		t.Fatalf("source id: %v", err)
+	}
+	return jobs.NewCompileCppJob(id, job.StatusOK, 1000, 256, 1000, expectedMemory, nil,
+		input.NewInput(input.Inline, sourceID), output.NewOutput("bin"), job.CompileOptions{})
+}
+
+func TestHandlerSeqAndAck(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	scheduler := &stubJobScheduler{
+		picks: make(chan pick, 16),
+		queue: make(chan jobs.Job, 16),
+	}
+	handler := NewHandler(log, config.StreamConfig{KeepaliveInterval: time.Hour, WriteTimeout: time.Second},
+		heartbeatUC.NewUseCase(log, stubWorkerPool{}, scheduler))
+	mux := chi.NewRouter()
+	handler.Register(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := NewStreamClient("ws" + strings.TrimPrefix(server.URL, "http")).Connect(ctx)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	report := func(ack uint64, freeSlots int, availableMemory int) {
+		t.Helper()
+		req := Request{
+			Request: heartbeat.Request{
+				WorkerID:        "worker-1",
+				TotalSlots:      2,
+				TotalMemory:     1024,
+				FreeSlots:       freeSlots,
+				AvailableMemory: availableMemory,
+			},
+			Ack: ack,
+		}
+		if err := conn.Send(ctx, req); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	expectPick := func(want pick) {
+		t.Helper()
+		select {
+		case got := <-scheduler.picks:
+			if got != want {
+				t.Fatalf("picked for %d slots and %d MB, want %d slots and %d MB", got.slots, got.memory, want.slots, want.memory)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no pick, want %d slots and %d MB", want.slots, want.memory)
+		}
+	}
+	expectResponse := func(wantSeq uint64, wantJobs int) {
+		t.Helper()
+		resp, err := conn.Receive(ctx)
+		if err != nil {
+			t.Fatalf("receive: %v", err)
+		}
+		if resp.Seq != wantSeq || len(resp.Jobs) != wantJobs {
+			t.Fatalf("response %d with %d jobs, want %d with %d jobs", resp.Seq, len(resp.Jobs), wantSeq, wantJobs)
+		}
+	}
+
+	// The first report gets the queued job.
+	scheduler.queue <- newTestJob(t, 1, 256)
+	report(0, 2, 1024)
+	expectPick(pick{slots: 2, memory: 1024})
+	expectResponse(1, 1)
+
+	// The pushed job is not acknowledged, so the next push leaves its slot and memory to it.
+	scheduler.queue <- newTestJob(t, 2, 256)
+	handler.Wake()
+	expectPick(pick{slots: 1, memory: 768})
+	expectResponse(2, 1)
+
+	// The worker has taken the first response only: the second one still holds a slot.
+	report(1, 1, 768)
+	expectPick(pick{slots: 0, memory: 512})
+
+	// Once both are acknowledged the reported state is taken as it is.
+	scheduler.queue <- newTestJob(t, 3, 256)
+	report(2, 1, 768)
+	expectPick(pick{slots: 1, memory: 768})
+	expectResponse(3, 1)
+}
+
+func TestSessionFree(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      *heartbeatUC.Command
+		pushed     []pushedJobs
+		ack        uint64
+		wantSlots  int
+		wantMemory int
+	}{
+		{
+			name: "no report yet",
+		},
+		{
+			name:       "nothing pushed",
+			state:      &heartbeatUC.Command{FreeSlots: 2, AvailableMemory: 512},
+			wantSlots:  2,
+			wantMemory: 512,
+		},
+		{
+			name:       "unacknowledged pushes are taken off",
+			state:      &heartbeatUC.Command{FreeSlots: 4, AvailableMemory: 1024},
+			pushed:     []pushedJobs{{seq: 1, slots: 1, memory: 256}, {seq: 2, slots: 2, memory: 128}},
+			ack:        1,
+			wantSlots:  2,
+			wantMemory: 896,
+		},
+		{
+			name:       "acknowledged pushes are forgotten",
+			state:      &heartbeatUC.Command{FreeSlots: 4, AvailableMemory: 1024},
+			pushed:     []pushedJobs{{seq: 1, slots: 1, memory: 256}, {seq: 2, slots: 2, memory: 128}},
+			ack:        2,
+			wantSlots:  4,
+			wantMemory: 1024,
+		},
+		{
+			name:   "never below zero",
+			state:  &heartbeatUC.Command{FreeSlots: 1, AvailableMemory: 100},
+			pushed: []pushedJobs{{seq: 3, slots: 2, memory: 256}},
+			ack:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &session{state: tt.state, pushed: tt.pushed}
+			s.acknowledge(tt.ack)
+			slots, memory := s.free()
+			if slots != tt.wantSlots || memory != tt.wantMemory {
+				t.Fatalf("free = %d slots, %d MB, want %d slots, %d MB", slots, memory, tt.wantSlots, tt.wantMemory)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package workers
+
+import "exesh/internal/api"
+
+type (
+	DrainRequest struct {
+		WorkerID string `json:"worker_id"`
+	}
+
+	DrainResponse struct {
+		api.Response
+	}
+)
@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"encoding/json"
+	"errors"
+	"exesh/internal/api"
+	workersUC "exesh/internal/usecase/workers"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const apiKeyHeader = "X-Api-Key"
+
+type Handler struct {
+	log *slog.Logger
+	uc  *workersUC.UseCase
+}
+
+func NewHandler(log *slog.Logger, uc *workersUC.UseCase) *Handler {
+	return &Handler{
+		log: log,
+		uc:  uc,
+	}
+}
+
+func (h *Handler) Register(r chi.Router) {
+	r.Post("/workers/drain", h.HandleDrain)
+}
+
+func (h *Handler) HandleDrain(w http.ResponseWriter, r *http.Request) {
+	var req DrainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Info("failed to decode request", slog.Any("err", err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, DrainResponse{Response: api.Error("failed to decode request")})
+		return
+	}
+	if req.WorkerID == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, DrainResponse{Response: api.Error("missing worker_id")})
+		return
+	}
+
+	err := h.uc.Drain(r.Context(), r.Header.Get(apiKeyHeader), req.WorkerID)
+	if errors.Is(err, workersUC.ErrUnauthorized) {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, DrainResponse{Response: api.Error(err.Error())})
+		return
+	}
+	if err != nil {
+		h.log.Info("failed to drain worker", slog.String("worker", req.WorkerID), slog.Any("err", err))
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, DrainResponse{Response: api.Error(err.Error())})
+		return
+	}
+
+	render.JSON(w, r, DrainResponse{Response: api.OK()})
+}
@@ -6,68 +6,35 @@ import (
 	"exesh/internal/domain/execution/job/jobs"
 )
 
-type CategoryStatsStorage interface {
-	GetExpectedByCategories(context.Context, []string) (execution.CategoryStats, error)
-}
-
 type Calculator struct {
-	statsStorage CategoryStatsStorage
+	estimator Estimator
 }
 
-func NewCalculator(statsStorage CategoryStatsStorage) *Calculator {
-	return &Calculator{statsStorage: statsStorage}
+func NewCalculator(estimator Estimator) *Calculator {
+	return &Calculator{estimator: estimator}
 }
 
 func (c *Calculator) LoadCategoryStats(
 	ctx context.Context,
 	stageDefs execution.StageDefinitions,
 ) (execution.CategoryStats, error) {
-	stats := execution.NewCategoryStats()
-
-	categories := make([]string, 0)
-	seen := make(map[string]struct{})
+	jobDefs := make([]jobs.Definition, 0)
 	for _, stageDef := range stageDefs {
-		for _, jobDef := range stageDef.Jobs {
-			category := jobDef.GetCategoryName()
-			if category == "" {
-				continue
-			}
-			if _, ok := seen[category]; ok {
-				continue
-			}
-			seen[category] = struct{}{}
-			categories = append(categories, category)
-		}
+		jobDefs = append(jobDefs, stageDef.Jobs...)
 	}
 
-	if len(categories) == 0 {
-		return stats, nil
+	if len(jobDefs) == 0 {
+		return execution.NewCategoryStats(), nil
 	}
 
-	return c.statsStorage.GetExpectedByCategories(ctx, categories)
+	return c.estimator.LoadStats(ctx, jobDefs)
 }
 
 func (c *Calculator) EstimateForJob(
 	jobDef jobs.Definition,
 	stats execution.CategoryStats,
 ) (expectedTime int, expectedMemory int) {
-	categoryName := jobDef.GetCategoryName()
-	timeLimit := jobDef.GetTimeLimit()
-	memoryLimit := jobDef.GetMemoryLimit()
-
-	expectedTime = estimateExpectedTime(
-		stats.TimeSamplesByCategory[categoryName],
-		stats.MedianTimeByCategory[categoryName],
-		stats.MaxTimeByCategory[categoryName],
-		timeLimit,
-	)
-	expectedMemory = estimateExpectedMemory(
-		stats.MemorySamplesByCategory[categoryName],
-		stats.MedianMemoryByCategory[categoryName],
-		stats.MaxMemoryByCategory[categoryName],
-		memoryLimit,
-	)
-	return expectedTime, expectedMemory
+	return c.estimator.Estimate(jobDef, stats)
 }
 
 func (c *Calculator) CalculateWeight(
@@ -84,22 +51,6 @@ func (c *Calculator) CalculateWeight(
 	return weight
 }
 
-func estimateExpectedTime(timeSamples int, median int, max int, timeLimit int) int {
-	if timeSamples == 0 {
-		return timeLimit
-	}
-	value := (3*max + 7*median) / 10
-	return clamp(value, 100, timeLimit)
-}
-
-func estimateExpectedMemory(memorySamples int, median int, max int, memoryLimit int) int {
-	if memorySamples == 0 {
-		return memoryLimit
-	}
-	value := (3*max + 7*median) / 10
-	return clamp(value, 16, memoryLimit)
-}
-
 func clamp(value int, minValue int, maxValue int) int {
 	if maxValue < minValue {
 		maxValue = minValue
@@ -0,0 +1,69 @@
+package calculator
+
+import (
+	"context"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job/jobs"
+	"fmt"
+)
+
+type (
+	// Estimator predicts the time and memory a job is going to use from the stats of the job categories.
+	Estimator interface {
+		LoadStats(ctx context.Context, jobDefs []jobs.Definition) (execution.CategoryStats, error)
+		Estimate(jobDef jobs.Definition, stats execution.CategoryStats) (expectedTime int, expectedMemory int)
+	}
+
+	CategoryStatsStorage interface {
+		GetExpectedByCategories(context.Context, []string) (execution.CategoryStats, error)
+		GetHistogramsByCategories(context.Context, []string) (execution.CategoryStats, error)
+	}
+)
+
+const (
+	MaxMedianEstimatorName = "max_median"
+	QuantileEstimatorName  = "quantile"
+
+	minExpectedTime   = 100
+	minExpectedMemory = 16
+)
+
+func NewEstimator(cfg config.CalculatorConfig, statsStorage CategoryStatsStorage) (Estimator, error) {
+	switch cfg.Estimator {
+	case "", MaxMedianEstimatorName:
+		return NewMaxMedianEstimator(statsStorage), nil
+	case QuantileEstimatorName:
+		return NewQuantileEstimator(cfg.Quantile, statsStorage), nil
+	default:
+		return nil, fmt.Errorf("unknown estimator '%s'", cfg.Estimator)
+	}
+}
+
+// categoryNames returns the distinct categories of the jobs,
+// followed by the keys of their priors if withPriors is set.
+func categoryNames(jobDefs []jobs.Definition, withPriors bool) []string {
+	categories := make([]string, 0)
+	seen := make(map[string]struct{})
+	add := func(category string) {
+		if _, ok := seen[category]; ok {
+			return
+		}
+		seen[category] = struct{}{}
+		categories = append(categories, category)
+	}
+
+	for _, jobDef := range jobDefs {
+		category := jobDef.GetCategoryName()
+		if category != "" {
+			add(category)
+		}
+		if withPriors {
+			for _, key := range execution.CategoryPriorKeys(category, jobDef.GetType()) {
+				add(key)
+			}
+		}
+	}
+
+	return categories
+}
@@ -0,0 +1,148 @@
+package calculator
+
+import (
+	"context"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type (
+	// HistogramRecorder records the measured jobs to the category histograms.
+	// The category of a job is updated in the transaction of the caller. The priors are shared
+	// by many categories, so their observations are summed in memory and added every flushInterval
+	// in one transaction instead of locking the same rows in every job completion.
+	HistogramRecorder struct {
+		log           *slog.Logger
+		flushInterval time.Duration
+
+		unitOfWork unitOfWork
+		storage    HistogramStorage
+
+		mu      sync.Mutex
+		pending map[string]pendingHistograms
+	}
+
+	HistogramStorage interface {
+		UpdateCategoryHistogram(ctx context.Context, categoryName string, elapsedTimeMs int, usedMemoryMb int) error
+		AddCategoryHistogram(ctx context.Context, categoryName string, timeCounts, memoryCounts execution.Histogram) error
+	}
+
+	unitOfWork interface {
+		Do(context.Context, func(context.Context) error) error
+	}
+
+	pendingHistograms struct {
+		time   execution.Histogram
+		memory execution.Histogram
+	}
+)
+
+func NewHistogramRecorder(
+	log *slog.Logger,
+	flushInterval time.Duration,
+	unitOfWork unitOfWork,
+	storage HistogramStorage,
+) *HistogramRecorder {
+	return &HistogramRecorder{
+		log:           log,
+		flushInterval: flushInterval,
+
+		unitOfWork: unitOfWork,
+		storage:    storage,
+
+		pending: make(map[string]pendingHistograms),
+	}
+}
+
+func (r *HistogramRecorder) Start(ctx context.Context) {
+	go r.run(ctx)
+}
+
+// RecordJob updates the histograms of the job category and keeps the observation for its priors until the next flush.
+func (r *HistogramRecorder) RecordJob(ctx context.Context, categoryName string, jobType job.Type, elapsedTimeMs int, usedMemoryMb int) error {
+	if err := r.storage.UpdateCategoryHistogram(ctx, categoryName, elapsedTimeMs, usedMemoryMb); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range execution.CategoryPriorKeys(categoryName, jobType) {
+		histograms, ok := r.pending[key]
+		if !ok {
+			histograms = pendingHistograms{
+				time:   execution.NewHistogram(execution.TimeHistogramBucketMs),
+				memory: execution.NewHistogram(execution.MemoryHistogramBucketMb),
+			}
+			r.pending[key] = histograms
+		}
+		histograms.time.Weights[histograms.time.Bucket(elapsedTimeMs)]++
+		histograms.memory.Weights[histograms.memory.Bucket(usedMemoryMb)]++
+	}
+	return nil
+}
+
+// Flush adds the observations of the priors recorded since the previous flush to the storage.
+// They are kept for the next flush if it fails.
+func (r *HistogramRecorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[string]pendingHistograms)
+	r.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := r.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		for key, histograms := range pending {
+			if err := r.storage.AddCategoryHistogram(ctx, key, histograms.time, histograms.memory); err != nil {
+				return fmt.Errorf("failed to add histogram of prior '%s': %w", key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.restore(pending)
+	}
+	return err
+}
+
+func (r *HistogramRecorder) restore(pending map[string]pendingHistograms) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, histograms := range pending {
+		current, ok := r.pending[key]
+		if !ok {
+			r.pending[key] = histograms
+			continue
+		}
+		current.time.Add(histograms.time, 1)
+		current.memory.Add(histograms.memory, 1)
+	}
+}
+
+func (r *HistogramRecorder) run(ctx context.Context) {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := r.Flush(context.WithoutCancel(ctx)); err != nil {
+				r.log.Error("failed to flush prior histograms", slog.Any("error", err))
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.Flush(ctx); err != nil {
+			r.log.Error("failed to flush prior histograms", slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type stubUnitOfWork struct{}
+
+func (stubUnitOfWork) Do(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+type stubHistogramStorage struct {
+	updated []string
+	added   map[string]execution.Histogram
+	err     error
+}
+
+func (s *stubHistogramStorage) UpdateCategoryHistogram(_ context.Context, categoryName string, _ int, _ int) error {
+	s.updated = append(s.updated, categoryName)
+	return nil
+}
+
+func (s *stubHistogramStorage) AddCategoryHistogram(_ context.Context, categoryName string, timeCounts, _ execution.Histogram) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.added[categoryName] = timeCounts
+	return nil
+}
+
+func TestHistogramRecorderFlush(t *testing.T) {
+	storage := &stubHistogramStorage{added: make(map[string]execution.Histogram)}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	recorder := NewHistogramRecorder(log, time.Minute, stubUnitOfWork{}, storage)
+	ctx := context.Background()
+
+	for _, elapsed := range []int{10, 20, 120} {
+		if err := recorder.RecordJob(ctx, "task: 1", job.RunCpp, elapsed, 8); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if len(storage.updated) != 3 || storage.updated[0] != "task: 1" {
+		t.Fatalf("updated = %v, want the category for every job", storage.updated)
+	}
+	if len(storage.added) != 0 {
+		t.Fatalf("priors added before the flush: %v", storage.added)
+	}
+
+	storage.err = errors.New("connection lost")
+	if err := recorder.Flush(ctx); err == nil {
+		t.Fatalf("flush: want error")
+	}
+	storage.err = nil
+	if err := recorder.RecordJob(ctx, "task: 2", job.RunCpp, 10, 8); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := recorder.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	// The observations of the failed flush are kept for the next one.
+	want := map[int]float64{0: 3, 100: 1}
+	for _, key := range []string{"@family/task/run_cpp", "@type/run_cpp"} {
+		got := storage.added[key].Weights
+		if len(got) != len(want) || got[0] != want[0] || got[100] != want[100] {
+			t.Fatalf("added to %s = %v, want %v", key, got, want)
+		}
+	}
+
+	storage.added = make(map[string]execution.Histogram)
+	if err := recorder.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(storage.added) != 0 {
+		t.Fatalf("added %v on an empty flush", storage.added)
+	}
+}
@@ -0,0 +1,57 @@
+package calculator
+
+import (
+	"context"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job/jobs"
+)
+
+// MaxMedianEstimator expects a job to take (3*max + 7*median)/10 of what its category took,
+// and the whole limit if the category was never seen.
+type MaxMedianEstimator struct {
+	statsStorage CategoryStatsStorage
+}
+
+func NewMaxMedianEstimator(statsStorage CategoryStatsStorage) *MaxMedianEstimator {
+	return &MaxMedianEstimator{statsStorage: statsStorage}
+}
+
+func (e *MaxMedianEstimator) LoadStats(ctx context.Context, jobDefs []jobs.Definition) (execution.CategoryStats, error) {
+	categories := categoryNames(jobDefs, false)
+	if len(categories) == 0 {
+		return execution.NewCategoryStats(), nil
+	}
+
+	return e.statsStorage.GetExpectedByCategories(ctx, categories)
+}
+
+func (e *MaxMedianEstimator) Estimate(
+	jobDef jobs.Definition,
+	stats execution.CategoryStats,
+) (expectedTime int, expectedMemory int) {
+	categoryName := jobDef.GetCategoryName()
+
+	expectedTime = estimateMaxMedian(
+		stats.TimeSamplesByCategory[categoryName],
+		stats.MedianTimeByCategory[categoryName],
+		stats.MaxTimeByCategory[categoryName],
+		minExpectedTime,
+		jobDef.GetTimeLimit(),
+	)
+	expectedMemory = estimateMaxMedian(
+		stats.MemorySamplesByCategory[categoryName],
+		stats.MedianMemoryByCategory[categoryName],
+		stats.MaxMemoryByCategory[categoryName],
+		minExpectedMemory,
+		jobDef.GetMemoryLimit(),
+	)
+	return expectedTime, expectedMemory
+}
+
+func estimateMaxMedian(samples int, median int, max int, minValue int, limit int) int {
+	if samples == 0 {
+		return limit
+	}
+	value := (3*max + 7*median) / 10
+	return clamp(value, minValue, limit)
+}
@@ -0,0 +1,91 @@
+package calculator
+
+import (
+	"context"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job/jobs"
+)
+
+// QuantileEstimator expects a job to take a quantile of the decayed distribution of its category.
+// The distribution is blended with the prior of the most specific similar group of categories,
+// which counts as PriorWeight observations, so new categories start from what similar jobs took
+// instead of the whole limit, and the category own observations take over as they accumulate.
+type QuantileEstimator struct {
+	cfg          config.QuantileEstimatorConfig
+	statsStorage CategoryStatsStorage
+}
+
+func NewQuantileEstimator(cfg config.QuantileEstimatorConfig, statsStorage CategoryStatsStorage) *QuantileEstimator {
+	return &QuantileEstimator{
+		cfg:          cfg,
+		statsStorage: statsStorage,
+	}
+}
+
+func (e *QuantileEstimator) LoadStats(ctx context.Context, jobDefs []jobs.Definition) (execution.CategoryStats, error) {
+	return e.statsStorage.GetHistogramsByCategories(ctx, categoryNames(jobDefs, true))
+}
+
+func (e *QuantileEstimator) Estimate(
+	jobDef jobs.Definition,
+	stats execution.CategoryStats,
+) (expectedTime int, expectedMemory int) {
+	categoryName := jobDef.GetCategoryName()
+	priorKeys := execution.CategoryPriorKeys(categoryName, jobDef.GetType())
+
+	expectedTime = jobDef.GetTimeLimit()
+	if value := e.estimateQuantile(
+		stats.TimeHistogramByCategory,
+		categoryName,
+		priorKeys,
+		e.cfg.TimeQuantile,
+		execution.TimeHistogramBucketMs,
+	); value > 0 {
+		expectedTime = clamp(value, minExpectedTime, expectedTime)
+	}
+
+	expectedMemory = jobDef.GetMemoryLimit()
+	if value := e.estimateQuantile(
+		stats.MemoryHistogramByCategory,
+		categoryName,
+		priorKeys,
+		e.cfg.MemoryQuantile,
+		execution.MemoryHistogramBucketMb,
+	); value > 0 {
+		expectedMemory = clamp(value, minExpectedMemory, expectedMemory)
+	}
+
+	return expectedTime, expectedMemory
+}
+
+// estimateQuantile returns 0 if neither the category nor its priors were ever observed.
+func (e *QuantileEstimator) estimateQuantile(
+	histograms map[string]execution.Histogram,
+	categoryName string,
+	priorKeys []string,
+	quantile float64,
+	bucketSize int,
+) int {
+	histogram := execution.NewHistogram(bucketSize)
+	if categoryName != "" {
+		if own, ok := histograms[categoryName]; ok {
+			histogram.Add(own, 1)
+		}
+	}
+
+	if e.cfg.PriorWeight > 0 {
+		for _, key := range priorKeys {
+			prior, ok := histograms[key]
+			if !ok {
+				continue
+			}
+			if total := prior.Total(); total > 0 {
+				histogram.Add(prior, e.cfg.PriorWeight/total)
+				break
+			}
+		}
+	}
+
+	return histogram.Quantile(quantile)
+}
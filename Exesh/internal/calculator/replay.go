@@ -0,0 +1,233 @@
+package calculator
+
+import (
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
+	"math"
+	"sort"
+	"time"
+)
+
+type (
+	// Sample is a measured run of a job, replayed to evaluate the estimators offline.
+	Sample struct {
+		At           time.Time
+		CategoryName string
+		JobType      job.Type
+		TimeLimit    int
+		MemoryLimit  int
+		ElapsedTime  int
+		UsedMemory   int
+	}
+
+	ReplayReport struct {
+		Estimator string
+		Samples   int
+
+		// TimeUnderestimated and MemoryUnderestimated are the shares of the samples
+		// which used more than expected.
+		TimeUnderestimated   float64
+		MemoryUnderestimated float64
+		// TimeOverestimation and MemoryOverestimation are the ratios of the expected totals to the used totals.
+		TimeOverestimation   float64
+		MemoryOverestimation float64
+		// PackingEfficiency is the share of the reserved memory-time which the jobs actually used.
+		PackingEfficiency float64
+	}
+
+	// replayCategory is the in-memory counterpart of the category histograms storage.
+	replayCategory struct {
+		timeCounts     execution.Histogram
+		memoryCounts   execution.Histogram
+		timeWeights    execution.Histogram
+		memoryWeights  execution.Histogram
+		weightsUpdated time.Time
+	}
+
+	replayTotals struct {
+		timeUnder      int
+		memoryUnder    int
+		expectedTime   float64
+		usedTime       float64
+		expectedMemory float64
+		usedMemory     float64
+		reserved       float64
+		used           float64
+	}
+)
+
+// Replay feeds the samples to the estimators in the order they happened.
+// Every sample is estimated from the samples preceding it only, the way the coordinator would have done it,
+// and then recorded to the stats of its category and of its priors decayed with halfLife.
+func Replay(samples []Sample, estimators map[string]Estimator, halfLife time.Duration) []ReplayReport {
+	samples = append([]Sample(nil), samples...)
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].At.Before(samples[j].At) })
+
+	categories := make(map[string]*replayCategory)
+	totals := make(map[string]*replayTotals, len(estimators))
+	for name := range estimators {
+		totals[name] = &replayTotals{}
+	}
+
+	for _, sample := range samples {
+		keys := append([]string{sample.CategoryName}, execution.CategoryPriorKeys(sample.CategoryName, sample.JobType)...)
+		stats := replayStats(categories, keys, sample.At, halfLife)
+		jobDef := sample.definition()
+
+		for name, estimator := range estimators {
+			expectedTime, expectedMemory := estimator.Estimate(jobDef, stats)
+			totals[name].add(sample, expectedTime, expectedMemory)
+		}
+
+		for _, key := range keys {
+			category, ok := categories[key]
+			if !ok {
+				category = newReplayCategory()
+				categories[key] = category
+			}
+			category.record(sample, halfLife)
+		}
+	}
+
+	reports := make([]ReplayReport, 0, len(estimators))
+	for name, t := range totals {
+		reports = append(reports, t.report(name, len(samples)))
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Estimator < reports[j].Estimator })
+	return reports
+}
+
+func (s Sample) definition() jobs.Definition {
+	return jobs.Definition{
+		IDefinition: &job.DefinitionDetails{
+			Type:         s.JobType,
+			CategoryName: s.CategoryName,
+			TimeLimit:    s.TimeLimit,
+			MemoryLimit:  s.MemoryLimit,
+		},
+	}
+}
+
+func newReplayCategory() *replayCategory {
+	return &replayCategory{
+		timeCounts:    execution.NewHistogram(execution.TimeHistogramBucketMs),
+		memoryCounts:  execution.NewHistogram(execution.MemoryHistogramBucketMb),
+		timeWeights:   execution.NewHistogram(execution.TimeHistogramBucketMs),
+		memoryWeights: execution.NewHistogram(execution.MemoryHistogramBucketMb),
+	}
+}
+
+func (c *replayCategory) record(sample Sample, halfLife time.Duration) {
+	factor := decayFactor(sample.At.Sub(c.weightsUpdated), halfLife)
+	if c.weightsUpdated.IsZero() {
+		factor = 1
+	}
+	c.timeWeights.Decay(factor)
+	c.memoryWeights.Decay(factor)
+	c.weightsUpdated = sample.At
+
+	c.timeCounts.Weights[c.timeCounts.Bucket(sample.ElapsedTime)]++
+	c.memoryCounts.Weights[c.memoryCounts.Bucket(sample.UsedMemory)]++
+	c.timeWeights.Weights[c.timeWeights.Bucket(sample.ElapsedTime)]++
+	c.memoryWeights.Weights[c.memoryWeights.Bucket(sample.UsedMemory)]++
+}
+
+// replayStats builds the stats the storage would have returned at the moment.
+func replayStats(categories map[string]*replayCategory, keys []string, now time.Time, halfLife time.Duration) execution.CategoryStats {
+	stats := execution.NewCategoryStats()
+	for _, key := range keys {
+		category, ok := categories[key]
+		if !ok {
+			continue
+		}
+
+		samples, median, max := medianAndMax(category.timeCounts)
+		stats.TimeSamplesByCategory[key] = samples
+		stats.MedianTimeByCategory[key] = median
+		stats.MaxTimeByCategory[key] = max
+		samples, median, max = medianAndMax(category.memoryCounts)
+		stats.MemorySamplesByCategory[key] = samples
+		stats.MedianMemoryByCategory[key] = median
+		stats.MaxMemoryByCategory[key] = max
+
+		factor := decayFactor(now.Sub(category.weightsUpdated), halfLife)
+		timeWeights := execution.NewHistogram(category.timeWeights.BucketSize)
+		timeWeights.Add(category.timeWeights, factor)
+		stats.TimeHistogramByCategory[key] = timeWeights
+		memoryWeights := execution.NewHistogram(category.memoryWeights.BucketSize)
+		memoryWeights.Add(category.memoryWeights, factor)
+		stats.MemoryHistogramByCategory[key] = memoryWeights
+	}
+	return stats
+}
+
+// medianAndMax mirrors the aggregates of the category histograms storage:
+// both are the upper bounds of the buckets holding them.
+func medianAndMax(counts execution.Histogram) (samples int, median int, max int) {
+	buckets := make([]int, 0, len(counts.Weights))
+	for bucket, cnt := range counts.Weights {
+		buckets = append(buckets, bucket)
+		samples += int(cnt)
+	}
+	if samples == 0 {
+		return 0, 0, 0
+	}
+	sort.Ints(buckets)
+
+	half := int(math.Ceil(float64(samples) * 0.5))
+	cumulative := 0
+	for _, bucket := range buckets {
+		cumulative += int(counts.Weights[bucket])
+		if median == 0 && cumulative >= half {
+			median = bucket + counts.BucketSize
+		}
+	}
+	max = buckets[len(buckets)-1] + counts.BucketSize
+	return samples, median, max
+}
+
+func decayFactor(elapsed time.Duration, halfLife time.Duration) float64 {
+	if halfLife <= 0 || elapsed <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, elapsed.Seconds()/halfLife.Seconds())
+}
+
+func (t *replayTotals) add(sample Sample, expectedTime int, expectedMemory int) {
+	if sample.ElapsedTime > expectedTime {
+		t.timeUnder++
+	}
+	if sample.UsedMemory > expectedMemory {
+		t.memoryUnder++
+	}
+	t.expectedTime += float64(expectedTime)
+	t.usedTime += float64(sample.ElapsedTime)
+	t.expectedMemory += float64(expectedMemory)
+	t.usedMemory += float64(sample.UsedMemory)
+	t.reserved += float64(expectedTime) * float64(expectedMemory)
+	t.used += float64(sample.ElapsedTime) * float64(sample.UsedMemory)
+}
+
+func (t *replayTotals) report(estimator string, samples int) ReplayReport {
+	report := ReplayReport{
+		Estimator: estimator,
+		Samples:   samples,
+	}
+	if samples == 0 {
+		return report
+	}
+
+	report.TimeUnderestimated = float64(t.timeUnder) / float64(samples)
+	report.MemoryUnderestimated = float64(t.memoryUnder) / float64(samples)
+	if t.usedTime > 0 {
+		report.TimeOverestimation = t.expectedTime / t.usedTime
+	}
+	if t.usedMemory > 0 {
+		report.MemoryOverestimation = t.expectedMemory / t.usedMemory
+	}
+	if t.reserved > 0 {
+		report.PackingEfficiency = t.used / t.reserved
+	}
+	return report
+}
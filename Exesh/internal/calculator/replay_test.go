@@ -0,0 +1,85 @@
+package calculator
+
+import (
+	"context"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fixedEstimator expects the same time and memory for every job and keeps the stats it was given.
+type fixedEstimator struct {
+	time   int
+	memory int
+
+	typeSamples     []int
+	categorySamples []int
+	typeWeights     []float64
+}
+
+func (e *fixedEstimator) LoadStats(context.Context, []jobs.Definition) (execution.CategoryStats, error) {
+	return execution.NewCategoryStats(), nil
+}
+
+func (e *fixedEstimator) Estimate(jobDef jobs.Definition, stats execution.CategoryStats) (int, int) {
+	prior := execution.CategoryPriorKeys(jobDef.GetCategoryName(), jobDef.GetType())
+	typeKey := prior[len(prior)-1]
+	e.typeSamples = append(e.typeSamples, stats.TimeSamplesByCategory[typeKey])
+	e.categorySamples = append(e.categorySamples, stats.TimeSamplesByCategory[jobDef.GetCategoryName()])
+	e.typeWeights = append(e.typeWeights, stats.TimeHistogramByCategory[typeKey].Total())
+	return e.time, e.memory
+}
+
+func TestReplay(t *testing.T) {
+	at := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		{At: at.Add(2 * time.Hour), CategoryName: "task: 1", JobType: job.RunCpp, ElapsedTime: 200, UsedMemory: 32},
+		{At: at, CategoryName: "task: 1", JobType: job.RunCpp, ElapsedTime: 50, UsedMemory: 8},
+		{At: at.Add(time.Hour), CategoryName: "task: 2", JobType: job.RunCpp, ElapsedTime: 150, UsedMemory: 100},
+	}
+	estimator := &fixedEstimator{time: 100, memory: 64}
+
+	reports := Replay(samples, map[string]Estimator{"fixed": estimator}, time.Hour)
+
+	// Every sample is estimated in the order it happened from the preceding samples only.
+	if want := []int{0, 1, 2}; !slices.Equal(estimator.typeSamples, want) {
+		t.Fatalf("type prior samples = %v, want %v", estimator.typeSamples, want)
+	}
+	if want := []int{0, 0, 1}; !slices.Equal(estimator.categorySamples, want) {
+		t.Fatalf("category samples = %v, want %v", estimator.categorySamples, want)
+	}
+	// The weights of the prior halve every hour.
+	if want := []float64{0, 0.5, 0.75}; !slices.Equal(estimator.typeWeights, want) {
+		t.Fatalf("type prior weights = %v, want %v", estimator.typeWeights, want)
+	}
+
+	if len(reports) != 1 {
+		t.Fatalf("reports = %d, want 1", len(reports))
+	}
+	report := reports[0]
+	if report.Estimator != "fixed" || report.Samples != 3 {
+		t.Fatalf("report of %s over %d samples, want fixed over 3", report.Estimator, report.Samples)
+	}
+	for name, got := range map[string][2]float64{
+		"time underestimated":   {report.TimeUnderestimated, 2.0 / 3},
+		"memory underestimated": {report.MemoryUnderestimated, 1.0 / 3},
+		"time overestimation":   {report.TimeOverestimation, 300.0 / 400},
+		"memory overestimation": {report.MemoryOverestimation, 192.0 / 140},
+		"packing efficiency":    {report.PackingEfficiency, 21800.0 / 19200},
+	} {
+		if math.Abs(got[0]-got[1]) > 1e-9 {
+			t.Fatalf("%s = %v, want %v", name, got[0], got[1])
+		}
+	}
+}
+
+func TestReplayNoSamples(t *testing.T) {
+	reports := Replay(nil, map[string]Estimator{"fixed": &fixedEstimator{}}, time.Hour)
+	if len(reports) != 1 || reports[0].Samples != 0 || reports[0].TimeUnderestimated != 0 {
+		t.Fatalf("reports = %+v, want one empty report", reports)
+	}
+}
@@ -15,10 +15,13 @@ type (
 		Storage            StorageConfig            `yaml:"storage" env-prefix:"STORAGE_"`
 		FileStorage        FileStorageConfig        `yaml:"filestorage" env-prefix:"FILE_STORAGE_"`
 		JobFactory         JobFactoryConfig         `yaml:"job_factory" env-prefix:"JOB_FACTORY_"`
+		Calculator         CalculatorConfig         `yaml:"calculator" env-prefix:"CALCULATOR_"`
 		ExecutionScheduler ExecutionSchedulerConfig `yaml:"execution_scheduler" env-prefix:"EXECUTION_SCHEDULER_"`
 		JobScheduler       JobSchedulerConfig       `yaml:"job_scheduler" env-prefix:"JOB_SCHEDULER_"`
 		WorkerPool         WorkerPoolConfig         `yaml:"worker_pool" env-prefix:"WORKER_POOL_"`
+		Stream             StreamConfig             `yaml:"stream" env-prefix:"STREAM_"`
 		Dispatcher         DispatcherConfig         `yaml:"dispatcher" env-prefix:"DISPATCHER_"`
+		Admission          AdmissionConfig          `yaml:"admission" env-prefix:"ADMISSION_"`
 	}
 
 	StorageConfig struct {
@@ -27,14 +30,19 @@ type (
 	}
 
 	ExecutionSchedulerConfig struct {
-		ExecutionsInterval  time.Duration `yaml:"executions_interval" env:"EXECUTIONS_INTERVAL"`
-		Capacity            int64         `yaml:"capacity" env:"CAPACITY"`
-		ExecutionRetryAfter time.Duration `yaml:"execution_retry_after" env:"EXECUTION_RETRY_AFTER"`
+		ExecutionsInterval  time.Duration  `yaml:"executions_interval" env:"EXECUTIONS_INTERVAL"`
+		Capacity            int64          `yaml:"capacity" env:"CAPACITY"`
+		BackgroundCapacity  int64          `yaml:"background_capacity" env:"BACKGROUND_CAPACITY"`
+		ExecutionRetryAfter time.Duration  `yaml:"execution_retry_after" env:"EXECUTION_RETRY_AFTER"`
+		MaxTries            int            `yaml:"max_tries" env:"MAX_TRIES"`
+		TenantShares        map[string]int `yaml:"tenant_shares"`
 	}
 
 	JobSchedulerConfig struct {
 		PromisedJobsLimit         int           `yaml:"promised_jobs_limit" env:"PROMISED_JOBS_LIMIT"`
 		PromiseRescheduleInterval time.Duration `yaml:"promise_reschedule_interval" env:"PROMISE_RESCHEDULE_INTERVAL"`
+		UnschedulableAfter        time.Duration `yaml:"unschedulable_after" env:"UNSCHEDULABLE_AFTER"`
+		MaxJobRetries             int           `yaml:"max_job_retries" env:"MAX_JOB_RETRIES"`
 	}
 
 	JobFactoryConfig struct {
@@ -46,10 +54,67 @@ type (
 			FilestorageBucket time.Duration `yaml:"filestorage_bucket" env:"FILESTORAGE_BUCKET"`
 		} `yaml:"source_ttl" env-prefix:"SOURCE_TTL_"`
 		FilestorageEndpoint string `yaml:"filestorage_endpoint" env:"FILESTORAGE_ENDPOINT"`
+		CompileCache        bool   `yaml:"compile_cache" env:"COMPILE_CACHE"`
+		// DefaultToolchains are the toolchain ids compile jobs of each job type get when
+		// they do not name one. A job type without one is compiled by the default toolchain
+		// of the worker it runs on and is not cached.
+		DefaultToolchains map[string]string `yaml:"default_toolchains"`
+		// Toolchains are the toolchains of the workers, which the compile options of a job are checked against
+		// when the execution is submitted. The options are not checked if none is configured.
+		Toolchains []ToolchainConfig `yaml:"toolchains"`
+	}
+
+	CalculatorConfig struct {
+		Estimator string                  `yaml:"estimator" env:"ESTIMATOR"`
+		HalfLife  time.Duration           `yaml:"half_life" env:"HALF_LIFE"`
+		Quantile  QuantileEstimatorConfig `yaml:"quantile" env-prefix:"QUANTILE_"`
+		// PriorFlushInterval is how often the observations of the category priors are added to the histograms.
+		PriorFlushInterval time.Duration `yaml:"prior_flush_interval" env:"PRIOR_FLUSH_INTERVAL"`
+	}
+
+	QuantileEstimatorConfig struct {
+		TimeQuantile   float64 `yaml:"time_quantile" env:"TIME_QUANTILE"`
+		MemoryQuantile float64 `yaml:"memory_quantile" env:"MEMORY_QUANTILE"`
+		PriorWeight    float64 `yaml:"prior_weight" env:"PRIOR_WEIGHT"`
+	}
+
+	AdmissionConfig struct {
+		RequireAPIKey  bool           `yaml:"require_api_key" env:"REQUIRE_API_KEY"`
+		BusyRetryAfter time.Duration  `yaml:"busy_retry_after" env:"BUSY_RETRY_AFTER"`
+		DefaultQuota   QuotaConfig    `yaml:"default_quota" env-prefix:"DEFAULT_QUOTA_"`
+		Tenants        []TenantConfig `yaml:"tenants"`
+		Dedup          DedupConfig    `yaml:"dedup" env-prefix:"DEDUP_"`
+	}
+
+	DedupConfig struct {
+		ContentHash bool          `yaml:"content_hash" env:"CONTENT_HASH"`
+		Window      time.Duration `yaml:"window" env:"WINDOW"`
+	}
+
+	TenantConfig struct {
+		ID      string      `yaml:"id"`
+		APIKeys []string    `yaml:"api_keys"`
+		Quota   QuotaConfig `yaml:"quota"`
+	}
+
+	QuotaConfig struct {
+		MaxConcurrentExecutions int   `yaml:"max_concurrent_executions" env:"MAX_CONCURRENT_EXECUTIONS"`
+		MaxQueuedWeight         int64 `yaml:"max_queued_weight" env:"MAX_QUEUED_WEIGHT"`
+		MaxExecutionsPerMinute  int   `yaml:"max_executions_per_minute" env:"MAX_EXECUTIONS_PER_MINUTE"`
 	}
 
 	WorkerPoolConfig struct {
 		WorkerDieAfter time.Duration `yaml:"worker_die_after" env:"WORKER_DIE_AFTER"`
+		// DrainAPIKeys are the api keys /workers/drain accepts. Without any a worker cannot be drained over HTTP.
+		DrainAPIKeys []string `yaml:"drain_api_keys" env:"DRAIN_API_KEYS" env-separator:","`
+	}
+
+	// StreamConfig configures the push dispatch to the workers connected over a stream.
+	// Every KeepaliveInterval the coordinator checks a stream is alive, keeps its worker in the pool
+	// and pushes the jobs promised to it which can start by now.
+	StreamConfig struct {
+		KeepaliveInterval time.Duration `yaml:"keepalive_interval" env:"KEEPALIVE_INTERVAL"`
+		WriteTimeout      time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT"`
 	}
 
 	DispatcherConfig struct {
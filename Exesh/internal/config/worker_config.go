@@ -29,15 +29,36 @@ type (
 	}
 
 	WorkConfig struct {
-		WorkerID            string        `yaml:"id" env:"ID"`
-		FreeSlots           int           `yaml:"free_slots" env:"FREE_SLOTS"`
-		AvailableMemory     int           `yaml:"available_memory_mb" env:"AVAILABLE_MEMORY_MB"`
-		CoordinatorEndpoint string        `yaml:"coordinator_endpoint" env:"COORDINATOR_ENDPOINT"`
-		HeartbeatDelay      time.Duration `yaml:"heartbeat_delay" env:"HEARTBEAT_DELAY"`
-		WorkerDelay         time.Duration `yaml:"worker_delay" env:"WORKER_DELAY"`
+		WorkerID            string              `yaml:"id" env:"ID"`
+		FreeSlots           int                 `yaml:"free_slots" env:"FREE_SLOTS"`
+		Cores               []int               `yaml:"cores" env:"CORES" env-separator:","`
+		AvailableMemory     int                 `yaml:"available_memory_mb" env:"AVAILABLE_MEMORY_MB"`
+		CoordinatorEndpoint string              `yaml:"coordinator_endpoint" env:"COORDINATOR_ENDPOINT"`
+		HeartbeatDelay      time.Duration       `yaml:"heartbeat_delay" env:"HEARTBEAT_DELAY"`
+		PushDispatch        bool                `yaml:"push_dispatch" env:"PUSH_DISPATCH"`
+		StreamRetryDelay    time.Duration       `yaml:"stream_retry_delay" env:"STREAM_RETRY_DELAY"`
+		DrainTimeout        time.Duration       `yaml:"drain_timeout" env:"DRAIN_TIMEOUT"`
+		Toolchains          []ToolchainConfig   `yaml:"toolchains"`
+		Labels              map[string][]string `yaml:"labels"`
+	}
+
+	ToolchainConfig struct {
+		ID           string   `yaml:"id"`
+		JobType      string   `yaml:"job_type"`
+		Command      []string `yaml:"command"`
+		AllowedFlags []string `yaml:"allowed_flags"`
+		Default      bool     `yaml:"default"`
 	}
 )
 
+func (c WorkConfig) ToolchainIDs() []string {
+	ids := make([]string, 0, len(c.Toolchains))
+	for _, toolchain := range c.Toolchains {
+		ids = append(ids, toolchain.ID)
+	}
+	return ids
+}
+
 func MustLoadWorkerConfig() (cfg *WorkerConfig) {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
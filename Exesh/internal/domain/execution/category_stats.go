@@ -1,13 +1,39 @@
 package execution
 
-type CategoryStats struct {
-	TimeSamplesByCategory   map[string]int
-	MedianTimeByCategory    map[string]int
-	MaxTimeByCategory       map[string]int
-	MemorySamplesByCategory map[string]int
-	MedianMemoryByCategory  map[string]int
-	MaxMemoryByCategory     map[string]int
-}
+import (
+	"exesh/internal/domain/execution/job"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+type (
+	CategoryStats struct {
+		TimeSamplesByCategory   map[string]int
+		MedianTimeByCategory    map[string]int
+		MaxTimeByCategory       map[string]int
+		MemorySamplesByCategory map[string]int
+		MedianMemoryByCategory  map[string]int
+		MaxMemoryByCategory     map[string]int
+
+		TimeHistogramByCategory   map[string]Histogram
+		MemoryHistogramByCategory map[string]Histogram
+	}
+
+	// Histogram is a distribution of observed values grouped into buckets of BucketSize.
+	// Weights are keyed by the lower bound of the bucket and may be fractional,
+	// since older observations are decayed.
+	Histogram struct {
+		BucketSize int
+		Weights    map[int]float64
+	}
+)
+
+const (
+	TimeHistogramBucketMs   = 50
+	MemoryHistogramBucketMb = 16
+)
 
 func NewCategoryStats() CategoryStats {
 	return CategoryStats{
@@ -17,5 +43,93 @@ func NewCategoryStats() CategoryStats {
 		MemorySamplesByCategory: make(map[string]int),
 		MedianMemoryByCategory:  make(map[string]int),
 		MaxMemoryByCategory:     make(map[string]int),
+
+		TimeHistogramByCategory:   make(map[string]Histogram),
+		MemoryHistogramByCategory: make(map[string]Histogram),
+	}
+}
+
+// CategoryPriorKeys returns the keys of the histograms aggregating the categories similar to the given one.
+// Categories named "<family>: <name>" share the family prior of their job type
+// (all the tests of one task), and every category shares the prior of its job type (the language).
+// The keys are ordered from the most specific to the most general one.
+func CategoryPriorKeys(categoryName string, jobType job.Type) []string {
+	keys := make([]string, 0, 2)
+	if family, _, ok := strings.Cut(categoryName, ": "); ok && family != "" {
+		keys = append(keys, fmt.Sprintf("@family/%s/%s", family, jobType))
+	}
+	keys = append(keys, fmt.Sprintf("@type/%s", jobType))
+	return keys
+}
+
+func NewHistogram(bucketSize int) Histogram {
+	return Histogram{
+		BucketSize: bucketSize,
+		Weights:    make(map[int]float64),
+	}
+}
+
+func (h Histogram) Bucket(value int) int {
+	if value < 0 {
+		value = 0
 	}
+	return (value / h.BucketSize) * h.BucketSize
+}
+
+func (h Histogram) Total() float64 {
+	total := 0.0
+	for _, weight := range h.Weights {
+		total += weight
+	}
+	return total
+}
+
+// Add puts the weight of the histogram other multiplied by scale into the histogram.
+func (h Histogram) Add(other Histogram, scale float64) {
+	for bucket, weight := range other.Weights {
+		h.Weights[bucket] += weight * scale
+	}
+}
+
+// Decay multiplies every weight by factor, dropping the buckets which weight became negligible.
+func (h Histogram) Decay(factor float64) {
+	for bucket, weight := range h.Weights {
+		weight *= factor
+		if weight < 1e-6 {
+			delete(h.Weights, bucket)
+			continue
+		}
+		h.Weights[bucket] = weight
+	}
+}
+
+// Quantile returns the value below which the q-th part of the weight lies,
+// interpolating linearly inside the bucket it falls into.
+func (h Histogram) Quantile(q float64) int {
+	total := h.Total()
+	if total <= 0 {
+		return 0
+	}
+
+	buckets := make([]int, 0, len(h.Weights))
+	for bucket := range h.Weights {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+
+	target := total * math.Min(math.Max(q, 0), 1)
+	cumulative := 0.0
+	for _, bucket := range buckets {
+		weight := h.Weights[bucket]
+		if weight <= 0 {
+			continue
+		}
+		if cumulative+weight >= target {
+			fraction := (target - cumulative) / weight
+			return bucket + int(math.Ceil(fraction*float64(h.BucketSize)))
+		}
+		cumulative += weight
+	}
+
+	return buckets[len(buckets)-1] + h.BucketSize
 }
@@ -0,0 +1,76 @@
+package execution
+
+import (
+	"testing"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	h := NewHistogram(50)
+	h.Weights[0] = 1
+	h.Weights[50] = 1
+	h.Weights[100] = 2
+
+	tests := []struct {
+		name string
+		q    float64
+		want int
+	}{
+		{name: "zero", q: 0, want: 0},
+		{name: "end of the first bucket", q: 0.25, want: 50},
+		{name: "median", q: 0.5, want: 100},
+		{name: "inside the last bucket", q: 0.75, want: 125},
+		{name: "max", q: 1, want: 150},
+		{name: "above one is clamped", q: 2, want: 150},
+		{name: "below zero is clamped", q: -1, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.Quantile(tt.q); got != tt.want {
+				t.Fatalf("Quantile(%v) = %d, want %d", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogramQuantileEmpty(t *testing.T) {
+	if got := NewHistogram(50).Quantile(0.9); got != 0 {
+		t.Fatalf("Quantile of empty histogram = %d, want 0", got)
+	}
+}
+
+func TestHistogramDecay(t *testing.T) {
+	h := NewHistogram(16)
+	h.Weights[0] = 4
+	h.Weights[16] = 1e-6
+
+	h.Decay(0.5)
+
+	if len(h.Weights) != 1 || h.Weights[0] != 2 {
+		t.Fatalf("weights = %v, want only bucket 0 with 2", h.Weights)
+	}
+	if got := h.Total(); got != 2 {
+		t.Fatalf("total = %v, want 2", got)
+	}
+}
+
+func TestHistogramBucket(t *testing.T) {
+	h := NewHistogram(16)
+
+	tests := []struct {
+		value int
+		want  int
+	}{
+		{value: -5, want: 0},
+		{value: 0, want: 0},
+		{value: 15, want: 0},
+		{value: 16, want: 16},
+		{value: 40, want: 32},
+	}
+
+	for _, tt := range tests {
+		if got := h.Bucket(tt.value); got != tt.want {
+			t.Fatalf("Bucket(%d) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
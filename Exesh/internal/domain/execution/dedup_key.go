@@ -0,0 +1,37 @@
+package execution
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"exesh/internal/domain/execution/source/sources"
+	"fmt"
+)
+
+// IdempotencyDedupKey builds the deduplication key of an execution submitted with an idempotency key.
+func IdempotencyDedupKey(tenant, idempotencyKey string) string {
+	return dedupKey("idempotency", tenant, idempotencyKey)
+}
+
+// ContentDedupKey builds the deduplication key of an execution from its stage graph and sources,
+// so that byte-identical submissions of the same tenant share one key.
+func ContentDedupKey(tenant string, stages StageDefinitions, srcs sources.Definitions) (string, error) {
+	stagesJSON, err := json.Marshal(stages)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal stages: %w", err)
+	}
+	sourcesJSON, err := json.Marshal(srcs)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sources: %w", err)
+	}
+	return dedupKey("content", tenant, string(stagesJSON), string(sourcesJSON)), nil
+}
+
+func dedupKey(kind string, vars ...string) string {
+	hash := sha1.New()
+	hash.Write([]byte(kind))
+	for _, v := range vars {
+		hash.Write([]byte("\x00"))
+		hash.Write([]byte(v))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
@@ -21,7 +21,12 @@ type (
 		SourceDefinitionByName map[source.DefinitionName]sources.Definition
 		SourceByID             map[source.ID]sources.Source
 
-		OutputByJob map[job.ID]output.Output
+		OutputByJob   map[job.ID]output.Output
+		CacheKeyByJob map[job.ID]job.ID
+
+		// ItemProducers maps fan-out items to the jobs which split their output into them.
+		ItemProducers    map[job.ID]job.ID
+		OutputCountByJob map[job.ID]int
 
 		graph *graph
 
@@ -41,7 +46,11 @@ func NewExecution(def Definition) *Execution {
 		SourceDefinitionByName: make(map[source.DefinitionName]sources.Definition),
 		SourceByID:             make(map[source.ID]sources.Source),
 
-		OutputByJob: make(map[job.ID]output.Output),
+		OutputByJob:   make(map[job.ID]output.Output),
+		CacheKeyByJob: make(map[job.ID]job.ID),
+
+		ItemProducers:    make(map[job.ID]job.ID),
+		OutputCountByJob: make(map[job.ID]int),
 	}
 
 	return &ex
@@ -66,6 +75,71 @@ func (ex *Execution) DoneJob(jobID job.ID, jobStatus job.Status) {
 	ex.graph.doneJob(jobID, jobStatus)
 }
 
+// ArtifactID returns the id of the bucket holding the output of the given job.
+func (ex *Execution) ArtifactID(jobID job.ID) job.ID {
+	if cacheKey, ok := ex.CacheKeyByJob[jobID]; ok {
+		return cacheKey
+	}
+	return jobID
+}
+
+// JobProducing returns the scheduled job which produces the output of the given job:
+// either the job itself or the chain job containing it.
+func (ex *Execution) JobProducing(jobID job.ID) (jobs.Job, bool) {
+	if producerID, ok := ex.ItemProducers[jobID]; ok {
+		jobID = producerID
+	}
+	for _, stage := range ex.Stages {
+		for _, jb := range stage.Jobs {
+			if jb.GetID() == jobID {
+				return jb, true
+			}
+			if jb.GetType() != job.Chain {
+				continue
+			}
+			for _, innerJob := range jb.AsChain().Jobs {
+				if innerJob.GetID() == jobID {
+					return jb, true
+				}
+			}
+		}
+	}
+	return jobs.Job{}, false
+}
+
+func (ex *Execution) SetOutputCount(jobID job.ID, outputCount int) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	ex.OutputCountByJob[jobID] = outputCount
+}
+
+func (ex *Execution) GetOutputCount(jobID job.ID) int {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	return ex.OutputCountByJob[jobID]
+}
+
+// StagesToExpand returns the fan-out stages which are ready to get their jobs.
+func (ex *Execution) StagesToExpand() []*Stage {
+	if ex.IsDone() {
+		return []*Stage{}
+	}
+
+	return ex.graph.stagesToExpand()
+}
+
+// ExpandStage puts the jobs created from the templates of the fan-out stage into the graph.
+func (ex *Execution) ExpandStage(stage *Stage, stageJobs []jobs.Job) {
+	ex.graph.expandStage(stage, reduceStageJobs(stageJobs))
+}
+
+// StageOutcome returns the stages which had failed jobs and the stages skipped because of them.
+func (ex *Execution) StageOutcome() (failed []StageName, skipped []StageName) {
+	return ex.graph.stageOutcome()
+}
+
 func (ex *Execution) IsDone() bool {
 	return ex.IsForceFailed() || ex.graph.isDone()
 }
@@ -2,20 +2,31 @@ package execution
 
 import (
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
+	"fmt"
 	"time"
 )
 
 type (
 	Definition struct {
-		ID          ID
-		Stages      StageDefinitions
-		Sources     sources.Definitions
-		Weight      int64
-		Tries       int
-		Status      Status
-		CreatedAt   time.Time
-		ScheduledAt *time.Time
-		FinishedAt  *time.Time
+		ID            ID
+		Stages        StageDefinitions
+		Sources       sources.Definitions
+		Constraints   placement.Constraints
+		PriorityClass PriorityClass
+		Tenant        string
+		Weight        int64
+		Deadline      *time.Time
+		MaxTries      int
+		DedupKey      string
+		Tries         int
+		Status        Status
+		CreatedAt     time.Time
+		ScheduledAt   *time.Time
+		FinishedAt    *time.Time
+
+		// ReplayOf is the identical execution whose messages answer this one instead of a run.
+		ReplayOf *ID
 	}
 
 	Status string
@@ -25,24 +36,39 @@ const (
 	StatusNew       Status = "new"
 	StatusScheduled Status = "scheduled"
 	StatusFinished  Status = "finished"
+	StatusExpired   Status = "expired"
 )
 
-func NewExecutionDefinition(stages StageDefinitions, sources sources.Definitions, weight int64) Definition {
+func NewExecutionDefinition(
+	stages StageDefinitions,
+	sources sources.Definitions,
+	constraints placement.Constraints,
+	priorityClass PriorityClass,
+	tenant string,
+	weight int64,
+	deadline *time.Time,
+	maxTries int,
+) Definition {
 	return Definition{
-		ID:          newID(),
-		Stages:      stages,
-		Sources:     sources,
-		Weight:      weight,
-		Tries:       0,
-		Status:      StatusNew,
-		CreatedAt:   time.Now(),
-		ScheduledAt: nil,
-		FinishedAt:  nil,
+		ID:            newID(),
+		Stages:        stages,
+		Sources:       sources,
+		Constraints:   constraints,
+		PriorityClass: priorityClass,
+		Tenant:        tenant,
+		Weight:        weight,
+		Deadline:      deadline,
+		MaxTries:      maxTries,
+		Tries:         0,
+		Status:        StatusNew,
+		CreatedAt:     time.Now(),
+		ScheduledAt:   nil,
+		FinishedAt:    nil,
 	}
 }
 
 func (def *Definition) SetScheduled(scheduledAt time.Time) {
-	if def.Status == StatusFinished {
+	if def.IsFinished() {
 		return
 	}
 
@@ -51,11 +77,63 @@ func (def *Definition) SetScheduled(scheduledAt time.Time) {
 	def.ScheduledAt = &scheduledAt
 }
 
+// KeepScheduled prolongs the scheduled execution without spending a try,
+// so that it is not picked for schedule again while it makes progress.
+func (def *Definition) KeepScheduled(at time.Time) {
+	if def.Status != StatusScheduled {
+		return
+	}
+
+	def.ScheduledAt = &at
+}
+
+// SetWaiting keeps the execution out of the schedule queue without spending a try
+// while the execution it replays is in flight.
+func (def *Definition) SetWaiting(at time.Time) {
+	if def.IsFinished() {
+		return
+	}
+
+	def.Status = StatusScheduled
+	def.ScheduledAt = &at
+}
+
 func (def *Definition) SetFinished(finishedAt time.Time) {
-	if def.Status == StatusFinished {
+	if def.IsFinished() {
 		return
 	}
 
 	def.Status = StatusFinished
 	def.FinishedAt = &finishedAt
 }
+
+func (def *Definition) SetExpired(expiredAt time.Time) {
+	if def.IsFinished() {
+		return
+	}
+
+	def.Status = StatusExpired
+	def.FinishedAt = &expiredAt
+}
+
+func (def *Definition) IsFinished() bool {
+	return def.Status == StatusFinished || def.Status == StatusExpired
+}
+
+// ExpiredReason returns why the execution must not run anymore or an empty string if it still may.
+// defaultMaxTries is used if the execution has no own tries budget, zero means unlimited.
+func (def *Definition) ExpiredReason(now time.Time, defaultMaxTries int) string {
+	if def.Deadline != nil && now.After(*def.Deadline) {
+		return fmt.Sprintf("execution deadline %s exceeded", def.Deadline.Format(time.RFC3339))
+	}
+
+	maxTries := def.MaxTries
+	if maxTries == 0 {
+		maxTries = defaultMaxTries
+	}
+	if maxTries > 0 && def.Tries >= maxTries {
+		return fmt.Sprintf("execution exhausted its %d tries", maxTries)
+	}
+
+	return ""
+}
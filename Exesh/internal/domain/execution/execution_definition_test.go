@@ -0,0 +1,100 @@
+package execution
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiredReason(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Second), now.Add(time.Second)
+
+	tests := []struct {
+		name            string
+		def             Definition
+		defaultMaxTries int
+		want            string
+	}{
+		{
+			name: "fresh",
+			def:  Definition{},
+		},
+		{
+			name: "before deadline",
+			def:  Definition{Deadline: &future},
+		},
+		{
+			name: "at deadline",
+			def:  Definition{Deadline: &now},
+		},
+		{
+			name: "after deadline",
+			def:  Definition{Deadline: &past, Tries: 5, MaxTries: 5},
+			want: "execution deadline 2026-01-01T11:59:59Z exceeded",
+		},
+		{
+			name:            "tries left",
+			def:             Definition{Tries: 2, MaxTries: 3},
+			defaultMaxTries: 1,
+		},
+		{
+			name:            "own tries exhausted",
+			def:             Definition{Tries: 3, MaxTries: 3},
+			defaultMaxTries: 10,
+			want:            "execution exhausted its 3 tries",
+		},
+		{
+			name:            "default tries exhausted",
+			def:             Definition{Tries: 5},
+			defaultMaxTries: 5,
+			want:            "execution exhausted its 5 tries",
+		},
+		{
+			name: "unlimited tries",
+			def:  Definition{Tries: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.def.ExpiredReason(now, tt.defaultMaxTries); got != tt.want {
+				t.Fatalf("expired reason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepScheduled(t *testing.T) {
+	scheduledAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	keptAt := scheduledAt.Add(time.Minute)
+
+	tests := []struct {
+		name            string
+		status          Status
+		wantScheduledAt *time.Time
+	}{
+		{name: "new", status: StatusNew},
+		{name: "scheduled", status: StatusScheduled, wantScheduledAt: &keptAt},
+		{name: "finished", status: StatusFinished, wantScheduledAt: &scheduledAt},
+		{name: "expired", status: StatusExpired, wantScheduledAt: &scheduledAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := Definition{Status: tt.status, Tries: 1}
+			if tt.status != StatusNew {
+				def.ScheduledAt = &scheduledAt
+			}
+
+			def.KeepScheduled(keptAt)
+
+			if def.Status != tt.status || def.Tries != 1 {
+				t.Fatalf("status = %s with %d tries, want %s with 1", def.Status, def.Tries, tt.status)
+			}
+			if (def.ScheduledAt == nil) != (tt.wantScheduledAt == nil) ||
+				def.ScheduledAt != nil && !def.ScheduledAt.Equal(*tt.wantScheduledAt) {
+				t.Fatalf("scheduled at = %v, want %v", def.ScheduledAt, tt.wantScheduledAt)
+			}
+		})
+	}
+}
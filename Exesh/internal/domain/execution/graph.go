@@ -22,9 +22,14 @@ type graph struct {
 	activeStages []*Stage
 	toPick       map[StageName][]jobs.Job
 
-	totalJobs    map[StageName]int
-	doneJobs     map[StageName]int
-	hasFailedJob map[StageName]bool
+	totalJobs       map[StageName]int
+	doneJobs        map[StageName]int
+	hasFailedJob    map[StageName]bool
+	isStageCanceled map[StageName]bool
+	isFinished      map[StageName]bool
+	isExpanded      map[StageName]bool
+
+	stages []*Stage
 }
 
 func newGraph(stages []*Stage) *graph {
@@ -44,9 +49,14 @@ func newGraph(stages []*Stage) *graph {
 		activeStages: make([]*Stage, 0),
 		toPick:       make(map[StageName][]jobs.Job),
 
-		totalJobs:    make(map[StageName]int),
-		doneJobs:     make(map[StageName]int),
-		hasFailedJob: make(map[StageName]bool),
+		totalJobs:       make(map[StageName]int),
+		doneJobs:        make(map[StageName]int),
+		hasFailedJob:    make(map[StageName]bool),
+		isStageCanceled: make(map[StageName]bool),
+		isFinished:      make(map[StageName]bool),
+		isExpanded:      make(map[StageName]bool),
+
+		stages: stages,
 	}
 
 	for _, stage := range stages {
@@ -84,6 +94,7 @@ func newGraph(stages []*Stage) *graph {
 		g.totalJobs[stage.Name] = len(stage.Jobs)
 		g.doneJobs[stage.Name] = 0
 		g.hasFailedJob[stage.Name] = false
+		g.isExpanded[stage.Name] = stage.FanOut == nil
 
 		if len(stage.Deps) == 0 {
 			g.isActive[stage.Name] = true
@@ -99,29 +110,36 @@ func (g *graph) pickJobs() []jobs.Job {
 	defer g.mu.Unlock()
 
 	pickedJobs := make([]jobs.Job, 0)
-	for i := 0; i < len(g.activeStages); i++ {
-		stage := g.activeStages[i]
-		for j := 0; j < len(g.toPick[stage.Name]); j++ {
-			jb := g.toPick[stage.Name][j]
-			if !g.isJobCanceled[jb.GetID()] {
-				pickedJobs = append(pickedJobs, jb)
-			} else {
-				g.doneJobs[stage.Name]++
-				for _, succJob := range g.succJobs[jb.GetID()] {
-					g.isJobCanceled[succJob.GetID()] = true
-					g.doneJobDeps[succJob.GetID()]++
-					if g.doneJobDeps[succJob.GetID()] == len(succJob.GetDependencies()) {
-						succStage := g.stageByJobID[succJob.GetID()]
-						g.toPick[succStage.Name] = append(g.toPick[succStage.Name], succJob)
-					}
+	for {
+		// canceling jobs may finish stages and activate new ones,
+		// so repeat until no active stage has jobs to pick
+		progressed := false
+		activeStages := append([]*Stage(nil), g.activeStages...)
+		for _, stage := range activeStages {
+			toPick := g.toPick[stage.Name]
+			if len(toPick) == 0 {
+				continue
+			}
+			progressed = true
+			g.toPick[stage.Name] = make([]jobs.Job, 0)
+
+			for _, jb := range toPick {
+				if !g.isJobCanceled[jb.GetID()] && !g.isStageCanceled[stage.Name] {
+					pickedJobs = append(pickedJobs, jb)
+					continue
 				}
+
+				g.doneJobs[stage.Name]++
+				g.hasFailedJob[stage.Name] = true
+				g.releaseSuccessors(jb.GetID(), true)
 				g.checkStageFinish(stage)
 			}
 		}
-		g.toPick[stage.Name] = make([]jobs.Job, 0)
-	}
 
-	return pickedJobs
+		if !progressed {
+			return pickedJobs
+		}
+	}
 }
 
 func (g *graph) doneJob(jobID job.ID, jobStatus job.Status) {
@@ -142,23 +160,79 @@ func (g *graph) doneJob(jobID job.ID, jobStatus job.Status) {
 	}
 
 	g.doneJobs[stage.Name]++
-	if jobStatus != jb.GetSuccessStatus() {
+	failed := jobStatus != jb.GetSuccessStatus()
+	if failed {
 		g.hasFailedJob[stage.Name] = true
-		for _, succJob := range g.succJobs[jobID] {
+		if stage.Policy == StagePolicyFailFast {
+			g.isStageCanceled[stage.Name] = true
+		}
+	}
+	g.releaseSuccessors(jobID, failed)
+
+	g.checkStageFinish(stage)
+}
+
+// releaseSuccessors marks the job as done for the jobs depending on it,
+// canceling them if the job did not succeed.
+func (g *graph) releaseSuccessors(jobID job.ID, cancel bool) {
+	for _, succJob := range g.succJobs[jobID] {
+		if cancel {
 			g.isJobCanceled[succJob.GetID()] = true
-			g.doneJobDeps[succJob.GetID()]++
-			if g.doneJobDeps[succJob.GetID()] == len(succJob.GetDependencies()) {
-				succStage := g.stageByJobID[succJob.GetID()]
-				g.toPick[succStage.Name] = append(g.toPick[succStage.Name], succJob)
-			}
 		}
-	} else {
-		for _, succJob := range g.succJobs[jobID] {
-			g.doneJobDeps[succJob.GetID()]++
-			if g.doneJobDeps[succJob.GetID()] == len(succJob.GetDependencies()) {
-				succStage := g.stageByJobID[succJob.GetID()]
-				g.toPick[succStage.Name] = append(g.toPick[succStage.Name], succJob)
+		g.doneJobDeps[succJob.GetID()]++
+		if g.doneJobDeps[succJob.GetID()] == len(succJob.GetDependencies()) {
+			succStage := g.stageByJobID[succJob.GetID()]
+			g.toPick[succStage.Name] = append(g.toPick[succStage.Name], succJob)
+		}
+	}
+}
+
+// stagesToExpand returns the active fan-out stages whose jobs are not created yet.
+func (g *graph) stagesToExpand() []*Stage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stages := make([]*Stage, 0)
+	for _, stage := range g.activeStages {
+		if !g.isExpanded[stage.Name] {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+// expandStage adds the jobs created for the fan-out stage.
+// A fan-out stage runs after the stages it depends on, so only the dependencies
+// between the added jobs themselves are waited for.
+func (g *graph) expandStage(stage *Stage, stageJobs []jobs.Job) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stage.Jobs = stageJobs
+	g.isExpanded[stage.Name] = true
+	g.totalJobs[stage.Name] = len(stageJobs)
+
+	added := make(map[job.ID]bool, len(stageJobs))
+	for _, jb := range stageJobs {
+		g.stageByJobID[jb.GetID()] = stage
+		g.isJobCanceled[jb.GetID()] = false
+		added[jb.GetID()] = true
+	}
+
+	for _, jb := range stageJobs {
+		deps := jb.GetDependencies()
+		pendingDeps := 0
+		for _, dep := range deps {
+			if !added[dep] {
+				continue
 			}
+			g.succJobs[dep] = append(g.succJobs[dep], jb)
+			pendingDeps++
+		}
+
+		g.doneJobDeps[jb.GetID()] = len(deps) - pendingDeps
+		if pendingDeps == 0 {
+			g.toPick[stage.Name] = append(g.toPick[stage.Name], jb)
 		}
 	}
 
@@ -172,12 +246,30 @@ func (g *graph) isDone() bool {
 	return len(g.activeStages) == 0
 }
 
+// stageOutcome returns the stages which had failed or canceled jobs
+// and the stages which were never run.
+func (g *graph) stageOutcome() (failed []StageName, skipped []StageName) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, stage := range g.stages {
+		if g.hasFailedJob[stage.Name] {
+			failed = append(failed, stage.Name)
+		}
+		if !g.isActive[stage.Name] && !g.isFinished[stage.Name] {
+			skipped = append(skipped, stage.Name)
+		}
+	}
+	return failed, skipped
+}
+
 func (g *graph) checkStageFinish(stage *Stage) {
 	doneJobs := g.doneJobs[stage.Name]
 	totalJobs := g.totalJobs[stage.Name]
-	isFinished := doneJobs == totalJobs
+	isFinished := g.isExpanded[stage.Name] && doneJobs == totalJobs
 	if isFinished {
 		g.isActive[stage.Name] = false
+		g.isFinished[stage.Name] = true
 		activeStages := make([]*Stage, 0, len(g.activeStages)-1)
 		for _, activeStage := range g.activeStages {
 			if activeStage.Name != stage.Name {
@@ -186,7 +278,7 @@ func (g *graph) checkStageFinish(stage *Stage) {
 		}
 		g.activeStages = activeStages
 
-		if !g.hasFailedJob[stage.Name] {
+		if !g.hasFailedJob[stage.Name] || stage.Policy == StagePolicyContinueOnFailure {
 			for _, succStage := range g.succStages[stage.Name] {
 				if g.isActive[succStage.Name] {
 					continue
@@ -0,0 +1,190 @@
+package execution
+
+import (
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
+	"slices"
+	"testing"
+)
+
+func jobIDs(stageJobs []jobs.Job) []job.ID {
+	ids := make([]job.ID, 0, len(stageJobs))
+	for _, jb := range stageJobs {
+		ids = append(ids, jb.GetID())
+	}
+	return ids
+}
+
+func TestGraphStagePolicy(t *testing.T) {
+	// Stage "tests" has the independent jobs a and b, and c which depends on b.
+	// Stage "report" with the job d depends on "tests".
+	a, b, c, d := testJobID(t, 'a'), testJobID(t, 'b'), testJobID(t, 'c'), testJobID(t, 'd')
+
+	tests := []struct {
+		name        string
+		policy      StagePolicy
+		statusA     job.Status
+		wantPicked  [][]job.ID
+		wantFailed  []StageName
+		wantSkipped []StageName
+	}{
+		{
+			name:       "success",
+			policy:     StagePolicyFailFast,
+			statusA:    job.StatusOK,
+			wantPicked: [][]job.ID{{a, b}, {c}, {d}},
+		},
+		{
+			name:        "fail fast cancels the jobs not started",
+			policy:      StagePolicyFailFast,
+			statusA:     job.StatusCE,
+			wantPicked:  [][]job.ID{{a, b}},
+			wantFailed:  []StageName{"tests"},
+			wantSkipped: []StageName{"report"},
+		},
+		{
+			name:        "run all runs the stage but not the next one",
+			policy:      StagePolicyRunAll,
+			statusA:     job.StatusCE,
+			wantPicked:  [][]job.ID{{a, b}, {c}},
+			wantFailed:  []StageName{"tests"},
+			wantSkipped: []StageName{"report"},
+		},
+		{
+			name:       "continue on failure runs the next stage",
+			policy:     StagePolicyContinueOnFailure,
+			statusA:    job.StatusCE,
+			wantPicked: [][]job.ID{{a, b}, {c}, {d}},
+			wantFailed: []StageName{"tests"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGraph([]*Stage{
+				{
+					Name:   "tests",
+					Policy: tt.policy,
+					Jobs: []jobs.Job{
+						newTestCompileJob(t, 'a', nil),
+						newTestCompileJob(t, 'b', nil),
+						newTestRunJob(t, 'c', b),
+					},
+				},
+				{
+					Name: "report",
+					Deps: []StageName{"tests"},
+					Jobs: []jobs.Job{newTestCompileJob(t, 'd', nil)},
+				},
+			})
+
+			statuses := map[job.ID]job.Status{a: tt.statusA}
+			picked := make([][]job.ID, 0)
+			for !g.isDone() {
+				ids := jobIDs(g.pickJobs())
+				if len(ids) == 0 {
+					if g.isDone() {
+						break
+					}
+					t.Fatalf("graph is stuck after picking %v", picked)
+				}
+				picked = append(picked, ids)
+				for _, id := range ids {
+					status, ok := statuses[id]
+					if !ok {
+						status = job.StatusOK
+					}
+					g.doneJob(id, status)
+				}
+			}
+
+			if !slices.EqualFunc(picked, tt.wantPicked, slices.Equal) {
+				t.Fatalf("picked = %v, want %v", picked, tt.wantPicked)
+			}
+			failed, skipped := g.stageOutcome()
+			if !slices.Equal(failed, tt.wantFailed) || !slices.Equal(skipped, tt.wantSkipped) {
+				t.Fatalf("outcome = %v failed, %v skipped, want %v failed, %v skipped", failed, skipped, tt.wantFailed, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestGraphExpandStage(t *testing.T) {
+	// Stage "split" has the job p splitting its output, stage "items" is expanded by p,
+	// and stage "report" with the job d depends on "items".
+	p, d := testJobID(t, '1'), testJobID(t, 'd')
+	e, f, h := testJobID(t, 'e'), testJobID(t, 'f'), testJobID(t, '2')
+
+	tests := []struct {
+		name       string
+		stageJobs  func(t *testing.T) []jobs.Job
+		wantPicked [][]job.ID
+	}{
+		{
+			name: "jobs wait only for each other",
+			stageJobs: func(t *testing.T) []jobs.Job {
+				return []jobs.Job{
+					newTestCompileJob(t, 'e', nil),
+					newTestRunJob(t, 'f', e),
+					newTestRunJob(t, '2', p),
+				}
+			},
+			wantPicked: [][]job.ID{{e, h}, {f}, {d}},
+		},
+		{
+			name:       "no items",
+			stageJobs:  func(t *testing.T) []jobs.Job { return nil },
+			wantPicked: [][]job.ID{{d}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := &Stage{Name: "items", Deps: []StageName{"split"}, FanOut: &p}
+			g := newGraph([]*Stage{
+				{Name: "split", Jobs: []jobs.Job{newTestCompileJob(t, '1', nil)}},
+				items,
+				{Name: "report", Deps: []StageName{"items"}, Jobs: []jobs.Job{newTestCompileJob(t, 'd', nil)}},
+			})
+
+			if got := g.stagesToExpand(); len(got) != 0 {
+				t.Fatalf("stages to expand before the split = %d, want none", len(got))
+			}
+			if ids := jobIDs(g.pickJobs()); !slices.Equal(ids, []job.ID{p}) {
+				t.Fatalf("picked = %v, want %v", ids, []job.ID{p})
+			}
+			g.doneJob(p, job.StatusOK)
+
+			if got := g.stagesToExpand(); len(got) != 1 || got[0] != items {
+				t.Fatalf("stages to expand = %v, want the items stage", got)
+			}
+			if ids := jobIDs(g.pickJobs()); len(ids) != 0 || g.isDone() {
+				t.Fatalf("picked %v before the expansion, want the graph to wait", ids)
+			}
+
+			g.expandStage(items, tt.stageJobs(t))
+			if got := g.stagesToExpand(); len(got) != 0 {
+				t.Fatalf("stages to expand after the expansion = %d, want none", len(got))
+			}
+
+			picked := make([][]job.ID, 0)
+			for !g.isDone() {
+				ids := jobIDs(g.pickJobs())
+				if len(ids) == 0 {
+					t.Fatalf("graph is stuck after picking %v", picked)
+				}
+				picked = append(picked, ids)
+				for _, id := range ids {
+					g.doneJob(id, job.StatusOK)
+				}
+			}
+
+			if !slices.EqualFunc(picked, tt.wantPicked, slices.Equal) {
+				t.Fatalf("picked = %v, want %v", picked, tt.wantPicked)
+			}
+			if failed, skipped := g.stageOutcome(); len(failed) != 0 || len(skipped) != 0 {
+				t.Fatalf("outcome = %v failed, %v skipped, want none", failed, skipped)
+			}
+		})
+	}
+}
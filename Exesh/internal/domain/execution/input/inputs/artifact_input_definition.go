@@ -8,4 +8,6 @@ import (
 type ArtifactInputDefinition struct {
 	input.DefinitionDetails
 	JobDefinitionName job.DefinitionName `json:"job"`
+	// Item makes the input the fan-out item of the job output the templated job is created for.
+	Item bool `json:"item,omitempty"`
 }
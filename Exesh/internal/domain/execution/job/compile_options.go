@@ -0,0 +1,14 @@
+package job
+
+type CompileOptions struct {
+	Toolchain string   `json:"toolchain,omitempty"`
+	Flags     []string `json:"flags,omitempty"`
+}
+
+func (opts *CompileOptions) GetToolchain() string {
+	return opts.Toolchain
+}
+
+func (opts *CompileOptions) GetFlags() []string {
+	return opts.Flags
+}
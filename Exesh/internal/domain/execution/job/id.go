@@ -40,3 +40,10 @@ func (id *ID) UnmarshalJSON(data []byte) error {
 	}
 	return id.FromString(s)
 }
+
+// ItemID returns the id of the bucket holding the item-th (1-based) fan-out item of the job output.
+func ItemID(jobID ID, item int) ID {
+	var id ID
+	_ = id.FromString(fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%s#%d", jobID.String(), item)))))
+	return id
+}
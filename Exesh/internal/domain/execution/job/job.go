@@ -3,6 +3,7 @@ package job
 import (
 	"exesh/internal/domain/execution/input"
 	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/placement"
 )
 
 type (
@@ -17,6 +18,7 @@ type (
 		GetInputs() []input.Input
 		GetOutput() *output.Output
 		GetDependencies() []ID
+		GetConstraints() placement.Constraints
 	}
 
 	Details struct {
@@ -27,6 +29,8 @@ type (
 		MemoryLimit    int    `json:"memory_limit"`
 		ExpectedTime   int    `json:"expected_time"`
 		ExpectedMemory int    `json:"expected_memory"`
+
+		Constraints placement.Constraints `json:"constraints,omitempty"`
 	}
 
 	Type   string
@@ -77,3 +81,7 @@ func (jb *Details) GetExpectedTime() int {
 func (jb *Details) GetExpectedMemory() int {
 	return jb.ExpectedMemory
 }
+
+func (jb *Details) GetConstraints() placement.Constraints {
+	return jb.Constraints
+}
@@ -1,5 +1,7 @@
 package job
 
+import "exesh/internal/domain/placement"
+
 type (
 	IDefinition interface {
 		GetType() Type
@@ -8,6 +10,7 @@ type (
 		GetCategoryName() string
 		GetTimeLimit() int
 		GetMemoryLimit() int
+		GetConstraints() placement.Constraints
 	}
 
 	DefinitionDetails struct {
@@ -17,6 +20,8 @@ type (
 		CategoryName  string         `json:"category_name"`
 		TimeLimit     int            `json:"time_limit"`
 		MemoryLimit   int            `json:"memory_limit"`
+
+		Constraints placement.Constraints `json:"constraints,omitempty"`
 	}
 
 	DefinitionName string
@@ -45,3 +50,7 @@ func (def *DefinitionDetails) GetTimeLimit() int {
 func (def *DefinitionDetails) GetMemoryLimit() int {
 	return def.MemoryLimit
 }
+
+func (def *DefinitionDetails) GetConstraints() placement.Constraints {
+	return def.Constraints
+}
@@ -5,6 +5,7 @@ import (
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/output"
 	"exesh/internal/domain/execution/source"
+	"exesh/internal/domain/placement"
 )
 
 type ChainJob struct {
@@ -21,7 +22,9 @@ func NewChainJob(jobs []Job) Job {
 	expectedMemory := 0
 	timeLimit := 0
 	memoryLimit := 0
+	constraints := make(placement.Constraints, 0)
 	for _, innerJob := range jobs {
+		constraints = append(constraints, innerJob.GetConstraints()...)
 		expectedTime += innerJob.GetExpectedTime()
 		if innerJob.GetExpectedMemory() > expectedMemory {
 			expectedMemory = innerJob.GetExpectedMemory()
@@ -43,6 +46,7 @@ func NewChainJob(jobs []Job) Job {
 				MemoryLimit:    memoryLimit,
 				ExpectedTime:   expectedTime,
 				ExpectedMemory: expectedMemory,
+				Constraints:    constraints,
 			},
 			Jobs: jobs,
 		},
@@ -4,6 +4,7 @@ import (
 	"exesh/internal/domain/execution/input"
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/placement"
 )
 
 type CheckCppJob struct {
@@ -21,6 +22,7 @@ func NewCheckCppJob(
 	memoryLimit int,
 	expectedTime int,
 	expectedMemory int,
+	constraints placement.Constraints,
 	compiledChecker input.Input,
 	testInput input.Input,
 	correctOutput input.Input,
@@ -36,6 +38,7 @@ func NewCheckCppJob(
 				MemoryLimit:    memoryLimit,
 				ExpectedTime:   expectedTime,
 				ExpectedMemory: expectedMemory,
+				Constraints:    constraints,
 			},
 			CompiledChecker: compiledChecker,
 			TestInput:       testInput,
@@ -4,12 +4,15 @@ import (
 	"exesh/internal/domain/execution/input"
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/placement"
 )
 
 type CompileGoJob struct {
 	job.Details
+	job.CompileOptions
 	Code         input.Input   `json:"code"`
 	CompiledCode output.Output `json:"compiled_code"`
+	CacheKey     *job.ID       `json:"cache_key,omitempty"`
 }
 
 func NewCompileGoJob(
@@ -19,8 +22,10 @@ func NewCompileGoJob(
 	memoryLimit int,
 	expectedTime int,
 	expectedMemory int,
+	constraints placement.Constraints,
 	code input.Input,
 	compiledCode output.Output,
+	compileOptions job.CompileOptions,
 ) Job {
 	return Job{
 		&CompileGoJob{
@@ -32,9 +37,11 @@ func NewCompileGoJob(
 				MemoryLimit:    memoryLimit,
 				ExpectedTime:   expectedTime,
 				ExpectedMemory: expectedMemory,
+				Constraints:    constraints,
 			},
-			Code:         code,
-			CompiledCode: compiledCode,
+			CompileOptions: compileOptions,
+			Code:           code,
+			CompiledCode:   compiledCode,
 		},
 	}
 }
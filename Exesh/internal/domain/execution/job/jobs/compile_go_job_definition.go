@@ -7,5 +7,6 @@ import (
 
 type CompileGoJobDefinition struct {
 	job.DefinitionDetails
+	job.CompileOptions
 	Code inputs.Definition `json:"code"`
 }
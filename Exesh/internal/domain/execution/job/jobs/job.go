@@ -79,6 +79,60 @@ func (jb *Job) AsChain() *ChainJob {
 	return jb.IJob.(*ChainJob)
 }
 
+// GetToolchains returns ids of the toolchains a worker must have to execute the job
+func (jb *Job) GetToolchains() []string {
+	toolchains := make([]string, 0)
+	switch jb.GetType() {
+	case job.CompileCpp:
+		if toolchain := jb.AsCompileCpp().Toolchain; toolchain != "" {
+			toolchains = append(toolchains, toolchain)
+		}
+	case job.CompileGo:
+		if toolchain := jb.AsCompileGo().Toolchain; toolchain != "" {
+			toolchains = append(toolchains, toolchain)
+		}
+	case job.Chain:
+		for _, innerJob := range jb.AsChain().Jobs {
+			toolchains = append(toolchains, innerJob.GetToolchains()...)
+		}
+	}
+	return toolchains
+}
+
+// GetCacheKey returns the hash of source, toolchain and flags the compile job output is cached by
+func (jb *Job) GetCacheKey() *job.ID {
+	switch jb.GetType() {
+	case job.CompileCpp:
+		return jb.AsCompileCpp().CacheKey
+	case job.CompileGo:
+		return jb.AsCompileGo().CacheKey
+	default:
+		return nil
+	}
+}
+
+// GetSplitOutput returns the separator line the run job output is split into fan-out items by
+func (jb *Job) GetSplitOutput() string {
+	switch jb.GetType() {
+	case job.RunCpp:
+		return jb.AsRunCpp().SplitOutput
+	case job.RunGo:
+		return jb.AsRunGo().SplitOutput
+	case job.RunPy:
+		return jb.AsRunPy().SplitOutput
+	default:
+		return ""
+	}
+}
+
+// GetArtifactID returns the id of the bucket the job output is stored in
+func (jb *Job) GetArtifactID() job.ID {
+	if cacheKey := jb.GetCacheKey(); cacheKey != nil {
+		return *cacheKey
+	}
+	return jb.GetID()
+}
+
 func getDependencies(ins []input.Input) []job.ID {
 	deps := make([]job.ID, 0)
 	for _, in := range ins {
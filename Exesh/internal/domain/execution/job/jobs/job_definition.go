@@ -71,3 +71,17 @@ func (def *Definition) AsRunPy() *RunPyJobDefinition {
 func (def *Definition) AsCheckCpp() *CheckCppJobDefinition {
 	return def.IDefinition.(*CheckCppJobDefinition)
 }
+
+// GetSplitOutput returns the separator line the run job output is split into fan-out items by
+func (def *Definition) GetSplitOutput() string {
+	switch def.GetType() {
+	case job.RunCpp:
+		return def.AsRunCpp().SplitOutput
+	case job.RunGo:
+		return def.AsRunGo().SplitOutput
+	case job.RunPy:
+		return def.AsRunPy().SplitOutput
+	default:
+		return ""
+	}
+}
@@ -10,4 +10,5 @@ type RunCppJobDefinition struct {
 	CompiledCode inputs.Definition `json:"compiled_code"`
 	RunInput     inputs.Definition `json:"input"`
 	ShowOutput   bool              `json:"show_output"`
+	SplitOutput  string            `json:"split_output,omitempty"`
 }
@@ -4,6 +4,7 @@ import (
 	"exesh/internal/domain/execution/input"
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/placement"
 )
 
 type RunGoJob struct {
@@ -12,6 +13,7 @@ type RunGoJob struct {
 	RunInput     input.Input   `json:"run_input"`
 	RunOutput    output.Output `json:"run_output"`
 	ShowOutput   bool          `json:"show_output"`
+	SplitOutput  string        `json:"split_output,omitempty"`
 }
 
 func NewRunGoJob(
@@ -21,6 +23,7 @@ func NewRunGoJob(
 	memoryLimit int,
 	expectedTime int,
 	expectedMemory int,
+	constraints placement.Constraints,
 	code input.Input,
 	runInput input.Input,
 	runOutput output.Output,
@@ -36,6 +39,7 @@ func NewRunGoJob(
 				MemoryLimit:    memoryLimit,
 				ExpectedTime:   expectedTime,
 				ExpectedMemory: expectedMemory,
+				Constraints:    constraints,
 			},
 			CompiledCode: code,
 			RunInput:     runInput,
@@ -4,14 +4,16 @@ import (
 	"exesh/internal/domain/execution/input"
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/placement"
 )
 
 type RunPyJob struct {
 	job.Details
-	Code       input.Input   `json:"code"`
-	RunInput   input.Input   `json:"run_input"`
-	RunOutput  output.Output `json:"run_output"`
-	ShowOutput bool          `json:"show_output"`
+	Code        input.Input   `json:"code"`
+	RunInput    input.Input   `json:"run_input"`
+	RunOutput   output.Output `json:"run_output"`
+	ShowOutput  bool          `json:"show_output"`
+	SplitOutput string        `json:"split_output,omitempty"`
 }
 
 func NewRunPyJob(
@@ -21,6 +23,7 @@ func NewRunPyJob(
 	memoryLimit int,
 	expectedTime int,
 	expectedMemory int,
+	constraints placement.Constraints,
 	code input.Input,
 	runInput input.Input,
 	runOutput output.Output,
@@ -36,6 +39,7 @@ func NewRunPyJob(
 				MemoryLimit:    memoryLimit,
 				ExpectedTime:   expectedTime,
 				ExpectedMemory: expectedMemory,
+				Constraints:    constraints,
 			},
 			Code:       code,
 			RunInput:   runInput,
@@ -7,7 +7,8 @@ import (
 
 type RunPyJobDefinition struct {
 	job.DefinitionDetails
-	Code       inputs.Definition `json:"code"`
-	RunInput   inputs.Definition `json:"input"`
-	ShowOutput bool              `json:"show_output"`
+	Code        inputs.Definition `json:"code"`
+	RunInput    inputs.Definition `json:"input"`
+	ShowOutput  bool              `json:"show_output"`
+	SplitOutput string            `json:"split_output,omitempty"`
 }
@@ -0,0 +1,38 @@
+package history
+
+import (
+	"context"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/message/messages"
+)
+
+// Reader returns at most count messages of the execution starting from the message startID.
+type Reader interface {
+	GetMessages(ctx context.Context, executionID execution.ID, startID int64, count int) ([]Message, error)
+}
+
+// ReadAll returns every message of the execution, reading batchSize messages at once.
+func ReadAll(ctx context.Context, r Reader, executionID execution.ID, batchSize int) ([]Message, error) {
+	msgs := make([]Message, 0)
+	startID := int64(1)
+	for {
+		batch, err := r.GetMessages(ctx, executionID, startID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, batch...)
+		if len(batch) < batchSize {
+			return msgs, nil
+		}
+		startID = batch[len(batch)-1].MessageID + 1
+	}
+}
+
+// FinishedOK reports whether the messages end with the execution finished without an error.
+func FinishedOK(msgs []Message) bool {
+	if len(msgs) == 0 {
+		return false
+	}
+	finish, ok := msgs[len(msgs)-1].Message.IMessage.(*messages.FinishExecutionMessage)
+	return ok && finish.Error == ""
+}
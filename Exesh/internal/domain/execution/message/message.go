@@ -6,6 +6,7 @@ type (
 	IMessage interface {
 		GetType() Type
 		GetExecutionID() execution.ID
+		SetExecutionID(execution.ID)
 	}
 
 	Details struct {
@@ -32,3 +33,7 @@ func (msg *Details) GetType() Type {
 func (msg *Details) GetExecutionID() execution.ID {
 	return msg.ExecutionID
 }
+
+func (msg *Details) SetExecutionID(executionID execution.ID) {
+	msg.ExecutionID = executionID
+}
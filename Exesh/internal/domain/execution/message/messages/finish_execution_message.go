@@ -7,16 +7,24 @@ import (
 
 type FinishExecutionMessage struct {
 	message.Details
-	Error string `json:"error,omitempty"`
+	Error         string                `json:"error,omitempty"`
+	FailedStages  []execution.StageName `json:"failed_stages,omitempty"`
+	SkippedStages []execution.StageName `json:"skipped_stages,omitempty"`
 }
 
-func NewFinishExecutionMessageOk(executionID execution.ID) Message {
+func NewFinishExecutionMessageOk(
+	executionID execution.ID,
+	failedStages []execution.StageName,
+	skippedStages []execution.StageName,
+) Message {
 	return Message{
 		&FinishExecutionMessage{
 			Details: message.Details{
 				ExecutionID: executionID,
 				Type:        message.FinishExecution,
 			},
+			FailedStages:  failedStages,
+			SkippedStages: skippedStages,
 		},
 	}
 }
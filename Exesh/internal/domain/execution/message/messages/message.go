@@ -45,3 +45,7 @@ func (msg *Message) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+func (msg *Message) AsRunJob() *RunJobMessage {
+	return msg.IMessage.(*RunJobMessage)
+}
@@ -4,14 +4,27 @@ import (
 	"exesh/internal/domain/execution"
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/message"
+	"time"
 )
 
-type RunJobMessage struct {
-	message.Details
-	JobName   job.DefinitionName `json:"job"`
-	RunStatus job.Status         `json:"status"`
-	Output    string             `json:"output,omitempty"`
-}
+type (
+	RunJobMessage struct {
+		message.Details
+		JobName   job.DefinitionName `json:"job"`
+		RunStatus job.Status         `json:"status"`
+		Output    string             `json:"output,omitempty"`
+		Artifact  *Artifact          `json:"artifact,omitempty"`
+	}
+
+	// Artifact is where the output of the job can be downloaded from:
+	// the file of the bucket in the filestorage of the worker, kept there until TrashTime.
+	Artifact struct {
+		Endpoint  string    `json:"endpoint"`
+		BucketID  string    `json:"bucket_id"`
+		File      string    `json:"file"`
+		TrashTime time.Time `json:"trash_time"`
+	}
+)
 
 func NewRunJobMessage(
 	executionID execution.ID,
@@ -0,0 +1,36 @@
+package execution
+
+import "fmt"
+
+type PriorityClass string
+
+const (
+	PriorityInteractive PriorityClass = "interactive"
+	PriorityRanked      PriorityClass = "ranked"
+	PriorityBackground  PriorityClass = "background"
+
+	DefaultPriorityClass = PriorityRanked
+	DefaultTenant        = "default"
+)
+
+// PriorityClasses lists the classes from the most to the least urgent one.
+var PriorityClasses = []PriorityClass{PriorityInteractive, PriorityRanked, PriorityBackground}
+
+func (c PriorityClass) Validate() error {
+	switch c {
+	case PriorityInteractive, PriorityRanked, PriorityBackground:
+		return nil
+	default:
+		return fmt.Errorf("unknown priority class '%s'", c)
+	}
+}
+
+// Rank returns the position of the class in PriorityClasses, lower is more urgent.
+func (c PriorityClass) Rank() int {
+	for i, class := range PriorityClasses {
+		if class == c {
+			return i
+		}
+	}
+	return len(PriorityClasses)
+}
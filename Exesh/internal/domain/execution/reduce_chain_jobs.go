@@ -35,6 +35,11 @@ func reduceStageJobs(stageJobs []jobs.Job) []jobs.Job {
 		if removed[jb.GetID()] {
 			continue
 		}
+		// a cached compile job stays on its own, so that a cache hit can skip it
+		if jb.GetCacheKey() != nil {
+			reduced = append(reduced, jb)
+			continue
+		}
 
 		innerJobs := make([]jobs.Job, 0, 2)
 		if jb.GetType() == job.Chain {
@@ -45,12 +50,19 @@ func reduceStageJobs(stageJobs []jobs.Job) []jobs.Job {
 
 		currentID := jb.GetID()
 		for {
+			// split outputs are saved only for the last job of a chain
+			if currentJob := jobByID[currentID]; currentJob.GetSplitOutput() != "" {
+				break
+			}
 			nextID, ok := findSingleAliveSuccessor(successors[currentID], removed)
 			if !ok {
 				break
 			}
 
 			nextJob := jobByID[nextID]
+			if nextJob.GetCacheKey() != nil {
+				break
+			}
 			if nextJob.GetType() == job.Chain {
 				innerJobs = append(innerJobs, nextJob.AsChain().Jobs...)
 			} else {
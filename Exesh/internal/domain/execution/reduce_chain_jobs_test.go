@@ -0,0 +1,97 @@
+package execution
+
+import (
+	"exesh/internal/domain/execution/input"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
+	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/execution/source"
+	"strings"
+	"testing"
+)
+
+func testJobID(t *testing.T, c byte) job.ID {
+	t.Helper()
+
+	var id job.ID
+	if err := id.FromString(strings.Repeat(string(c), len(id))); err != nil {
+		t.Fatalf("job id: %v", err)
+	}
+	return id
+}
+
+func artifactOf(t *testing.T, id job.ID) input.Input {
+	t.Helper()
+
+	var sourceID source.ID
+	if err := sourceID.FromString(id.String()); err != nil {
+		t.Fatalf("source id: %v", err)
+	}
+	return input.NewInput(input.Artifact, sourceID)
+}
+
+func newTestCompileJob(t *testing.T, c byte, cacheKey *job.ID) jobs.Job {
+	t.Helper()
+
+	jb := jobs.NewCompileCppJob(testJobID(t, c), job.StatusOK, 1000, 256, 1000, 256, nil,
+		input.Input{}, output.NewOutput("bin"), job.CompileOptions{})
+	jb.AsCompileCpp().CacheKey = cacheKey
+	return jb
+}
+
+func newTestRunJob(t *testing.T, c byte, compiled job.ID) jobs.Job {
+	t.Helper()
+
+	return jobs.NewRunCppJob(testJobID(t, c), job.StatusOK, 1000, 256, 1000, 256, nil,
+		artifactOf(t, compiled), input.Input{}, output.NewOutput("output"), false)
+}
+
+func TestReduceStageJobs(t *testing.T) {
+	cacheKey := testJobID(t, 'f')
+
+	tests := []struct {
+		name      string
+		stageJobs []jobs.Job
+		wantTypes []job.Type
+	}{
+		{
+			name: "compile and run are chained",
+			stageJobs: []jobs.Job{
+				newTestCompileJob(t, 'a', nil),
+				newTestRunJob(t, 'b', testJobID(t, 'a')),
+			},
+			wantTypes: []job.Type{job.Chain},
+		},
+		{
+			name: "cached compile stays on its own",
+			stageJobs: []jobs.Job{
+				newTestCompileJob(t, 'a', &cacheKey),
+				newTestRunJob(t, 'b', testJobID(t, 'a')),
+			},
+			wantTypes: []job.Type{job.CompileCpp, job.RunCpp},
+		},
+		{
+			name: "two runs of one compile are not chained",
+			stageJobs: []jobs.Job{
+				newTestCompileJob(t, 'a', nil),
+				newTestRunJob(t, 'b', testJobID(t, 'a')),
+				newTestRunJob(t, 'c', testJobID(t, 'a')),
+			},
+			wantTypes: []job.Type{job.CompileCpp, job.RunCpp, job.RunCpp},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reduced := reduceStageJobs(tt.stageJobs)
+			if len(reduced) != len(tt.wantTypes) {
+				t.Fatalf("reduced to %d jobs, want %d", len(reduced), len(tt.wantTypes))
+			}
+			for i, jb := range reduced {
+				if jb.GetType() != tt.wantTypes[i] {
+					t.Fatalf("job %d type = %s, want %s", i, jb.GetType(), tt.wantTypes[i])
+				}
+			}
+		})
+	}
+}
@@ -19,6 +19,8 @@ type (
 		GetError() error
 		SetArtifactTrashTime(*time.Time)
 		GetArtifactTrashTime() *time.Time
+		SetOutputCount(int)
+		GetOutputCount() int
 	}
 
 	Details struct {
@@ -31,6 +33,7 @@ type (
 		UsedMemory        int        `json:"used_memory"`
 		Error             string     `json:"error,omitempty"`
 		ArtifactTrashTime *time.Time `json:"artifact_trash_time,omitempty"`
+		OutputCount       int        `json:"output_count,omitempty"`
 	}
 
 	Type string
@@ -90,3 +93,11 @@ func (res *Details) SetArtifactTrashTime(trashTime *time.Time) {
 func (res *Details) GetArtifactTrashTime() *time.Time {
 	return res.ArtifactTrashTime
 }
+
+func (res *Details) SetOutputCount(outputCount int) {
+	res.OutputCount = outputCount
+}
+
+func (res *Details) GetOutputCount() int {
+	return res.OutputCount
+}
@@ -1,14 +1,21 @@
 package execution
 
 import (
+	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
 )
 
 type (
 	Stage struct {
-		Name StageName   `json:"name"`
-		Deps []StageName `json:"deps"`
-		Jobs []jobs.Job  `json:"jobs"`
+		Name   StageName   `json:"name"`
+		Deps   []StageName `json:"deps"`
+		Policy StagePolicy `json:"policy"`
+		Jobs   []jobs.Job  `json:"jobs"`
+
+		// FanOut is the job whose output items the stage jobs are created from
+		// once it is done, Templates are the definitions of the jobs created per item.
+		FanOut    *job.ID           `json:"fan_out,omitempty"`
+		Templates []jobs.Definition `json:"templates,omitempty"`
 	}
 
 	StageName string
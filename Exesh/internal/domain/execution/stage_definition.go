@@ -3,20 +3,29 @@ package execution
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
 	"fmt"
 )
 
 type (
 	StageDefinition struct {
-		Name StageName         `json:"name"`
-		Deps []StageName       `json:"deps"`
-		Jobs []jobs.Definition `json:"jobs"`
+		Name   StageName         `json:"name"`
+		Deps   []StageName       `json:"deps"`
+		Policy StagePolicy       `json:"policy,omitempty"`
+		Jobs   []jobs.Definition `json:"jobs"`
+
+		// FanOut names the job whose split output the stage is expanded by:
+		// Jobs become templates instantiated once per output item,
+		// with FanOutPlaceholder in their strings replaced by the 1-based item number.
+		FanOut job.DefinitionName `json:"fan_out,omitempty"`
 	}
 
 	StageDefinitions []StageDefinition
 )
 
+const FanOutPlaceholder = "{i}"
+
 func (s StageDefinitions) Value() (driver.Value, error) {
 	b, err := json.Marshal(s)
 	if err != nil {
@@ -0,0 +1,29 @@
+package execution
+
+import "fmt"
+
+// StagePolicy tells the graph what to do once a job of the stage ends with a non-success status.
+type StagePolicy string
+
+const (
+	// StagePolicyFailFast cancels the jobs of the stage which have not started yet
+	// and does not run the stages depending on it.
+	StagePolicyFailFast StagePolicy = "fail_fast"
+	// StagePolicyRunAll runs every job of the stage which does not depend on the failed one,
+	// but does not run the stages depending on it.
+	StagePolicyRunAll StagePolicy = "run_all"
+	// StagePolicyContinueOnFailure runs the stage like StagePolicyRunAll
+	// and then runs the stages depending on it as if it succeeded.
+	StagePolicyContinueOnFailure StagePolicy = "continue_on_failure"
+
+	DefaultStagePolicy = StagePolicyRunAll
+)
+
+func (p StagePolicy) Validate() error {
+	switch p {
+	case StagePolicyFailFast, StagePolicyRunAll, StagePolicyContinueOnFailure:
+		return nil
+	default:
+		return fmt.Errorf("unknown stage policy '%s'", p)
+	}
+}
@@ -0,0 +1,125 @@
+package placement
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+type (
+	Labels map[string][]string
+
+	Constraint struct {
+		Key      string   `json:"key"`
+		Operator Operator `json:"operator"`
+		Values   []string `json:"values,omitempty"`
+	}
+
+	Constraints []Constraint
+
+	Operator string
+)
+
+const (
+	LabelLanguage = "lang"
+	LabelRuntime  = "runtime"
+	LabelCPU      = "cpu"
+
+	OperatorIn        Operator = "in"
+	OperatorNotIn     Operator = "not_in"
+	OperatorExists    Operator = "exists"
+	OperatorNotExists Operator = "not_exists"
+)
+
+func (c Constraint) Validate() error {
+	if c.Key == "" {
+		return fmt.Errorf("constraint key is empty")
+	}
+
+	switch c.Operator {
+	case OperatorIn, OperatorNotIn:
+		if len(c.Values) == 0 {
+			return fmt.Errorf("constraint on '%s' with operator %s requires values", c.Key, c.Operator)
+		}
+	case OperatorExists, OperatorNotExists:
+		break
+	default:
+		return fmt.Errorf("unknown constraint operator '%s'", c.Operator)
+	}
+
+	return nil
+}
+
+func (c Constraint) Match(labels Labels) bool {
+	values, ok := labels[c.Key]
+	switch c.Operator {
+	case OperatorIn:
+		return slices.ContainsFunc(values, func(v string) bool { return slices.Contains(c.Values, v) })
+	case OperatorNotIn:
+		return !slices.ContainsFunc(values, func(v string) bool { return slices.Contains(c.Values, v) })
+	case OperatorExists:
+		return ok
+	case OperatorNotExists:
+		return !ok
+	default:
+		return false
+	}
+}
+
+func (cs Constraints) Validate() error {
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cs Constraints) Match(labels Labels) bool {
+	for _, c := range cs {
+		if !c.Match(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cs Constraints) Value() (driver.Value, error) {
+	b, err := json.Marshal(cs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal constraints: %w", err)
+	}
+	return b, nil
+}
+
+func (cs *Constraints) Scan(src any) error {
+	if src == nil {
+		*cs = nil
+		return nil
+	}
+
+	var data []byte
+
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("failed to scan constraints from type %T", src)
+	}
+
+	if len(data) == 0 {
+		*cs = nil
+		return nil
+	}
+
+	var out Constraints
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Errorf("failed to unmarshal constraints: %w", err)
+	}
+
+	*cs = out
+	return nil
+}
@@ -0,0 +1,156 @@
+package placement
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConstraintsMatch(t *testing.T) {
+	labels := Labels{
+		LabelLanguage: {"cpp", "go"},
+		LabelCPU:      {"x86"},
+	}
+
+	tests := []struct {
+		name        string
+		constraints Constraints
+		want        bool
+	}{
+		{name: "no constraints", want: true},
+		{
+			name:        "in matches any value",
+			constraints: Constraints{{Key: LabelLanguage, Operator: OperatorIn, Values: []string{"py", "go"}}},
+			want:        true,
+		},
+		{
+			name:        "in without common value",
+			constraints: Constraints{{Key: LabelLanguage, Operator: OperatorIn, Values: []string{"py"}}},
+			want:        false,
+		},
+		{
+			name:        "in on missing label",
+			constraints: Constraints{{Key: LabelRuntime, Operator: OperatorIn, Values: []string{"docker"}}},
+			want:        false,
+		},
+		{
+			name:        "not in with common value",
+			constraints: Constraints{{Key: LabelCPU, Operator: OperatorNotIn, Values: []string{"x86"}}},
+			want:        false,
+		},
+		{
+			name:        "not in on missing label",
+			constraints: Constraints{{Key: LabelRuntime, Operator: OperatorNotIn, Values: []string{"docker"}}},
+			want:        true,
+		},
+		{
+			name:        "exists",
+			constraints: Constraints{{Key: LabelCPU, Operator: OperatorExists}},
+			want:        true,
+		},
+		{
+			name:        "not exists",
+			constraints: Constraints{{Key: LabelCPU, Operator: OperatorNotExists}},
+			want:        false,
+		},
+		{
+			name: "all constraints must match",
+			constraints: Constraints{
+				{Key: LabelLanguage, Operator: OperatorIn, Values: []string{"cpp"}},
+				{Key: LabelRuntime, Operator: OperatorExists},
+			},
+			want: false,
+		},
+		{
+			name:        "unknown operator",
+			constraints: Constraints{{Key: LabelCPU, Operator: "like"}},
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.constraints.Match(labels); got != tt.want {
+				t.Fatalf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraintsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints Constraints
+		wantErr     bool
+	}{
+		{name: "no constraints"},
+		{
+			name: "valid constraints",
+			constraints: Constraints{
+				{Key: LabelLanguage, Operator: OperatorIn, Values: []string{"cpp"}},
+				{Key: LabelCPU, Operator: OperatorNotExists},
+			},
+		},
+		{
+			name:        "empty key",
+			constraints: Constraints{{Operator: OperatorExists}},
+			wantErr:     true,
+		},
+		{
+			name:        "in without values",
+			constraints: Constraints{{Key: LabelLanguage, Operator: OperatorIn}},
+			wantErr:     true,
+		},
+		{
+			name:        "not in without values",
+			constraints: Constraints{{Key: LabelLanguage, Operator: OperatorNotIn}},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown operator",
+			constraints: Constraints{{Key: LabelLanguage, Operator: "like", Values: []string{"cpp"}}},
+			wantErr:     true,
+		},
+		{
+			name: "second constraint invalid",
+			constraints: Constraints{
+				{Key: LabelLanguage, Operator: OperatorExists},
+				{Key: "", Operator: OperatorExists},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.constraints.Validate()
+			if tt.wantErr && err == nil {
+				t.Fatal("validate succeeded, want error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestConstraintsScan(t *testing.T) {
+	want := Constraints{{Key: LabelLanguage, Operator: OperatorIn, Values: []string{"cpp"}}}
+	value, err := want.Value()
+	if err != nil {
+		t.Fatalf("value: %v", err)
+	}
+
+	var got Constraints
+	if err = got.Scan(value); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Fatalf("scan = %s, want %s", gotJSON, wantJSON)
+	}
+
+	if err = got.Scan(nil); err != nil || got != nil {
+		t.Fatalf("scan of null = %v, %v, want nil constraints", got, err)
+	}
+}
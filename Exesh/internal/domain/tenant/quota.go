@@ -0,0 +1,67 @@
+package tenant
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	Quota struct {
+		MaxConcurrentExecutions int
+		MaxQueuedWeight         int64
+		MaxExecutionsPerMinute  int
+	}
+
+	// Usage is what the tenant holds in the executions queue at the moment.
+	Usage struct {
+		ActiveExecutions int
+		QueuedWeight     int64
+		RecentExecutions int
+		OldestRecentAt   *time.Time
+	}
+
+	QuotaExceededError struct {
+		Tenant     string
+		Reason     string
+		RetryAfter time.Duration
+	}
+)
+
+// RateWindow is the window MaxExecutionsPerMinute is measured over.
+const RateWindow = time.Minute
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota of tenant '%s' exceeded: %s", e.Tenant, e.Reason)
+}
+
+// Check returns *QuotaExceededError if one more execution of the given weight does not fit the quota.
+// Zero limits are not checked. busyRetryAfter is the hint given when the tenant has to wait
+// for its executions to finish, which cannot be predicted.
+func (q Quota) Check(tenant string, usage Usage, weight int64, now time.Time, busyRetryAfter time.Duration) error {
+	if q.MaxExecutionsPerMinute > 0 && usage.RecentExecutions >= q.MaxExecutionsPerMinute {
+		retryAfter := RateWindow
+		if usage.OldestRecentAt != nil {
+			retryAfter = max(usage.OldestRecentAt.Add(RateWindow).Sub(now), time.Second)
+		}
+		return &QuotaExceededError{
+			Tenant:     tenant,
+			Reason:     fmt.Sprintf("more than %d executions per minute", q.MaxExecutionsPerMinute),
+			RetryAfter: retryAfter,
+		}
+	}
+	if q.MaxConcurrentExecutions > 0 && usage.ActiveExecutions >= q.MaxConcurrentExecutions {
+		return &QuotaExceededError{
+			Tenant:     tenant,
+			Reason:     fmt.Sprintf("more than %d concurrent executions", q.MaxConcurrentExecutions),
+			RetryAfter: busyRetryAfter,
+		}
+	}
+	if q.MaxQueuedWeight > 0 && usage.QueuedWeight+weight > q.MaxQueuedWeight {
+		return &QuotaExceededError{
+			Tenant:     tenant,
+			Reason:     fmt.Sprintf("queued weight exceeds %d", q.MaxQueuedWeight),
+			RetryAfter: busyRetryAfter,
+		}
+	}
+	return nil
+}
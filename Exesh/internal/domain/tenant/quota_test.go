@@ -0,0 +1,102 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuotaCheck(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	oldest := now.Add(-45 * time.Second)
+	justNow := now.Add(-time.Minute + 100*time.Millisecond)
+	busy := 5 * time.Second
+
+	quota := Quota{MaxConcurrentExecutions: 2, MaxQueuedWeight: 100, MaxExecutionsPerMinute: 10}
+
+	tests := []struct {
+		name       string
+		quota      Quota
+		usage      Usage
+		weight     int64
+		wantReason string
+		wantRetry  time.Duration
+	}{
+		{
+			name:   "no limits",
+			quota:  Quota{},
+			usage:  Usage{ActiveExecutions: 1000, QueuedWeight: 1 << 40, RecentExecutions: 1000},
+			weight: 1 << 40,
+		},
+		{
+			name:   "within quota",
+			quota:  quota,
+			usage:  Usage{ActiveExecutions: 1, QueuedWeight: 40, RecentExecutions: 9},
+			weight: 60,
+		},
+		{
+			name:       "rate",
+			quota:      quota,
+			usage:      Usage{RecentExecutions: 10, OldestRecentAt: &oldest},
+			wantReason: "more than 10 executions per minute",
+			wantRetry:  15 * time.Second,
+		},
+		{
+			name:       "rate without the oldest execution",
+			quota:      quota,
+			usage:      Usage{RecentExecutions: 10},
+			wantReason: "more than 10 executions per minute",
+			wantRetry:  RateWindow,
+		},
+		{
+			name:       "rate retries after at least a second",
+			quota:      quota,
+			usage:      Usage{RecentExecutions: 10, OldestRecentAt: &justNow},
+			wantReason: "more than 10 executions per minute",
+			wantRetry:  time.Second,
+		},
+		{
+			name:       "concurrent",
+			quota:      quota,
+			usage:      Usage{ActiveExecutions: 2},
+			wantReason: "more than 2 concurrent executions",
+			wantRetry:  busy,
+		},
+		{
+			name:       "queued weight",
+			quota:      quota,
+			usage:      Usage{QueuedWeight: 40},
+			weight:     61,
+			wantReason: "queued weight exceeds 100",
+			wantRetry:  busy,
+		},
+		{
+			name:       "rate goes first",
+			quota:      quota,
+			usage:      Usage{ActiveExecutions: 2, QueuedWeight: 100, RecentExecutions: 10},
+			weight:     1,
+			wantReason: "more than 10 executions per minute",
+			wantRetry:  RateWindow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quota.Check("t", tt.usage, tt.weight, now, busy)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("check: %v", err)
+				}
+				return
+			}
+
+			var quotaErr *QuotaExceededError
+			if !errors.As(err, &quotaErr) {
+				t.Fatalf("error = %v, want quota exceeded", err)
+			}
+			if quotaErr.Tenant != "t" || quotaErr.Reason != tt.wantReason || quotaErr.RetryAfter != tt.wantRetry {
+				t.Fatalf("error = %+v, want reason %q and retry after %s", quotaErr, tt.wantReason, tt.wantRetry)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ type CompileCppJobExecutor struct {
 	outputProvider outputProvider
 	runtimeFactory runtime.RuntimeFactory
 	runtime        runtime.Runtime
+	toolchains     *executor.ToolchainRegistry
 
 	job jobs.Job
 
@@ -33,6 +34,7 @@ type CompileCppExecutorFactory struct {
 	outputProvider outputProvider
 
 	runtimeFactory runtime.RuntimeFactory
+	toolchains     *executor.ToolchainRegistry
 }
 
 func NewCompileCppExecutorFactory(
@@ -40,6 +42,7 @@ func NewCompileCppExecutorFactory(
 	sourceProvider sourceProvider,
 	outputProvider outputProvider,
 	runtimeFactory runtime.RuntimeFactory,
+	toolchains *executor.ToolchainRegistry,
 ) *CompileCppExecutorFactory {
 	return &CompileCppExecutorFactory{
 		log:            log,
@@ -47,6 +50,7 @@ func NewCompileCppExecutorFactory(
 		outputProvider: outputProvider,
 
 		runtimeFactory: runtimeFactory,
+		toolchains:     toolchains,
 	}
 }
 
@@ -76,6 +80,7 @@ func (f *CompileCppExecutorFactory) CreateWithRuntime(
 		outputProvider:          f.outputProvider,
 		runtimeFactory:          f.runtimeFactory,
 		runtime:                 rt,
+		toolchains:              f.toolchains,
 		runtimeResourceRegistry: runtimeResourceRegistry,
 
 		job: jb,
@@ -142,10 +147,15 @@ func (e *CompileCppJobExecutor) ExecuteCommand(ctx context.Context) results.Resu
 
 	compiledCodeRuntimePath := "a.out"
 
+	compiler, err := e.toolchains.Command(job.CompileCpp, jb.CompileOptions, []string{"g++", "-x", "c++"})
+	if err != nil {
+		return errorResult(fmt.Errorf("failed to resolve toolchain: %w", err))
+	}
+
 	stderr := bytes.NewBuffer(nil)
 	usage, err := e.runtime.RunCommand(
 		ctx,
-		[]string{"g++", "-x", "c++", codeRuntimePath, "-o", compiledCodeRuntimePath},
+		append(compiler, codeRuntimePath, "-o", compiledCodeRuntimePath),
 		runtime.RunParams{
 			Limits: runtime.Limits{
 				Memory: runtime.MemoryLimit(int64(jb.MemoryLimit) * int64(runtime.Megabyte)),
@@ -171,7 +181,7 @@ func (e *CompileCppJobExecutor) ExecuteCommand(ctx context.Context) results.Resu
 func (e *CompileCppJobExecutor) SaveOutput(ctx context.Context, res *results.Result) error {
 	jb := e.job.AsCompileCpp()
 
-	compiledCode, commitOutput, abortOutput, err := e.outputProvider.Reserve(ctx, jb.GetID(), jb.CompiledCode.File)
+	compiledCode, commitOutput, abortOutput, err := e.outputProvider.Reserve(ctx, e.job.GetArtifactID(), jb.CompiledCode.File)
 	if err != nil {
 		trashTime, _ := abortOutput()
 		res.SetArtifactTrashTime(trashTime)
@@ -21,6 +21,7 @@ type CompileGoJobExecutor struct {
 	outputProvider outputProvider
 	runtimeFactory runtime.RuntimeFactory
 	runtime        runtime.Runtime
+	toolchains     *executor.ToolchainRegistry
 
 	job jobs.Job
 
@@ -33,6 +34,7 @@ type CompileGoExecutorFactory struct {
 	outputProvider outputProvider
 
 	runtimeFactory runtime.RuntimeFactory
+	toolchains     *executor.ToolchainRegistry
 }
 
 func NewCompileGoExecutorFactory(
@@ -40,6 +42,7 @@ func NewCompileGoExecutorFactory(
 	sourceProvider sourceProvider,
 	outputProvider outputProvider,
 	runtimeFactory runtime.RuntimeFactory,
+	toolchains *executor.ToolchainRegistry,
 ) *CompileGoExecutorFactory {
 	return &CompileGoExecutorFactory{
 		log:            log,
@@ -47,6 +50,7 @@ func NewCompileGoExecutorFactory(
 		outputProvider: outputProvider,
 
 		runtimeFactory: runtimeFactory,
+		toolchains:     toolchains,
 	}
 }
 
@@ -76,6 +80,7 @@ func (f *CompileGoExecutorFactory) CreateWithRuntime(
 		outputProvider:          f.outputProvider,
 		runtimeFactory:          f.runtimeFactory,
 		runtime:                 rt,
+		toolchains:              f.toolchains,
 		runtimeResourceRegistry: runtimeResourceRegistry,
 
 		job: jb,
@@ -140,11 +145,17 @@ func (e *CompileGoJobExecutor) ExecuteCommand(ctx context.Context) results.Resul
 		return errorResult(fmt.Errorf("failed to get code runtime path: %w", err))
 	}
 
-	stderr := bytes.NewBuffer(nil)
 	compiledCodeRuntimePath := "bin"
+
+	compiler, err := e.toolchains.Command(job.CompileGo, jb.CompileOptions, []string{"/usr/local/go/bin/go", "build"})
+	if err != nil {
+		return errorResult(fmt.Errorf("failed to resolve toolchain: %w", err))
+	}
+
+	stderr := bytes.NewBuffer(nil)
 	usage, err := e.runtime.RunCommand(
 		ctx,
-		[]string{"/usr/local/go/bin/go", "build", "-o", compiledCodeRuntimePath, codeRuntimePath},
+		append(compiler, "-o", compiledCodeRuntimePath, codeRuntimePath),
 		runtime.RunParams{
 			Limits: runtime.Limits{
 				Memory: runtime.MemoryLimit(int64(jb.MemoryLimit) * int64(runtime.Megabyte)),
@@ -170,7 +181,7 @@ func (e *CompileGoJobExecutor) ExecuteCommand(ctx context.Context) results.Resul
 func (e *CompileGoJobExecutor) SaveOutput(ctx context.Context, res *results.Result) error {
 	jb := e.job.AsCompileGo()
 
-	compiledCode, commitOutput, abortOutput, err := e.outputProvider.Reserve(ctx, jb.GetID(), jb.CompiledCode.File)
+	compiledCode, commitOutput, abortOutput, err := e.outputProvider.Reserve(ctx, e.job.GetArtifactID(), jb.CompiledCode.File)
 	if err != nil {
 		trashTime, _ := abortOutput()
 		res.SetArtifactTrashTime(trashTime)
@@ -229,7 +229,7 @@ func (e *RunCppJobExecutor) SaveOutput(ctx context.Context, res *results.Result)
 		trashTime, _ := abortOutput()
 		res.SetArtifactTrashTime(trashTime)
 		if errors.Is(err, errs.ErrFileAlreadyExists) {
-			return nil
+			return e.saveSplitOutput(ctx, res)
 		}
 		return fmt.Errorf("failed to reserve run_output output: %w", err)
 	}
@@ -259,7 +259,16 @@ func (e *RunCppJobExecutor) SaveOutput(ctx context.Context, res *results.Result)
 		return commitErr
 	}
 
-	return nil
+	return e.saveSplitOutput(ctx, res)
+}
+
+func (e *RunCppJobExecutor) saveSplitOutput(ctx context.Context, res *results.Result) error {
+	jb := e.job.AsRunCpp()
+	if jb.SplitOutput == "" {
+		return nil
+	}
+	return saveSplitOutput(ctx, e.runtime, e.outputProvider, e.runtimeResourceRegistry,
+		jb.GetID(), jb.RunOutput.File, jb.SplitOutput, res)
 }
 
 func (e *RunCppJobExecutor) Stop(ctx context.Context) error {
@@ -226,7 +226,7 @@ func (e *RunPyJobExecutor) SaveOutput(ctx context.Context, res *results.Result)
 		trashTime, _ := abortOutput()
 		res.SetArtifactTrashTime(trashTime)
 		if errors.Is(err, errs.ErrFileAlreadyExists) {
-			return nil
+			return e.saveSplitOutput(ctx, res)
 		}
 		return fmt.Errorf("failed to reserve run_output output: %w", err)
 	}
@@ -256,7 +256,16 @@ func (e *RunPyJobExecutor) SaveOutput(ctx context.Context, res *results.Result)
 		return commitErr
 	}
 
-	return nil
+	return e.saveSplitOutput(ctx, res)
+}
+
+func (e *RunPyJobExecutor) saveSplitOutput(ctx context.Context, res *results.Result) error {
+	jb := e.job.AsRunPy()
+	if jb.SplitOutput == "" {
+		return nil
+	}
+	return saveSplitOutput(ctx, e.runtime, e.outputProvider, e.runtimeResourceRegistry,
+		jb.GetID(), jb.RunOutput.File, jb.SplitOutput, res)
 }
 
 func (e *RunPyJobExecutor) Stop(ctx context.Context) error {
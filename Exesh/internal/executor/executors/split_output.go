@@ -0,0 +1,87 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/result/results"
+	"exesh/internal/executor"
+	"exesh/internal/runtime"
+	"fmt"
+	errs "github.com/DIvanCode/filestorage/pkg/errors"
+	"os"
+	"strings"
+)
+
+// saveSplitOutput cuts the run output at the separator lines and saves every item
+// into its own bucket (see job.ItemID), so fan-out jobs can take them as separate inputs.
+func saveSplitOutput(
+	ctx context.Context,
+	rt runtime.Runtime,
+	outputProvider outputProvider,
+	runtimeResourceRegistry *executor.RuntimeResourceRegistry,
+	jobID job.ID,
+	file string,
+	separator string,
+	res *results.Result,
+) error {
+	runOutputRuntimePath, err := executor.GetJobOutputRuntimePath(runtimeResourceRegistry, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get run_output runtimePath: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("/tmp", "*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary run output file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	defer func() { _ = tmp.Close() }()
+
+	if err = rt.CopyFromRuntime(ctx, runOutputRuntimePath, tmp.Name()); err != nil {
+		return fmt.Errorf("failed to copy run_output from runtime: %w", err)
+	}
+	out, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read run_output: %w", err)
+	}
+
+	items := splitOutput(string(out), separator)
+	for i, item := range items {
+		itemPath, commit, abort, err := outputProvider.Reserve(ctx, job.ItemID(jobID, i+1), file)
+		if err != nil {
+			_, _ = abort()
+			if errors.Is(err, errs.ErrFileAlreadyExists) {
+				continue
+			}
+			return fmt.Errorf("failed to reserve output item %d: %w", i+1, err)
+		}
+		if err = os.WriteFile(itemPath, []byte(item), 0644); err != nil {
+			_, _ = abort()
+			return fmt.Errorf("failed to write output item %d: %w", i+1, err)
+		}
+		if _, err = commit(); err != nil {
+			_, _ = abort()
+			return fmt.Errorf("failed to commit output item %d: %w", i+1, err)
+		}
+	}
+
+	res.SetOutputCount(len(items))
+	return nil
+}
+
+func splitOutput(out string, separator string) []string {
+	items := make([]string, 0)
+	var item strings.Builder
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if strings.TrimRight(line, "\r\n") == separator {
+			items = append(items, item.String())
+			item.Reset()
+			continue
+		}
+		item.WriteString(line)
+	}
+	if item.Len() > 0 {
+		items = append(items, item.String())
+	}
+	return items
+}
@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"exesh/internal/config"
+	"exesh/internal/domain/execution/job"
+	"fmt"
+	"slices"
+)
+
+type ToolchainRegistry struct {
+	toolchains map[string]config.ToolchainConfig
+	defaults   map[job.Type]config.ToolchainConfig
+}
+
+func NewToolchainRegistry(cfgs []config.ToolchainConfig) *ToolchainRegistry {
+	r := &ToolchainRegistry{
+		toolchains: make(map[string]config.ToolchainConfig, len(cfgs)),
+		defaults:   make(map[job.Type]config.ToolchainConfig),
+	}
+	for _, cfg := range cfgs {
+		r.toolchains[cfg.ID] = cfg
+		if cfg.Default {
+			r.defaults[job.Type(cfg.JobType)] = cfg
+		}
+	}
+	return r
+}
+
+// Validate
+// returns the error Command would return for the job
+func (r *ToolchainRegistry) Validate(jobType job.Type, opts job.CompileOptions) error {
+	_, err := r.Command(jobType, opts, nil)
+	return err
+}
+
+// Command
+// returns the compiler command for the job with the requested flags appended
+// if the job does not request a toolchain, the default one for the job type is used,
+// and if there is no default toolchain, the builtin command is used
+func (r *ToolchainRegistry) Command(jobType job.Type, opts job.CompileOptions, builtin []string) ([]string, error) {
+	var (
+		toolchain config.ToolchainConfig
+		ok        bool
+	)
+	if opts.Toolchain == "" {
+		toolchain, ok = r.defaults[jobType]
+		if !ok {
+			if len(opts.Flags) > 0 {
+				return nil, fmt.Errorf("flags are not allowed without toolchain")
+			}
+			return slices.Clone(builtin), nil
+		}
+	} else {
+		toolchain, ok = r.toolchains[opts.Toolchain]
+		if !ok {
+			return nil, fmt.Errorf("unknown toolchain %s", opts.Toolchain)
+		}
+		if job.Type(toolchain.JobType) != jobType {
+			return nil, fmt.Errorf("toolchain %s does not support %s jobs", opts.Toolchain, jobType)
+		}
+	}
+
+	for _, flag := range opts.Flags {
+		if !slices.Contains(toolchain.AllowedFlags, flag) {
+			return nil, fmt.Errorf("flag %s is not allowed for toolchain %s", flag, toolchain.ID)
+		}
+	}
+
+	cmd := slices.Clone(toolchain.Command)
+	return append(cmd, opts.Flags...), nil
+}
@@ -0,0 +1,95 @@
+package executor
+
+import (
+	"exesh/internal/config"
+	"exesh/internal/domain/execution/job"
+	"slices"
+	"testing"
+)
+
+func TestToolchainRegistryCommand(t *testing.T) {
+	registry := NewToolchainRegistry([]config.ToolchainConfig{
+		{
+			ID:           "gcc-cpp17",
+			JobType:      string(job.CompileCpp),
+			Command:      []string{"g++", "-x", "c++", "-std=c++17"},
+			AllowedFlags: []string{"-O0", "-O2"},
+			Default:      true,
+		},
+		{
+			ID:           "gcc-cpp20",
+			JobType:      string(job.CompileCpp),
+			Command:      []string{"g++", "-x", "c++", "-std=c++20"},
+			AllowedFlags: []string{"-O2"},
+		},
+	})
+	builtin := []string{"builtin"}
+
+	tests := []struct {
+		name    string
+		jobType job.Type
+		opts    job.CompileOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "default toolchain",
+			jobType: job.CompileCpp,
+			opts:    job.CompileOptions{Flags: []string{"-O0"}},
+			want:    []string{"g++", "-x", "c++", "-std=c++17", "-O0"},
+		},
+		{
+			name:    "requested toolchain",
+			jobType: job.CompileCpp,
+			opts:    job.CompileOptions{Toolchain: "gcc-cpp20", Flags: []string{"-O2"}},
+			want:    []string{"g++", "-x", "c++", "-std=c++20", "-O2"},
+		},
+		{
+			name:    "builtin command without default toolchain",
+			jobType: job.CompileGo,
+			want:    []string{"builtin"},
+		},
+		{
+			name:    "flags without toolchain",
+			jobType: job.CompileGo,
+			opts:    job.CompileOptions{Flags: []string{"-race"}},
+			wantErr: true,
+		},
+		{
+			name:    "flag not in whitelist",
+			jobType: job.CompileCpp,
+			opts:    job.CompileOptions{Toolchain: "gcc-cpp20", Flags: []string{"-O0"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown toolchain",
+			jobType: job.CompileCpp,
+			opts:    job.CompileOptions{Toolchain: "clang"},
+			wantErr: true,
+		},
+		{
+			name:    "toolchain of another job type",
+			jobType: job.CompileGo,
+			opts:    job.CompileOptions{Toolchain: "gcc-cpp20"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Command(tt.jobType, tt.opts, builtin)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("command = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("command: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("command = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package factory
 import (
 	"context"
 	"crypto/sha1"
+	"encoding/json"
 	"exesh/internal/config"
 	"exesh/internal/domain/execution"
 	"exesh/internal/domain/execution/input"
@@ -12,8 +13,13 @@ import (
 	"exesh/internal/domain/execution/output"
 	"exesh/internal/domain/execution/source"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/executor"
 	"fmt"
 	"github.com/DIvanCode/filestorage/pkg/bucket"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,11 +28,13 @@ type (
 		cfg         config.JobFactoryConfig
 		filestorage filestorage
 		calc        calculator
+		toolchains  *executor.ToolchainRegistry
 	}
 
 	filestorage interface {
 		DownloadBucket(context.Context, bucket.ID, time.Duration, string) error
 		DownloadFile(context.Context, bucket.ID, string, time.Duration, string) error
+		ReadFile(context.Context, bucket.ID, string, time.Duration) (io.Reader, func(), error)
 	}
 
 	calculator interface {
@@ -40,11 +48,40 @@ func NewExecutionFactory(
 	filestorage filestorage,
 	calc calculator,
 ) *ExecutionFactory {
-	return &ExecutionFactory{
+	f := &ExecutionFactory{
 		cfg:         cfg,
 		filestorage: filestorage,
 		calc:        calc,
 	}
+	if len(cfg.Toolchains) > 0 {
+		f.toolchains = executor.NewToolchainRegistry(cfg.Toolchains)
+	}
+	return f
+}
+
+// ValidateStages checks the compile options of the jobs against the configured toolchains, so that a job
+// no worker can compile fails the submission instead of the execution.
+func (f *ExecutionFactory) ValidateStages(stages execution.StageDefinitions) error {
+	if f.toolchains == nil {
+		return nil
+	}
+	for _, stage := range stages {
+		for _, def := range stage.Jobs {
+			var opts job.CompileOptions
+			switch def.GetType() {
+			case job.CompileCpp:
+				opts = def.AsCompileCpp().CompileOptions
+			case job.CompileGo:
+				opts = def.AsCompileGo().CompileOptions
+			default:
+				continue
+			}
+			if err := f.toolchains.Validate(def.GetType(), f.resolveToolchain(def.GetType(), opts)); err != nil {
+				return fmt.Errorf("invalid compile options of job '%s': %w", def.GetName(), err)
+			}
+		}
+	}
+	return nil
 }
 
 func (f *ExecutionFactory) Create(ctx context.Context, def execution.Definition) (*execution.Execution, error) {
@@ -62,7 +99,7 @@ func (f *ExecutionFactory) Create(ctx context.Context, def execution.Definition)
 	}
 
 	for _, stageDef := range def.Stages {
-		stage, err := f.createStage(ex, stageDef, categoryStats)
+		stage, err := f.createStage(ctx, ex, stageDef, categoryStats)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create stage '%s': %w", stageDef.Name, err)
 		}
@@ -110,18 +147,34 @@ func (f *ExecutionFactory) saveSource(ctx context.Context, ex *execution.Executi
 }
 
 func (f *ExecutionFactory) createStage(
+	ctx context.Context,
 	ex *execution.Execution,
 	def execution.StageDefinition,
 	categoryStats execution.CategoryStats,
 ) (*execution.Stage, error) {
 	stage := execution.Stage{
-		Name: def.Name,
-		Deps: def.Deps,
-		Jobs: make([]jobs.Job, 0, len(def.Jobs)),
+		Name:   def.Name,
+		Deps:   def.Deps,
+		Policy: def.Policy,
+		Jobs:   make([]jobs.Job, 0, len(def.Jobs)),
+	}
+	if stage.Policy == "" {
+		stage.Policy = execution.DefaultStagePolicy
+	}
+
+	if def.FanOut != "" {
+		producer, ok := ex.JobByName[def.FanOut]
+		if !ok {
+			return nil, fmt.Errorf("failed to find fan-out job '%s'", def.FanOut)
+		}
+		producerID := producer.GetID()
+		stage.FanOut = &producerID
+		stage.Templates = def.Jobs
+		return &stage, nil
 	}
 
 	for _, jobDef := range def.Jobs {
-		jb, err := f.createJob(ex, jobDef, categoryStats)
+		jb, err := f.createJob(ctx, ex, jobDef, categoryStats, 0)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create job '%s': %w", jobDef.GetName(), err)
 		}
@@ -133,10 +186,61 @@ func (f *ExecutionFactory) createStage(
 	return &stage, nil
 }
 
+// ExpandStage creates the jobs of the fan-out stage, one set of templates per output item.
+func (f *ExecutionFactory) ExpandStage(
+	ctx context.Context,
+	ex *execution.Execution,
+	stage *execution.Stage,
+	items int,
+) ([]jobs.Job, error) {
+	categoryStats, err := f.calc.LoadCategoryStats(ctx, execution.StageDefinitions{{Jobs: stage.Templates}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load expected values by category: %w", err)
+	}
+
+	stageJobs := make([]jobs.Job, 0, items*len(stage.Templates))
+	for item := 1; item <= items; item++ {
+		for _, template := range stage.Templates {
+			jobDef, err := instantiateTemplate(template, item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to instantiate job '%s': %w", template.GetName(), err)
+			}
+
+			jb, err := f.createJob(ctx, ex, jobDef, categoryStats, item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create job '%s': %w", jobDef.GetName(), err)
+			}
+
+			stageJobs = append(stageJobs, jb)
+			ex.JobByName[jobDef.GetName()] = jb
+		}
+	}
+
+	return stageJobs, nil
+}
+
+// instantiateTemplate replaces the fan-out placeholder in every string of the job definition with the item number.
+func instantiateTemplate(template jobs.Definition, item int) (jobs.Definition, error) {
+	var def jobs.Definition
+
+	data, err := json.Marshal(template)
+	if err != nil {
+		return def, fmt.Errorf("failed to marshal job template: %w", err)
+	}
+	data = []byte(strings.ReplaceAll(string(data), execution.FanOutPlaceholder, strconv.Itoa(item)))
+	if err = json.Unmarshal(data, &def); err != nil {
+		return def, fmt.Errorf("failed to unmarshal job template: %w", err)
+	}
+
+	return def, nil
+}
+
 func (f *ExecutionFactory) createJob(
+	ctx context.Context,
 	ex *execution.Execution,
 	def jobs.Definition,
 	categoryStats execution.CategoryStats,
+	item int,
 ) (jobs.Job, error) {
 	var jb jobs.Job
 
@@ -149,94 +253,108 @@ func (f *ExecutionFactory) createJob(
 	timeLimit := def.GetTimeLimit()
 	memoryLimit := def.GetMemoryLimit()
 	expectedTime, expectedMemory := f.calc.EstimateForJob(def, categoryStats)
+	constraints := append(slices.Clone(ex.Constraints), def.GetConstraints()...)
 
 	switch def.GetType() {
 	case job.CompileCpp:
 		typedDef := def.AsCompileCpp()
 
-		code, err := f.createInput(ex, typedDef.Code)
+		code, err := f.createInput(ex, typedDef.Code, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create code source: %w", err)
 		}
 		compiledCode := output.NewOutput(f.cfg.Output.CompiledBinary)
+		compileOptions := f.resolveToolchain(def.GetType(), typedDef.CompileOptions)
 
-		jb = jobs.NewCompileCppJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, code, compiledCode)
+		jb = jobs.NewCompileCppJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, constraints, code, compiledCode, compileOptions)
+		jb.AsCompileCpp().CacheKey, err = f.calculateCompileCacheKey(ctx, ex, def.GetType(), typedDef.Code, compileOptions)
+		if err != nil {
+			return jb, fmt.Errorf("failed to calculate compile cache key: %w", err)
+		}
 	case job.CompileGo:
 		typedDef := def.AsCompileGo()
 
-		code, err := f.createInput(ex, typedDef.Code)
+		code, err := f.createInput(ex, typedDef.Code, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create code source: %w", err)
 		}
 		compiledCode := output.NewOutput(f.cfg.Output.CompiledBinary)
+		compileOptions := f.resolveToolchain(def.GetType(), typedDef.CompileOptions)
 
-		jb = jobs.NewCompileGoJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, code, compiledCode)
+		jb = jobs.NewCompileGoJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, constraints, code, compiledCode, compileOptions)
+		jb.AsCompileGo().CacheKey, err = f.calculateCompileCacheKey(ctx, ex, def.GetType(), typedDef.Code, compileOptions)
+		if err != nil {
+			return jb, fmt.Errorf("failed to calculate compile cache key: %w", err)
+		}
 	case job.RunCpp:
 		typedDef := def.AsRunCpp()
 
-		compiledCode, err := f.createInput(ex, typedDef.CompiledCode)
+		compiledCode, err := f.createInput(ex, typedDef.CompiledCode, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create compiled_code source: %w", err)
 		}
-		runInput, err := f.createInput(ex, typedDef.RunInput)
+		runInput, err := f.createInput(ex, typedDef.RunInput, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create run_input source: %w", err)
 		}
 		runOutput := output.NewOutput(f.cfg.Output.RunOutput)
 		showOutput := typedDef.ShowOutput
 
-		jb = jobs.NewRunCppJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, compiledCode, runInput, runOutput, showOutput)
+		jb = jobs.NewRunCppJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, constraints, compiledCode, runInput, runOutput, showOutput)
+		jb.AsRunCpp().SplitOutput = typedDef.SplitOutput
 	case job.RunGo:
 		typedDef := def.AsRunGo()
 
-		compiledCode, err := f.createInput(ex, typedDef.CompiledCode)
+		compiledCode, err := f.createInput(ex, typedDef.CompiledCode, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create compiled_code source: %w", err)
 		}
-		runInput, err := f.createInput(ex, typedDef.RunInput)
+		runInput, err := f.createInput(ex, typedDef.RunInput, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create run_input source: %w", err)
 		}
 		runOutput := output.NewOutput(f.cfg.Output.RunOutput)
 		showOutput := typedDef.ShowOutput
 
-		jb = jobs.NewRunGoJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, compiledCode, runInput, runOutput, showOutput)
+		jb = jobs.NewRunGoJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, constraints, compiledCode, runInput, runOutput, showOutput)
+		jb.AsRunGo().SplitOutput = typedDef.SplitOutput
 	case job.RunPy:
 		typedDef := def.AsRunPy()
 
-		code, err := f.createInput(ex, typedDef.Code)
+		code, err := f.createInput(ex, typedDef.Code, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create code source: %w", err)
 		}
-		runInput, err := f.createInput(ex, typedDef.RunInput)
+		runInput, err := f.createInput(ex, typedDef.RunInput, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create run_input source: %w", err)
 		}
 		runOutput := output.NewOutput(f.cfg.Output.RunOutput)
 		showOutput := typedDef.ShowOutput
 
-		jb = jobs.NewRunPyJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, code, runInput, runOutput, showOutput)
+		jb = jobs.NewRunPyJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, constraints, code, runInput, runOutput, showOutput)
+		jb.AsRunPy().SplitOutput = typedDef.SplitOutput
 	case job.CheckCpp:
 		typedDef := def.AsCheckCpp()
 
-		compiledChecker, err := f.createInput(ex, typedDef.CompiledChecker)
+		compiledChecker, err := f.createInput(ex, typedDef.CompiledChecker, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create compiled_checker source: %w", err)
 		}
-		testInput, err := f.createInput(ex, typedDef.TestInput)
+		testInput, err := f.createInput(ex, typedDef.TestInput, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create test_input source: %w", err)
 		}
-		correctOutput, err := f.createInput(ex, typedDef.CorrectOutput)
+		correctOutput, err := f.createInput(ex, typedDef.CorrectOutput, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create correct_output source: %w", err)
 		}
-		suspectOutput, err := f.createInput(ex, typedDef.SuspectOutput)
+		suspectOutput, err := f.createInput(ex, typedDef.SuspectOutput, item)
 		if err != nil {
 			return jb, fmt.Errorf("failed to create suspect_output source: %w", err)
 		}
 
-		jb = jobs.NewCheckCppJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, compiledChecker, testInput, correctOutput, suspectOutput)
+		jb = jobs.NewCheckCppJob(id, successStatus, timeLimit, memoryLimit, expectedTime, expectedMemory, constraints, compiledChecker, testInput, correctOutput, suspectOutput)
 	default:
 		return jb, fmt.Errorf("unknown job type %s", def.GetType())
 	}
@@ -247,11 +365,14 @@ func (f *ExecutionFactory) createJob(
 	if out != nil {
 		ex.OutputByJob[jb.GetID()] = *out
 	}
+	if cacheKey := jb.GetCacheKey(); cacheKey != nil {
+		ex.CacheKeyByJob[jb.GetID()] = *cacheKey
+	}
 
 	return jb, nil
 }
 
-func (f *ExecutionFactory) createInput(ex *execution.Execution, def inputs.Definition) (input.Input, error) {
+func (f *ExecutionFactory) createInput(ex *execution.Execution, def inputs.Definition, item int) (input.Input, error) {
 	var in input.Input
 
 	switch def.GetType() {
@@ -326,6 +447,20 @@ func (f *ExecutionFactory) createInput(ex *execution.Execution, def inputs.Defin
 		}
 
 		jobID := jb.GetID()
+		if typedDef.Item {
+			if item == 0 {
+				return in, fmt.Errorf("item of job '%s' is used outside of a fan-out stage", typedDef.JobDefinitionName)
+			}
+			out, ok := ex.OutputByJob[jobID]
+			if !ok {
+				return in, fmt.Errorf("failed to find output of job '%s'", typedDef.JobDefinitionName)
+			}
+
+			itemID := job.ItemID(jobID, item)
+			ex.ItemProducers[itemID] = jobID
+			ex.OutputByJob[itemID] = out
+			jobID = itemID
+		}
 		var sourceID source.ID
 		if err := sourceID.FromString(jobID.String()); err != nil {
 			return in, fmt.Errorf("failed to calculate source id: %w", err)
@@ -339,6 +474,91 @@ func (f *ExecutionFactory) createInput(ex *execution.Execution, def inputs.Defin
 	return in, nil
 }
 
+// resolveToolchain names the configured default toolchain of the job type in the options
+// which do not name one, so that the job is compiled by it on any worker.
+func (f *ExecutionFactory) resolveToolchain(jobType job.Type, opts job.CompileOptions) job.CompileOptions {
+	if opts.Toolchain != "" {
+		return opts
+	}
+	opts.Toolchain = f.cfg.DefaultToolchains[string(jobType)]
+	return opts
+}
+
+// calculateCompileCacheKey hashes the code together with the toolchain and flags,
+// so equal compilations share their output across executions.
+// The code of a bucket is hashed by its content: a bucket keeps its ID when the task it belongs to is uploaded again.
+// Returns nil if the code is not known before the execution (e.g. it is an artifact),
+// or if no toolchain is named: the worker would compile with its own default, which
+// may differ between workers and change over time.
+func (f *ExecutionFactory) calculateCompileCacheKey(
+	ctx context.Context,
+	ex *execution.Execution,
+	jobType job.Type,
+	code inputs.Definition,
+	opts job.CompileOptions,
+) (*job.ID, error) {
+	if !f.cfg.CompileCache || opts.Toolchain == "" {
+		return nil, nil
+	}
+
+	vars := []string{"compile", string(jobType), opts.Toolchain, strings.Join(opts.Flags, " ")}
+	switch code.GetType() {
+	case input.InlineDefinition:
+		srcDef, ok := ex.SourceDefinitionByName[code.AsInline().SourceDefinitionName]
+		if !ok {
+			return nil, fmt.Errorf("failed to find source definition '%s'", code.AsInline().SourceDefinitionName)
+		}
+		vars = append(vars, "inline", srcDef.AsInlineDefinition().Content)
+	case input.FilestorageBucketDefinition:
+		srcDef, ok := ex.SourceDefinitionByName[code.AsFilestorageBucket().SourceDefinitionName]
+		if !ok {
+			return nil, fmt.Errorf("failed to find source definition '%s'", code.AsFilestorageBucket().SourceDefinitionName)
+		}
+		digest, err := f.calculateFileDigest(ctx, srcDef.AsFilestorageBucketDefinition().BucketID, code.AsFilestorageBucket().File)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, "file", digest)
+	case input.FilestorageBucketFileDefinition:
+		srcDef, ok := ex.SourceDefinitionByName[code.AsFilestorageBucketFile().SourceDefinitionName]
+		if !ok {
+			return nil, fmt.Errorf("failed to find source definition '%s'", code.AsFilestorageBucketFile().SourceDefinitionName)
+		}
+		typedSrcDef := srcDef.AsFilestorageBucketFileDefinition()
+		digest, err := f.calculateFileDigest(ctx, typedSrcDef.BucketID, typedSrcDef.File)
+		if err != nil {
+			return nil, err
+		}
+		vars = append(vars, "file", digest)
+	default:
+		return nil, nil
+	}
+
+	cacheKey, err := f.calculateJobID(strings.Join(vars, "\x00"))
+	if err != nil {
+		return nil, err
+	}
+	return &cacheKey, nil
+}
+
+// calculateFileDigest hashes the content of the file of the source bucket, downloaded by saveSource.
+func (f *ExecutionFactory) calculateFileDigest(ctx context.Context, bucketID bucket.ID, file string) (string, error) {
+	r, unlock, err := f.filestorage.ReadFile(ctx, bucketID, file, f.cfg.SourceTTL.FilestorageBucket)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s of bucket %s: %w", file, bucketID, err)
+	}
+	defer unlock()
+	if closer, ok := r.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	hash := sha1.New()
+	if _, err = io.Copy(hash, r); err != nil {
+		return "", fmt.Errorf("failed to read file %s of bucket %s: %w", file, bucketID, err)
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
 func (f *ExecutionFactory) calculateSourceID(vars ...string) (source.ID, error) {
 	var id source.ID
 
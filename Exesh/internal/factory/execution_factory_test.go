@@ -0,0 +1,203 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/input/inputs"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/source"
+	"exesh/internal/domain/execution/source/sources"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DIvanCode/filestorage/pkg/bucket"
+)
+
+// stubFilestorage serves the files of the buckets from memory.
+type stubFilestorage map[string]string
+
+func (stubFilestorage) DownloadBucket(context.Context, bucket.ID, time.Duration, string) error {
+	return nil
+}
+
+func (stubFilestorage) DownloadFile(context.Context, bucket.ID, string, time.Duration, string) error {
+	return nil
+}
+
+func (s stubFilestorage) ReadFile(_ context.Context, bucketID bucket.ID, file string, _ time.Duration) (io.Reader, func(), error) {
+	content, ok := s[bucketID.String()+"/"+file]
+	if !ok {
+		return nil, nil, fmt.Errorf("file %s of bucket %s not found", file, bucketID)
+	}
+	return strings.NewReader(content), func() {}, nil
+}
+
+func TestCompileCacheKeyNamesToolchain(t *testing.T) {
+	var code inputs.Definition
+	if err := json.Unmarshal([]byte(`{"type":"inline","source":"main"}`), &code); err != nil {
+		t.Fatalf("unmarshal code: %v", err)
+	}
+	var srcDef sources.Definition
+	if err := json.Unmarshal([]byte(`{"type":"inline","name":"main","content":"int main() {}"}`), &srcDef); err != nil {
+		t.Fatalf("unmarshal source: %v", err)
+	}
+	ex := &execution.Execution{SourceDefinitionByName: map[source.DefinitionName]sources.Definition{"main": srcDef}}
+
+	key := func(defaults map[string]string, opts job.CompileOptions) *job.ID {
+		t.Helper()
+
+		f := NewExecutionFactory(config.JobFactoryConfig{CompileCache: true, DefaultToolchains: defaults}, nil, nil)
+		opts = f.resolveToolchain(job.CompileCpp, opts)
+		cacheKey, err := f.calculateCompileCacheKey(context.Background(), ex, job.CompileCpp, code, opts)
+		if err != nil {
+			t.Fatalf("calculate compile cache key: %v", err)
+		}
+		return cacheKey
+	}
+
+	if cacheKey := key(nil, job.CompileOptions{}); cacheKey != nil {
+		t.Fatalf("cache key without a toolchain = %s, want none", cacheKey)
+	}
+
+	gcc := key(map[string]string{string(job.CompileCpp): "gcc"}, job.CompileOptions{})
+	clang := key(map[string]string{string(job.CompileCpp): "clang"}, job.CompileOptions{})
+	named := key(nil, job.CompileOptions{Toolchain: "gcc"})
+	if gcc == nil || clang == nil || named == nil {
+		t.Fatalf("cache keys = %v, %v, %v, want all set", gcc, clang, named)
+	}
+	if *gcc == *clang {
+		t.Fatal("changing the default toolchain kept the cache key")
+	}
+	if *gcc != *named {
+		t.Fatal("the default toolchain and the same named toolchain have different cache keys")
+	}
+}
+
+func TestCompileCacheKeyHashesBucketContent(t *testing.T) {
+	var bucketID bucket.ID
+	if err := bucketID.FromString(strings.Repeat("a", len(bucketID))); err != nil {
+		t.Fatalf("bucket id: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		code   string
+		source source.IDefinition
+	}{
+		{
+			name: "bucket",
+			code: `{"type":"filestorage_bucket","source":"task","file":"checker.cpp"}`,
+			source: &sources.FilestorageBucketSourceDefinition{
+				DefinitionDetails: source.DefinitionDetails{Type: source.FilestorageBucketDefinition, Name: "task"},
+				BucketID:          bucketID,
+			},
+		},
+		{
+			name: "bucket file",
+			code: `{"type":"filestorage_bucket_file","source":"checker"}`,
+			source: &sources.FilestorageBucketFileSourceDefinition{
+				DefinitionDetails: source.DefinitionDetails{Type: source.FilestorageBucketFileDefinition, Name: "checker"},
+				BucketID:          bucketID,
+				File:              "checker.cpp",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var code inputs.Definition
+			if err := json.Unmarshal([]byte(tt.code), &code); err != nil {
+				t.Fatalf("unmarshal code: %v", err)
+			}
+			srcDef := sources.Definition{IDefinition: tt.source}
+			ex := &execution.Execution{SourceDefinitionByName: map[source.DefinitionName]sources.Definition{srcDef.GetName(): srcDef}}
+			opts := job.CompileOptions{Toolchain: "gcc"}
+
+			key := func(content string) *job.ID {
+				t.Helper()
+
+				fs := stubFilestorage{bucketID.String() + "/checker.cpp": content}
+				f := NewExecutionFactory(config.JobFactoryConfig{CompileCache: true}, fs, nil)
+				cacheKey, err := f.calculateCompileCacheKey(context.Background(), ex, job.CompileCpp, code, opts)
+				if err != nil {
+					t.Fatalf("calculate compile cache key: %v", err)
+				}
+				if cacheKey == nil {
+					t.Fatal("cache key = nil, want one")
+				}
+				return cacheKey
+			}
+
+			first, same, changed := key("int main() {}"), key("int main() {}"), key("int main() { return 1; }")
+			if *first != *same {
+				t.Fatal("the same content has different cache keys")
+			}
+			if *first == *changed {
+				t.Fatal("changing the content of the bucket file kept the cache key")
+			}
+		})
+	}
+}
+
+func TestValidateStagesChecksToolchains(t *testing.T) {
+	cfg := config.JobFactoryConfig{
+		DefaultToolchains: map[string]string{string(job.CompileCpp): "gcc"},
+		Toolchains: []config.ToolchainConfig{
+			{ID: "gcc", JobType: string(job.CompileCpp), AllowedFlags: []string{"-O2"}, Default: true},
+			{ID: "go", JobType: string(job.CompileGo), Default: true},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		job     string
+		wantErr bool
+	}{
+		{
+			name: "default toolchain",
+			job:  `{"type":"compile_cpp","name":"compile","code":{"type":"inline","source":"main"}}`,
+		},
+		{
+			name: "allowed flag",
+			job:  `{"type":"compile_cpp","name":"compile","toolchain":"gcc","flags":["-O2"],"code":{"type":"inline","source":"main"}}`,
+		},
+		{
+			name:    "unknown toolchain",
+			job:     `{"type":"compile_cpp","name":"compile","toolchain":"clang","code":{"type":"inline","source":"main"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "toolchain of another job type",
+			job:     `{"type":"compile_cpp","name":"compile","toolchain":"go","code":{"type":"inline","source":"main"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "disallowed flag",
+			job:     `{"type":"compile_cpp","name":"compile","flags":["-fplugin=evil.so"],"code":{"type":"inline","source":"main"}}`,
+			wantErr: true,
+		},
+	}
+
+	f := NewExecutionFactory(cfg, nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stages execution.StageDefinitions
+			if err := json.Unmarshal([]byte(`[{"name":"compile","deps":[],"jobs":[`+tt.job+`]}]`), &stages); err != nil {
+				t.Fatalf("unmarshal stages: %v", err)
+			}
+			err := f.ValidateStages(stages)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateStages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := NewExecutionFactory(config.JobFactoryConfig{}, nil, nil).ValidateStages(nil); err != nil {
+		t.Fatalf("ValidateStages() without toolchains = %v, want nil", err)
+	}
+}
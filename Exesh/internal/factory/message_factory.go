@@ -19,10 +19,13 @@ func (f *MessageFactory) CreateExecutionStarted(executionID execution.ID) messag
 	return messages.NewStartExecutionMessage(executionID)
 }
 
+// CreateForJob creates the message of the job result. The artifact is where the output of a run job
+// can be downloaded from, it is nil if the output is not kept.
 func (f *MessageFactory) CreateForJob(
 	executionID execution.ID,
 	jobName job.DefinitionName,
 	res results.Result,
+	artifact *messages.Artifact,
 ) (messages.Message, error) {
 	var msg messages.Message
 
@@ -48,6 +51,7 @@ func (f *MessageFactory) CreateForJob(
 		} else {
 			msg = messages.NewRunJobMessageWithOutput(executionID, jobName, typedRes.Output)
 		}
+		msg.AsRunJob().Artifact = artifact
 	case result.Check:
 		typedRes := res.AsCheck()
 		msg = messages.NewCheckJobMessage(executionID, jobName, typedRes.Status)
@@ -58,8 +62,12 @@ func (f *MessageFactory) CreateForJob(
 	return msg, nil
 }
 
-func (f *MessageFactory) CreateExecutionFinished(executionID execution.ID) messages.Message {
-	return messages.NewFinishExecutionMessageOk(executionID)
+func (f *MessageFactory) CreateExecutionFinished(
+	executionID execution.ID,
+	failedStages []execution.StageName,
+	skippedStages []execution.StageName,
+) messages.Message {
+	return messages.NewFinishExecutionMessageOk(executionID, failedStages, skippedStages)
 }
 
 func (f *MessageFactory) CreateExecutionFinishedError(executionID execution.ID, err string) messages.Message {
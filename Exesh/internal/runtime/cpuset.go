@@ -0,0 +1,34 @@
+package runtime
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+type cpuSetKey struct{}
+
+// WithCPUSet makes the commands run with the context to be pinned to the cpus.
+func WithCPUSet(ctx context.Context, cpus []int) context.Context {
+	return context.WithValue(ctx, cpuSetKey{}, cpus)
+}
+
+func CPUSet(ctx context.Context) []int {
+	cpus, _ := ctx.Value(cpuSetKey{}).([]int)
+	return cpus
+}
+
+// PinCommand wraps the command to run on the cpus of the context only.
+// The affinity is inherited, so a sandbox started by the command keeps to the cpus as well.
+func PinCommand(ctx context.Context, cmd []string) []string {
+	cpus := CPUSet(ctx)
+	if len(cpus) == 0 {
+		return cmd
+	}
+
+	cpuList := make([]string, 0, len(cpus))
+	for _, cpu := range cpus {
+		cpuList = append(cpuList, strconv.Itoa(cpu))
+	}
+	return append([]string{"taskset", "--cpu-list", strings.Join(cpuList, ",")}, cmd...)
+}
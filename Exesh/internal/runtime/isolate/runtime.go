@@ -151,7 +151,8 @@ func (rt *Runtime) RunCommand(ctx context.Context, cmd []string, params runtime.
 	runArgs = append(runArgs, "--")
 	runArgs = append(runArgs, cmd...)
 
-	runCmd := exec.CommandContext(ctx, rt.binPath, runArgs...)
+	pinnedCmd := runtime.PinCommand(ctx, append([]string{rt.binPath}, runArgs...))
+	runCmd := exec.CommandContext(ctx, pinnedCmd[0], pinnedCmd[1:]...)
 	runCmd.Dir = b.Root
 	var runStderr bytes.Buffer
 	runCmd.Stderr = &runStderr
@@ -87,6 +87,7 @@ func (rt *Runtime) RunCommand(ctx context.Context, cmd []string, params runtime.
 		defer cancel()
 	}
 
+	cmd = runtime.PinCommand(ctx, cmd)
 	execCmd := exec.CommandContext(ctxExec, cmd[0], cmd[1:]...)
 	execCmd.Dir = workDir
 	execCmd.Stderr = params.Stderr
@@ -0,0 +1,14 @@
+package scheduler
+
+import "time"
+
+// Clock tells the schedulers what time it is, so that a simulation can run them on a virtual one.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -40,6 +40,11 @@ type JobEvent struct {
 	ExpectedFinishedAt      *time.Time
 	ActualDurationSeconds   float64
 	SchedulerLatencySeconds float64
+	CategoryName            string
+	TimeLimitMillis         int
+	MemoryLimitMB           int
+	ElapsedMillis           int
+	UsedMemoryMB            int
 	At                      time.Time
 }
 
@@ -2,7 +2,10 @@ package scheduler
 
 import (
 	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
 	"exesh/internal/lib/queue"
+	"fmt"
 	"math"
 	"math/rand/v2"
 	"sync"
@@ -21,6 +24,9 @@ type Execution struct {
 
 	scheduledJobs queue.Queue[*Job]
 
+	recomputing map[job.ID][]*Job
+	recomputes  map[job.ID]int
+
 	TotalExpectedTime         int64
 	TotalDoneJobsExpectedTime int64
 }
@@ -38,11 +44,24 @@ func NewExecution(ex *execution.Execution) *Execution {
 
 		scheduledJobs: *queue.NewQueue[*Job](),
 
+		recomputing: make(map[job.ID][]*Job),
+		recomputes:  make(map[job.ID]int),
+
 		TotalExpectedTime:         totalExpectedTime,
 		TotalDoneJobsExpectedTime: 0,
 	}
 }
 
+// addExpectedTime accounts the jobs created for a fan-out stage in the execution progress.
+func (ex *Execution) addExpectedTime(stageJobs []jobs.Job) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	for _, jb := range stageJobs {
+		ex.TotalExpectedTime += int64(jb.GetExpectedTime())
+	}
+}
+
 func (ex *Execution) EnqueueJob(jb *Job) {
 	ex.mu.Lock()
 	defer ex.mu.Unlock()
@@ -73,6 +92,35 @@ func (ex *Execution) DequeueJob(jb *Job) {
 	ex.scheduledJobs.Dequeue()
 }
 
+// parkJob holds the job until the producer job is recomputed.
+// It reports whether the caller has to schedule the producer job.
+func (ex *Execution) parkJob(jb *Job, producerID job.ID, maxRecomputes int) (bool, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	if parked, ok := ex.recomputing[producerID]; ok {
+		ex.recomputing[producerID] = append(parked, jb)
+		return false, nil
+	}
+
+	if ex.recomputes[producerID] >= maxRecomputes {
+		return false, fmt.Errorf("retry budget of job %s is exhausted", producerID.String())
+	}
+	ex.recomputes[producerID]++
+	ex.recomputing[producerID] = []*Job{jb}
+	return true, nil
+}
+
+// releaseJobs returns the jobs parked until the producer job is recomputed.
+func (ex *Execution) releaseJobs(producerID job.ID) []*Job {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	parked := ex.recomputing[producerID]
+	delete(ex.recomputing, producerID)
+	return parked
+}
+
 func (ex *Execution) GetPriority(now time.Time) float64 {
 	ex.mu.Lock()
 	defer ex.mu.Unlock()
@@ -8,6 +8,7 @@ import (
 	"exesh/internal/domain/execution/input"
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
+	"exesh/internal/domain/execution/message/history"
 	"exesh/internal/domain/execution/message/messages"
 	"exesh/internal/domain/execution/result"
 	"exesh/internal/domain/execution/result/results"
@@ -32,6 +33,7 @@ type (
 		unitOfWork       unitOfWork
 		executionStorage executionStorage
 		categoryStats    categoryStats
+		messageStorage   history.Reader
 
 		executionFactory executionFactory
 		workerPool       *WorkerPool
@@ -39,12 +41,17 @@ type (
 		messageFactory    messageFactory
 		messageDispatcher messageDispatcher
 
-		nowWeight      atomic.Int64
-		nowWeightGauge prometheus.Collector
-		events         EventRecorder
-
-		mu         sync.Mutex
-		executions map[execution.ID]*Execution
+		nowWeight       atomic.Int64
+		nowWeightGauge  prometheus.Collector
+		queueDepthGauge *prometheus.GaugeVec
+		events          EventRecorder
+		clock           Clock
+		onJobEnqueued   func()
+
+		mu           sync.Mutex
+		executions   map[execution.ID]*Execution
+		classWeight  map[execution.PriorityClass]int64
+		tenantWeight map[string]int64
 	}
 
 	unitOfWork interface {
@@ -53,22 +60,26 @@ type (
 
 	executionStorage interface {
 		GetExecutionForUpdate(context.Context, execution.ID) (*execution.Definition, error)
-		GetExecutionForSchedule(context.Context, time.Time) (*execution.Definition, error)
+		GetExecutionForSchedule(context.Context, time.Time, execution.PriorityClass, string) (*execution.Definition, error)
+		GetWaitingTenants(context.Context, time.Time, execution.PriorityClass) ([]string, error)
+		CountWaitingExecutions(context.Context, time.Time) (map[execution.PriorityClass]int, error)
 		SaveExecution(context.Context, execution.Definition) error
+		WakeReplays(context.Context, execution.ID) error
 	}
 
 	categoryStats interface {
-		UpdateCategoryHistogram(context.Context, string, int, int) error
+		RecordJob(context.Context, string, job.Type, int, int) error
 	}
 
 	executionFactory interface {
 		Create(context.Context, execution.Definition) (*execution.Execution, error)
+		ExpandStage(context.Context, *execution.Execution, *execution.Stage, int) ([]jobs.Job, error)
 	}
 
 	messageFactory interface {
 		CreateExecutionStarted(execution.ID) messages.Message
-		CreateForJob(execution.ID, job.DefinitionName, results.Result) (messages.Message, error)
-		CreateExecutionFinished(execution.ID) messages.Message
+		CreateForJob(execution.ID, job.DefinitionName, results.Result, *messages.Artifact) (messages.Message, error)
+		CreateExecutionFinished(execution.ID, []execution.StageName, []execution.StageName) messages.Message
 		CreateExecutionFinishedError(execution.ID, string) messages.Message
 	}
 
@@ -77,12 +88,15 @@ type (
 	}
 )
 
+const replayBatchSize = 100
+
 func NewExecutionScheduler(
 	log *slog.Logger,
 	cfg config.ExecutionSchedulerConfig,
 	unitOfWork unitOfWork,
 	executionStorage executionStorage,
 	categoryStats categoryStats,
+	messageStorage history.Reader,
 	executionFactory executionFactory,
 	workerPool *WorkerPool,
 	messageFactory messageFactory,
@@ -99,6 +113,7 @@ func NewExecutionScheduler(
 		unitOfWork:       unitOfWork,
 		executionStorage: executionStorage,
 		categoryStats:    categoryStats,
+		messageStorage:   messageStorage,
 
 		executionFactory: executionFactory,
 		workerPool:       workerPool,
@@ -108,9 +123,12 @@ func NewExecutionScheduler(
 
 		nowWeight: atomic.Int64{},
 		events:    events,
+		clock:     systemClock{},
 
-		mu:         sync.Mutex{},
-		executions: make(map[execution.ID]*Execution),
+		mu:           sync.Mutex{},
+		executions:   make(map[execution.ID]*Execution),
+		classWeight:  make(map[execution.PriorityClass]int64),
+		tenantWeight: make(map[string]int64),
 	}
 
 	s.nowWeightGauge = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
@@ -119,6 +137,10 @@ func NewExecutionScheduler(
 	}, func() float64 {
 		return float64(s.nowWeight.Load())
 	})
+	s.queueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "queue_depth",
+		Help: "Number of executions waiting to be scheduled by priority class",
+	}, []string{"class"})
 
 	return s
 }
@@ -126,6 +148,7 @@ func NewExecutionScheduler(
 func (s *ExecutionScheduler) RegisterMetrics(r prometheus.Registerer) error {
 	return errors.Join(
 		r.Register(s.nowWeightGauge),
+		r.Register(s.queueDepthGauge),
 	)
 }
 
@@ -133,6 +156,18 @@ func (s *ExecutionScheduler) Start(ctx context.Context) {
 	go s.runExecutionScheduler(ctx)
 }
 
+// SetClock makes the scheduler take the time from the clock instead of the system one.
+// It must be called before the scheduler is started.
+func (s *ExecutionScheduler) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// OnJobEnqueued registers a callback called every time a job is queued to be picked.
+// It is called under the scheduler locks, so it must not block.
+func (s *ExecutionScheduler) OnJobEnqueued(cb func()) {
+	s.onJobEnqueued = cb
+}
+
 func (s *ExecutionScheduler) runExecutionScheduler(ctx context.Context) {
 	for {
 		timer := time.NewTicker(s.cfg.ExecutionsInterval)
@@ -145,70 +180,215 @@ func (s *ExecutionScheduler) runExecutionScheduler(ctx context.Context) {
 			break
 		}
 
-		remainingCapacity := s.cfg.Capacity - s.nowWeight.Load()
-		if remainingCapacity <= 0 {
-			s.log.Debug("skip execution scheduler loop (capacity reached)")
-			continue
-		}
+		s.Tick(ctx)
+	}
+}
 
-		nowWeight := s.nowWeight.Load()
-		if nowWeight > 0 {
+// Tick runs one iteration of the scheduler loop: expires the executions which have passed their deadline
+// and schedules a waiting execution if the capacity allows. Start calls it every ExecutionsInterval.
+func (s *ExecutionScheduler) Tick(ctx context.Context) {
+	s.expireRunningExecutions(ctx)
+
+	if s.cfg.Capacity-s.nowWeight.Load() <= 0 {
+		s.log.Debug("skip execution scheduler loop (capacity reached)")
+		return
+	}
+
+	nowWeight := s.nowWeight.Load()
+	if nowWeight > 0 {
+		s.log.Debug(
+			"begin execution scheduler loop",
+			slog.Int64("now_weight", nowWeight),
+			slog.Int64("remaining_capacity", s.cfg.Capacity-nowWeight),
+		)
+	}
+
+	var scheduledDef *execution.Definition
+	if err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		def, err := s.getExecutionForSchedule(ctx, s.clock.Now().Add(-s.cfg.ExecutionRetryAfter))
+		if err != nil {
+			return fmt.Errorf("failed to get execution for schedule from storage: %w", err)
+		}
+		if def == nil {
+			return nil
+		}
+		if reason := def.ExpiredReason(s.clock.Now(), s.cfg.MaxTries); reason != "" {
+			return s.expireExecution(ctx, def, reason)
+		}
+		if def.ReplayOf != nil {
+			if replayed, err := s.replayExecution(ctx, def); replayed || err != nil {
+				return err
+			}
+		}
+		remainingCapacity := s.remainingCapacity(def.PriorityClass)
+		if def.Weight > remainingCapacity {
 			s.log.Debug(
-				"begin execution scheduler loop",
-				slog.Int64("now_weight", nowWeight),
+				"skip picked execution due to capacity",
+				slog.String("execution_id", def.ID.String()),
+				slog.Int64("weight", def.Weight),
 				slog.Int64("remaining_capacity", remainingCapacity),
 			)
+			return nil
 		}
 
-		weight := int64(0)
-		if err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
-			def, err := s.executionStorage.GetExecutionForSchedule(ctx, time.Now().Add(-s.cfg.ExecutionRetryAfter))
-			if err != nil {
-				return fmt.Errorf("failed to get execution for schedule from storage: %w", err)
-			}
-			if def == nil {
-				return nil
-			}
-			if def.Weight > remainingCapacity {
-				s.log.Debug(
-					"skip picked execution due to capacity",
-					slog.String("execution_id", def.ID.String()),
-					slog.Int64("weight", def.Weight),
-					slog.Int64("remaining_capacity", remainingCapacity),
-				)
-				return nil
-			}
+		innerEx, err := s.executionFactory.Create(ctx, *def)
+		if err != nil {
+			return fmt.Errorf("failed to create execution: %w", err)
+		}
 
-			innerEx, err := s.executionFactory.Create(ctx, *def)
-			if err != nil {
-				return fmt.Errorf("failed to create execution: %w", err)
-			}
+		ex := NewExecution(innerEx)
+		func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.executions[ex.ID] = ex
+		}()
 
-			ex := NewExecution(innerEx)
-			func() {
-				s.mu.Lock()
-				defer s.mu.Unlock()
-				s.executions[ex.ID] = ex
-			}()
+		ex.SetScheduled(s.clock.Now())
+		s.addRunningWeight(ex.Definition, +ex.Definition.Weight)
+		scheduledDef = &ex.Definition
 
-			ex.SetScheduled(time.Now())
-			s.nowWeight.Add(+ex.Definition.Weight)
-			weight = ex.Definition.Weight
+		if err = s.scheduleExecution(ctx, ex); err != nil {
+			return fmt.Errorf("failed to schedule execution: %w", err)
+		}
 
-			if err = s.scheduleExecution(ctx, ex); err != nil {
-				return fmt.Errorf("failed to schedule execution: %w", err)
-			}
+		if err = s.executionStorage.SaveExecution(ctx, ex.Definition); err != nil {
+			return fmt.Errorf("failed to update execution in storage %s: %w", def.ID.String(), err)
+		}
 
-			if err = s.executionStorage.SaveExecution(ctx, ex.Definition); err != nil {
-				return fmt.Errorf("failed to update execution in storage %s: %w", def.ID.String(), err)
-			}
+		return nil
+	}); err != nil {
+		if scheduledDef != nil {
+			s.addRunningWeight(*scheduledDef, -scheduledDef.Weight)
+		}
+		s.log.Error("failed to schedule execution", slog.Any("error", err))
+	}
+}
 
-			return nil
-		}); err != nil {
-			s.nowWeight.Add(-weight)
-			s.log.Error("failed to schedule execution", slog.Any("error", err))
+// getExecutionForSchedule picks a waiting execution of the most urgent class.
+// Within the class the tenant with the least running weight per share goes first.
+func (s *ExecutionScheduler) getExecutionForSchedule(ctx context.Context, retryBefore time.Time) (*execution.Definition, error) {
+	counts, err := s.executionStorage.CountWaitingExecutions(ctx, retryBefore)
+	if err != nil {
+		return nil, err
+	}
+	for _, class := range execution.PriorityClasses {
+		s.queueDepthGauge.WithLabelValues(string(class)).Set(float64(counts[class]))
+	}
+
+	for _, class := range execution.PriorityClasses {
+		if counts[class] == 0 {
+			continue
+		}
+
+		tenants, err := s.executionStorage.GetWaitingTenants(ctx, retryBefore, class)
+		if err != nil {
+			return nil, err
+		}
+		if len(tenants) == 0 {
+			continue
+		}
+
+		return s.executionStorage.GetExecutionForSchedule(ctx, retryBefore, class, s.pickFairTenant(tenants))
+	}
+
+	return nil, nil
+}
+
+// replayExecution answers the deduplicated execution with the messages of the execution it replays,
+// or makes it wait while that one is in flight. It returns false when that one has not finished
+// successfully, so that the execution has to run on its own.
+func (s *ExecutionScheduler) replayExecution(ctx context.Context, def *execution.Definition) (bool, error) {
+	replayed, err := s.executionStorage.GetExecutionForUpdate(ctx, *def.ReplayOf)
+	if err != nil {
+		return false, fmt.Errorf("failed to get replayed execution %s: %w", def.ReplayOf.String(), err)
+	}
+	if replayed != nil && !replayed.IsFinished() {
+		def.SetWaiting(s.clock.Now())
+		if err = s.executionStorage.SaveExecution(ctx, *def); err != nil {
+			return false, fmt.Errorf("failed to update execution in storage %s: %w", def.ID.String(), err)
+		}
+		return true, nil
+	}
+
+	var msgs []history.Message
+	if replayed != nil && replayed.Status == execution.StatusFinished {
+		if msgs, err = history.ReadAll(ctx, s.messageStorage, replayed.ID, replayBatchSize); err != nil {
+			return false, fmt.Errorf("failed to get messages of execution %s: %w", replayed.ID.String(), err)
+		}
+	}
+	if !history.FinishedOK(msgs) {
+		s.log.Info("run deduplicated execution on its own",
+			slog.String("execution_id", def.ID.String()),
+			slog.String("replay_of", def.ReplayOf.String()),
+		)
+		def.ReplayOf = nil
+		return false, nil
+	}
+
+	for _, msg := range msgs {
+		msg.Message.SetExecutionID(def.ID)
+		if err = s.messageDispatcher.Send(ctx, msg.Message); err != nil {
+			return false, fmt.Errorf("failed to replay message %d of execution %s: %w", msg.MessageID, replayed.ID.String(), err)
+		}
+	}
+
+	def.SetFinished(s.clock.Now())
+	if err = s.executionStorage.SaveExecution(ctx, *def); err != nil {
+		return false, fmt.Errorf("failed to update execution in storage %s: %w", def.ID.String(), err)
+	}
+	s.log.Info("replay execution",
+		slog.String("execution_id", def.ID.String()),
+		slog.String("replay_of", replayed.ID.String()),
+	)
+	return true, nil
+}
+
+func (s *ExecutionScheduler) pickFairTenant(tenants []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best := tenants[0]
+	bestUsage := float64(s.tenantWeight[best]) / s.tenantShare(best)
+	for _, tenant := range tenants[1:] {
+		usage := float64(s.tenantWeight[tenant]) / s.tenantShare(tenant)
+		if usage < bestUsage {
+			best, bestUsage = tenant, usage
 		}
 	}
+	return best
+}
+
+func (s *ExecutionScheduler) tenantShare(tenant string) float64 {
+	if share, ok := s.cfg.TenantShares[tenant]; ok && share > 0 {
+		return float64(share)
+	}
+	return 1
+}
+
+// remainingCapacity returns the capacity left for a new execution of the class.
+// Running executions never hold more than Capacity together; background ones hold at most
+// BackgroundCapacity, so that the rest stays free for interactive and ranked executions.
+func (s *ExecutionScheduler) remainingCapacity(class execution.PriorityClass) int64 {
+	remaining := s.cfg.Capacity - s.nowWeight.Load()
+	if class == execution.PriorityBackground && s.cfg.BackgroundCapacity > 0 {
+		s.mu.Lock()
+		remaining = min(remaining, s.cfg.BackgroundCapacity-s.classWeight[execution.PriorityBackground])
+		s.mu.Unlock()
+	}
+	return remaining
+}
+
+func (s *ExecutionScheduler) addRunningWeight(def execution.Definition, delta int64) {
+	s.nowWeight.Add(delta)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.classWeight[def.PriorityClass] += delta
+	s.tenantWeight[def.Tenant] += delta
+	if s.tenantWeight[def.Tenant] == 0 {
+		delete(s.tenantWeight, def.Tenant)
+	}
 }
 
 func (s *ExecutionScheduler) scheduleExecution(ctx context.Context, ex *Execution) error {
@@ -217,7 +397,7 @@ func (s *ExecutionScheduler) scheduleExecution(ctx context.Context, ex *Executio
 		Type:          "started",
 		ExecutionID:   ex.ID,
 		ProgressRatio: ex.GetProgressRatio(),
-		At:            time.Now(),
+		At:            s.clock.Now(),
 	})
 
 	msg := s.messageFactory.CreateExecutionStarted(ex.ID)
@@ -226,7 +406,7 @@ func (s *ExecutionScheduler) scheduleExecution(ctx context.Context, ex *Executio
 	}
 
 	for _, jb := range ex.PickJobs() {
-		if err := s.scheduleJob(ex, jb); err != nil {
+		if err := s.scheduleJob(ctx, ex, jb); err != nil {
 			return err
 		}
 	}
@@ -234,12 +414,27 @@ func (s *ExecutionScheduler) scheduleExecution(ctx context.Context, ex *Executio
 	return nil
 }
 
-func (s *ExecutionScheduler) scheduleJob(ex *Execution, jb jobs.Job) error {
+func (s *ExecutionScheduler) scheduleJob(ctx context.Context, ex *Execution, jb jobs.Job) error {
 	if ex.IsDone() {
 		return nil
 	}
 
 	jobID := jb.GetID()
+	if cacheKey := jb.GetCacheKey(); cacheKey != nil && s.workerPool.hasArtifact(*cacheKey) {
+		s.log.Info("compile cache hit",
+			slog.String("job", jobID.String()),
+			slog.String("cache_key", cacheKey.String()),
+		)
+		s.events.RecordExecutionEvent(ctx, ExecutionEvent{
+			Type:          "compile_cache_hit",
+			ExecutionID:   ex.ID,
+			ProgressRatio: ex.GetProgressRatio(),
+			At:            s.clock.Now(),
+		})
+		s.doneJob(ctx, ex, jb, results.NewCompileResultOK(jobID, true, 0, 0), true)
+		return nil
+	}
+
 	logArgs := []any{
 		slog.String("job", jobID.String()),
 		slog.String("type", string(jb.GetType())),
@@ -249,6 +444,97 @@ func (s *ExecutionScheduler) scheduleJob(ex *Execution, jb jobs.Job) error {
 	}
 	s.log.Info("schedule job", logArgs...)
 
+	s.enqueueJob(ex, s.newJob(ex, jb))
+
+	return nil
+}
+
+// recomputeArtifact parks the job whose input artifact was lost together with
+// the worker holding it and reschedules the job producing that artifact.
+func (s *ExecutionScheduler) recomputeArtifact(ctx context.Context, jb *Job, artifactJobID job.ID, maxRecomputes int) error {
+	ex := func() *Execution {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.executions[jb.ExecutionID]
+	}()
+	if ex == nil || ex.IsDone() {
+		return fmt.Errorf("execution %s is not running", jb.ExecutionID.String())
+	}
+
+	producer, ok := ex.JobProducing(artifactJobID)
+	if !ok {
+		return fmt.Errorf("failed to find job producing artifact %s", artifactJobID.String())
+	}
+	producerID := producer.GetID()
+
+	shouldSchedule, err := ex.parkJob(jb, producerID, maxRecomputes)
+	if err != nil {
+		return fmt.Errorf("failed to recompute artifact %s: %w", artifactJobID.String(), err)
+	}
+	if !shouldSchedule {
+		return nil
+	}
+
+	s.log.Warn("recompute lost artifact",
+		slog.String("artifact", artifactJobID.String()),
+		slog.String("job", producerID.String()),
+		slog.String("execution", ex.ID.String()),
+	)
+	s.events.RecordExecutionEvent(ctx, ExecutionEvent{
+		Type:          "artifact_recomputed",
+		ExecutionID:   ex.ID,
+		ProgressRatio: ex.GetProgressRatio(),
+		At:            s.clock.Now(),
+	})
+
+	scheduledJob := s.newJob(ex, producer)
+	scheduledJob.OnDone = func(ctx context.Context, res results.Result) {
+		if res.GetError() != nil {
+			s.failJob(ctx, ex, producer, res)
+			return
+		}
+		for _, parked := range ex.releaseJobs(producerID) {
+			s.enqueueJob(ex, parked)
+		}
+	}
+	s.enqueueJob(ex, scheduledJob)
+
+	return nil
+}
+
+func (s *ExecutionScheduler) enqueueJob(ex *Execution, jb *Job) {
+	ex.EnqueueJob(jb)
+	if s.onJobEnqueued != nil {
+		s.onJobEnqueued()
+	}
+}
+
+// jobArtifact returns where the kept output of the run job can be downloaded from, or nil if no worker has it.
+func (s *ExecutionScheduler) jobArtifact(ex *Execution, res results.Result) *messages.Artifact {
+	if res.GetType() != result.Run || !res.GetHasOutput() || res.GetArtifactTrashTime() == nil {
+		return nil
+	}
+
+	jobID := res.GetJobID()
+	out, ok := ex.OutputByJob[jobID]
+	if !ok {
+		return nil
+	}
+	artifactID := ex.ArtifactID(jobID)
+	workerID, err := s.workerPool.getWorkerWithArtifact(artifactID)
+	if err != nil {
+		return nil
+	}
+
+	return &messages.Artifact{
+		Endpoint:  workerID,
+		BucketID:  artifactID.String(),
+		File:      out.File,
+		TrashTime: *res.GetArtifactTrashTime(),
+	}
+}
+
+func (s *ExecutionScheduler) newJob(ex *Execution, jb jobs.Job) *Job {
 	scheduledJob := &Job{Job: jb, ExecutionID: ex.ID}
 	scheduledJob.Sources = func(ctx context.Context) ([]sources.Source, error) {
 		srcs := make([]sources.Source, 0)
@@ -258,13 +544,14 @@ func (s *ExecutionScheduler) scheduleJob(ex *Execution, jb jobs.Job) error {
 				if err := inputJobID.FromString(in.SourceID.String()); err != nil {
 					return nil, fmt.Errorf("failed to convert artifact source name to job id: %w", err)
 				}
+				artifactID := ex.ArtifactID(inputJobID)
 				var bucketID bucket.ID
-				if err := bucketID.FromString(inputJobID.String()); err != nil {
+				if err := bucketID.FromString(artifactID.String()); err != nil {
 					return nil, fmt.Errorf("failed to convert artifact id to bucket id: %w", err)
 				}
-				workerID, err := s.workerPool.getWorkerWithArtifact(inputJobID)
+				workerID, err := s.workerPool.getWorkerWithArtifact(artifactID)
 				if err != nil {
-					return nil, fmt.Errorf("failed to get worker for job %s: %w", inputJobID.String(), err)
+					return nil, &artifactLostError{jobID: inputJobID, err: err}
 				}
 				out, ok := ex.OutputByJob[inputJobID]
 				if !ok {
@@ -300,13 +587,11 @@ func (s *ExecutionScheduler) scheduleJob(ex *Execution, jb jobs.Job) error {
 		if res.GetError() != nil {
 			s.failJob(ctx, ex, jb, res)
 		} else {
-			s.doneJob(ctx, ex, jb, res)
+			s.doneJob(ctx, ex, jb, res, false)
 		}
 	}
 
-	ex.EnqueueJob(scheduledJob)
-
-	return nil
+	return scheduledJob
 }
 
 func (s *ExecutionScheduler) pickJobs() []*Job {
@@ -322,17 +607,34 @@ func (s *ExecutionScheduler) pickJobs() []*Job {
 		return exs
 	}()
 
-	now := time.Now()
+	now := s.clock.Now()
 	priorities := make(map[execution.ID]float64, len(executions))
 	for i := range executions {
 		priorities[executions[i].ID] = executions[i].GetPriority(now)
 	}
 	sort.Slice(executions, func(i, j int) bool {
+		rankI, rankJ := executions[i].PriorityClass.Rank(), executions[j].PriorityClass.Rank()
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
 		return priorities[executions[i].ID] > priorities[executions[j].ID]
 	})
 
+	interactiveWaiting := false
+	for i := range executions {
+		if executions[i].PriorityClass == execution.PriorityInteractive && executions[i].GetPeekJob() != nil {
+			interactiveWaiting = true
+			break
+		}
+	}
+
 	jbs := make([]*Job, 0)
 	for i := range executions {
+		if interactiveWaiting && executions[i].PriorityClass == execution.PriorityBackground {
+			// background work is preempted: its running jobs finish, but no new ones start
+			continue
+		}
+
 		jb := executions[i].GetPeekJob()
 		if jb != nil {
 			priority := priorities[executions[i].ID]
@@ -342,7 +644,7 @@ func (s *ExecutionScheduler) pickJobs() []*Job {
 				ExecutionID:   executions[i].ID,
 				Priority:      priority,
 				ProgressRatio: progressRatio,
-				At:            time.Now(),
+				At:            s.clock.Now(),
 			})
 			jbs = append(jbs, jb)
 		}
@@ -373,7 +675,11 @@ func (s *ExecutionScheduler) failJob(
 	s.finishExecution(ctx, ex, res.GetError())
 }
 
-func (s *ExecutionScheduler) doneJob(ctx context.Context, ex *Execution, jb jobs.Job, res results.Result) {
+// doneJob records the job result and schedules the jobs depending on it.
+// Results satisfied from the compile cache do not update the category stats.
+// Other results update the stats of the job category and of its priors,
+// and are recorded as measured job events for the offline evaluation of the estimators.
+func (s *ExecutionScheduler) doneJob(ctx context.Context, ex *Execution, jb jobs.Job, res results.Result, fromCache bool) {
 	if ex.IsDone() {
 		return
 	}
@@ -407,18 +713,36 @@ func (s *ExecutionScheduler) doneJob(ctx context.Context, ex *Execution, jb jobs
 				return fmt.Errorf("failed to find job definition by id: %s", jobResID.String())
 			}
 
-			if s.categoryStats != nil {
-				if err = s.categoryStats.UpdateCategoryHistogram(
+			if s.categoryStats != nil && !fromCache {
+				categoryName := jobDef.GetCategoryName()
+				if err = s.categoryStats.RecordJob(
 					ctx,
-					jobDef.GetCategoryName(),
+					categoryName,
+					jobDef.GetType(),
 					jobRes.GetElapsedTime(),
 					jobRes.GetUsedMemory(),
 				); err != nil {
 					return fmt.Errorf("failed to update category histogram: %w", err)
 				}
+				s.events.RecordJobEvent(ctx, JobEvent{
+					Type:            "measured",
+					JobID:           jobRes.GetJobID(),
+					ExecutionID:     ex.ID,
+					JobType:         string(jobDef.GetType()),
+					Status:          string(jobRes.GetStatus()),
+					CategoryName:    categoryName,
+					TimeLimitMillis: jobDef.GetTimeLimit(),
+					MemoryLimitMB:   jobDef.GetMemoryLimit(),
+					ElapsedMillis:   jobRes.GetElapsedTime(),
+					UsedMemoryMB:    jobRes.GetUsedMemory(),
+				})
+			}
+
+			if outputCount := jobRes.GetOutputCount(); outputCount > 0 {
+				ex.SetOutputCount(jobRes.GetJobID(), outputCount)
 			}
 
-			msg, msgErr := s.messageFactory.CreateForJob(ex.ID, jobDef.GetName(), jobRes)
+			msg, msgErr := s.messageFactory.CreateForJob(ex.ID, jobDef.GetName(), jobRes, s.jobArtifact(ex, jobRes))
 			if msgErr != nil {
 				return fmt.Errorf("failed to create message for job: %w", msgErr)
 			}
@@ -430,7 +754,7 @@ func (s *ExecutionScheduler) doneJob(ctx context.Context, ex *Execution, jb jobs
 
 		ex.DoneJob(jobID, res.GetStatus())
 
-		e.SetScheduled(time.Now())
+		e.KeepScheduled(s.clock.Now())
 
 		if err = s.executionStorage.SaveExecution(ctx, *e); err != nil {
 			return err
@@ -443,13 +767,26 @@ func (s *ExecutionScheduler) doneJob(ctx context.Context, ex *Execution, jb jobs
 		return
 	}
 
-	for _, pickedJob := range ex.PickJobs() {
-		if err := s.scheduleJob(ex, pickedJob); err != nil {
-			pickedJobID := pickedJob.GetID()
-			s.log.Error("failed to schedule job",
-				slog.String("job", pickedJobID.String()),
-				slog.Any("error", err))
-			s.finishExecution(ctx, ex, fmt.Errorf("failed to schedule job %s: %w", pickedJobID, err))
+	// picking jobs may activate fan-out stages, which give new jobs to pick once expanded
+	for {
+		if err := s.expandStages(ctx, ex); err != nil {
+			s.log.Error("failed to expand stages", slog.Any("error", err))
+			s.finishExecution(ctx, ex, err)
+			return
+		}
+
+		for _, pickedJob := range ex.PickJobs() {
+			if err := s.scheduleJob(ctx, ex, pickedJob); err != nil {
+				pickedJobID := pickedJob.GetID()
+				s.log.Error("failed to schedule job",
+					slog.String("job", pickedJobID.String()),
+					slog.Any("error", err))
+				s.finishExecution(ctx, ex, fmt.Errorf("failed to schedule job %s: %w", pickedJobID, err))
+			}
+		}
+
+		if len(ex.StagesToExpand()) == 0 {
+			break
 		}
 	}
 
@@ -458,7 +795,81 @@ func (s *ExecutionScheduler) doneJob(ctx context.Context, ex *Execution, jb jobs
 	}
 }
 
+// expandStages creates the jobs of the active fan-out stages from the output items of their jobs.
+func (s *ExecutionScheduler) expandStages(ctx context.Context, ex *Execution) error {
+	for stages := ex.StagesToExpand(); len(stages) > 0; stages = ex.StagesToExpand() {
+		for _, stage := range stages {
+			items := ex.GetOutputCount(*stage.FanOut)
+			stageJobs, err := s.executionFactory.ExpandStage(ctx, ex.Execution, stage, items)
+			if err != nil {
+				return fmt.Errorf("failed to expand stage '%s': %w", stage.Name, err)
+			}
+
+			s.log.Info("expand stage",
+				slog.String("execution", ex.ID.String()),
+				slog.String("stage", string(stage.Name)),
+				slog.Int("items", items),
+				slog.Int("jobs", len(stageJobs)),
+			)
+			ex.addExpectedTime(stageJobs)
+			ex.ExpandStage(stage, stageJobs)
+		}
+	}
+	return nil
+}
+
+// expireExecution finishes a waiting execution which must not be scheduled anymore.
+func (s *ExecutionScheduler) expireExecution(ctx context.Context, def *execution.Definition, reason string) error {
+	s.log.Warn("expire execution",
+		slog.String("execution", def.ID.String()),
+		slog.String("reason", reason),
+	)
+	s.events.RecordExecutionEvent(ctx, ExecutionEvent{
+		Type:        "expired",
+		ExecutionID: def.ID,
+		Status:      string(execution.StatusExpired),
+		At:          s.clock.Now(),
+	})
+
+	msg := s.messageFactory.CreateExecutionFinishedError(def.ID, reason)
+	if err := s.messageDispatcher.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send execution expired message: %w", err)
+	}
+
+	def.SetExpired(s.clock.Now())
+	if err := s.executionStorage.SaveExecution(ctx, *def); err != nil {
+		return fmt.Errorf("failed to save expired execution %s: %w", def.ID.String(), err)
+	}
+	return nil
+}
+
+// expireRunningExecutions stops the running executions which have passed their deadline.
+func (s *ExecutionScheduler) expireRunningExecutions(ctx context.Context) {
+	now := s.clock.Now()
+	expired := func() []*Execution {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		exs := make([]*Execution, 0)
+		for _, ex := range s.executions {
+			if ex.Deadline != nil && now.After(*ex.Deadline) {
+				exs = append(exs, ex)
+			}
+		}
+		return exs
+	}()
+
+	for _, ex := range expired {
+		reason := ex.ExpiredReason(now, 0)
+		s.completeExecution(ctx, ex, errors.New(reason), true)
+	}
+}
+
 func (s *ExecutionScheduler) finishExecution(ctx context.Context, ex *Execution, exError error) {
+	s.completeExecution(ctx, ex, exError, false)
+}
+
+func (s *ExecutionScheduler) completeExecution(ctx context.Context, ex *Execution, exError error, expired bool) {
 	if ex.IsForceFailed() {
 		return
 	}
@@ -471,10 +882,12 @@ func (s *ExecutionScheduler) finishExecution(ctx context.Context, ex *Execution,
 			slog.Any("error", exError))
 	}
 	finishStatus := "ok"
-	if exError != nil {
+	if expired {
+		finishStatus = string(execution.StatusExpired)
+	} else if exError != nil {
 		finishStatus = "error"
 	}
-	finishedAt := time.Now()
+	finishedAt := s.clock.Now()
 	duration := ex.GetDuration(finishedAt)
 	progressRatio := ex.GetProgressRatio()
 	s.events.RecordExecutionEvent(ctx, ExecutionEvent{
@@ -486,7 +899,7 @@ func (s *ExecutionScheduler) finishExecution(ctx context.Context, ex *Execution,
 		At:              finishedAt,
 	})
 
-	defer s.nowWeight.Add(-ex.Definition.Weight)
+	defer s.addRunningWeight(ex.Definition, -ex.Definition.Weight)
 
 	ex.ForceFail()
 	func() {
@@ -498,7 +911,8 @@ func (s *ExecutionScheduler) finishExecution(ctx context.Context, ex *Execution,
 	if err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
 		var msg messages.Message
 		if exError == nil {
-			msg = s.messageFactory.CreateExecutionFinished(ex.ID)
+			failedStages, skippedStages := ex.StageOutcome()
+			msg = s.messageFactory.CreateExecutionFinished(ex.ID, failedStages, skippedStages)
 		} else {
 			msg = s.messageFactory.CreateExecutionFinishedError(ex.ID, exError.Error())
 		}
@@ -507,7 +921,11 @@ func (s *ExecutionScheduler) finishExecution(ctx context.Context, ex *Execution,
 			return fmt.Errorf("failed to send execution finished message: %w", err)
 		}
 
-		ex.SetFinished(time.Now())
+		if expired {
+			ex.SetExpired(s.clock.Now())
+		} else {
+			ex.SetFinished(s.clock.Now())
+		}
 
 		if err := s.executionStorage.SaveExecution(ctx, ex.Definition); err != nil {
 			return err
@@ -517,4 +935,12 @@ func (s *ExecutionScheduler) finishExecution(ctx context.Context, ex *Execution,
 		s.log.Error("failed to finish execution in storage", slog.Any("error", err))
 		return
 	}
+
+	// Separately from the finish, so that the waiting executions are locked after the finished one.
+	// They are picked again after ExecutionRetryAfter if it fails.
+	if err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		return s.executionStorage.WakeReplays(ctx, ex.ID)
+	}); err != nil {
+		s.log.Error("failed to wake executions replaying the finished one", slog.Any("error", err))
+	}
 }
@@ -0,0 +1,359 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/message/history"
+	"exesh/internal/domain/execution/message/messages"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type stubUnitOfWork struct{}
+
+func (stubUnitOfWork) Do(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+type stubExecutionStorage struct {
+	waiting    map[execution.PriorityClass][]string
+	weight     int64
+	executions map[execution.ID]execution.Definition
+
+	pickedClass  execution.PriorityClass
+	pickedTenant string
+	saved        []execution.Definition
+}
+
+func (s *stubExecutionStorage) GetExecutionForUpdate(_ context.Context, id execution.ID) (*execution.Definition, error) {
+	def, ok := s.executions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &def, nil
+}
+
+func (s *stubExecutionStorage) GetExecutionForSchedule(
+	_ context.Context,
+	_ time.Time,
+	class execution.PriorityClass,
+	tenant string,
+) (*execution.Definition, error) {
+	s.pickedClass, s.pickedTenant = class, tenant
+	return &execution.Definition{PriorityClass: class, Tenant: tenant, Weight: s.weight, Status: execution.StatusNew}, nil
+}
+
+func (s *stubExecutionStorage) GetWaitingTenants(_ context.Context, _ time.Time, class execution.PriorityClass) ([]string, error) {
+	return s.waiting[class], nil
+}
+
+func (s *stubExecutionStorage) CountWaitingExecutions(context.Context, time.Time) (map[execution.PriorityClass]int, error) {
+	counts := make(map[execution.PriorityClass]int)
+	for class, tenants := range s.waiting {
+		counts[class] = len(tenants)
+	}
+	return counts, nil
+}
+
+func (s *stubExecutionStorage) SaveExecution(_ context.Context, def execution.Definition) error {
+	s.saved = append(s.saved, def)
+	return nil
+}
+
+func (s *stubExecutionStorage) WakeReplays(context.Context, execution.ID) error {
+	return nil
+}
+
+type stubMessageStorage map[execution.ID][]history.Message
+
+func (s stubMessageStorage) GetMessages(_ context.Context, id execution.ID, startID int64, count int) ([]history.Message, error) {
+	msgs := make([]history.Message, 0, count)
+	for _, msg := range s[id] {
+		if msg.MessageID >= startID && len(msgs) < count {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+type stubMessageDispatcher struct {
+	sent []messages.Message
+}
+
+func (d *stubMessageDispatcher) Send(_ context.Context, msg messages.Message) error {
+	d.sent = append(d.sent, msg)
+	return nil
+}
+
+type running struct {
+	class  execution.PriorityClass
+	tenant string
+	weight int64
+}
+
+func newTestExecutionScheduler(cfg config.ExecutionSchedulerConfig, storage executionStorage, runs []running) *ExecutionScheduler {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := NewExecutionScheduler(log, cfg, stubUnitOfWork{}, storage, nil, nil, nil, nil, nil, nil, nil)
+	for _, r := range runs {
+		s.addRunningWeight(execution.Definition{PriorityClass: r.class, Tenant: r.tenant}, r.weight)
+	}
+	return s
+}
+
+func TestRemainingCapacity(t *testing.T) {
+	tests := []struct {
+		name               string
+		backgroundCapacity int64
+		runs               []running
+		want               map[execution.PriorityClass]int64
+	}{
+		{
+			name: "idle",
+			want: map[execution.PriorityClass]int64{
+				execution.PriorityInteractive: 100,
+				execution.PriorityRanked:      100,
+				execution.PriorityBackground:  100,
+			},
+		},
+		{
+			name: "background does not go over capacity",
+			runs: []running{{execution.PriorityBackground, "a", 70}, {execution.PriorityRanked, "a", 20}},
+			want: map[execution.PriorityClass]int64{
+				execution.PriorityInteractive: 10,
+				execution.PriorityRanked:      10,
+				execution.PriorityBackground:  10,
+			},
+		},
+		{
+			name:               "background capacity leaves room for others",
+			backgroundCapacity: 60,
+			runs:               []running{{execution.PriorityBackground, "a", 50}, {execution.PriorityInteractive, "a", 10}},
+			want: map[execution.PriorityClass]int64{
+				execution.PriorityInteractive: 40,
+				execution.PriorityRanked:      40,
+				execution.PriorityBackground:  10,
+			},
+		},
+		{
+			name:               "total capacity is lower than background capacity left",
+			backgroundCapacity: 60,
+			runs:               []running{{execution.PriorityBackground, "a", 10}, {execution.PriorityRanked, "a", 80}},
+			want: map[execution.PriorityClass]int64{
+				execution.PriorityInteractive: 10,
+				execution.PriorityRanked:      10,
+				execution.PriorityBackground:  10,
+			},
+		},
+		{
+			name:               "full",
+			backgroundCapacity: 60,
+			runs:               []running{{execution.PriorityBackground, "a", 60}, {execution.PriorityInteractive, "a", 40}},
+			want: map[execution.PriorityClass]int64{
+				execution.PriorityInteractive: 0,
+				execution.PriorityRanked:      0,
+				execution.PriorityBackground:  0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.ExecutionSchedulerConfig{Capacity: 100, BackgroundCapacity: tt.backgroundCapacity}
+			s := newTestExecutionScheduler(cfg, nil, tt.runs)
+			for class, want := range tt.want {
+				if got := s.remainingCapacity(class); got != want {
+					t.Fatalf("remaining capacity of %s = %d, want %d", class, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTickPicksFairTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		shares     map[string]int
+		runs       []running
+		waiting    map[execution.PriorityClass][]string
+		wantClass  execution.PriorityClass
+		wantTenant string
+	}{
+		{
+			name:       "most urgent class first",
+			runs:       []running{{execution.PriorityInteractive, "a", 50}},
+			waiting:    map[execution.PriorityClass][]string{execution.PriorityInteractive: {"a"}, execution.PriorityBackground: {"b"}},
+			wantClass:  execution.PriorityInteractive,
+			wantTenant: "a",
+		},
+		{
+			name:       "least running weight",
+			runs:       []running{{execution.PriorityRanked, "a", 30}, {execution.PriorityBackground, "b", 20}},
+			waiting:    map[execution.PriorityClass][]string{execution.PriorityRanked: {"a", "b"}},
+			wantClass:  execution.PriorityRanked,
+			wantTenant: "b",
+		},
+		{
+			name:       "idle tenant",
+			runs:       []running{{execution.PriorityRanked, "a", 30}},
+			waiting:    map[execution.PriorityClass][]string{execution.PriorityRanked: {"a", "c"}},
+			wantClass:  execution.PriorityRanked,
+			wantTenant: "c",
+		},
+		{
+			name:       "weight per share",
+			shares:     map[string]int{"a": 4},
+			runs:       []running{{execution.PriorityRanked, "a", 30}, {execution.PriorityRanked, "b", 10}},
+			waiting:    map[execution.PriorityClass][]string{execution.PriorityRanked: {"b", "a"}},
+			wantClass:  execution.PriorityRanked,
+			wantTenant: "a",
+		},
+		{
+			name:       "tie goes to the first tenant",
+			runs:       []running{{execution.PriorityRanked, "a", 10}, {execution.PriorityRanked, "b", 10}},
+			waiting:    map[execution.PriorityClass][]string{execution.PriorityRanked: {"b", "a"}},
+			wantClass:  execution.PriorityRanked,
+			wantTenant: "b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The picked execution is heavier than the capacity left, so the tick does not run it.
+			storage := &stubExecutionStorage{waiting: tt.waiting, weight: 100}
+			cfg := config.ExecutionSchedulerConfig{Capacity: 100, TenantShares: tt.shares}
+			s := newTestExecutionScheduler(cfg, storage, tt.runs)
+
+			s.Tick(context.Background())
+
+			if storage.pickedClass != tt.wantClass || storage.pickedTenant != tt.wantTenant {
+				t.Fatalf("picked %s/%s, want %s/%s", storage.pickedClass, storage.pickedTenant, tt.wantClass, tt.wantTenant)
+			}
+			if len(storage.saved) != 0 {
+				t.Fatalf("saved %d executions over capacity", len(storage.saved))
+			}
+		})
+	}
+}
+
+func TestTickSkipsWhenFull(t *testing.T) {
+	storage := &stubExecutionStorage{waiting: map[execution.PriorityClass][]string{execution.PriorityInteractive: {"a"}}}
+	cfg := config.ExecutionSchedulerConfig{Capacity: 100, BackgroundCapacity: 60}
+	s := newTestExecutionScheduler(cfg, storage, []running{{execution.PriorityBackground, "b", 60}, {execution.PriorityRanked, "c", 40}})
+
+	s.Tick(context.Background())
+
+	if storage.pickedTenant != "" {
+		t.Fatalf("picked %s/%s at full capacity", storage.pickedClass, storage.pickedTenant)
+	}
+}
+
+func TestReplayExecution(t *testing.T) {
+	replayedID, otherID := execution.ID{1}, execution.ID{2}
+	started := messages.NewStartExecutionMessage(replayedID)
+	finished := messages.NewFinishExecutionMessageOk(replayedID, nil, nil)
+	failed := messages.NewFinishExecutionMessageError(replayedID, "worker lost")
+
+	tests := []struct {
+		name         string
+		replayed     *execution.Definition
+		messages     []messages.Message
+		wantReplayed bool
+		wantStatus   execution.Status
+		wantSent     int
+	}{
+		{
+			name:         "in flight",
+			replayed:     &execution.Definition{ID: replayedID, Status: execution.StatusScheduled},
+			messages:     []messages.Message{started},
+			wantReplayed: true,
+			wantStatus:   execution.StatusScheduled,
+		},
+		{
+			name:         "finished",
+			replayed:     &execution.Definition{ID: replayedID, Status: execution.StatusFinished},
+			messages:     []messages.Message{started, finished},
+			wantReplayed: true,
+			wantStatus:   execution.StatusFinished,
+			wantSent:     2,
+		},
+		{
+			name:       "finished with error",
+			replayed:   &execution.Definition{ID: replayedID, Status: execution.StatusFinished},
+			messages:   []messages.Message{started, failed},
+			wantStatus: execution.StatusNew,
+		},
+		{
+			name:       "expired",
+			replayed:   &execution.Definition{ID: replayedID, Status: execution.StatusExpired},
+			messages:   []messages.Message{started, failed},
+			wantStatus: execution.StatusNew,
+		},
+		{
+			name:       "gone",
+			wantStatus: execution.StatusNew,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &stubExecutionStorage{executions: make(map[execution.ID]execution.Definition)}
+			if tt.replayed != nil {
+				storage.executions[replayedID] = *tt.replayed
+			}
+			stored := make(stubMessageStorage)
+			for i, msg := range tt.messages {
+				stored[replayedID] = append(stored[replayedID], historyMessage(t, int64(i+1), msg))
+			}
+			dispatcher := &stubMessageDispatcher{}
+
+			s := newTestExecutionScheduler(config.ExecutionSchedulerConfig{Capacity: 100}, storage, nil)
+			s.messageStorage = stored
+			s.messageDispatcher = dispatcher
+
+			def := execution.Definition{ID: otherID, Status: execution.StatusNew, ReplayOf: &replayedID}
+			replayed, err := s.replayExecution(context.Background(), &def)
+			if err != nil {
+				t.Fatalf("replay: %v", err)
+			}
+
+			if replayed != tt.wantReplayed || def.Status != tt.wantStatus || def.Tries != 0 {
+				t.Fatalf("replayed = %t with status %s and %d tries, want %t with status %s and no tries",
+					replayed, def.Status, def.Tries, tt.wantReplayed, tt.wantStatus)
+			}
+			if (def.ReplayOf == nil) == tt.wantReplayed {
+				t.Fatalf("replay of = %v, want it kept only while replayed", def.ReplayOf)
+			}
+			if tt.wantReplayed && (len(storage.saved) != 1 || storage.saved[0].Status != tt.wantStatus) {
+				t.Fatalf("saved %+v, want the execution %s", storage.saved, tt.wantStatus)
+			}
+			if len(dispatcher.sent) != tt.wantSent {
+				t.Fatalf("sent %d messages, want %d", len(dispatcher.sent), tt.wantSent)
+			}
+			for _, msg := range dispatcher.sent {
+				if msg.GetExecutionID() != otherID {
+					t.Fatalf("sent message of execution %v, want %v", msg.GetExecutionID(), otherID)
+				}
+			}
+		})
+	}
+}
+
+// historyMessage round-trips the message through JSON as the message storage does,
+// so that replaying it does not change the original.
+func historyMessage(t *testing.T, id int64, msg messages.Message) history.Message {
+	t.Helper()
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal message: %v", err)
+	}
+	var stored messages.Message
+	if err = json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("unmarshal message: %v", err)
+	}
+	return history.Message{MessageID: id, Message: stored}
+}
@@ -3,9 +3,11 @@ package scheduler
 import (
 	"context"
 	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
 	"exesh/internal/domain/execution/result/results"
 	"exesh/internal/domain/execution/source/sources"
+	"fmt"
 )
 
 type (
@@ -21,4 +23,17 @@ type (
 	sourcesCallback func(context.Context) ([]sources.Source, error)
 	startCallback   func(context.Context)
 	doneCallback    func(context.Context, results.Result)
+
+	artifactLostError struct {
+		jobID job.ID
+		err   error
+	}
 )
+
+func (e *artifactLostError) Error() string {
+	return fmt.Sprintf("failed to get worker for job %s: %v", e.jobID.String(), e.err)
+}
+
+func (e *artifactLostError) Unwrap() error {
+	return e.err
+}
@@ -2,11 +2,16 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"exesh/internal/config"
 	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
+	"exesh/internal/domain/execution/result"
 	"exesh/internal/domain/execution/result/results"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
+	"fmt"
+	"slices"
 	"sort"
 	"time"
 
@@ -23,10 +28,14 @@ type (
 		workerPool         *WorkerPool
 		executionScheduler *ExecutionScheduler
 
-		mu           sync.Mutex
-		promisedJobs []promisedJob
-		startedJobs  map[job.ID]startedJob
-		events       EventRecorder
+		mu               sync.Mutex
+		promisedJobs     []promisedJob
+		startedJobs      map[job.ID]startedJob
+		unplaceableSince map[job.ID]time.Time
+		retries          map[job.ID]int
+		events           EventRecorder
+		clock            Clock
+		onJobsReady      []func()
 
 		lastPromiseRescheduleAt time.Time
 	}
@@ -37,6 +46,11 @@ type (
 		PromisedStartAt  time.Time
 	}
 
+	unschedulableJob struct {
+		*Job
+		reason string
+	}
+
 	startedJob struct {
 		*Job
 		workerID     string
@@ -47,6 +61,8 @@ type (
 	workerState struct {
 		Slots                          int
 		Memory                         int
+		Toolchains                     map[string]struct{}
+		Labels                         placement.Labels
 		RunningJobs                    []runningJob
 		RunningJobsTotalExpectedMemory int
 	}
@@ -74,17 +90,45 @@ func NewJobScheduler(
 		workerPool:         workerPool,
 		executionScheduler: executionScheduler,
 
-		mu:           sync.Mutex{},
-		promisedJobs: make([]promisedJob, 0),
-		startedJobs:  make(map[job.ID]startedJob),
-		events:       events,
+		mu:               sync.Mutex{},
+		promisedJobs:     make([]promisedJob, 0),
+		startedJobs:      make(map[job.ID]startedJob),
+		unplaceableSince: make(map[job.ID]time.Time),
+		retries:          make(map[job.ID]int),
+		events:           events,
+		clock:            systemClock{},
+		onJobsReady:      make([]func(), 0),
 	}
+	workerPool.OnWorkerRemoved(s.rescheduleOrphanedJobs)
+	executionScheduler.OnJobEnqueued(s.notifyJobsReady)
 	return s
 }
 
+// SetClock makes the scheduler take the time from the clock instead of the system one.
+func (s *JobScheduler) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// OnJobsReady registers a callback called when there may be new jobs to pick: a job was queued
+// by the execution scheduler or was returned to the promised ones. The callbacks must be registered
+// before the scheduler is used and must not block, since they are called under the scheduler locks.
+func (s *JobScheduler) OnJobsReady(cb func()) {
+	s.onJobsReady = append(s.onJobsReady, cb)
+}
+
+func (s *JobScheduler) notifyJobsReady() {
+	for _, cb := range s.onJobsReady {
+		cb()
+	}
+}
+
 func (s *JobScheduler) PickJobs(ctx context.Context, workerID string, slots, memory int) ([]jobs.Job, []sources.Source) {
 	pickedJobs := make([]jobs.Job, 0)
 	pickedSources := make([]sources.Source, 0)
+	if s.workerPool.IsDraining(workerID) {
+		return pickedJobs, pickedSources
+	}
+
 	for range slots {
 		jb, srcs := s.pickJob(ctx, workerID, memory)
 		if jb == nil {
@@ -111,8 +155,10 @@ func (s *JobScheduler) DoneJob(ctx context.Context, workerID string, res results
 		}
 
 		delete(s.startedJobs, jobID)
+		delete(s.retries, jobID)
 		s.workerPool.removeJob(workerID, jobID)
-		finishedAt := time.Now()
+		s.putCachedArtifacts(workerID, started.Job.Job, res)
+		finishedAt := s.clock.Now()
 		expectedFinishedAt := started.startedAt.Add(time.Millisecond * time.Duration(started.GetExpectedTime()))
 		s.events.RecordJobEvent(ctx, JobEvent{
 			Type:                   "finished",
@@ -141,6 +187,128 @@ func (s *JobScheduler) DoneJob(ctx context.Context, workerID string, res results
 	}
 }
 
+// putCachedArtifacts registers compile outputs under their cache keys,
+// so later executions can reuse them without dispatching the compilation.
+func (s *JobScheduler) putCachedArtifacts(workerID string, jb jobs.Job, res results.Result) {
+	cacheKeys := make(map[job.ID]job.ID)
+	innerJobs := []jobs.Job{jb}
+	if jb.GetType() == job.Chain {
+		innerJobs = jb.AsChain().Jobs
+	}
+	for _, innerJob := range innerJobs {
+		if cacheKey := innerJob.GetCacheKey(); cacheKey != nil {
+			cacheKeys[innerJob.GetID()] = *cacheKey
+		}
+	}
+	if len(cacheKeys) == 0 {
+		return
+	}
+
+	jobResults := []results.Result{res}
+	if res.GetType() == result.Chain {
+		jobResults = res.AsChain().Results
+	}
+	for _, jobRes := range jobResults {
+		cacheKey, ok := cacheKeys[jobRes.GetJobID()]
+		if !ok || jobRes.GetStatus() != job.StatusOK || !jobRes.GetHasOutput() || jobRes.GetArtifactTrashTime() == nil {
+			continue
+		}
+		s.workerPool.PutArtifact(workerID, cacheKey, *jobRes.GetArtifactTrashTime())
+	}
+}
+
+func (s *JobScheduler) ReturnJob(ctx context.Context, workerID string, jobID job.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	started, ok := s.startedJobs[jobID]
+	if !ok || started.workerID != workerID {
+		return
+	}
+
+	delete(s.startedJobs, jobID)
+	s.workerPool.removeJob(workerID, jobID)
+
+	s.repromiseJob(ctx, started.Job, workerID, s.workerPool.getWorkersState(), "returned")
+}
+
+func (s *JobScheduler) rescheduleOrphanedJobs(ctx context.Context, workerID string) {
+	failedJobs := make([]*Job, 0)
+
+	s.mu.Lock()
+	workers := s.workerPool.getWorkersState()
+	for jobID, started := range s.startedJobs {
+		if started.workerID != workerID {
+			continue
+		}
+
+		delete(s.startedJobs, jobID)
+		s.retries[jobID]++
+		if s.retries[jobID] > s.cfg.MaxJobRetries {
+			delete(s.retries, jobID)
+			failedJobs = append(failedJobs, started.Job)
+			continue
+		}
+
+		s.log.Warn("reschedule job orphaned by removed worker",
+			slog.String("job", jobID.String()),
+			slog.String("worker", workerID),
+			slog.Int("retry", s.retries[jobID]),
+		)
+		s.repromiseJob(ctx, started.Job, workerID, workers, "orphaned")
+	}
+	s.mu.Unlock()
+
+	for _, jb := range failedJobs {
+		jb.OnDone(ctx, results.Error(jb.Job,
+			fmt.Errorf("job was lost with worker %s and its retry budget is exhausted", workerID)))
+	}
+}
+
+// repromiseJob puts an already started job back to the promised jobs. Must be called under s.mu.
+func (s *JobScheduler) repromiseJob(ctx context.Context, jb *Job, workerID string, workers map[string]workerState, eventType string) {
+	now := s.clock.Now()
+	promisedWorkerID, promisedStartAt := s.getBestPromise(jb, now, workers, s.promisedJobs)
+	s.events.RecordJobEvent(ctx, JobEvent{
+		Type:                   eventType,
+		JobID:                  jb.GetID(),
+		ExecutionID:            jb.ExecutionID,
+		WorkerID:               workerID,
+		JobType:                string(jb.GetType()),
+		ExpectedMemoryMB:       jb.GetExpectedMemory(),
+		ExpectedDurationMillis: jb.GetExpectedTime(),
+		PromisedStartAt:        &promisedStartAt,
+		At:                     now,
+	})
+	s.promisedJobs = append(s.promisedJobs, promisedJob{
+		Job:              jb,
+		PromisedWorkerID: promisedWorkerID,
+		PromisedStartAt:  promisedStartAt,
+	})
+	s.notifyJobsReady()
+}
+
+func (s *JobScheduler) recomputeLostArtifact(ctx context.Context, workerID string, jb *Job, artifactJobID job.ID) {
+	jobID := jb.GetID()
+
+	s.mu.Lock()
+	delete(s.startedJobs, jobID)
+	s.workerPool.removeJob(workerID, jobID)
+	s.mu.Unlock()
+
+	s.events.RecordJobEvent(ctx, JobEvent{
+		Type:        "artifact_lost",
+		JobID:       jobID,
+		ExecutionID: jb.ExecutionID,
+		WorkerID:    workerID,
+		JobType:     string(jb.GetType()),
+		At:          s.clock.Now(),
+	})
+	if err := s.executionScheduler.recomputeArtifact(ctx, jb, artifactJobID, s.cfg.MaxJobRetries); err != nil {
+		jb.OnDone(ctx, results.Error(jb.Job, err))
+	}
+}
+
 func (s *JobScheduler) pickJob(ctx context.Context, workerID string, memory int) (*jobs.Job, []sources.Source) {
 	s.mu.Lock()
 
@@ -151,7 +319,8 @@ func (s *JobScheduler) pickJob(ctx context.Context, workerID string, memory int)
 	s.promisedJobs = make([]promisedJob, 0)
 
 	var pickedJob *Job = nil
-	now := time.Now()
+	unschedulable := make([]unschedulableJob, 0)
+	now := s.clock.Now()
 	shouldReschedulePromises := s.shouldReschedulePromises(now)
 	for _, jb := range promisedJobs {
 		if pickedJob == nil && s.canStartNowOnWorker(workerID, jb.Job, now, workers, s.promisedJobs) {
@@ -171,7 +340,13 @@ func (s *JobScheduler) pickJob(ctx context.Context, workerID string, memory int)
 			continue
 		}
 
-		if shouldReschedulePromises {
+		if reason, ok := s.checkUnschedulable(jb.Job, workers, now); ok {
+			unschedulable = append(unschedulable, unschedulableJob{Job: jb.Job, reason: reason})
+			continue
+		}
+
+		// promises to a draining or removed worker are moved elsewhere right away
+		if _, ok := workers[jb.PromisedWorkerID]; !ok || shouldReschedulePromises {
 			jb.PromisedWorkerID, jb.PromisedStartAt = s.getBestPromise(jb.Job, now, workers, s.promisedJobs)
 		}
 		s.promisedJobs = append(s.promisedJobs, jb)
@@ -180,6 +355,15 @@ func (s *JobScheduler) pickJob(ctx context.Context, workerID string, memory int)
 	if pickedJob == nil {
 		jbs := s.executionScheduler.pickJobs()
 		for _, jb := range jbs {
+			if reason, ok := s.checkUnschedulable(jb, workers, now); ok {
+				jb.OnStart(ctx)
+				unschedulable = append(unschedulable, unschedulableJob{Job: jb, reason: reason})
+				continue
+			}
+			if !isPlaceable(jb, workers) {
+				continue
+			}
+
 			if s.canStartNowOnWorker(workerID, jb, now, workers, s.promisedJobs) {
 				pickedJob = jb
 				pickedJob.OnStart(ctx)
@@ -223,11 +407,18 @@ func (s *JobScheduler) pickJob(ctx context.Context, workerID string, memory int)
 
 	s.mu.Unlock()
 
+	s.failUnschedulable(ctx, unschedulable)
+
 	if pickedJob == nil {
 		return nil, nil
 	}
 
 	srcs, err := pickedJob.Sources(ctx)
+	var lostErr *artifactLostError
+	if errors.As(err, &lostErr) {
+		s.recomputeLostArtifact(ctx, workerID, pickedJob, lostErr.jobID)
+		return s.pickJob(ctx, workerID, memory)
+	}
 	if err != nil {
 		res := results.Error(pickedJob.Job, err)
 		s.DoneJob(ctx, workerID, res)
@@ -263,6 +454,9 @@ func (s *JobScheduler) canStartNowOnWorker(
 	promisedJobsState []promisedJob,
 ) bool {
 	w := workers[workerID]
+	if !w.supports(jb) {
+		return false
+	}
 	if len(w.RunningJobs)+1 > w.Slots || w.RunningJobsTotalExpectedMemory+jb.GetExpectedMemory() > w.Memory {
 		return false
 	}
@@ -314,6 +508,10 @@ func (s *JobScheduler) getBestPromise(
 	bestWorker := ""
 	var bestStartedAt *time.Time = nil
 	for id, w := range workers {
+		if !w.supports(jb) {
+			continue
+		}
+
 		events := make([]scanlineEvent, 0)
 
 		for _, runningJb := range w.RunningJobs {
@@ -416,10 +614,137 @@ func (s *JobScheduler) shouldReschedulePromises(now time.Time) bool {
 	return false
 }
 
+func (w workerState) supports(jb *Job) bool {
+	for _, toolchain := range jb.GetToolchains() {
+		if _, ok := w.Toolchains[toolchain]; !ok {
+			return false
+		}
+	}
+	return jb.GetConstraints().Match(w.Labels)
+}
+
+// isPlaceable reports whether some worker could ever run the job, see unplaceableReason.
+func isPlaceable(jb *Job, workers map[string]workerState) bool {
+	return unplaceableReason(jb, workers) == ""
+}
+
+// unplaceableReason returns why no worker could ever run the job, or "" if some worker could.
+// The workers are narrowed down by toolchains, placement constraints, memory, and slots, and
+// the reason names the first requirement no worker is left after.
+//
+// Without any workers the job is placeable: right after the coordinator starts or while all
+// workers reconnect, failing the jobs would fail every queued execution, so they wait instead.
+func unplaceableReason(jb *Job, workers map[string]workerState) string {
+	if len(workers) == 0 {
+		return ""
+	}
+
+	candidates := make([]workerState, 0, len(workers))
+	for _, w := range workers {
+		candidates = append(candidates, w)
+	}
+
+	missing := make([]string, 0)
+	for _, toolchain := range jb.GetToolchains() {
+		supported := false
+		for _, w := range candidates {
+			if _, ok := w.Toolchains[toolchain]; ok {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			missing = append(missing, toolchain)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("no worker has toolchains %q", missing)
+	}
+
+	steps := []struct {
+		reason string
+		keep   func(w workerState) bool
+	}{
+		{
+			reason: "no worker has all of its toolchains",
+			keep: func(w workerState) bool {
+				for _, toolchain := range jb.GetToolchains() {
+					if _, ok := w.Toolchains[toolchain]; !ok {
+						return false
+					}
+				}
+				return true
+			},
+		},
+		{
+			reason: "no worker satisfies its placement constraints",
+			keep:   func(w workerState) bool { return jb.GetConstraints().Match(w.Labels) },
+		},
+		{
+			reason: fmt.Sprintf("no worker has %d MB of memory", jb.GetExpectedMemory()),
+			keep:   func(w workerState) bool { return w.Memory >= jb.GetExpectedMemory() },
+		},
+		{
+			reason: "no worker has a slot",
+			keep:   func(w workerState) bool { return w.Slots >= 1 },
+		},
+	}
+	for _, step := range steps {
+		candidates = slices.DeleteFunc(candidates, func(w workerState) bool { return !step.keep(w) })
+		if len(candidates) == 0 {
+			return step.reason
+		}
+	}
+	return ""
+}
+
+// checkUnschedulable returns why the job cannot be placed and true once it has not been placeable
+// for UnschedulableAfter.
+func (s *JobScheduler) checkUnschedulable(jb *Job, workers map[string]workerState, now time.Time) (string, bool) {
+	reason := unplaceableReason(jb, workers)
+	if reason == "" {
+		delete(s.unplaceableSince, jb.GetID())
+		return "", false
+	}
+	since, ok := s.unplaceableSince[jb.GetID()]
+	if !ok {
+		s.unplaceableSince[jb.GetID()] = now
+		return "", false
+	}
+	if now.Sub(since) < s.cfg.UnschedulableAfter {
+		return "", false
+	}
+	delete(s.unplaceableSince, jb.GetID())
+	return reason, true
+}
+
+func (s *JobScheduler) failUnschedulable(ctx context.Context, jbs []unschedulableJob) {
+	now := s.clock.Now()
+	for _, jb := range jbs {
+		jobID := jb.GetID()
+		s.log.Warn("job is unschedulable",
+			slog.String("job", jobID.String()),
+			slog.String("execution", jb.ExecutionID.String()),
+			slog.String("reason", jb.reason),
+		)
+		s.events.RecordJobEvent(ctx, JobEvent{
+			Type:             "unschedulable",
+			JobID:            jb.GetID(),
+			ExecutionID:      jb.ExecutionID,
+			JobType:          string(jb.GetType()),
+			ExpectedMemoryMB: jb.GetExpectedMemory(),
+			At:               now,
+		})
+		jb.OnDone(ctx, results.Error(jb.Job.Job, fmt.Errorf("job is unschedulable: %s", jb.reason)))
+	}
+}
+
 func cloneWorkerState(w workerState) workerState {
 	state := workerState{
 		Slots:                          w.Slots,
 		Memory:                         w.Memory,
+		Toolchains:                     w.Toolchains,
+		Labels:                         w.Labels,
 		RunningJobs:                    make([]runningJob, 0, len(w.RunningJobs)),
 		RunningJobsTotalExpectedMemory: w.RunningJobsTotalExpectedMemory,
 	}
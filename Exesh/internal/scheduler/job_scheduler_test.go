@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"exesh/internal/domain/execution/input"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
+	"exesh/internal/domain/execution/output"
+	"exesh/internal/domain/placement"
+	"strings"
+	"testing"
+)
+
+func newCompileJob(toolchain string, memory int, constraints placement.Constraints) *Job {
+	return &Job{
+		Job: jobs.NewCompileCppJob(
+			job.ID{},
+			job.StatusOK,
+			1000,
+			256,
+			1000,
+			memory,
+			constraints,
+			input.Input{},
+			output.Output{},
+			job.CompileOptions{Toolchain: toolchain},
+		),
+	}
+}
+
+func TestUnplaceableReason(t *testing.T) {
+	workers := map[string]workerState{
+		"cpp": {
+			Slots:      2,
+			Memory:     512,
+			Toolchains: map[string]struct{}{"gcc-cpp17": {}},
+			Labels:     placement.Labels{placement.LabelCPU: {"x86"}},
+		},
+		"big": {
+			Slots:      0,
+			Memory:     4096,
+			Toolchains: map[string]struct{}{"gcc-cpp17": {}, "gcc-cpp20": {}},
+			Labels:     placement.Labels{placement.LabelCPU: {"arm"}},
+		},
+	}
+	onCPU := func(cpu string) placement.Constraints {
+		return placement.Constraints{{Key: placement.LabelCPU, Operator: placement.OperatorIn, Values: []string{cpu}}}
+	}
+
+	tests := []struct {
+		name    string
+		job     *Job
+		workers map[string]workerState
+		want    string
+	}{
+		{name: "placeable", job: newCompileJob("gcc-cpp17", 256, onCPU("x86")), workers: workers},
+		{name: "no workers", job: newCompileJob("clang", 1<<20, nil)},
+		{name: "missing toolchain", job: newCompileJob("clang", 256, nil), workers: workers, want: "toolchains"},
+		{name: "constraints", job: newCompileJob("gcc-cpp17", 256, onCPU("riscv")), workers: workers, want: "placement constraints"},
+		{name: "memory", job: newCompileJob("gcc-cpp17", 1024, onCPU("x86")), workers: workers, want: "1024 MB of memory"},
+		{name: "slots", job: newCompileJob("gcc-cpp20", 1024, nil), workers: workers, want: "slot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unplaceableReason(tt.job, tt.workers)
+			if tt.want == "" {
+				if got != "" {
+					t.Fatalf("reason = %q, want placeable", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("reason = %q, want it to name %q", got, tt.want)
+			}
+		})
+	}
+}
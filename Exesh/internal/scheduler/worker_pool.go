@@ -4,6 +4,7 @@ import (
 	"context"
 	"exesh/internal/config"
 	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/placement"
 	"fmt"
 	"log/slog"
 	"math/rand/v2"
@@ -16,15 +17,22 @@ type (
 		log *slog.Logger
 		cfg config.WorkerPoolConfig
 
-		mu      sync.Mutex
-		workers map[string]*worker
-		events  EventRecorder
+		mu              sync.Mutex
+		workers         map[string]*worker
+		events          EventRecorder
+		clock           Clock
+		onWorkerRemoved []workerRemovedCallback
 	}
 
+	workerRemovedCallback func(ctx context.Context, workerID string)
+
 	worker struct {
 		ID                             string
 		Slots                          int
 		Memory                         int
+		Toolchains                     map[string]struct{}
+		Labels                         placement.Labels
+		Draining                       bool
 		LastHeartbeat                  time.Time
 		Artifacts                      map[job.ID]time.Time
 		RunningJobs                    map[job.ID]runningJob
@@ -47,9 +55,38 @@ func NewWorkerPool(log *slog.Logger, cfg config.WorkerPoolConfig, events EventRe
 		log: log,
 		cfg: cfg,
 
-		mu:      sync.Mutex{},
-		workers: make(map[string]*worker),
-		events:  events,
+		mu:              sync.Mutex{},
+		workers:         make(map[string]*worker),
+		events:          events,
+		clock:           systemClock{},
+		onWorkerRemoved: make([]workerRemovedCallback, 0),
+	}
+}
+
+// SetClock makes the pool take the time from the clock instead of the system one.
+func (p *WorkerPool) SetClock(clock Clock) {
+	p.clock = clock
+}
+
+// OnWorkerRemoved registers a callback called after a worker is removed
+// from the pool, either for a missed heartbeat or on deregistration.
+func (p *WorkerPool) OnWorkerRemoved(cb workerRemovedCallback) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.onWorkerRemoved = append(p.onWorkerRemoved, cb)
+}
+
+func (p *WorkerPool) notifyWorkersRemoved(ctx context.Context, workerIDs ...string) {
+	p.mu.Lock()
+	callbacks := make([]workerRemovedCallback, len(p.onWorkerRemoved))
+	copy(callbacks, p.onWorkerRemoved)
+	p.mu.Unlock()
+
+	for _, workerID := range workerIDs {
+		for _, cb := range callbacks {
+			cb(ctx, workerID)
+		}
 	}
 }
 
@@ -65,7 +102,7 @@ func (p *WorkerPool) StartObserver(ctx context.Context) {
 				p.mu.Lock()
 				deadWorkers := make([]string, 0)
 				for _, w := range p.workers {
-					if w.LastHeartbeat.Add(p.cfg.WorkerDieAfter).Before(time.Now()) {
+					if w.LastHeartbeat.Add(p.cfg.WorkerDieAfter).Before(p.clock.Now()) {
 						deadWorkers = append(deadWorkers, w.ID)
 					}
 				}
@@ -75,20 +112,22 @@ func (p *WorkerPool) StartObserver(ctx context.Context) {
 					p.events.RecordWorkerEvent(ctx, WorkerEvent{
 						Type:     "removed",
 						WorkerID: w,
-						At:       time.Now(),
+						At:       p.clock.Now(),
 					})
 				}
 				p.mu.Unlock()
+
+				p.notifyWorkersRemoved(ctx, deadWorkers...)
 			}
 		}
 	}()
 }
 
-func (p *WorkerPool) Heartbeat(workerID string, slots, memory int) {
+func (p *WorkerPool) Heartbeat(workerID string, slots, memory int, toolchains []string, labels placement.Labels, draining bool) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	now := time.Now()
+	now := p.clock.Now()
 	if _, ok := p.workers[workerID]; !ok {
 		p.log.Info("worker registered",
 			slog.String("worker", workerID),
@@ -117,6 +156,14 @@ func (p *WorkerPool) Heartbeat(workerID string, slots, memory int) {
 
 	p.workers[workerID].LastHeartbeat = now
 	w := p.workers[workerID]
+	w.Toolchains = make(map[string]struct{}, len(toolchains))
+	for _, toolchain := range toolchains {
+		w.Toolchains[toolchain] = struct{}{}
+	}
+	w.Labels = labels
+	if draining && !w.Draining {
+		p.startDraining(w, now)
+	}
 	p.events.RecordWorkerEvent(context.Background(), WorkerEvent{
 		Type:              "heartbeat",
 		WorkerID:          workerID,
@@ -130,11 +177,75 @@ func (p *WorkerPool) Heartbeat(workerID string, slots, memory int) {
 	})
 }
 
+func (p *WorkerPool) Drain(workerID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w, ok := p.workers[workerID]
+	if !ok {
+		return fmt.Errorf("worker %s not found", workerID)
+	}
+	if !w.Draining {
+		p.startDraining(w, p.clock.Now())
+	}
+	return nil
+}
+
+func (p *WorkerPool) IsDraining(workerID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w, ok := p.workers[workerID]
+	return ok && w.Draining
+}
+
+func (p *WorkerPool) Deregister(workerID string) {
+	p.mu.Lock()
+	if _, ok := p.workers[workerID]; !ok {
+		p.mu.Unlock()
+		return
+	}
+
+	p.log.Info("worker deregistered", slog.String("worker", workerID))
+	delete(p.workers, workerID)
+	p.events.RecordWorkerEvent(context.Background(), WorkerEvent{
+		Type:     "deregistered",
+		WorkerID: workerID,
+		At:       p.clock.Now(),
+	})
+	p.mu.Unlock()
+
+	p.notifyWorkersRemoved(context.Background(), workerID)
+}
+
+func (p *WorkerPool) startDraining(w *worker, now time.Time) {
+	p.log.Info("worker is draining",
+		slog.String("worker", w.ID),
+		slog.Int("running_jobs", len(w.RunningJobs)),
+	)
+	w.Draining = true
+	p.events.RecordWorkerEvent(context.Background(), WorkerEvent{
+		Type:              "draining",
+		WorkerID:          w.ID,
+		TotalSlots:        w.Slots,
+		TotalMemoryMB:     w.Memory,
+		FreeSlots:         w.Slots - len(w.RunningJobs),
+		AvailableMemoryMB: w.Memory - w.RunningJobsTotalExpectedMemory,
+		RunningJobs:       len(w.RunningJobs),
+		UsedMemoryMB:      w.RunningJobsTotalExpectedMemory,
+		At:                now,
+	})
+}
+
 func (p *WorkerPool) PutArtifact(workerID string, jobID job.ID, trashTime time.Time) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.workers[workerID].Artifacts[jobID] = trashTime
+	w, ok := p.workers[workerID]
+	if !ok {
+		return
+	}
+	w.Artifacts[jobID] = trashTime
 }
 
 func (p *WorkerPool) getWorkersState() map[string]workerState {
@@ -143,9 +254,14 @@ func (p *WorkerPool) getWorkersState() map[string]workerState {
 
 	workers := make(map[string]workerState)
 	for _, w := range p.workers {
+		if w.Draining {
+			continue
+		}
 		state := workerState{
 			Slots:                          w.Slots,
 			Memory:                         w.Memory,
+			Toolchains:                     w.Toolchains,
+			Labels:                         w.Labels,
 			RunningJobs:                    make([]runningJob, 0, len(w.RunningJobs)),
 			RunningJobsTotalExpectedMemory: w.RunningJobsTotalExpectedMemory,
 		}
@@ -162,7 +278,10 @@ func (p *WorkerPool) placeJob(workerID string, jobID job.ID, jb runningJob) int
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	w := p.workers[workerID]
+	w, ok := p.workers[workerID]
+	if !ok {
+		return 0
+	}
 	if exJb, ok := w.RunningJobs[jobID]; ok {
 		w.RunningJobsTotalExpectedMemory -= exJb.expectedMemory
 	}
@@ -178,7 +297,7 @@ func (p *WorkerPool) placeJob(workerID string, jobID job.ID, jb runningJob) int
 		AvailableMemoryMB: w.Memory - w.RunningJobsTotalExpectedMemory,
 		RunningJobs:       len(w.RunningJobs),
 		UsedMemoryMB:      w.RunningJobsTotalExpectedMemory,
-		At:                time.Now(),
+		At:                p.clock.Now(),
 	})
 	return jb.memoryOffset
 }
@@ -187,7 +306,10 @@ func (p *WorkerPool) removeJob(workerID string, jobID job.ID) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	w := p.workers[workerID]
+	w, ok := p.workers[workerID]
+	if !ok {
+		return
+	}
 	if jb, ok := w.RunningJobs[jobID]; ok {
 		w.RunningJobsTotalExpectedMemory -= jb.expectedMemory
 		delete(w.RunningJobs, jobID)
@@ -200,7 +322,7 @@ func (p *WorkerPool) removeJob(workerID string, jobID job.ID) {
 			AvailableMemoryMB: w.Memory - w.RunningJobsTotalExpectedMemory,
 			RunningJobs:       len(w.RunningJobs),
 			UsedMemoryMB:      w.RunningJobsTotalExpectedMemory,
-			At:                time.Now(),
+			At:                p.clock.Now(),
 		})
 	}
 }
@@ -236,21 +358,40 @@ func firstFreeMemoryOffset(runningJobs map[job.ID]runningJob, memory int, totalM
 	return 0
 }
 
+func (p *WorkerPool) hasArtifact(jobID job.ID) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, w := range p.workers {
+		if trashTime, ok := w.Artifacts[jobID]; ok && p.clock.Now().Add(time.Minute).Before(trashTime) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *WorkerPool) getWorkerWithArtifact(jobID job.ID) (workerID string, err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	ws := make([]*worker, 0)
+	drainingWs := make([]*worker, 0)
 	for _, w := range p.workers {
 		if trashTime, ok := w.Artifacts[jobID]; ok {
-			if time.Now().Add(time.Minute).After(trashTime) {
+			if p.clock.Now().Add(time.Minute).After(trashTime) {
 				delete(w.Artifacts, jobID)
+			} else if w.Draining {
+				drainingWs = append(drainingWs, w)
 			} else {
 				ws = append(ws, w)
 			}
 		}
 	}
 
+	if len(ws) == 0 {
+		// a draining worker still serves its artifacts until it deregisters
+		ws = drainingWs
+	}
 	if len(ws) == 0 {
 		err = fmt.Errorf("worker for artifact not found")
 		return
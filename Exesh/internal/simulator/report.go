@@ -0,0 +1,186 @@
+package simulator
+
+import (
+	"context"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/scheduler"
+	"math"
+	"sort"
+	"time"
+)
+
+type (
+	Report struct {
+		Duration  time.Duration
+		Submitted int
+		Finished  int
+		Failed    int
+		Expired   int
+
+		// QueueLatency is the time from the submission of an execution to its start,
+		// ExecutionLatency is the time from the submission to the finish.
+		QueueLatency     Percentiles
+		ExecutionLatency Percentiles
+
+		// SlotUtilisation and MemoryUtilisation are the shares of the worker slots and memory
+		// busy with running jobs over the simulation. ReservedMemoryUtilisation counts
+		// the memory the scheduler reserved for the running jobs instead of the memory they used.
+		SlotUtilisation           float64
+		MemoryUtilisation         float64
+		ReservedMemoryUtilisation float64
+
+		// PromisedJobs is the number of jobs started on a promise. PromiseError is how far
+		// the actual start of such a job was from the promised one, and PromisesLate is the share
+		// of them which started later than a heartbeat interval after the promised time.
+		PromisedJobs int
+		PromiseError Percentiles
+		PromisesLate float64
+	}
+
+	Percentiles struct {
+		P50 time.Duration
+		P90 time.Duration
+		P99 time.Duration
+		Max time.Duration
+	}
+
+	resources struct {
+		slots  int
+		memory int
+	}
+
+	// recorder receives the events of the schedulers and the workers of a simulation.
+	recorder struct {
+		submittedAt map[execution.ID]time.Time
+		startedAt   map[execution.ID]time.Time
+		finished    map[execution.ID]scheduler.ExecutionEvent
+
+		promisedStartAt map[job.ID]time.Time
+		promiseErrors   []time.Duration
+
+		busySlotTime       float64
+		usedMemoryTime     float64
+		reservedMemoryTime float64
+	}
+)
+
+func newRecorder() *recorder {
+	return &recorder{
+		submittedAt: make(map[execution.ID]time.Time),
+		startedAt:   make(map[execution.ID]time.Time),
+		finished:    make(map[execution.ID]scheduler.ExecutionEvent),
+
+		promisedStartAt: make(map[job.ID]time.Time),
+		promiseErrors:   make([]time.Duration, 0),
+	}
+}
+
+func (r *recorder) RecordExecutionEvent(_ context.Context, event scheduler.ExecutionEvent) {
+	switch event.Type {
+	case "started":
+		if _, ok := r.startedAt[event.ExecutionID]; !ok {
+			r.startedAt[event.ExecutionID] = event.At
+		}
+	case "finished":
+		r.finished[event.ExecutionID] = event
+	}
+}
+
+func (r *recorder) RecordJobEvent(_ context.Context, event scheduler.JobEvent) {
+	switch event.Type {
+	case "promised", "returned", "orphaned":
+		if event.PromisedStartAt != nil {
+			r.promisedStartAt[event.JobID] = *event.PromisedStartAt
+		}
+	case "promised_started":
+		promisedStartAt, ok := r.promisedStartAt[event.JobID]
+		if ok && event.StartedAt != nil {
+			r.promiseErrors = append(r.promiseErrors, event.StartedAt.Sub(promisedStartAt))
+			delete(r.promisedStartAt, event.JobID)
+		}
+	}
+}
+
+func (r *recorder) RecordWorkerEvent(context.Context, scheduler.WorkerEvent) {}
+
+func (r *recorder) submitted(id execution.ID, at time.Time) {
+	r.submittedAt[id] = at
+}
+
+func (r *recorder) jobRan(jb simulatedJob) {
+	duration := jb.finishesAt.Sub(jb.startedAt).Seconds()
+	r.busySlotTime += duration
+	r.usedMemoryTime += duration * float64(jb.spec.UsedMemory)
+	r.reservedMemoryTime += duration * float64(jb.job.GetExpectedMemory())
+}
+
+func (r *recorder) report(duration time.Duration, capacity resources, heartbeatInterval time.Duration) Report {
+	report := Report{
+		Duration:  duration,
+		Submitted: len(r.submittedAt),
+	}
+
+	queueLatencies := make([]time.Duration, 0, len(r.startedAt))
+	for id, startedAt := range r.startedAt {
+		queueLatencies = append(queueLatencies, startedAt.Sub(r.submittedAt[id]))
+	}
+	report.QueueLatency = percentiles(queueLatencies)
+
+	executionLatencies := make([]time.Duration, 0, len(r.finished))
+	for id, event := range r.finished {
+		switch event.Status {
+		case string(execution.StatusExpired):
+			report.Expired++
+		case "error":
+			report.Failed++
+		default:
+			report.Finished++
+		}
+		executionLatencies = append(executionLatencies, event.At.Sub(r.submittedAt[id]))
+	}
+	report.ExecutionLatency = percentiles(executionLatencies)
+
+	if seconds := duration.Seconds(); seconds > 0 {
+		if capacity.slots > 0 {
+			report.SlotUtilisation = r.busySlotTime / (float64(capacity.slots) * seconds)
+		}
+		if capacity.memory > 0 {
+			report.MemoryUtilisation = r.usedMemoryTime / (float64(capacity.memory) * seconds)
+			report.ReservedMemoryUtilisation = r.reservedMemoryTime / (float64(capacity.memory) * seconds)
+		}
+	}
+
+	report.PromisedJobs = len(r.promiseErrors)
+	absErrors := make([]time.Duration, 0, len(r.promiseErrors))
+	late := 0
+	for _, err := range r.promiseErrors {
+		if err > heartbeatInterval {
+			late++
+		}
+		absErrors = append(absErrors, time.Duration(math.Abs(float64(err))))
+	}
+	report.PromiseError = percentiles(absErrors)
+	if len(r.promiseErrors) > 0 {
+		report.PromisesLate = float64(late) / float64(len(r.promiseErrors))
+	}
+
+	return report
+}
+
+func percentiles(values []time.Duration) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	at := func(q float64) time.Duration {
+		return values[int(math.Ceil(q*float64(len(values))))-1]
+	}
+	return Percentiles{
+		P50: at(0.5),
+		P90: at(0.9),
+		P99: at(0.99),
+		Max: values[len(values)-1],
+	}
+}
@@ -0,0 +1,320 @@
+package simulator
+
+import (
+	"context"
+	"exesh/internal/calculator"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/input"
+	"exesh/internal/domain/execution/input/inputs"
+	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/job/jobs"
+	"exesh/internal/domain/execution/result/results"
+	"exesh/internal/domain/execution/source"
+	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/factory"
+	"exesh/internal/scheduler"
+	heartbeatUC "exesh/internal/usecase/heartbeat"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+type (
+	Config struct {
+		Workers      int
+		WorkerSlots  int
+		WorkerMemory int
+		// HeartbeatInterval is the step of the virtual clock: every worker sends a heartbeat once a step.
+		HeartbeatInterval time.Duration
+		// MaxDuration stops the simulation even if some executions have not finished yet.
+		MaxDuration time.Duration
+
+		ExecutionScheduler config.ExecutionSchedulerConfig
+		JobScheduler       config.JobSchedulerConfig
+		WorkerPool         config.WorkerPoolConfig
+	}
+
+	// Simulator runs the coordinator schedulers on a virtual clock
+	// with in-memory storage and simulated workers executing the jobs of a workload.
+	Simulator struct {
+		log *slog.Logger
+		cfg Config
+
+		clock    *virtualClock
+		storage  *executionStorage
+		recorder *recorder
+		calc     *calculator.Calculator
+
+		executionScheduler *scheduler.ExecutionScheduler
+		heartbeat          *heartbeatUC.UseCase
+
+		specByCategory map[string]JobSpec
+		specByJob      map[job.ID]JobSpec
+		workers        []*simulatedWorker
+	}
+
+	virtualClock struct {
+		now time.Time
+	}
+
+	simulatedWorker struct {
+		id      string
+		running map[job.ID]simulatedJob
+	}
+
+	simulatedJob struct {
+		job        jobs.Job
+		spec       JobSpec
+		startedAt  time.Time
+		finishesAt time.Time
+	}
+
+	// workloadEstimator expects from every job exactly what its spec says.
+	workloadEstimator struct {
+		specByCategory map[string]JobSpec
+	}
+
+	// executionFactory remembers the specs of the created jobs, so that the workers know how long to run them.
+	executionFactory struct {
+		*factory.ExecutionFactory
+		sim *Simulator
+	}
+)
+
+const (
+	simulatedCodeSource  source.DefinitionName = "code"
+	simulatedInputSource source.DefinitionName = "input"
+)
+
+var simulationStart = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func New(log *slog.Logger, cfg Config) *Simulator {
+	s := &Simulator{
+		log: log,
+		cfg: cfg,
+
+		clock:    &virtualClock{now: simulationStart},
+		storage:  newExecutionStorage(),
+		recorder: newRecorder(),
+
+		specByCategory: make(map[string]JobSpec),
+		specByJob:      make(map[job.ID]JobSpec),
+		workers:        make([]*simulatedWorker, 0, cfg.Workers),
+	}
+	s.calc = calculator.NewCalculator(&workloadEstimator{specByCategory: s.specByCategory})
+
+	workerPool := scheduler.NewWorkerPool(log, cfg.WorkerPool, s.recorder)
+	workerPool.SetClock(s.clock)
+
+	executionFactory := &executionFactory{
+		ExecutionFactory: factory.NewExecutionFactory(config.JobFactoryConfig{}, nil, s.calc),
+		sim:              s,
+	}
+	s.executionScheduler = scheduler.NewExecutionScheduler(log, cfg.ExecutionScheduler,
+		unitOfWork{}, s.storage, nil, nil,
+		executionFactory, workerPool, factory.NewMessageFactory(), messageDispatcher{}, s.recorder)
+	s.executionScheduler.SetClock(s.clock)
+
+	jobScheduler := scheduler.NewJobScheduler(log, cfg.JobScheduler, workerPool, s.executionScheduler, s.recorder)
+	jobScheduler.SetClock(s.clock)
+
+	s.heartbeat = heartbeatUC.NewUseCase(log, workerPool, jobScheduler)
+
+	for i := range cfg.Workers {
+		s.workers = append(s.workers, &simulatedWorker{
+			id:      fmt.Sprintf("simulated-worker-%d", i+1),
+			running: make(map[job.ID]simulatedJob),
+		})
+	}
+
+	return s
+}
+
+// Run submits the workload and advances the virtual clock by heartbeat intervals
+// until every execution has finished or MaxDuration has passed.
+func (s *Simulator) Run(ctx context.Context, workload Workload) (Report, error) {
+	workload = append(Workload(nil), workload...)
+	sort.SliceStable(workload, func(i, j int) bool { return workload[i].At < workload[j].At })
+
+	start := s.clock.Now()
+	nextTickAt := start
+	submitted := 0
+	for {
+		now := s.clock.Now()
+		for submitted < len(workload) && !start.Add(workload[submitted].At).After(now) {
+			if err := s.submit(ctx, submitted, workload[submitted]); err != nil {
+				return Report{}, fmt.Errorf("failed to submit execution %d: %w", submitted, err)
+			}
+			submitted++
+		}
+
+		for !nextTickAt.After(now) {
+			s.executionScheduler.Tick(ctx)
+			nextTickAt = nextTickAt.Add(s.cfg.ExecutionScheduler.ExecutionsInterval)
+		}
+
+		for _, w := range s.workers {
+			s.sendHeartbeat(ctx, w, now)
+		}
+
+		if submitted == len(workload) && s.storage.allFinished() && s.idle() {
+			break
+		}
+		if now.Sub(start) >= s.cfg.MaxDuration {
+			s.log.Warn("simulation stopped before all executions finished", slog.Duration("max_duration", s.cfg.MaxDuration))
+			break
+		}
+
+		s.clock.now = now.Add(s.cfg.HeartbeatInterval)
+	}
+
+	capacity := resources{
+		slots:  s.cfg.Workers * s.cfg.WorkerSlots,
+		memory: s.cfg.Workers * s.cfg.WorkerMemory,
+	}
+	return s.recorder.report(s.clock.Now().Sub(start), capacity, s.cfg.HeartbeatInterval), nil
+}
+
+func (s *Simulator) submit(ctx context.Context, index int, submission Submission) error {
+	stage := execution.StageDefinition{
+		Name: "tests",
+		Jobs: make([]jobs.Definition, 0, len(submission.Jobs)),
+	}
+	for i, spec := range submission.Jobs {
+		categoryName := fmt.Sprintf("simulated-%d: test-%d(%s)", index, i+1, job.RunPy)
+		s.specByCategory[categoryName] = spec
+		stage.Jobs = append(stage.Jobs, newSimulatedJobDefinition(job.DefinitionName(fmt.Sprintf("test-%d", i+1)), categoryName, spec))
+	}
+	stages := execution.StageDefinitions{stage}
+	srcs := sources.Definitions{
+		newSimulatedSourceDefinition(simulatedCodeSource),
+		newSimulatedSourceDefinition(simulatedInputSource),
+	}
+
+	stats, err := s.calc.LoadCategoryStats(ctx, stages)
+	if err != nil {
+		return fmt.Errorf("failed to load category stats: %w", err)
+	}
+
+	def := execution.NewExecutionDefinition(stages, srcs, nil,
+		submission.PriorityClass, submission.Tenant, s.calc.CalculateWeight(stages, stats), nil, 0)
+	def.CreatedAt = s.clock.Now()
+	s.storage.createExecution(def)
+	s.recorder.submitted(def.ID, def.CreatedAt)
+
+	return nil
+}
+
+func (s *Simulator) sendHeartbeat(ctx context.Context, w *simulatedWorker, now time.Time) {
+	doneJobs := make([]results.Result, 0)
+	for jobID, jb := range w.running {
+		if jb.finishesAt.After(now) {
+			continue
+		}
+		delete(w.running, jobID)
+		doneJobs = append(doneJobs, jb.result())
+		s.recorder.jobRan(jb)
+	}
+
+	freeSlots := s.cfg.WorkerSlots - len(w.running)
+	availableMemory := s.cfg.WorkerMemory
+	for _, jb := range w.running {
+		availableMemory -= jb.job.GetExpectedMemory()
+	}
+
+	pickedJobs, _, _ := s.heartbeat.Heartbeat(ctx, heartbeatUC.Command{
+		WorkerID:        w.id,
+		DoneJobs:        doneJobs,
+		TotalSlots:      s.cfg.WorkerSlots,
+		TotalMemory:     s.cfg.WorkerMemory,
+		FreeSlots:       freeSlots,
+		AvailableMemory: availableMemory,
+	})
+
+	for _, jb := range pickedJobs {
+		spec := s.specByJob[jb.GetID()]
+		w.running[jb.GetID()] = simulatedJob{
+			job:        jb,
+			spec:       spec,
+			startedAt:  now,
+			finishesAt: now.Add(time.Duration(min(spec.ElapsedTime, jb.GetTimeLimit())) * time.Millisecond),
+		}
+	}
+}
+
+func (s *Simulator) idle() bool {
+	for _, w := range s.workers {
+		if len(w.running) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (jb simulatedJob) result() results.Result {
+	elapsedTime := min(jb.spec.ElapsedTime, jb.job.GetTimeLimit())
+	if jb.spec.ElapsedTime > jb.job.GetTimeLimit() {
+		return results.NewRunResultTL(jb.job.GetID(), false, elapsedTime, jb.spec.UsedMemory)
+	}
+	return results.NewRunResultOK(jb.job.GetID(), false, elapsedTime, jb.spec.UsedMemory)
+}
+
+func (c *virtualClock) Now() time.Time {
+	return c.now
+}
+
+func (e *workloadEstimator) LoadStats(context.Context, []jobs.Definition) (execution.CategoryStats, error) {
+	return execution.NewCategoryStats(), nil
+}
+
+func (e *workloadEstimator) Estimate(jobDef jobs.Definition, _ execution.CategoryStats) (int, int) {
+	spec := e.specByCategory[jobDef.GetCategoryName()]
+	return spec.ExpectedTime, spec.ExpectedMemory
+}
+
+func (f *executionFactory) Create(ctx context.Context, def execution.Definition) (*execution.Execution, error) {
+	ex, err := f.ExecutionFactory.Create(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+	for jobID, jobDef := range ex.JobDefinitionByID {
+		f.sim.specByJob[jobID] = f.sim.specByCategory[jobDef.GetCategoryName()]
+	}
+	return ex, nil
+}
+
+func newSimulatedJobDefinition(name job.DefinitionName, categoryName string, spec JobSpec) jobs.Definition {
+	return jobs.Definition{
+		IDefinition: &jobs.RunPyJobDefinition{
+			DefinitionDetails: job.DefinitionDetails{
+				Type:          job.RunPy,
+				Name:          name,
+				SuccessStatus: job.StatusOK,
+				CategoryName:  categoryName,
+				TimeLimit:     spec.TimeLimit,
+				MemoryLimit:   spec.MemoryLimit,
+			},
+			Code:     newSimulatedInputDefinition(simulatedCodeSource),
+			RunInput: newSimulatedInputDefinition(simulatedInputSource),
+		},
+	}
+}
+
+func newSimulatedInputDefinition(sourceName source.DefinitionName) inputs.Definition {
+	return inputs.Definition{
+		IDefinition: &inputs.InlineInputDefinition{
+			DefinitionDetails:    input.DefinitionDetails{Type: input.InlineDefinition},
+			SourceDefinitionName: sourceName,
+		},
+	}
+}
+
+func newSimulatedSourceDefinition(name source.DefinitionName) sources.Definition {
+	return sources.Definition{
+		IDefinition: &sources.InlineSourceDefinition{
+			DefinitionDetails: source.DefinitionDetails{Type: source.InlineDefinition, Name: name},
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package simulator
+
+import (
+	"context"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestSimulator(slots int, maxDuration time.Duration) *Simulator {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(log, Config{
+		Workers:           1,
+		WorkerSlots:       slots,
+		WorkerMemory:      1024,
+		HeartbeatInterval: 100 * time.Millisecond,
+		MaxDuration:       maxDuration,
+
+		ExecutionScheduler: config.ExecutionSchedulerConfig{
+			ExecutionsInterval:  500 * time.Millisecond,
+			Capacity:            1 << 40,
+			ExecutionRetryAfter: 30 * time.Second,
+			MaxTries:            5,
+		},
+		JobScheduler: config.JobSchedulerConfig{
+			PromisedJobsLimit:         5,
+			PromiseRescheduleInterval: 100 * time.Millisecond,
+			UnschedulableAfter:        30 * time.Second,
+			MaxJobRetries:             3,
+		},
+		WorkerPool: config.WorkerPoolConfig{WorkerDieAfter: time.Second},
+	})
+}
+
+func TestSimulatorVirtualClock(t *testing.T) {
+	// Every job takes a second, the execution of two jobs is submitted first
+	// and the one of a single job after three seconds, though it is listed first.
+	spec := JobSpec{TimeLimit: 2000, MemoryLimit: 256, ExpectedTime: 1000, ExpectedMemory: 64, ElapsedTime: 1000, UsedMemory: 64}
+	workload := Workload{
+		{At: 3 * time.Second, Tenant: execution.DefaultTenant, PriorityClass: execution.DefaultPriorityClass, Jobs: []JobSpec{spec}},
+		{At: 0, Tenant: execution.DefaultTenant, PriorityClass: execution.DefaultPriorityClass, Jobs: []JobSpec{spec, spec}},
+	}
+
+	tests := []struct {
+		name           string
+		slots          int
+		maxDuration    time.Duration
+		wantDuration   time.Duration
+		wantSubmitted  int
+		wantFinished   int
+		wantMaxLatency time.Duration
+		wantSlotUsage  float64
+	}{
+		{
+			name:           "one slot runs the jobs one after another",
+			slots:          1,
+			maxDuration:    time.Hour,
+			wantDuration:   4 * time.Second,
+			wantSubmitted:  2,
+			wantFinished:   2,
+			wantMaxLatency: 2 * time.Second,
+			wantSlotUsage:  0.75,
+		},
+		{
+			name:           "two slots run the jobs together",
+			slots:          2,
+			maxDuration:    time.Hour,
+			wantDuration:   4 * time.Second,
+			wantSubmitted:  2,
+			wantFinished:   2,
+			wantMaxLatency: time.Second,
+			wantSlotUsage:  0.375,
+		},
+		{
+			name:          "max duration stops the clock",
+			slots:         1,
+			maxDuration:   500 * time.Millisecond,
+			wantDuration:  500 * time.Millisecond,
+			wantSubmitted: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := time.Now()
+			report, err := newTestSimulator(tt.slots, tt.maxDuration).Run(context.Background(), workload)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if elapsed := time.Since(started); elapsed >= tt.wantDuration {
+				t.Fatalf("simulation took %v of wall time, want less than the virtual %v", elapsed, tt.wantDuration)
+			}
+
+			if report.Duration != tt.wantDuration {
+				t.Fatalf("duration = %v, want %v", report.Duration, tt.wantDuration)
+			}
+			if report.Submitted != tt.wantSubmitted || report.Finished != tt.wantFinished {
+				t.Fatalf("submitted %d, finished %d, want %d submitted, %d finished",
+					report.Submitted, report.Finished, tt.wantSubmitted, tt.wantFinished)
+			}
+			if tt.wantFinished == 0 {
+				return
+			}
+			// The capacity is never short, so every execution starts on the tick it is submitted at.
+			if report.QueueLatency.Max != 0 {
+				t.Fatalf("max queue latency = %v, want 0", report.QueueLatency.Max)
+			}
+			if report.ExecutionLatency.Max != tt.wantMaxLatency {
+				t.Fatalf("max execution latency = %v, want %v", report.ExecutionLatency.Max, tt.wantMaxLatency)
+			}
+			if report.SlotUtilisation != tt.wantSlotUsage {
+				t.Fatalf("slot utilisation = %v, want %v", report.SlotUtilisation, tt.wantSlotUsage)
+			}
+		})
+	}
+}
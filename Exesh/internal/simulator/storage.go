@@ -0,0 +1,127 @@
+package simulator
+
+import (
+	"context"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/message/messages"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type (
+	// executionStorage keeps the executions of a simulation in memory
+	// and picks them for schedule the way the postgres storage does.
+	executionStorage struct {
+		executions map[execution.ID]execution.Definition
+	}
+
+	unitOfWork struct{}
+
+	messageDispatcher struct{}
+)
+
+func newExecutionStorage() *executionStorage {
+	return &executionStorage{executions: make(map[execution.ID]execution.Definition)}
+}
+
+func (s *executionStorage) GetExecutionForUpdate(_ context.Context, id execution.ID) (*execution.Definition, error) {
+	ex, ok := s.executions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &ex, nil
+}
+
+func (s *executionStorage) GetExecutionForSchedule(
+	_ context.Context,
+	retryBefore time.Time,
+	priorityClass execution.PriorityClass,
+	tenant string,
+) (*execution.Definition, error) {
+	waiting := s.waiting(retryBefore, func(ex execution.Definition) bool {
+		return ex.PriorityClass == priorityClass && ex.Tenant == tenant
+	})
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+	return &waiting[0], nil
+}
+
+func (s *executionStorage) GetWaitingTenants(
+	_ context.Context,
+	retryBefore time.Time,
+	priorityClass execution.PriorityClass,
+) ([]string, error) {
+	seen := make(map[string]struct{})
+	tenants := make([]string, 0)
+	for _, ex := range s.waiting(retryBefore, func(ex execution.Definition) bool { return ex.PriorityClass == priorityClass }) {
+		if _, ok := seen[ex.Tenant]; !ok {
+			seen[ex.Tenant] = struct{}{}
+			tenants = append(tenants, ex.Tenant)
+		}
+	}
+	sort.Strings(tenants)
+	return tenants, nil
+}
+
+func (s *executionStorage) CountWaitingExecutions(_ context.Context, retryBefore time.Time) (map[execution.PriorityClass]int, error) {
+	counts := make(map[execution.PriorityClass]int)
+	for _, ex := range s.waiting(retryBefore, func(execution.Definition) bool { return true }) {
+		counts[ex.PriorityClass]++
+	}
+	return counts, nil
+}
+
+func (s *executionStorage) SaveExecution(_ context.Context, ex execution.Definition) error {
+	if _, ok := s.executions[ex.ID]; !ok {
+		return fmt.Errorf("execution %s not found", ex.ID.String())
+	}
+	s.executions[ex.ID] = ex
+	return nil
+}
+
+func (s *executionStorage) WakeReplays(_ context.Context, id execution.ID) error {
+	for _, ex := range s.executions {
+		if ex.ReplayOf != nil && *ex.ReplayOf == id && ex.Status == execution.StatusScheduled {
+			ex.Status = execution.StatusNew
+			s.executions[ex.ID] = ex
+		}
+	}
+	return nil
+}
+
+func (s *executionStorage) createExecution(ex execution.Definition) {
+	s.executions[ex.ID] = ex
+}
+
+func (s *executionStorage) allFinished() bool {
+	for _, ex := range s.executions {
+		if !ex.IsFinished() {
+			return false
+		}
+	}
+	return true
+}
+
+// waiting returns the new and the stale scheduled executions matching the filter, the oldest first.
+func (s *executionStorage) waiting(retryBefore time.Time, filter func(execution.Definition) bool) []execution.Definition {
+	waiting := make([]execution.Definition, 0)
+	for _, ex := range s.executions {
+		isWaiting := ex.Status == execution.StatusNew ||
+			(ex.Status == execution.StatusScheduled && ex.ScheduledAt != nil && ex.ScheduledAt.Before(retryBefore))
+		if isWaiting && filter(ex) {
+			waiting = append(waiting, ex)
+		}
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].CreatedAt.Before(waiting[j].CreatedAt) })
+	return waiting
+}
+
+func (unitOfWork) Do(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func (messageDispatcher) Send(context.Context, messages.Message) error {
+	return nil
+}
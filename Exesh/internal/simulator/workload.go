@@ -0,0 +1,175 @@
+package simulator
+
+import (
+	"exesh/internal/domain/execution"
+	"exesh/internal/scheduler"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+type (
+	// Workload is the executions submitted during a simulation.
+	Workload []Submission
+
+	Submission struct {
+		// At is the moment the execution is submitted at, counted from the start of the simulation.
+		At            time.Duration
+		Tenant        string
+		PriorityClass execution.PriorityClass
+		Jobs          []JobSpec
+	}
+
+	// JobSpec describes a job of a submitted execution: its limits, what the coordinator expects it to take
+	// and what it actually takes on a worker. Time is in milliseconds, memory is in megabytes.
+	JobSpec struct {
+		TimeLimit      int
+		MemoryLimit    int
+		ExpectedTime   int
+		ExpectedMemory int
+		ElapsedTime    int
+		UsedMemory     int
+	}
+
+	SyntheticConfig struct {
+		Executions int
+		// Rate is the mean number of executions submitted per second.
+		Rate    float64
+		MinJobs int
+		MaxJobs int
+		// MeanTime and MeanMemory are the mean expected time and memory of a job over the tasks.
+		MeanTime    int
+		MeanMemory  int
+		TimeLimit   int
+		MemoryLimit int
+		// Spread is the standard deviation of the logarithm of actual to expected job time and memory.
+		Spread float64
+		Seed   uint64
+	}
+)
+
+// SyntheticWorkload generates executions arriving as a Poisson process.
+// The jobs of an execution share the expected time and memory of its task,
+// and the actual values deviate from the expected ones log-normally.
+func SyntheticWorkload(cfg SyntheticConfig) Workload {
+	rnd := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed))
+
+	workload := make(Workload, 0, cfg.Executions)
+	at := time.Duration(0)
+	for range cfg.Executions {
+		if cfg.Rate > 0 {
+			at += time.Duration(rnd.ExpFloat64() / cfg.Rate * float64(time.Second))
+		}
+
+		expectedTime := clamp(int(float64(cfg.MeanTime)*logNormal(rnd, 0.5)), 1, cfg.TimeLimit)
+		expectedMemory := clamp(int(float64(cfg.MeanMemory)*logNormal(rnd, 0.5)), 1, cfg.MemoryLimit)
+
+		jobCount := cfg.MinJobs
+		if cfg.MaxJobs > cfg.MinJobs {
+			jobCount += rnd.IntN(cfg.MaxJobs - cfg.MinJobs + 1)
+		}
+
+		jobs := make([]JobSpec, 0, jobCount)
+		for range jobCount {
+			jobs = append(jobs, JobSpec{
+				TimeLimit:      cfg.TimeLimit,
+				MemoryLimit:    cfg.MemoryLimit,
+				ExpectedTime:   expectedTime,
+				ExpectedMemory: expectedMemory,
+				ElapsedTime:    clamp(int(float64(expectedTime)*logNormal(rnd, cfg.Spread)), 1, cfg.TimeLimit),
+				UsedMemory:     clamp(int(float64(expectedMemory)*logNormal(rnd, cfg.Spread)), 1, cfg.MemoryLimit),
+			})
+		}
+
+		workload = append(workload, Submission{
+			At:            at,
+			Tenant:        execution.DefaultTenant,
+			PriorityClass: execution.DefaultPriorityClass,
+			Jobs:          jobs,
+		})
+	}
+
+	return workload
+}
+
+// RecordedWorkload rebuilds the executions from the finished and measured job events.
+// An execution is submitted when its first job started and consists of independent jobs,
+// since the events keep neither the submission time nor the stages of the execution.
+func RecordedWorkload(events []scheduler.JobEvent) Workload {
+	usedMemory := make(map[string]int)
+	for _, event := range events {
+		if event.Type == "measured" {
+			usedMemory[event.JobID.String()] = event.UsedMemoryMB
+		}
+	}
+
+	type recordedExecution struct {
+		startedAt time.Time
+		jobs      []JobSpec
+	}
+	executions := make(map[execution.ID]*recordedExecution)
+	for _, event := range events {
+		if event.Type != "finished" || event.StartedAt == nil {
+			continue
+		}
+
+		ex, ok := executions[event.ExecutionID]
+		if !ok {
+			ex = &recordedExecution{startedAt: *event.StartedAt}
+			executions[event.ExecutionID] = ex
+		}
+		if event.StartedAt.Before(ex.startedAt) {
+			ex.startedAt = *event.StartedAt
+		}
+
+		elapsedTime := max(int(event.ActualDurationSeconds*1000), 1)
+		expectedTime := event.ExpectedDurationMillis
+		if expectedTime <= 0 {
+			expectedTime = elapsedTime
+		}
+		expectedMemory := max(event.ExpectedMemoryMB, 1)
+		used, ok := usedMemory[event.JobID.String()]
+		if !ok {
+			used = expectedMemory
+		}
+
+		ex.jobs = append(ex.jobs, JobSpec{
+			TimeLimit:      max(expectedTime, elapsedTime),
+			MemoryLimit:    max(expectedMemory, used),
+			ExpectedTime:   expectedTime,
+			ExpectedMemory: expectedMemory,
+			ElapsedTime:    elapsedTime,
+			UsedMemory:     used,
+		})
+	}
+
+	recorded := make([]*recordedExecution, 0, len(executions))
+	for _, ex := range executions {
+		recorded = append(recorded, ex)
+	}
+	sort.Slice(recorded, func(i, j int) bool { return recorded[i].startedAt.Before(recorded[j].startedAt) })
+
+	workload := make(Workload, 0, len(recorded))
+	for _, ex := range recorded {
+		workload = append(workload, Submission{
+			At:            ex.startedAt.Sub(recorded[0].startedAt),
+			Tenant:        execution.DefaultTenant,
+			PriorityClass: execution.DefaultPriorityClass,
+			Jobs:          ex.jobs,
+		})
+	}
+
+	return workload
+}
+
+func logNormal(rnd *rand.Rand, sigma float64) float64 {
+	return math.Exp(rnd.NormFloat64() * sigma)
+}
+
+func clamp(value int, minValue int, maxValue int) int {
+	if maxValue < minValue {
+		maxValue = minValue
+	}
+	return min(max(value, minValue), maxValue)
+}
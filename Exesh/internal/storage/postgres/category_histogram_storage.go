@@ -5,10 +5,12 @@ import (
 	"exesh/internal/domain/execution"
 	"fmt"
 	"log/slog"
+	"time"
 )
 
 type CategoryHistogramStorage struct {
-	log *slog.Logger
+	log           *slog.Logger
+	decayHalfLife time.Duration
 }
 
 const (
@@ -44,18 +46,62 @@ const (
 		ON category_memory_histogram(category_name);
 	`
 
+	alterCategoryTimeHistogramAddWeightQuery = `
+		ALTER TABLE category_time_histogram
+			ADD COLUMN IF NOT EXISTS weight double precision NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS updated_at timestamptz NULL;
+		UPDATE category_time_histogram SET weight = cnt, updated_at = now() WHERE updated_at IS NULL;
+	`
+
+	alterCategoryMemoryHistogramAddWeightQuery = `
+		ALTER TABLE category_memory_histogram
+			ADD COLUMN IF NOT EXISTS weight double precision NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS updated_at timestamptz NULL;
+		UPDATE category_memory_histogram SET weight = cnt, updated_at = now() WHERE updated_at IS NULL;
+	`
+
+	// weight keeps the count of the bucket decayed by its half-life ($3, in seconds) as of updated_at.
+	// $4 is the count of the observations added to the bucket.
 	upsertCategoryTimeHistogramQuery = `
-		INSERT INTO category_time_histogram(category_name, time_bucket_ms, cnt)
-		VALUES ($1, $2, 1)
+		INSERT INTO category_time_histogram(category_name, time_bucket_ms, cnt, weight, updated_at)
+		VALUES ($1, $2, $4, $4, now())
 		ON CONFLICT (category_name, time_bucket_ms)
-		DO UPDATE SET cnt = category_time_histogram.cnt + 1;
+		DO UPDATE SET
+			cnt = category_time_histogram.cnt + $4,
+			weight = category_time_histogram.weight * CASE
+				WHEN $3::double precision <= 0 OR category_time_histogram.updated_at IS NULL THEN 1
+				ELSE power(0.5, EXTRACT(EPOCH FROM now() - category_time_histogram.updated_at)::double precision / $3::double precision)
+			END + $4,
+			updated_at = now();
 	`
 
 	upsertCategoryMemoryHistogramQuery = `
-		INSERT INTO category_memory_histogram(category_name, memory_bucket_mb, cnt)
-		VALUES ($1, $2, 1)
+		INSERT INTO category_memory_histogram(category_name, memory_bucket_mb, cnt, weight, updated_at)
+		VALUES ($1, $2, $4, $4, now())
 		ON CONFLICT (category_name, memory_bucket_mb)
-		DO UPDATE SET cnt = category_memory_histogram.cnt + 1;
+		DO UPDATE SET
+			cnt = category_memory_histogram.cnt + $4,
+			weight = category_memory_histogram.weight * CASE
+				WHEN $3::double precision <= 0 OR category_memory_histogram.updated_at IS NULL THEN 1
+				ELSE power(0.5, EXTRACT(EPOCH FROM now() - category_memory_histogram.updated_at)::double precision / $3::double precision)
+			END + $4,
+			updated_at = now();
+	`
+
+	selectCategoryHistogramsQuery = `
+		SELECT 'time' AS kind, category_name, time_bucket_ms AS bucket, weight * CASE
+			WHEN $2::double precision <= 0 OR updated_at IS NULL THEN 1
+			ELSE power(0.5, EXTRACT(EPOCH FROM now() - updated_at)::double precision / $2::double precision)
+		END AS weight
+		FROM category_time_histogram
+		WHERE category_name = ANY($1::text[]) AND weight > 0
+		UNION ALL
+		SELECT 'memory' AS kind, category_name, memory_bucket_mb AS bucket, weight * CASE
+			WHEN $2::double precision <= 0 OR updated_at IS NULL THEN 1
+			ELSE power(0.5, EXTRACT(EPOCH FROM now() - updated_at)::double precision / $2::double precision)
+		END AS weight
+		FROM category_memory_histogram
+		WHERE category_name = ANY($1::text[]) AND weight > 0;
 	`
 
 	selectCategoryExpectedQuery = `
@@ -116,7 +162,7 @@ const (
 	`
 )
 
-func NewCategoryHistogramStorage(ctx context.Context, log *slog.Logger) (*CategoryHistogramStorage, error) {
+func NewCategoryHistogramStorage(ctx context.Context, log *slog.Logger, decayHalfLife time.Duration) (*CategoryHistogramStorage, error) {
 	tx := extractTx(ctx)
 
 	if _, err := tx.ExecContext(ctx, createCategoryTimeHistogramTableQuery); err != nil {
@@ -131,8 +177,14 @@ func NewCategoryHistogramStorage(ctx context.Context, log *slog.Logger) (*Catego
 	if _, err := tx.ExecContext(ctx, createCategoryMemoryHistogramCategoryIdxQuery); err != nil {
 		return nil, fmt.Errorf("failed to create category_memory_histogram index: %w", err)
 	}
+	if _, err := tx.ExecContext(ctx, alterCategoryTimeHistogramAddWeightQuery); err != nil {
+		return nil, fmt.Errorf("failed to add weight to category_time_histogram table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, alterCategoryMemoryHistogramAddWeightQuery); err != nil {
+		return nil, fmt.Errorf("failed to add weight to category_memory_histogram table: %w", err)
+	}
 
-	return &CategoryHistogramStorage{log: log}, nil
+	return &CategoryHistogramStorage{log: log, decayHalfLife: decayHalfLife}, nil
 }
 
 func (s *CategoryHistogramStorage) UpdateCategoryHistogram(
@@ -146,16 +198,39 @@ func (s *CategoryHistogramStorage) UpdateCategoryHistogram(
 	timeBucketMs := bucketTimeMs(elapsedTimeMs)
 	memoryBucketMb := bucketMemoryMb(usedMemoryMb)
 
-	if _, err := tx.ExecContext(ctx, upsertCategoryTimeHistogramQuery, categoryName, timeBucketMs); err != nil {
+	if _, err := tx.ExecContext(ctx, upsertCategoryTimeHistogramQuery, categoryName, timeBucketMs, s.decayHalfLife.Seconds(), 1); err != nil {
 		return fmt.Errorf("failed to upsert category_time_histogram: %w", err)
 	}
-	if _, err := tx.ExecContext(ctx, upsertCategoryMemoryHistogramQuery, categoryName, memoryBucketMb); err != nil {
+	if _, err := tx.ExecContext(ctx, upsertCategoryMemoryHistogramQuery, categoryName, memoryBucketMb, s.decayHalfLife.Seconds(), 1); err != nil {
 		return fmt.Errorf("failed to upsert category_memory_histogram: %w", err)
 	}
 
 	return nil
 }
 
+// AddCategoryHistogram adds the counts of observations by bucket to the histograms of the category.
+func (s *CategoryHistogramStorage) AddCategoryHistogram(
+	ctx context.Context,
+	categoryName string,
+	timeCounts execution.Histogram,
+	memoryCounts execution.Histogram,
+) error {
+	tx := extractTx(ctx)
+
+	for bucket, cnt := range timeCounts.Weights {
+		if _, err := tx.ExecContext(ctx, upsertCategoryTimeHistogramQuery, categoryName, bucket, s.decayHalfLife.Seconds(), int64(cnt)); err != nil {
+			return fmt.Errorf("failed to upsert category_time_histogram: %w", err)
+		}
+	}
+	for bucket, cnt := range memoryCounts.Weights {
+		if _, err := tx.ExecContext(ctx, upsertCategoryMemoryHistogramQuery, categoryName, bucket, s.decayHalfLife.Seconds(), int64(cnt)); err != nil {
+			return fmt.Errorf("failed to upsert category_memory_histogram: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (s *CategoryHistogramStorage) GetExpectedByCategories(
 	ctx context.Context,
 	categoryNames []string,
@@ -207,16 +282,63 @@ func (s *CategoryHistogramStorage) GetExpectedByCategories(
 	return stats, nil
 }
 
+// GetHistogramsByCategories returns the decayed time and memory histograms of the categories.
+// Categories without observations are absent from the result.
+func (s *CategoryHistogramStorage) GetHistogramsByCategories(
+	ctx context.Context,
+	categoryNames []string,
+) (execution.CategoryStats, error) {
+	stats := execution.NewCategoryStats()
+	if len(categoryNames) == 0 {
+		return stats, nil
+	}
+
+	tx := extractTx(ctx)
+
+	rows, err := tx.QueryContext(ctx, selectCategoryHistogramsQuery, categoryNames, s.decayHalfLife.Seconds())
+	if err != nil {
+		return execution.CategoryStats{}, fmt.Errorf("failed to query histograms by categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var categoryName string
+		var bucket int
+		var weight float64
+		if err = rows.Scan(&kind, &categoryName, &bucket, &weight); err != nil {
+			return execution.CategoryStats{}, fmt.Errorf("failed to scan histograms by categories row: %w", err)
+		}
+
+		histograms, bucketSize := stats.TimeHistogramByCategory, execution.TimeHistogramBucketMs
+		if kind == "memory" {
+			histograms, bucketSize = stats.MemoryHistogramByCategory, execution.MemoryHistogramBucketMb
+		}
+		histogram, ok := histograms[categoryName]
+		if !ok {
+			histogram = execution.NewHistogram(bucketSize)
+			histograms[categoryName] = histogram
+		}
+		histogram.Weights[bucket] += weight
+	}
+
+	if err = rows.Err(); err != nil {
+		return execution.CategoryStats{}, fmt.Errorf("failed while iterate histograms by categories rows: %w", err)
+	}
+
+	return stats, nil
+}
+
 func bucketTimeMs(elapsedTimeMs int) int {
 	if elapsedTimeMs < 0 {
 		elapsedTimeMs = 0
 	}
-	return (elapsedTimeMs / 50) * 50
+	return (elapsedTimeMs / execution.TimeHistogramBucketMs) * execution.TimeHistogramBucketMs
 }
 
 func bucketMemoryMb(usedMemoryMb int) int {
 	if usedMemoryMb < 0 {
 		usedMemoryMb = 0
 	}
-	return (usedMemoryMb / 16) * 16
+	return (usedMemoryMb / execution.MemoryHistogramBucketMb) * execution.MemoryHistogramBucketMb
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"exesh/internal/domain/execution"
+	"exesh/internal/domain/tenant"
 	"fmt"
 	"log/slog"
 	"time"
@@ -20,12 +21,19 @@ const (
 			id varchar(36) PRIMARY KEY,
 			stages jsonb,
 		    sources jsonb,
+			constraints jsonb NULL,
+			priority_class varchar(32) NOT NULL DEFAULT 'ranked',
+			tenant varchar(128) NOT NULL DEFAULT 'default',
 			weight bigint NOT NULL DEFAULT 0,
+			deadline timestamp NULL,
+			max_tries integer NOT NULL DEFAULT 0,
 			tries integer NOT NULL DEFAULT 0,
 			status varchar(32),
 			created_at timestamp,
 			scheduled_at timestamp NULL,
-			finished_at timestamp NULL
+			finished_at timestamp NULL,
+			dedup_key varchar(40) NOT NULL DEFAULT '',
+			replay_of varchar(36) NULL
 		);
 	`
 
@@ -39,29 +47,124 @@ const (
 		ADD COLUMN IF NOT EXISTS tries integer NOT NULL DEFAULT 0;
 	`
 
+	addConstraintsToExecutionTableQuery = `
+		ALTER TABLE Executions
+		ADD COLUMN IF NOT EXISTS constraints jsonb NULL;
+	`
+
+	addPriorityClassToExecutionTableQuery = `
+		ALTER TABLE Executions
+		ADD COLUMN IF NOT EXISTS priority_class varchar(32) NOT NULL DEFAULT 'ranked';
+	`
+
+	addTenantToExecutionTableQuery = `
+		ALTER TABLE Executions
+		ADD COLUMN IF NOT EXISTS tenant varchar(128) NOT NULL DEFAULT 'default';
+	`
+
+	addDeadlineToExecutionTableQuery = `
+		ALTER TABLE Executions
+		ADD COLUMN IF NOT EXISTS deadline timestamp NULL;
+	`
+
+	addMaxTriesToExecutionTableQuery = `
+		ALTER TABLE Executions
+		ADD COLUMN IF NOT EXISTS max_tries integer NOT NULL DEFAULT 0;
+	`
+
+	addDedupKeyToExecutionTableQuery = `
+		ALTER TABLE Executions
+		ADD COLUMN IF NOT EXISTS dedup_key varchar(40) NOT NULL DEFAULT '';
+	`
+
+	addReplayOfToExecutionTableQuery = `
+		ALTER TABLE Executions
+		ADD COLUMN IF NOT EXISTS replay_of varchar(36) NULL;
+	`
+
+	createExecutionDedupKeyIdxQuery = `
+		CREATE INDEX IF NOT EXISTS idx_executions_dedup_key
+		ON Executions(dedup_key)
+		WHERE dedup_key <> '';
+	`
+
+	createExecutionReplayOfIdxQuery = `
+		CREATE INDEX IF NOT EXISTS idx_executions_replay_of
+		ON Executions(replay_of)
+		WHERE replay_of IS NOT NULL;
+	`
+
+	createExecutionTenantStatusIdxQuery = `
+		CREATE INDEX IF NOT EXISTS idx_executions_tenant_status
+		ON Executions(tenant, status);
+	`
+
+	createExecutionStatusPriorityClassIdxQuery = `
+		CREATE INDEX IF NOT EXISTS idx_executions_status_priority_class
+		ON Executions(status, priority_class, scheduled_at);
+	`
+
 	insertExecutionQuery = `
-		INSERT INTO Executions(id, stages, sources, weight, tries, status, created_at, scheduled_at, finished_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+		INSERT INTO Executions(id, stages, sources, constraints, priority_class, tenant, weight, deadline, max_tries, tries, status, created_at, scheduled_at, finished_at, dedup_key, replay_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16);
 	`
 
 	selectExecutionForUpdateQuery = `
-		SELECT id, stages, sources, weight, tries, status, created_at, scheduled_at, finished_at FROM Executions
+		SELECT id, stages, sources, constraints, priority_class, tenant, weight, deadline, max_tries, tries, status, created_at, scheduled_at, finished_at, dedup_key, replay_of FROM Executions
 		WHERE id = $1
 		FOR UPDATE
 	`
 
 	selectExecutionForScheduleQuery = `
-		SELECT id, stages, sources, weight, tries, status, created_at, scheduled_at, finished_at FROM Executions
-		WHERE status = $1 OR (status = $2 AND scheduled_at < $3)
+		SELECT id, stages, sources, constraints, priority_class, tenant, weight, deadline, max_tries, tries, status, created_at, scheduled_at, finished_at, dedup_key, replay_of FROM Executions
+		WHERE (status = $1 OR (status = $2 AND scheduled_at < $3)) AND priority_class = $4 AND tenant = $5
 		ORDER BY created_at
 		LIMIT 1
 		FOR UPDATE SKIP LOCKED;
 	`
 
+	selectWaitingTenantsQuery = `
+		SELECT DISTINCT tenant FROM Executions
+		WHERE (status = $1 OR (status = $2 AND scheduled_at < $3)) AND priority_class = $4
+		ORDER BY tenant;
+	`
+
+	lockTenantQuery = `
+		SELECT pg_advisory_xact_lock(hashtext($1));
+	`
+
+	selectTenantUsageQuery = `
+		SELECT
+			COUNT(*) FILTER (WHERE status = $2 OR status = $3),
+			COALESCE(SUM(weight) FILTER (WHERE status = $2 OR status = $3), 0),
+			COUNT(*) FILTER (WHERE created_at >= $4),
+			MIN(created_at) FILTER (WHERE created_at >= $4)
+		FROM Executions
+		WHERE tenant = $1 AND (status = $2 OR status = $3 OR created_at >= $4);
+	`
+
+	countWaitingExecutionsQuery = `
+		SELECT priority_class, COUNT(*) FROM Executions
+		WHERE status = $1 OR (status = $2 AND scheduled_at < $3)
+		GROUP BY priority_class;
+	`
+
+	selectExecutionByDedupKeyQuery = `
+		SELECT id, stages, sources, constraints, priority_class, tenant, weight, deadline, max_tries, tries, status, created_at, scheduled_at, finished_at, dedup_key, replay_of FROM Executions
+		WHERE dedup_key = $1 AND (status = $2 OR status = $3 OR (status = $4 AND finished_at >= $5))
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
 	updateExecutionQuery = `
-		UPDATE Executions SET stages=$2, sources=$3, weight=$4, tries=$5, status=$6, created_at=$7, scheduled_at=$8, finished_at=$9
+		UPDATE Executions SET stages=$2, sources=$3, constraints=$4, priority_class=$5, tenant=$6, weight=$7, deadline=$8, max_tries=$9, tries=$10, status=$11, created_at=$12, scheduled_at=$13, finished_at=$14, replay_of=$15
 		WHERE id=$1;
 	`
+
+	wakeReplaysQuery = `
+		UPDATE Executions SET status = $2
+		WHERE replay_of = $1 AND status = $3;
+	`
 )
 
 func NewExecutionStorage(ctx context.Context, log *slog.Logger) (*ExecutionStorage, error) {
@@ -76,6 +179,39 @@ func NewExecutionStorage(ctx context.Context, log *slog.Logger) (*ExecutionStora
 	if _, err := tx.ExecContext(ctx, addTriesToExecutionTableQuery); err != nil {
 		return nil, fmt.Errorf("failed to add tries to execution table: %w", err)
 	}
+	if _, err := tx.ExecContext(ctx, addConstraintsToExecutionTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to add constraints to execution table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addPriorityClassToExecutionTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to add priority class to execution table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addTenantToExecutionTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to add tenant to execution table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addDeadlineToExecutionTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to add deadline to execution table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addMaxTriesToExecutionTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to add max tries to execution table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addDedupKeyToExecutionTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to add dedup key to execution table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, addReplayOfToExecutionTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to add replay of to execution table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, createExecutionTenantStatusIdxQuery); err != nil {
+		return nil, fmt.Errorf("failed to create execution tenant status index: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, createExecutionStatusPriorityClassIdxQuery); err != nil {
+		return nil, fmt.Errorf("failed to create execution status priority class index: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, createExecutionReplayOfIdxQuery); err != nil {
+		return nil, fmt.Errorf("failed to create execution replay of index: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, createExecutionDedupKeyIdxQuery); err != nil {
+		return nil, fmt.Errorf("failed to create execution dedup key index: %w", err)
+	}
 
 	return &ExecutionStorage{log: log}, nil
 }
@@ -84,7 +220,7 @@ func (s *ExecutionStorage) CreateExecution(ctx context.Context, ex execution.Def
 	tx := extractTx(ctx)
 
 	if _, err := tx.ExecContext(ctx, insertExecutionQuery,
-		ex.ID, ex.Stages, ex.Sources, ex.Weight, ex.Tries, ex.Status, ex.CreatedAt, ex.ScheduledAt, ex.FinishedAt); err != nil {
+		ex.ID, ex.Stages, ex.Sources, ex.Constraints, ex.PriorityClass, ex.Tenant, ex.Weight, ex.Deadline, ex.MaxTries, ex.Tries, ex.Status, ex.CreatedAt, ex.ScheduledAt, ex.FinishedAt, ex.DedupKey, ex.ReplayOf); err != nil {
 		return fmt.Errorf("failed to do insert execution query: %w", err)
 	}
 
@@ -96,7 +232,7 @@ func (s *ExecutionStorage) GetExecutionForUpdate(ctx context.Context, id executi
 
 	ex := execution.Definition{}
 	if err := tx.QueryRowContext(ctx, selectExecutionForUpdateQuery, id).
-		Scan(&ex.ID, &ex.Stages, &ex.Sources, &ex.Weight, &ex.Tries, &ex.Status, &ex.CreatedAt, &ex.ScheduledAt, &ex.FinishedAt); err != nil {
+		Scan(&ex.ID, &ex.Stages, &ex.Sources, &ex.Constraints, &ex.PriorityClass, &ex.Tenant, &ex.Weight, &ex.Deadline, &ex.MaxTries, &ex.Tries, &ex.Status, &ex.CreatedAt, &ex.ScheduledAt, &ex.FinishedAt, &ex.DedupKey, &ex.ReplayOf); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -109,13 +245,15 @@ func (s *ExecutionStorage) GetExecutionForUpdate(ctx context.Context, id executi
 func (s *ExecutionStorage) GetExecutionForSchedule(
 	ctx context.Context,
 	retryBefore time.Time,
+	priorityClass execution.PriorityClass,
+	tenant string,
 ) (*execution.Definition, error) {
 	tx := extractTx(ctx)
 
 	ex := execution.Definition{}
 	if err := tx.QueryRowContext(ctx, selectExecutionForScheduleQuery,
-		execution.StatusNew, execution.StatusScheduled, retryBefore).
-		Scan(&ex.ID, &ex.Stages, &ex.Sources, &ex.Weight, &ex.Tries, &ex.Status, &ex.CreatedAt, &ex.ScheduledAt, &ex.FinishedAt); err != nil {
+		execution.StatusNew, execution.StatusScheduled, retryBefore, priorityClass, tenant).
+		Scan(&ex.ID, &ex.Stages, &ex.Sources, &ex.Constraints, &ex.PriorityClass, &ex.Tenant, &ex.Weight, &ex.Deadline, &ex.MaxTries, &ex.Tries, &ex.Status, &ex.CreatedAt, &ex.ScheduledAt, &ex.FinishedAt, &ex.DedupKey, &ex.ReplayOf); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -125,13 +263,125 @@ func (s *ExecutionStorage) GetExecutionForSchedule(
 	return &ex, nil
 }
 
+// GetExecutionByDedupKey returns the latest execution with the dedup key
+// which is still in flight or has finished not earlier than finishedSince.
+func (s *ExecutionStorage) GetExecutionByDedupKey(
+	ctx context.Context,
+	dedupKey string,
+	finishedSince time.Time,
+) (*execution.Definition, error) {
+	tx := extractTx(ctx)
+
+	ex := execution.Definition{}
+	if err := tx.QueryRowContext(ctx, selectExecutionByDedupKeyQuery,
+		dedupKey, execution.StatusNew, execution.StatusScheduled, execution.StatusFinished, finishedSince).
+		Scan(&ex.ID, &ex.Stages, &ex.Sources, &ex.Constraints, &ex.PriorityClass, &ex.Tenant, &ex.Weight, &ex.Deadline, &ex.MaxTries, &ex.Tries, &ex.Status, &ex.CreatedAt, &ex.ScheduledAt, &ex.FinishedAt, &ex.DedupKey, &ex.ReplayOf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to do select execution by dedup key query: %w", err)
+	}
+
+	return &ex, nil
+}
+
+func (s *ExecutionStorage) GetWaitingTenants(
+	ctx context.Context,
+	retryBefore time.Time,
+	priorityClass execution.PriorityClass,
+) ([]string, error) {
+	tx := extractTx(ctx)
+
+	rows, err := tx.QueryContext(ctx, selectWaitingTenantsQuery,
+		execution.StatusNew, execution.StatusScheduled, retryBefore, priorityClass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do select waiting tenants query: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := make([]string, 0)
+	for rows.Next() {
+		var tenant string
+		if err = rows.Scan(&tenant); err != nil {
+			return nil, fmt.Errorf("failed to scan waiting tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate waiting tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+// GetTenantUsage locks the tenant until the end of the transaction,
+// so that concurrent admissions of the same tenant see each other.
+func (s *ExecutionStorage) GetTenantUsage(ctx context.Context, tenantID string, since time.Time) (tenant.Usage, error) {
+	tx := extractTx(ctx)
+
+	if _, err := tx.ExecContext(ctx, lockTenantQuery, tenantID); err != nil {
+		return tenant.Usage{}, fmt.Errorf("failed to do lock tenant query: %w", err)
+	}
+
+	var usage tenant.Usage
+	if err := tx.QueryRowContext(ctx, selectTenantUsageQuery,
+		tenantID, execution.StatusNew, execution.StatusScheduled, since).
+		Scan(&usage.ActiveExecutions, &usage.QueuedWeight, &usage.RecentExecutions, &usage.OldestRecentAt); err != nil {
+		return tenant.Usage{}, fmt.Errorf("failed to do select tenant usage query: %w", err)
+	}
+
+	return usage, nil
+}
+
+func (s *ExecutionStorage) CountWaitingExecutions(
+	ctx context.Context,
+	retryBefore time.Time,
+) (map[execution.PriorityClass]int, error) {
+	tx := extractTx(ctx)
+
+	rows, err := tx.QueryContext(ctx, countWaitingExecutionsQuery,
+		execution.StatusNew, execution.StatusScheduled, retryBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do count waiting executions query: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[execution.PriorityClass]int)
+	for rows.Next() {
+		var (
+			priorityClass execution.PriorityClass
+			count         int
+		)
+		if err = rows.Scan(&priorityClass, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan waiting executions count: %w", err)
+		}
+		counts[priorityClass] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate waiting executions counts: %w", err)
+	}
+
+	return counts, nil
+}
+
 func (s *ExecutionStorage) SaveExecution(ctx context.Context, ex execution.Definition) error {
 	tx := extractTx(ctx)
 
 	if _, err := tx.ExecContext(ctx, updateExecutionQuery,
-		ex.ID, ex.Stages, ex.Sources, ex.Weight, ex.Tries, ex.Status, ex.CreatedAt, ex.ScheduledAt, ex.FinishedAt); err != nil {
+		ex.ID, ex.Stages, ex.Sources, ex.Constraints, ex.PriorityClass, ex.Tenant, ex.Weight, ex.Deadline, ex.MaxTries, ex.Tries, ex.Status, ex.CreatedAt, ex.ScheduledAt, ex.FinishedAt, ex.ReplayOf); err != nil {
 		return fmt.Errorf("failed to do update execution query: %w", err)
 	}
 
 	return nil
 }
+
+// WakeReplays returns the executions waiting to replay the execution to the schedule queue.
+func (s *ExecutionStorage) WakeReplays(ctx context.Context, id execution.ID) error {
+	tx := extractTx(ctx)
+
+	if _, err := tx.ExecContext(ctx, wakeReplaysQuery, id, execution.StatusNew, execution.StatusScheduled); err != nil {
+		return fmt.Errorf("failed to do wake replays query: %w", err)
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"exesh/internal/calculator"
 	"exesh/internal/scheduler"
 	"fmt"
 	"log/slog"
@@ -73,6 +74,12 @@ const createSchedulerEventTablesQuery = `
 	CREATE INDEX IF NOT EXISTS exesh_job_events_job_happened_idx ON exesh_job_events(job_id, happened_at);
 	CREATE INDEX IF NOT EXISTS exesh_job_events_happened_type_idx ON exesh_job_events(happened_at, event_type);
 	CREATE INDEX IF NOT EXISTS exesh_job_events_happened_job_idx ON exesh_job_events(happened_at, job_id);
+	ALTER TABLE exesh_job_events
+		ADD COLUMN IF NOT EXISTS category_name text NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS time_limit_ms integer NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS memory_limit_mb integer NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS elapsed_ms integer NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS used_memory_mb integer NOT NULL DEFAULT 0;
 
 	CREATE TABLE IF NOT EXISTS exesh_worker_events(
 		id bigserial PRIMARY KEY,
@@ -144,13 +151,15 @@ func (s *SchedulerEventStorage) RecordJobEvent(ctx context.Context, event schedu
 				happened_at, event_type, job_id, execution_id, worker_id, job_type, status,
 				expected_memory_mb, expected_duration_ms, memory_start_mb, memory_end_mb,
 				promised_start_at, started_at, finished_at, expected_finished_at,
-				actual_duration_seconds, scheduler_latency_seconds
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17);
+				actual_duration_seconds, scheduler_latency_seconds,
+				category_name, time_limit_ms, memory_limit_mb, elapsed_ms, used_memory_mb
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22);
 		`,
 			event.At, event.Type, event.JobID.String(), event.ExecutionID.String(), event.WorkerID, event.JobType, event.Status,
 			event.ExpectedMemoryMB, event.ExpectedDurationMillis, event.MemoryStartMB, event.MemoryEndMB,
 			nullableTime(event.PromisedStartAt), nullableTime(event.StartedAt), nullableTime(event.FinishedAt), nullableTime(event.ExpectedFinishedAt),
-			event.ActualDurationSeconds, event.SchedulerLatencySeconds)
+			event.ActualDurationSeconds, event.SchedulerLatencySeconds,
+			event.CategoryName, event.TimeLimitMillis, event.MemoryLimitMB, event.ElapsedMillis, event.UsedMemoryMB)
 		return err
 	})
 }
@@ -171,6 +180,98 @@ func (s *SchedulerEventStorage) RecordWorkerEvent(ctx context.Context, event sch
 	})
 }
 
+// GetJobEvents returns the job events of the given types recorded since the given moment in the order they happened.
+func (s *SchedulerEventStorage) GetJobEvents(ctx context.Context, eventTypes []string, since time.Time) ([]scheduler.JobEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT happened_at, event_type, job_id, execution_id, worker_id, job_type, status,
+			expected_memory_mb, expected_duration_ms, started_at, finished_at,
+			actual_duration_seconds, category_name, elapsed_ms, used_memory_mb
+		FROM exesh_job_events
+		WHERE event_type = ANY($1::text[]) AND happened_at >= $2
+		ORDER BY happened_at, id;
+	`, eventTypes, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]scheduler.JobEvent, 0)
+	for rows.Next() {
+		var event scheduler.JobEvent
+		var jobID string
+		var executionID string
+		if err = rows.Scan(
+			&event.At,
+			&event.Type,
+			&jobID,
+			&executionID,
+			&event.WorkerID,
+			&event.JobType,
+			&event.Status,
+			&event.ExpectedMemoryMB,
+			&event.ExpectedDurationMillis,
+			&event.StartedAt,
+			&event.FinishedAt,
+			&event.ActualDurationSeconds,
+			&event.CategoryName,
+			&event.ElapsedMillis,
+			&event.UsedMemoryMB,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan job event row: %w", err)
+		}
+		if err = event.JobID.FromString(jobID); err != nil {
+			return nil, fmt.Errorf("failed to parse job id of job event: %w", err)
+		}
+		if err = event.ExecutionID.FromString(executionID); err != nil {
+			return nil, fmt.Errorf("failed to parse execution id of job event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed while iterate job event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetJobSamples returns the jobs measured since the given moment in the order they were measured.
+func (s *SchedulerEventStorage) GetJobSamples(ctx context.Context, since time.Time) ([]calculator.Sample, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT happened_at, category_name, job_type, time_limit_ms, memory_limit_mb, elapsed_ms, used_memory_mb
+		FROM exesh_job_events
+		WHERE event_type = 'measured' AND happened_at >= $1
+		ORDER BY happened_at, id;
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job samples: %w", err)
+	}
+	defer rows.Close()
+
+	samples := make([]calculator.Sample, 0)
+	for rows.Next() {
+		var sample calculator.Sample
+		if err = rows.Scan(
+			&sample.At,
+			&sample.CategoryName,
+			&sample.JobType,
+			&sample.TimeLimit,
+			&sample.MemoryLimit,
+			&sample.ElapsedTime,
+			&sample.UsedMemory,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan job sample row: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed while iterate job sample rows: %w", err)
+	}
+
+	return samples, nil
+}
+
 func (s *SchedulerEventStorage) enqueue(ctx context.Context, write func(context.Context) error) {
 	select {
 	case s.ch <- write:
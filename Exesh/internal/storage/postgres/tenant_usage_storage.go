@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type TenantUsageStorage struct {
+	log *slog.Logger
+}
+
+const (
+	createTenantUsageTableQuery = `
+		CREATE TABLE IF NOT EXISTS tenant_usage(
+			tenant varchar(128) NOT NULL,
+			day date NOT NULL,
+			executions bigint NOT NULL DEFAULT 0,
+			weight bigint NOT NULL DEFAULT 0,
+			rejected bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (tenant, day)
+		);
+	`
+
+	upsertTenantUsageQuery = `
+		INSERT INTO tenant_usage(tenant, day, executions, weight, rejected)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant, day) DO UPDATE SET
+			executions = tenant_usage.executions + EXCLUDED.executions,
+			weight = tenant_usage.weight + EXCLUDED.weight,
+			rejected = tenant_usage.rejected + EXCLUDED.rejected;
+	`
+)
+
+func NewTenantUsageStorage(ctx context.Context, log *slog.Logger) (*TenantUsageStorage, error) {
+	tx := extractTx(ctx)
+
+	if _, err := tx.ExecContext(ctx, createTenantUsageTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to create tenant usage table: %w", err)
+	}
+
+	return &TenantUsageStorage{log: log}, nil
+}
+
+func (s *TenantUsageStorage) RecordUsage(
+	ctx context.Context,
+	tenant string,
+	at time.Time,
+	executions int,
+	weight int64,
+	rejected int,
+) error {
+	tx := extractTx(ctx)
+
+	day := at.UTC().Truncate(24 * time.Hour)
+	if _, err := tx.ExecContext(ctx, upsertTenantUsageQuery, tenant, day, executions, weight, rejected); err != nil {
+		return fmt.Errorf("failed to do upsert tenant usage query: %w", err)
+	}
+
+	return nil
+}
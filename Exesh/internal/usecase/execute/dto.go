@@ -3,15 +3,29 @@ package execute
 import (
 	"exesh/internal/domain/execution"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
+	"time"
 )
 
 type (
 	Command struct {
 		Sources sources.Definitions
 		Stages  execution.StageDefinitions
+
+		Constraints placement.Constraints
+
+		PriorityClass execution.PriorityClass
+		Tenant        string
+		APIKey        string
+
+		Deadline *time.Time
+		MaxTries int
+
+		IdempotencyKey string
 	}
 
 	Result struct {
-		ExecutionID execution.ID
+		ExecutionID  execution.ID
+		Deduplicated bool
 	}
 )
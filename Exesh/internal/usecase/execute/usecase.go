@@ -2,19 +2,33 @@ package execute
 
 import (
 	"context"
+	"errors"
+	"exesh/internal/config"
 	"exesh/internal/domain/execution"
 	"exesh/internal/domain/execution/job"
+	"exesh/internal/domain/execution/message/history"
 	"exesh/internal/domain/execution/source"
+	"exesh/internal/domain/tenant"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 )
 
 type (
 	UseCase struct {
 		log              *slog.Logger
+		cfg              config.AdmissionConfig
 		unitOfWork       unitOfWork
 		executionStorage executionStorage
+		usageStorage     usageStorage
+		messageStorage   history.Reader
 		calc             calculator
+		stageValidator   stageValidator
+
+		tenantByAPIKey map[string]config.TenantConfig
+		tenantByID     map[string]config.TenantConfig
 	}
 
 	unitOfWork interface {
@@ -23,25 +37,111 @@ type (
 
 	executionStorage interface {
 		CreateExecution(context.Context, execution.Definition) error
+		GetTenantUsage(context.Context, string, time.Time) (tenant.Usage, error)
+		GetExecutionByDedupKey(context.Context, string, time.Time) (*execution.Definition, error)
+	}
+
+	usageStorage interface {
+		RecordUsage(context.Context, string, time.Time, int, int64, int) error
 	}
 
 	calculator interface {
 		LoadCategoryStats(context.Context, execution.StageDefinitions) (execution.CategoryStats, error)
 		CalculateWeight(execution.StageDefinitions, execution.CategoryStats) int64
 	}
+
+	stageValidator interface {
+		ValidateStages(execution.StageDefinitions) error
+	}
+)
+
+const (
+	maxTenantLength         = 128
+	maxIdempotencyKeyLength = 255
+
+	replayBatchSize = 100
+)
+
+var (
+	ErrUnauthorized   = errors.New("invalid or missing api key")
+	ErrInvalidCommand = errors.New("invalid execution command")
 )
 
 func NewUseCase(
 	log *slog.Logger,
+	cfg config.AdmissionConfig,
 	unitOfWork unitOfWork,
 	executionStorage executionStorage,
+	usageStorage usageStorage,
+	messageStorage history.Reader,
 	calc calculator,
+	stageValidator stageValidator,
 ) *UseCase {
-	return &UseCase{
+	uc := &UseCase{
 		log:              log,
+		cfg:              cfg,
 		unitOfWork:       unitOfWork,
 		executionStorage: executionStorage,
+		usageStorage:     usageStorage,
+		messageStorage:   messageStorage,
 		calc:             calc,
+		stageValidator:   stageValidator,
+
+		tenantByAPIKey: make(map[string]config.TenantConfig),
+		tenantByID:     make(map[string]config.TenantConfig),
+	}
+	for _, t := range cfg.Tenants {
+		uc.tenantByID[t.ID] = t
+		for _, key := range t.APIKeys {
+			uc.tenantByAPIKey[key] = t
+		}
+	}
+	return uc
+}
+
+// resolveTenant sets the tenant of the command by its api key and returns the tenant quota.
+// Without an api key the command may only name a tenant which has no api keys configured
+// and no quota above the default one, so that a larger quota cannot be taken by naming its tenant.
+func (uc *UseCase) resolveTenant(command *Command) (tenant.Quota, error) {
+	if command.APIKey != "" {
+		t, ok := uc.tenantByAPIKey[command.APIKey]
+		if !ok {
+			return tenant.Quota{}, ErrUnauthorized
+		}
+		command.Tenant = t.ID
+		return toQuota(t.Quota), nil
+	}
+
+	if uc.cfg.RequireAPIKey {
+		return tenant.Quota{}, ErrUnauthorized
+	}
+	if command.Tenant == "" {
+		command.Tenant = execution.DefaultTenant
+	}
+	if t, ok := uc.tenantByID[command.Tenant]; ok {
+		if len(t.APIKeys) > 0 || exceedsQuota(t.Quota, uc.cfg.DefaultQuota) {
+			return tenant.Quota{}, ErrUnauthorized
+		}
+		return toQuota(t.Quota), nil
+	}
+	return toQuota(uc.cfg.DefaultQuota), nil
+}
+
+// exceedsQuota reports whether the quota allows more than the base one in any of its limits. Zero limits are unlimited.
+func exceedsQuota(quota, base config.QuotaConfig) bool {
+	exceeds := func(limit, baseLimit int64) bool {
+		return baseLimit > 0 && (limit == 0 || limit > baseLimit)
+	}
+	return exceeds(int64(quota.MaxConcurrentExecutions), int64(base.MaxConcurrentExecutions)) ||
+		exceeds(quota.MaxQueuedWeight, base.MaxQueuedWeight) ||
+		exceeds(int64(quota.MaxExecutionsPerMinute), int64(base.MaxExecutionsPerMinute))
+}
+
+func toQuota(cfg config.QuotaConfig) tenant.Quota {
+	return tenant.Quota{
+		MaxConcurrentExecutions: cfg.MaxConcurrentExecutions,
+		MaxQueuedWeight:         cfg.MaxQueuedWeight,
+		MaxExecutionsPerMinute:  cfg.MaxExecutionsPerMinute,
 	}
 }
 
@@ -56,6 +156,47 @@ func (uc *UseCase) Execute(ctx context.Context, command Command) (result Result,
 		srcs[src.GetName()] = src
 	}
 
+	if command.PriorityClass == "" {
+		command.PriorityClass = execution.DefaultPriorityClass
+	}
+	if err = command.PriorityClass.Validate(); err != nil {
+		err = fmt.Errorf("invalid execution priority class: %w", err)
+		uc.log.Warn("invalid execution command", slog.Any("error", err))
+		return
+	}
+	quota, err := uc.resolveTenant(&command)
+	if err != nil {
+		uc.log.Warn("unauthorized execution command", slog.String("tenant", command.Tenant))
+		return
+	}
+	if len(command.Tenant) > maxTenantLength {
+		err = fmt.Errorf("tenant is longer than %d characters", maxTenantLength)
+		uc.log.Warn("invalid execution command", slog.Any("error", err))
+		return
+	}
+
+	if command.Deadline != nil && !command.Deadline.After(time.Now()) {
+		err = fmt.Errorf("execution deadline %s is already in the past", command.Deadline.Format(time.RFC3339))
+		uc.log.Warn("invalid execution command", slog.Any("error", err))
+		return
+	}
+	if command.MaxTries < 0 {
+		err = fmt.Errorf("max tries must be non-negative")
+		uc.log.Warn("invalid execution command", slog.Any("error", err))
+		return
+	}
+	if len(command.IdempotencyKey) > maxIdempotencyKeyLength {
+		err = fmt.Errorf("idempotency key is longer than %d characters", maxIdempotencyKeyLength)
+		uc.log.Warn("invalid execution command", slog.Any("error", err))
+		return
+	}
+
+	if err = command.Constraints.Validate(); err != nil {
+		err = fmt.Errorf("invalid execution constraints: %w", err)
+		uc.log.Warn("invalid execution command", slog.Any("error", err))
+		return
+	}
+
 	stages := make(map[execution.StageName]any, len(command.Stages))
 	for _, stage := range command.Stages {
 		if _, exists := stages[stage.Name]; exists {
@@ -65,6 +206,14 @@ func (uc *UseCase) Execute(ctx context.Context, command Command) (result Result,
 		}
 		stages[stage.Name] = struct{}{}
 
+		if stage.Policy != "" {
+			if err = stage.Policy.Validate(); err != nil {
+				err = fmt.Errorf("invalid policy of stage '%s': %w", stage.Name, err)
+				uc.log.Warn("invalid execution command", slog.Any("error", err))
+				return
+			}
+		}
+
 		jbs := make(map[job.DefinitionName]any, len(stage.Jobs))
 		for _, jb := range stage.Jobs {
 			jobName := jb.GetName()
@@ -74,7 +223,41 @@ func (uc *UseCase) Execute(ctx context.Context, command Command) (result Result,
 				return
 			}
 			jbs[jobName] = struct{}{}
+
+			if err = jb.GetConstraints().Validate(); err != nil {
+				err = fmt.Errorf("invalid constraints of job '%s': %w", jobName, err)
+				uc.log.Warn("invalid execution command", slog.Any("error", err))
+				return
+			}
+		}
+	}
+
+	stageByJob := make(map[job.DefinitionName]execution.StageDefinition)
+	for _, stage := range command.Stages {
+		for _, jb := range stage.Jobs {
+			stageByJob[jb.GetName()] = stage
+		}
+	}
+	for _, stage := range command.Stages {
+		if stage.FanOut == "" {
+			continue
 		}
+		if err = validateFanOut(stage, stageByJob); err != nil {
+			err = fmt.Errorf("invalid fan-out of stage '%s': %w", stage.Name, err)
+			uc.log.Warn("invalid execution command", slog.Any("error", err))
+			return
+		}
+	}
+	if err = uc.stageValidator.ValidateStages(command.Stages); err != nil {
+		err = fmt.Errorf("%w: %w", ErrInvalidCommand, err)
+		uc.log.Warn("invalid execution command", slog.Any("error", err))
+		return
+	}
+
+	dedupKey, err := uc.dedupKey(command)
+	if err != nil {
+		uc.log.Error("failed to calculate execution dedup key", slog.Any("error", err))
+		return
 	}
 
 	var weight int64
@@ -85,24 +268,154 @@ func (uc *UseCase) Execute(ctx context.Context, command Command) (result Result,
 		}
 		weight = uc.calc.CalculateWeight(command.Stages, stats)
 
-		e := execution.NewExecutionDefinition(command.Stages, command.Sources, weight)
-		if err = uc.executionStorage.CreateExecution(ctx, e); err != nil {
-			return fmt.Errorf("failed to create execution in storage: %w", err)
+		now := time.Now()
+		usage, err := uc.executionStorage.GetTenantUsage(ctx, command.Tenant, now.Add(-tenant.RateWindow))
+		if err != nil {
+			return fmt.Errorf("failed to get tenant usage: %w", err)
+		}
+		// a deduplicated execution is a row of its own too, so it is admitted by the quota like a run
+		if err = quota.Check(command.Tenant, usage, weight, now, uc.cfg.BusyRetryAfter); err != nil {
+			return err
+		}
+
+		if dedupKey != "" {
+			var executionID *execution.ID
+			if executionID, err = uc.deduplicate(ctx, command, dedupKey, weight, now); err != nil {
+				return fmt.Errorf("failed to deduplicate execution: %w", err)
+			}
+			if executionID != nil {
+				result = Result{ExecutionID: *executionID, Deduplicated: true}
+			}
+		}
+		if !result.Deduplicated {
+			e := execution.NewExecutionDefinition(command.Stages, command.Sources, command.Constraints, command.PriorityClass, command.Tenant, weight, command.Deadline, command.MaxTries)
+			e.DedupKey = dedupKey
+			if err = uc.executionStorage.CreateExecution(ctx, e); err != nil {
+				return fmt.Errorf("failed to create execution in storage: %w", err)
+			}
+			result = Result{ExecutionID: e.ID}
 		}
 
-		result = Result{ExecutionID: e.ID}
+		if err = uc.usageStorage.RecordUsage(ctx, command.Tenant, now, 1, weight, 0); err != nil {
+			return fmt.Errorf("failed to record tenant usage: %w", err)
+		}
 		return nil
 	})
 
+	var quotaErr *tenant.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		uc.log.Warn("execution rejected by quota",
+			slog.String("tenant", command.Tenant),
+			slog.String("reason", quotaErr.Reason),
+		)
+		if recordErr := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			return uc.usageStorage.RecordUsage(ctx, command.Tenant, time.Now(), 0, 0, 1)
+		}); recordErr != nil {
+			uc.log.Error("failed to record rejected execution", slog.Any("error", recordErr))
+		}
+		return
+	}
 	if err != nil {
 		uc.log.Error("failed to create execution", slog.Any("error", err))
 		return
 	}
 
+	if result.Deduplicated {
+		uc.log.Info("deduplicated execution",
+			slog.String("execution", result.ExecutionID.String()),
+			slog.String("tenant", command.Tenant),
+		)
+		return
+	}
+
 	uc.log.Info("created execution",
 		slog.String("execution", result.ExecutionID.String()),
+		slog.String("priority_class", string(command.PriorityClass)),
+		slog.String("tenant", command.Tenant),
 		slog.Int64("weight", weight),
 	)
 
 	return
 }
+
+// validateFanOut checks that the fan-out job splits its output and is done before the stage is expanded,
+// and that the templated job names get unique per item.
+func validateFanOut(stage execution.StageDefinition, stageByJob map[job.DefinitionName]execution.StageDefinition) error {
+	producerStage, ok := stageByJob[stage.FanOut]
+	if !ok {
+		return fmt.Errorf("job '%s' is not defined", stage.FanOut)
+	}
+	if producerStage.FanOut != "" {
+		return fmt.Errorf("job '%s' is a template itself", stage.FanOut)
+	}
+	if !slices.Contains(stage.Deps, producerStage.Name) {
+		return fmt.Errorf("stage does not depend on stage '%s' of job '%s'", producerStage.Name, stage.FanOut)
+	}
+	for _, jb := range producerStage.Jobs {
+		if jb.GetName() == stage.FanOut && jb.GetSplitOutput() == "" {
+			return fmt.Errorf("job '%s' does not split its output", stage.FanOut)
+		}
+	}
+
+	for _, jb := range stage.Jobs {
+		if !strings.Contains(string(jb.GetName()), execution.FanOutPlaceholder) {
+			return fmt.Errorf("name of job '%s' does not contain %s", jb.GetName(), execution.FanOutPlaceholder)
+		}
+	}
+	return nil
+}
+
+// dedupKey returns the key identical submissions share, or an empty string if the command
+// has no idempotency key and content deduplication is disabled.
+func (uc *UseCase) dedupKey(command Command) (string, error) {
+	if command.IdempotencyKey != "" {
+		return execution.IdempotencyDedupKey(command.Tenant, command.IdempotencyKey), nil
+	}
+	if !uc.cfg.Dedup.ContentHash {
+		return "", nil
+	}
+	return execution.ContentDedupKey(command.Tenant, command.Stages, command.Sources)
+}
+
+// deduplicate creates an execution which answers the command with the messages of an identical one
+// instead of a run, and returns its id. The execution scheduler replays the messages once the identical
+// execution has finished, so that every caller gets its own execution and no message is sent
+// before the caller knows its id.
+func (uc *UseCase) deduplicate(
+	ctx context.Context,
+	command Command,
+	dedupKey string,
+	weight int64,
+	now time.Time,
+) (*execution.ID, error) {
+	existing, err := uc.executionStorage.GetExecutionByDedupKey(ctx, dedupKey, now.Add(-uc.cfg.Dedup.Window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution by dedup key: %w", err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+	if existing.IsFinished() {
+		msgs, err := history.ReadAll(ctx, uc.messageStorage, existing.ID, replayBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get messages of execution %s: %w", existing.ID.String(), err)
+		}
+		if !history.FinishedOK(msgs) {
+			return nil, nil
+		}
+	}
+
+	replayOf := existing.ID
+	if existing.ReplayOf != nil {
+		replayOf = *existing.ReplayOf
+	}
+
+	e := execution.NewExecutionDefinition(command.Stages, command.Sources, command.Constraints, command.PriorityClass, command.Tenant, weight, command.Deadline, command.MaxTries)
+	e.DedupKey = dedupKey
+	e.ReplayOf = &replayOf
+	if err = uc.executionStorage.CreateExecution(ctx, e); err != nil {
+		return nil, fmt.Errorf("failed to create execution in storage: %w", err)
+	}
+
+	return &e.ID, nil
+}
@@ -0,0 +1,274 @@
+package execute
+
+import (
+	"context"
+	"errors"
+	"exesh/internal/config"
+	"exesh/internal/domain/execution"
+	"exesh/internal/domain/execution/message/history"
+	"exesh/internal/domain/execution/message/messages"
+	"exesh/internal/domain/tenant"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type stubUnitOfWork struct{}
+
+func (stubUnitOfWork) Do(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+type stubExecutionStorage struct {
+	executions []execution.Definition
+}
+
+func (s *stubExecutionStorage) CreateExecution(_ context.Context, def execution.Definition) error {
+	s.executions = append(s.executions, def)
+	return nil
+}
+
+func (s *stubExecutionStorage) GetTenantUsage(_ context.Context, tenantID string, _ time.Time) (tenant.Usage, error) {
+	var usage tenant.Usage
+	for _, def := range s.executions {
+		if def.Tenant == tenantID {
+			usage.RecentExecutions++
+		}
+	}
+	return usage, nil
+}
+
+func (s *stubExecutionStorage) GetExecutionByDedupKey(_ context.Context, dedupKey string, _ time.Time) (*execution.Definition, error) {
+	for i := len(s.executions) - 1; i >= 0; i-- {
+		def := s.executions[i]
+		if def.DedupKey == dedupKey && def.Status != execution.StatusExpired {
+			return &def, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *stubExecutionStorage) finish(t *testing.T, id execution.ID) {
+	t.Helper()
+
+	for i := range s.executions {
+		if s.executions[i].ID == id {
+			s.executions[i].SetFinished(time.Now())
+			return
+		}
+	}
+	t.Fatalf("execution %v not found", id)
+}
+
+type stubUsageStorage struct {
+	executions int
+	rejected   int
+}
+
+func (s *stubUsageStorage) RecordUsage(_ context.Context, _ string, _ time.Time, executions int, _ int64, rejected int) error {
+	s.executions += executions
+	s.rejected += rejected
+	return nil
+}
+
+type stubMessageStorage map[execution.ID][]history.Message
+
+func (s stubMessageStorage) GetMessages(_ context.Context, id execution.ID, startID int64, count int) ([]history.Message, error) {
+	msgs := make([]history.Message, 0, count)
+	for _, msg := range s[id] {
+		if msg.MessageID >= startID && len(msgs) < count {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+type stubCalculator struct{}
+
+func (stubCalculator) LoadCategoryStats(context.Context, execution.StageDefinitions) (execution.CategoryStats, error) {
+	return execution.CategoryStats{}, nil
+}
+
+func (stubCalculator) CalculateWeight(execution.StageDefinitions, execution.CategoryStats) int64 {
+	return 10
+}
+
+type stubStageValidator struct{}
+
+func (stubStageValidator) ValidateStages(execution.StageDefinitions) error {
+	return nil
+}
+
+func TestExecuteDeduplicates(t *testing.T) {
+	tests := []struct {
+		name string
+		// finish finishes the first execution with the message, or leaves it in flight if nil.
+		finish       *messages.Message
+		wantReplayed bool
+	}{
+		{
+			name:         "in flight",
+			wantReplayed: true,
+		},
+		{
+			name:         "finished",
+			finish:       ptr(messages.NewFinishExecutionMessageOk(execution.ID{}, nil, nil)),
+			wantReplayed: true,
+		},
+		{
+			name:   "finished with error",
+			finish: ptr(messages.NewFinishExecutionMessageError(execution.ID{}, "worker lost")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &stubExecutionStorage{}
+			stored := make(stubMessageStorage)
+			cfg := config.AdmissionConfig{Dedup: config.DedupConfig{Window: time.Minute}}
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			uc := NewUseCase(log, cfg, stubUnitOfWork{}, storage, &stubUsageStorage{}, stored, stubCalculator{}, stubStageValidator{})
+			command := Command{IdempotencyKey: "solution-1"}
+
+			first, err := uc.Execute(context.Background(), command)
+			if err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			if first.Deduplicated {
+				t.Fatalf("first execution is deduplicated")
+			}
+			if tt.finish != nil {
+				tt.finish.SetExecutionID(first.ExecutionID)
+				stored[first.ExecutionID] = []history.Message{
+					{MessageID: 1, Message: messages.NewStartExecutionMessage(first.ExecutionID)},
+					{MessageID: 2, Message: *tt.finish},
+				}
+				storage.finish(t, first.ExecutionID)
+			}
+
+			second, err := uc.Execute(context.Background(), command)
+			if err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			if second.ExecutionID == first.ExecutionID {
+				t.Fatalf("second caller got the execution of the first one")
+			}
+			if second.Deduplicated != tt.wantReplayed {
+				t.Fatalf("deduplicated = %t, want %t", second.Deduplicated, tt.wantReplayed)
+			}
+
+			created := storage.executions[len(storage.executions)-1]
+			if created.ID != second.ExecutionID || created.Status != execution.StatusNew {
+				t.Fatalf("created %v with status %s, want %v new", created.ID, created.Status, second.ExecutionID)
+			}
+			if !tt.wantReplayed {
+				if created.ReplayOf != nil {
+					t.Fatalf("replay of = %v, want a run", *created.ReplayOf)
+				}
+				return
+			}
+			if created.ReplayOf == nil || *created.ReplayOf != first.ExecutionID {
+				t.Fatalf("replay of = %v, want %v", created.ReplayOf, first.ExecutionID)
+			}
+
+			// A third caller replays the first execution too, not the replay of it.
+			third, err := uc.Execute(context.Background(), command)
+			if err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			created = storage.executions[len(storage.executions)-1]
+			if third.ExecutionID == second.ExecutionID || created.ReplayOf == nil || *created.ReplayOf != first.ExecutionID {
+				t.Fatalf("third execution replays %v, want %v", created.ReplayOf, first.ExecutionID)
+			}
+		})
+	}
+}
+
+func TestExecuteDedupHitCountsAgainstQuota(t *testing.T) {
+	storage := &stubExecutionStorage{}
+	usage := &stubUsageStorage{}
+	cfg := config.AdmissionConfig{
+		DefaultQuota: config.QuotaConfig{MaxExecutionsPerMinute: 2},
+		Dedup:        config.DedupConfig{Window: time.Minute},
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	uc := NewUseCase(log, cfg, stubUnitOfWork{}, storage, usage, make(stubMessageStorage), stubCalculator{}, stubStageValidator{})
+	command := Command{IdempotencyKey: "solution-1"}
+
+	if _, err := uc.Execute(context.Background(), command); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	second, err := uc.Execute(context.Background(), command)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !second.Deduplicated {
+		t.Fatalf("second execution is not deduplicated")
+	}
+	if usage.executions != 2 {
+		t.Fatalf("recorded executions = %d, want the run and the replay", usage.executions)
+	}
+
+	// The tenant is at its rate limit, so a resubmission is rejected even though it would be deduplicated.
+	_, err = uc.Execute(context.Background(), command)
+	var quotaErr *tenant.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("execute = %v, want a quota error", err)
+	}
+	if len(storage.executions) != 2 || usage.rejected != 1 {
+		t.Fatalf("executions = %d, rejected = %d, want 2 executions and 1 rejected", len(storage.executions), usage.rejected)
+	}
+}
+
+func TestResolveTenant(t *testing.T) {
+	cfg := config.AdmissionConfig{
+		DefaultQuota: config.QuotaConfig{MaxConcurrentExecutions: 10, MaxExecutionsPerMinute: 100},
+		Tenants: []config.TenantConfig{
+			{ID: "keyed", APIKeys: []string{"secret"}, Quota: config.QuotaConfig{MaxConcurrentExecutions: 50}},
+			{ID: "large", Quota: config.QuotaConfig{MaxConcurrentExecutions: 50, MaxExecutionsPerMinute: 100}},
+			{ID: "unlimited", Quota: config.QuotaConfig{MaxConcurrentExecutions: 10}},
+			{ID: "small", Quota: config.QuotaConfig{MaxConcurrentExecutions: 5, MaxExecutionsPerMinute: 50}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		command    Command
+		wantTenant string
+		wantErr    bool
+	}{
+		{name: "api key", command: Command{APIKey: "secret", Tenant: "small"}, wantTenant: "keyed"},
+		{name: "unknown api key", command: Command{APIKey: "other"}, wantErr: true},
+		{name: "tenant with api keys", command: Command{Tenant: "keyed"}, wantErr: true},
+		{name: "tenant above the default quota", command: Command{Tenant: "large"}, wantErr: true},
+		{name: "tenant without a rate limit", command: Command{Tenant: "unlimited"}, wantErr: true},
+		{name: "tenant below the default quota", command: Command{Tenant: "small"}, wantTenant: "small"},
+		{name: "tenant not configured", command: Command{Tenant: "other"}, wantTenant: "other"},
+		{name: "no tenant", command: Command{}, wantTenant: execution.DefaultTenant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, nil, nil, nil, nil, nil, nil)
+			command := tt.command
+			_, err := uc.resolveTenant(&command)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("resolve tenant = %v, want %v", err, ErrUnauthorized)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve tenant: %v", err)
+			}
+			if command.Tenant != tt.wantTenant {
+				t.Fatalf("tenant = %s, want %s", command.Tenant, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
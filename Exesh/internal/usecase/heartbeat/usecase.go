@@ -7,6 +7,7 @@ import (
 	"exesh/internal/domain/execution/result"
 	"exesh/internal/domain/execution/result/results"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
 	"log/slog"
 	"time"
 )
@@ -19,6 +20,11 @@ type (
 		TotalMemory     int
 		FreeSlots       int
 		AvailableMemory int
+		Toolchains      []string
+		Labels          placement.Labels
+		Draining        bool
+		ReturnedJobs    []job.ID
+		Deregister      bool
 	}
 
 	UseCase struct {
@@ -29,13 +35,16 @@ type (
 	}
 
 	workerPool interface {
-		Heartbeat(string, int, int)
+		Heartbeat(string, int, int, []string, placement.Labels, bool)
 		PutArtifact(string, job.ID, time.Time)
+		IsDraining(string) bool
+		Deregister(string)
 	}
 
 	jobScheduler interface {
 		PickJobs(context.Context, string, int, int) ([]jobs.Job, []sources.Source)
 		DoneJob(context.Context, string, results.Result)
+		ReturnJob(context.Context, string, job.ID)
 	}
 )
 
@@ -48,7 +57,7 @@ func NewUseCase(log *slog.Logger, workerPool workerPool, jobScheduler jobSchedul
 	}
 }
 
-func (uc *UseCase) Heartbeat(ctx context.Context, command Command) ([]jobs.Job, []sources.Source) {
+func (uc *UseCase) Heartbeat(ctx context.Context, command Command) ([]jobs.Job, []sources.Source, bool) {
 	if len(command.DoneJobs) > 0 {
 		uc.log.Info("heartbeat with completed jobs",
 			slog.String("worker", command.WorkerID),
@@ -60,23 +69,54 @@ func (uc *UseCase) Heartbeat(ctx context.Context, command Command) ([]jobs.Job,
 
 	totalSlots := max(command.TotalSlots, command.FreeSlots)
 	totalMemory := max(command.TotalMemory, command.AvailableMemory)
-	uc.workerPool.Heartbeat(command.WorkerID, totalSlots, totalMemory)
+	uc.workerPool.Heartbeat(command.WorkerID, totalSlots, totalMemory, command.Toolchains, command.Labels, command.Draining)
+
+	for _, jobID := range command.ReturnedJobs {
+		uc.jobScheduler.ReturnJob(ctx, command.WorkerID, jobID)
+	}
 
 	for _, jobResult := range command.DoneJobs {
 		if jobResult.GetType() == result.Chain {
 			for _, res := range jobResult.AsChain().Results {
 				if res.GetHasOutput() {
-					uc.workerPool.PutArtifact(command.WorkerID, res.GetJobID(), *res.GetArtifactTrashTime())
+					uc.putArtifacts(command.WorkerID, res)
 				}
 			}
 		} else {
 			if jobResult.GetHasOutput() {
-				uc.workerPool.PutArtifact(command.WorkerID, jobResult.GetJobID(), *jobResult.GetArtifactTrashTime())
+				uc.putArtifacts(command.WorkerID, jobResult)
 			}
 		}
 
 		uc.jobScheduler.DoneJob(ctx, command.WorkerID, jobResult)
 	}
 
-	return uc.jobScheduler.PickJobs(ctx, command.WorkerID, command.FreeSlots, command.AvailableMemory)
+	if command.Deregister {
+		uc.workerPool.Deregister(command.WorkerID)
+		return nil, nil, false
+	}
+	if uc.workerPool.IsDraining(command.WorkerID) {
+		return nil, nil, true
+	}
+
+	jbs, srcs := uc.jobScheduler.PickJobs(ctx, command.WorkerID, command.FreeSlots, command.AvailableMemory)
+	return jbs, srcs, false
+}
+
+// ReturnJobs gives back the jobs picked for a worker which have never reached it.
+func (uc *UseCase) ReturnJobs(ctx context.Context, workerID string, jbs []jobs.Job) {
+	for _, jb := range jbs {
+		uc.jobScheduler.ReturnJob(ctx, workerID, jb.GetID())
+	}
+}
+
+// putArtifacts registers the job output and the fan-out items split from it on the worker.
+func (uc *UseCase) putArtifacts(workerID string, res results.Result) {
+	jobID := res.GetJobID()
+	trashTime := *res.GetArtifactTrashTime()
+
+	uc.workerPool.PutArtifact(workerID, jobID, trashTime)
+	for item := 1; item <= res.GetOutputCount(); item++ {
+		uc.workerPool.PutArtifact(workerID, job.ItemID(jobID, item), trashTime)
+	}
 }
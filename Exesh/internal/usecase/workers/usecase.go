@@ -0,0 +1,60 @@
+package workers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+type (
+	UseCase struct {
+		log          *slog.Logger
+		drainAPIKeys []string
+
+		workerPool workerPool
+	}
+
+	workerPool interface {
+		Drain(string) error
+	}
+)
+
+var ErrUnauthorized = errors.New("invalid or missing api key")
+
+func NewUseCase(log *slog.Logger, drainAPIKeys []string, workerPool workerPool) *UseCase {
+	return &UseCase{
+		log:          log,
+		drainAPIKeys: drainAPIKeys,
+
+		workerPool: workerPool,
+	}
+}
+
+// Drain marks the worker as draining: it gets no new jobs and is asked
+// to hand back its queue and deregister on the next heartbeat.
+// Only the operators holding one of the drain api keys may drain a worker.
+func (uc *UseCase) Drain(_ context.Context, apiKey string, workerID string) error {
+	if !uc.authorized(apiKey) {
+		uc.log.Warn("unauthorized drain request", slog.String("worker", workerID))
+		return ErrUnauthorized
+	}
+	if err := uc.workerPool.Drain(workerID); err != nil {
+		return fmt.Errorf("failed to drain worker: %w", err)
+	}
+	uc.log.Info("drain requested", slog.String("worker", workerID))
+	return nil
+}
+
+func (uc *UseCase) authorized(apiKey string) bool {
+	if apiKey == "" {
+		return false
+	}
+	for _, key := range uc.drainAPIKeys {
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
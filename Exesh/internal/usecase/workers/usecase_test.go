@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+type stubWorkerPool struct {
+	drained []string
+}
+
+func (p *stubWorkerPool) Drain(workerID string) error {
+	p.drained = append(p.drained, workerID)
+	return nil
+}
+
+func TestDrainRequiresAPIKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainAPIKeys []string
+		apiKey       string
+		wantErr      error
+	}{
+		{name: "valid api key", drainAPIKeys: []string{"first", "second"}, apiKey: "second"},
+		{name: "invalid api key", drainAPIKeys: []string{"first"}, apiKey: "other", wantErr: ErrUnauthorized},
+		{name: "missing api key", drainAPIKeys: []string{"first"}, wantErr: ErrUnauthorized},
+		{name: "no api keys configured", apiKey: "first", wantErr: ErrUnauthorized},
+		{name: "empty api key configured", drainAPIKeys: []string{""}, wantErr: ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &stubWorkerPool{}
+			uc := NewUseCase(slog.New(slog.NewTextHandler(io.Discard, nil)), tt.drainAPIKeys, pool)
+
+			err := uc.Drain(context.Background(), tt.apiKey, "worker-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("drain = %v, want %v", err, tt.wantErr)
+			}
+			wantDrained := []string{"worker-1"}
+			if tt.wantErr != nil {
+				wantDrained = nil
+			}
+			if !slices.Equal(pool.drained, wantDrained) {
+				t.Fatalf("drained = %v, want %v", pool.drained, wantDrained)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"exesh/internal/config"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type (
+	// resources are the cores and the memory of the worker taken by the running jobs.
+	// Every job runs on a core of its own, so that two jobs never share a core and skew each other's time.
+	// Without the configured cores the worker runs FreeSlots jobs at once without pinning them.
+	resources struct {
+		freeCores      [][]int
+		totalCores     int
+		totalMemory    int
+		reservedMemory int
+
+		// cgroupDir is the cgroup v2 directory of the worker, the sandboxes of the jobs run in it as well.
+		// It is empty if the worker is not in a cgroup v2 hierarchy.
+		cgroupDir string
+	}
+)
+
+const (
+	procSelfCgroupPath = "/proc/self/cgroup"
+	cgroupMountPath    = "/sys/fs/cgroup"
+)
+
+func newResources(cfg config.WorkConfig) *resources {
+	freeCores := make([][]int, 0, max(len(cfg.Cores), cfg.FreeSlots))
+	if len(cfg.Cores) > 0 {
+		for _, core := range cfg.Cores {
+			freeCores = append(freeCores, []int{core})
+		}
+	} else {
+		for range cfg.FreeSlots {
+			freeCores = append(freeCores, nil)
+		}
+	}
+
+	cgroupDir, _ := selfCgroupDir(procSelfCgroupPath, cgroupMountPath)
+
+	return &resources{
+		freeCores:   freeCores,
+		totalCores:  len(freeCores),
+		totalMemory: cfg.AvailableMemory,
+		cgroupDir:   cgroupDir,
+	}
+}
+
+// acquire takes a core and the memory for a job. A job expecting more memory than the worker has
+// is admitted once nothing else runs, otherwise it would never start.
+func (r *resources) acquire(memory int) ([]int, bool) {
+	if len(r.freeCores) == 0 {
+		return nil, false
+	}
+	if r.reservedMemory > 0 && r.reservedMemory+memory > r.totalMemory {
+		return nil, false
+	}
+
+	cpus := r.freeCores[len(r.freeCores)-1]
+	r.freeCores = r.freeCores[:len(r.freeCores)-1]
+	r.reservedMemory += memory
+	return cpus, true
+}
+
+// record grows the memory reserved for a job to the peak it was measured to use
+// and returns the memory the job holds now, which is what its release gives back.
+func (r *resources) record(reserved int, peak int) int {
+	if peak <= reserved {
+		return reserved
+	}
+	r.reservedMemory += peak - reserved
+	return peak
+}
+
+func (r *resources) release(cpus []int, memory int) {
+	r.freeCores = append(r.freeCores, cpus)
+	r.reservedMemory -= memory
+}
+
+// free returns the cores and the memory no job runs on. The memory is the least of what is not reserved
+// by the running jobs and what is left below the memory limit of the worker cgroup, so the jobs using more
+// than they were expected to leave less for the others.
+func (r *resources) free() (int, int) {
+	memory := r.totalMemory - r.reservedMemory
+	if available, ok := cgroupAvailableMemory(r.cgroupDir); ok {
+		memory = min(memory, available)
+	}
+	return len(r.freeCores), max(memory, 0)
+}
+
+func (r *resources) idle() bool {
+	return len(r.freeCores) == r.totalCores
+}
+
+// selfCgroupDir finds the cgroup v2 directory of the process from its cgroup file, the "0::<path>" line.
+func selfCgroupDir(procCgroupPath string, mountPath string) (string, bool) {
+	data, err := os.ReadFile(procCgroupPath)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		path, ok := strings.CutPrefix(line, "0::")
+		if !ok {
+			continue
+		}
+		return filepath.Join(mountPath, filepath.Clean("/"+path)), true
+	}
+	return "", false
+}
+
+// cgroupAvailableMemory reads how much memory in megabytes is left below the limit of the cgroup.
+// It is not known if the cgroup has no limit.
+func cgroupAvailableMemory(cgroupDir string) (int, bool) {
+	if cgroupDir == "" {
+		return 0, false
+	}
+	limit, err := os.ReadFile(filepath.Join(cgroupDir, "memory.max"))
+	if err != nil {
+		return 0, false
+	}
+	limitBytes, err := strconv.ParseInt(strings.TrimSpace(string(limit)), 10, 64)
+	if err != nil {
+		// the limit is "max"
+		return 0, false
+	}
+	current, err := os.ReadFile(filepath.Join(cgroupDir, "memory.current"))
+	if err != nil {
+		return 0, false
+	}
+	currentBytes, err := strconv.ParseInt(strings.TrimSpace(string(current)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(max(limitBytes-currentBytes, 0) / (1024 * 1024)), true
+}
@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"exesh/internal/config"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestResourcesAcquire(t *testing.T) {
+	r := newResources(config.WorkConfig{Cores: []int{2, 3}, AvailableMemory: 1024})
+	r.cgroupDir = ""
+
+	first, ok := r.acquire(512)
+	if !ok || !slices.Equal(first, []int{3}) {
+		t.Fatalf("acquire = %v, %t, want [3], true", first, ok)
+	}
+	if _, ok = r.acquire(768); ok {
+		t.Fatalf("acquired more memory than the worker has")
+	}
+	second, ok := r.acquire(512)
+	if !ok || !slices.Equal(second, []int{2}) {
+		t.Fatalf("acquire = %v, %t, want [2], true", second, ok)
+	}
+	if _, ok = r.acquire(0); ok {
+		t.Fatalf("acquired a core while every core is taken")
+	}
+	if slots, memory := r.free(); slots != 0 || memory != 0 {
+		t.Fatalf("free = %d slots, %d MB, want none", slots, memory)
+	}
+
+	r.release(first, 512)
+	r.release(second, 512)
+	if !r.idle() {
+		t.Fatalf("resources are not idle after every release")
+	}
+
+	// A job expecting more than the worker has runs alone.
+	cpus, ok := r.acquire(2048)
+	if !ok {
+		t.Fatalf("oversized job is not admitted on an idle worker")
+	}
+	if _, ok = r.acquire(1); ok {
+		t.Fatalf("acquired next to the oversized job")
+	}
+	r.release(cpus, 2048)
+	if slots, memory := r.free(); slots != 2 || memory != 1024 {
+		t.Fatalf("free = %d slots, %d MB, want 2 slots, 1024 MB", slots, memory)
+	}
+}
+
+func TestResourcesWithoutCores(t *testing.T) {
+	r := newResources(config.WorkConfig{FreeSlots: 2, AvailableMemory: 1024})
+	r.cgroupDir = ""
+
+	for range 2 {
+		if cpus, ok := r.acquire(100); !ok || cpus != nil {
+			t.Fatalf("acquire = %v, %t, want unpinned slot", cpus, ok)
+		}
+	}
+	if _, ok := r.acquire(100); ok {
+		t.Fatalf("acquired more slots than configured")
+	}
+}
+
+func TestResourcesRecord(t *testing.T) {
+	r := newResources(config.WorkConfig{Cores: []int{0, 1}, AvailableMemory: 1024})
+	r.cgroupDir = ""
+
+	overrun, _ := r.acquire(100)
+	other, _ := r.acquire(100)
+
+	held := r.record(100, 300)
+	if held != 300 {
+		t.Fatalf("record = %d, want the measured peak 300", held)
+	}
+	if _, memory := r.free(); memory != 624 {
+		t.Fatalf("free memory = %d, want 624", memory)
+	}
+	r.release(overrun, held)
+
+	if held = r.record(100, 50); held != 100 {
+		t.Fatalf("record = %d, want the reserved 100", held)
+	}
+	r.release(other, held)
+	if _, memory := r.free(); memory != 1024 || !r.idle() {
+		t.Fatalf("free memory = %d, want 1024 on an idle worker", memory)
+	}
+}
+
+func TestResourcesCgroupMemory(t *testing.T) {
+	tests := []struct {
+		name       string
+		max        string
+		current    string
+		wantMemory int
+	}{
+		{name: "limit below the reservations", max: "536870912\n", current: "268435456\n", wantMemory: 256},
+		{name: "limit above the reservations", max: "4294967296\n", current: "268435456\n", wantMemory: 924},
+		{name: "over the limit", max: "268435456\n", current: "536870912\n", wantMemory: 0},
+		{name: "no limit", max: "max\n", current: "268435456\n", wantMemory: 924},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "memory.max"), tt.max)
+			writeFile(t, filepath.Join(dir, "memory.current"), tt.current)
+
+			r := newResources(config.WorkConfig{FreeSlots: 2, AvailableMemory: 1024})
+			r.cgroupDir = dir
+			if _, ok := r.acquire(100); !ok {
+				t.Fatalf("acquire failed")
+			}
+
+			if _, memory := r.free(); memory != tt.wantMemory {
+				t.Fatalf("free memory = %d, want %d", memory, tt.wantMemory)
+			}
+		})
+	}
+}
+
+func TestSelfCgroupDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{name: "unified", content: "0::/system.slice/worker.service\n", want: "/sys/fs/cgroup/system.slice/worker.service", wantOK: true},
+		{name: "root", content: "0::/\n", want: "/sys/fs/cgroup", wantOK: true},
+		{name: "hybrid", content: "12:memory:/docker/abc\n0::/docker/abc\n", want: "/sys/fs/cgroup/docker/abc", wantOK: true},
+		{name: "v1 only", content: "12:memory:/docker/abc\n"},
+		{name: "escaping path", content: "0::/../../etc\n", want: "/sys/fs/cgroup/etc", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cgroup")
+			writeFile(t, path, tt.content)
+
+			got, ok := selfCgroupDir(path, "/sys/fs/cgroup")
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("selfCgroupDir = %q, %t, want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
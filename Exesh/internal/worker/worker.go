@@ -3,12 +3,16 @@ package worker
 import (
 	"context"
 	"exesh/internal/api/heartbeat"
+	"exesh/internal/api/stream"
 	"exesh/internal/config"
+	"exesh/internal/domain/execution/job"
 	"exesh/internal/domain/execution/job/jobs"
 	"exesh/internal/domain/execution/result/results"
 	"exesh/internal/domain/execution/source/sources"
+	"exesh/internal/domain/placement"
 	"exesh/internal/executor"
 	"exesh/internal/lib/queue"
+	"exesh/internal/runtime"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -17,10 +21,12 @@ import (
 
 type (
 	Worker struct {
-		log *slog.Logger
-		cfg config.WorkConfig
+		log    *slog.Logger
+		cfg    config.WorkConfig
+		labels placement.Labels
 
-	heartbeatClient heartbeatClient
+		heartbeatClient heartbeatClient
+		streamClient    streamClient
 		executorFactory *executor.ExecutorFactory
 
 		jobs                    queue.Queue[jobs.Job]
@@ -28,14 +34,28 @@ type (
 
 		sourceProvider sourceProvider
 
-		mu              sync.Mutex
-		doneJobs        []results.Result
-		freeSlots       int
-		availableMemory int
+		mu        sync.Mutex
+		doneJobs  []results.Result
+		resources *resources
+		// admit tells the job loop a job was queued or the resources were released.
+		admit chan struct{}
+
+		draining     bool
+		returnedJobs []job.ID
+		drained      chan struct{}
+
+		// wake tells the stream there is a new state to report,
+		// ackSeq is the last stream response the jobs were taken from.
+		wake   chan struct{}
+		ackSeq uint64
 	}
 
 	heartbeatClient interface {
-		Heartbeat(context.Context, string, []results.Result, int, int, int, int) ([]jobs.Job, []sources.Source, error)
+		Heartbeat(context.Context, string, []results.Result, int, int, int, int, []string, placement.Labels, bool, []job.ID, bool) ([]jobs.Job, []sources.Source, bool, error)
+	}
+
+	streamClient interface {
+		Connect(context.Context) (*stream.Conn, error)
 	}
 
 	sourceProvider interface {
@@ -47,13 +67,16 @@ type (
 func NewWorker(
 	log *slog.Logger,
 	cfg config.WorkConfig,
+	labels placement.Labels,
 	sourceProvider sourceProvider,
 	executorFactory *executor.ExecutorFactory) *Worker {
 	return &Worker{
-		log: log,
-		cfg: cfg,
+		log:    log,
+		cfg:    cfg,
+		labels: labels,
 
 		heartbeatClient: heartbeat.NewHeartbeatClient(cfg.CoordinatorEndpoint),
+		streamClient:    stream.NewStreamClient(cfg.CoordinatorEndpoint),
 		executorFactory: executorFactory,
 
 		jobs:                    *queue.NewQueue[jobs.Job](),
@@ -61,24 +84,51 @@ func NewWorker(
 
 		sourceProvider: sourceProvider,
 
-		mu:              sync.Mutex{},
-		doneJobs:        make([]results.Result, 0),
-		freeSlots:       cfg.FreeSlots,
-		availableMemory: cfg.AvailableMemory,
+		mu:        sync.Mutex{},
+		doneJobs:  make([]results.Result, 0),
+		resources: newResources(cfg),
+		admit:     make(chan struct{}, 1),
+
+		draining:     false,
+		returnedJobs: make([]job.ID, 0),
+		drained:      make(chan struct{}),
+
+		wake: make(chan struct{}, 1),
 	}
 }
 
 func (w *Worker) Start(ctx context.Context) {
 	go w.runHeartbeat(ctx)
-	for range w.cfg.FreeSlots {
-		go w.runWorker(ctx)
+	go w.runJobs(ctx)
+}
+
+// Drain stops taking new jobs: queued jobs are handed back to the coordinator,
+// running jobs are finished and the worker deregisters once it is idle.
+func (w *Worker) Drain() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.draining {
+		return
 	}
+	w.log.Info("worker is draining", slog.Int("queued_jobs", w.jobs.Size()))
+	w.draining = true
+	w.signal()
 }
 
+// Drained is closed once the worker has deregistered from the coordinator.
+func (w *Worker) Drained() <-chan struct{} {
+	return w.drained
+}
+
+// runHeartbeat reports the state to the coordinator and takes the jobs from it.
+// With the push dispatch it does so over a stream and falls back to the heartbeat requests
+// while the stream can not be connected.
 func (w *Worker) runHeartbeat(ctx context.Context) {
 	timer := time.NewTicker(w.cfg.HeartbeatDelay)
 	defer timer.Stop()
 
+	var streamRetryAt time.Time
 	for {
 		select {
 		case <-ctx.Done():
@@ -88,99 +138,280 @@ func (w *Worker) runHeartbeat(ctx context.Context) {
 			break
 		}
 
-		w.mu.Lock()
+		if w.cfg.PushDispatch && !time.Now().Before(streamRetryAt) {
+			deregistered, err := w.runStream(ctx)
+			if deregistered {
+				w.deregistered()
+				return
+			}
+			if ctx.Err() != nil {
+				w.log.Info("exit heartbeat loop")
+				return
+			}
+			w.log.Warn("stream to coordinator failed, falling back to heartbeat", slog.Any("err", err))
+			streamRetryAt = time.Now().Add(w.cfg.StreamRetryDelay)
+		}
 
-		doneJobs := make([]results.Result, len(w.doneJobs))
-		copy(doneJobs, w.doneJobs)
-		w.doneJobs = make([]results.Result, 0)
+		if w.heartbeat(ctx) {
+			w.deregistered()
+			return
+		}
+	}
+}
 
-		freeSlots := w.freeSlots - w.jobs.Size()
-		availableMemory := w.availableMemory - w.jobsExpectedTotalMemory
+// heartbeat reports the state in a heartbeat request. It returns true once the worker has deregistered.
+func (w *Worker) heartbeat(ctx context.Context) bool {
+	state, _ := w.takeState()
+
+	jbs, srcs, drain, err := w.heartbeatClient.Heartbeat(
+		ctx,
+		state.WorkerID,
+		state.DoneJobs,
+		state.TotalSlots,
+		state.TotalMemory,
+		state.FreeSlots,
+		state.AvailableMemory,
+		state.Toolchains,
+		state.Labels,
+		state.Draining,
+		state.ReturnedJobs,
+		state.Deregister,
+	)
+	if err != nil {
+		w.log.Error("failed to do heartbeat request", slog.Any("err", err))
+		w.restoreState(state)
+		return false
+	}
 
-		w.mu.Unlock()
+	if state.Deregister {
+		return true
+	}
+	if drain {
+		w.Drain()
+	}
 
-		jbs, srcs, err := w.heartbeatClient.Heartbeat(
-			ctx,
-			w.cfg.WorkerID,
-			doneJobs,
-			w.cfg.FreeSlots,
-			w.cfg.AvailableMemory,
-			freeSlots,
-			availableMemory,
-		)
-		if err != nil {
-			w.log.Error("failed to do heartbeat request", slog.Any("err", err))
+	w.takeJobs(ctx, jbs, srcs, 0)
+	return false
+}
 
-			w.mu.Lock()
-			w.doneJobs = append(w.doneJobs, doneJobs...)
-			w.mu.Unlock()
+// runStream reports the state over a stream every time it changes and takes the jobs pushed by the coordinator
+// until the stream breaks. It returns true once the worker has deregistered.
+func (w *Worker) runStream(ctx context.Context) (bool, error) {
+	conn, err := w.streamClient.Connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	w.log.Info("connected to coordinator stream")
+
+	w.mu.Lock()
+	w.ackSeq = 0
+	w.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	received := make(chan error, 1)
+	go func() {
+		received <- w.receiveJobs(ctx, conn)
+	}()
+
+	w.signal()
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case err = <-received:
+			return err == nil, err
+		case <-w.wake:
+			break
+		}
 
-			continue
+		state, ack := w.takeState()
+		if err = conn.Send(ctx, stream.Request{Request: state, Ack: ack}); err != nil {
+			w.restoreState(state)
+			return false, err
 		}
+	}
+}
 
-		for _, src := range srcs {
-			if err := w.sourceProvider.SaveSource(ctx, src); err != nil {
-				w.log.Error("failed to create source", slog.Any("err", err))
-			}
+// receiveJobs takes the jobs pushed over the stream. It returns nil once the coordinator has deregistered the worker.
+func (w *Worker) receiveJobs(ctx context.Context, conn *stream.Conn) error {
+	for {
+		resp, err := conn.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		if resp.Deregistered {
+			return nil
 		}
 
-		for _, jb := range jbs {
-			w.mu.Lock()
-			w.jobs.Enqueue(jb)
-			w.jobsExpectedTotalMemory += jb.GetExpectedMemory()
-			w.mu.Unlock()
+		if resp.Drain {
+			w.Drain()
 		}
+		w.takeJobs(ctx, resp.Jobs, resp.Sources, resp.Seq)
 	}
 }
 
-func (w *Worker) runWorker(ctx context.Context) {
-	timer := time.NewTicker(w.cfg.WorkerDelay)
-	defer timer.Stop()
+// takeState collects the state to report and the last stream response it accounts for.
+func (w *Worker) takeState() (heartbeat.Request, uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	doneJobs := make([]results.Result, len(w.doneJobs))
+	copy(doneJobs, w.doneJobs)
+	w.doneJobs = make([]results.Result, 0)
+
+	if w.draining {
+		for jb := w.jobs.Dequeue(); jb != nil; jb = w.jobs.Dequeue() {
+			w.returnedJobs = append(w.returnedJobs, jb.GetID())
+			w.jobsExpectedTotalMemory -= jb.GetExpectedMemory()
+		}
+	}
+	returnedJobs := w.returnedJobs
+	w.returnedJobs = make([]job.ID, 0)
+
+	freeSlots, availableMemory := w.resources.free()
+	freeSlots -= w.jobs.Size()
+	availableMemory -= w.jobsExpectedTotalMemory
+	if w.draining {
+		freeSlots, availableMemory = 0, 0
+	}
+
+	return heartbeat.Request{
+		WorkerID:        w.cfg.WorkerID,
+		DoneJobs:        doneJobs,
+		TotalSlots:      w.resources.totalCores,
+		TotalMemory:     w.resources.totalMemory,
+		FreeSlots:       max(freeSlots, 0),
+		AvailableMemory: max(availableMemory, 0),
+		Toolchains:      w.cfg.ToolchainIDs(),
+		Labels:          w.labels,
+		Draining:        w.draining,
+		ReturnedJobs:    returnedJobs,
+		Deregister:      w.draining && w.resources.idle(),
+	}, w.ackSeq
+}
+
+// restoreState keeps the done and returned jobs of a state the coordinator has not got to report them again.
+func (w *Worker) restoreState(state heartbeat.Request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.doneJobs = append(w.doneJobs, state.DoneJobs...)
+	w.returnedJobs = append(w.returnedJobs, state.ReturnedJobs...)
+}
+
+func (w *Worker) takeJobs(ctx context.Context, jbs []jobs.Job, srcs []sources.Source, seq uint64) {
+	for _, src := range srcs {
+		if err := w.sourceProvider.SaveSource(ctx, src); err != nil {
+			w.log.Error("failed to create source", slog.Any("err", err))
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, jb := range jbs {
+		w.jobs.Enqueue(jb)
+		w.jobsExpectedTotalMemory += jb.GetExpectedMemory()
+	}
+	w.ackSeq = max(w.ackSeq, seq)
+
+	if len(jbs) > 0 {
+		w.signalAdmit()
+	}
+	// the jobs pushed while draining are handed back right away
+	if w.draining && len(jbs) > 0 {
+		w.signal()
+	}
+}
+
+func (w *Worker) deregistered() {
+	w.log.Info("worker deregistered")
+	close(w.drained)
+}
+
+// signal wakes the stream to report the state. It does not block.
+func (w *Worker) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Worker) signalAdmit() {
+	select {
+	case w.admit <- struct{}{}:
+	default:
+	}
+}
 
+// runJobs starts the queued jobs in order as soon as the worker has a free core and enough memory for the next one.
+func (w *Worker) runJobs(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			w.log.Info("exit worker")
+			w.log.Info("exit job loop")
 			return
-		case <-timer.C:
+		case <-w.admit:
 			break
 		}
 
-		w.mu.Lock()
+		for {
+			w.mu.Lock()
 
-		job := w.jobs.Dequeue()
-		if job == nil {
-			w.mu.Unlock()
-			continue
-		}
+			if w.draining {
+				w.mu.Unlock()
+				break
+			}
 
-		w.freeSlots -= 1
-		w.jobsExpectedTotalMemory -= job.GetExpectedMemory()
-		w.availableMemory -= job.GetExpectedMemory()
+			jb := w.jobs.Peek()
+			if jb == nil {
+				w.mu.Unlock()
+				break
+			}
+			cpus, ok := w.resources.acquire(jb.GetExpectedMemory())
+			if !ok {
+				w.mu.Unlock()
+				break
+			}
 
-		w.mu.Unlock()
+			w.jobs.Dequeue()
+			w.jobsExpectedTotalMemory -= jb.GetExpectedMemory()
 
-		jobID := job.GetID()
-		w.log.Debug("picked job", slog.String("job", jobID.String()))
+			w.mu.Unlock()
 
-		result := w.executeJob(ctx, *job)
+			go w.runJob(ctx, *jb, cpus)
+		}
+	}
+}
 
-		w.mu.Lock()
+func (w *Worker) runJob(ctx context.Context, jb jobs.Job, cpus []int) {
+	jobID := jb.GetID()
+	w.log.Debug("picked job", slog.String("job", jobID.String()), slog.Any("cpus", cpus))
+
+	result := w.executeJob(runtime.WithCPUSet(ctx, cpus), jb)
+	usedMemory := result.GetUsedMemory()
+	if usedMemory > jb.GetExpectedMemory() {
+		w.log.Debug("job used more memory than expected",
+			slog.String("job", jobID.String()),
+			slog.Int("expected_memory_mb", jb.GetExpectedMemory()),
+			slog.Int("used_memory_mb", usedMemory),
+		)
+	}
 
-		w.doneJobs = append(w.doneJobs, result)
-		w.freeSlots += 1
-		w.availableMemory += job.GetExpectedMemory()
+	w.mu.Lock()
 
-		w.mu.Unlock()
+	w.doneJobs = append(w.doneJobs, result)
+	w.resources.release(cpus, w.resources.record(jb.GetExpectedMemory(), usedMemory))
+	w.signal()
+	w.signalAdmit()
 
-		w.log.Debug("done job", slog.String("job", jobID.String()))
-	}
-}
+	w.mu.Unlock()
 
-func (w *Worker) changeFreeSlots(delta int) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	w.freeSlots += delta
+	w.log.Debug("done job", slog.String("job", jobID.String()))
 }
 
 func (w *Worker) executeJob(ctx context.Context, jb jobs.Job) results.Result {
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"taski/internal/config"
+	"taski/internal/storage/postgres"
+	"taski/internal/usecase/testing/usecase/backfill"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var batchSize int
+	flag.IntVar(&batchSize, "batch-size", 1000, "count of solutions read at once")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	cfg := config.MustLoad()
+
+	unitOfWork, err := postgres.NewUnitOfWork(cfg.Db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create unit of work:", err)
+		return 1
+	}
+
+	var (
+		solutionStorage *postgres.SolutionStorage
+		statsStorage    *postgres.TaskStatsStorage
+	)
+	initCtx, initCancel := context.WithTimeout(ctx, cfg.Db.InitTimeout)
+	defer initCancel()
+	if err = unitOfWork.Do(initCtx, func(ctx context.Context) error {
+		if solutionStorage, err = postgres.NewSolutionStorage(ctx, log); err != nil {
+			return fmt.Errorf("failed to create solution storage: %w", err)
+		}
+		if statsStorage, err = postgres.NewTaskStatsStorage(ctx, log); err != nil {
+			return fmt.Errorf("failed to create task stats storage: %w", err)
+		}
+		return nil
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to setup db:", err)
+		return 1
+	}
+
+	uc := backfill.NewUseCase(log, unitOfWork, solutionStorage, statsStorage)
+	report, err := uc.Backfill(ctx, backfill.Command{BatchSize: batchSize})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backfill error:", err)
+		return 1
+	}
+
+	fmt.Printf("Solutions: %d\nFinished: %d\n", report.Solutions, report.Finished)
+	return 0
+}
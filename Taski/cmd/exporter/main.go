@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"taski/internal/config"
+	"taski/internal/domain/task"
+	"taski/internal/storage/filestorage"
+	"taski/internal/usecase/task/usecase/export"
+
+	fs "github.com/DIvanCode/filestorage/pkg/filestorage"
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var (
+		id  string
+		out string
+	)
+	flag.StringVar(&id, "id", "", "id of the task to export")
+	flag.StringVar(&out, "out", "", "path of the package zip, <id>.zip by default")
+	flag.Parse()
+
+	var taskID task.ID
+	if err := taskID.FromString(id); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid task id:", err)
+		return 1
+	}
+	if out == "" {
+		out = taskID.String() + ".zip"
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	cfg := config.MustLoadUploader()
+
+	fileStorage, err := fs.New(log, cfg.FileStorage, chi.NewRouter())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create filestorage:", err)
+		return 1
+	}
+	defer fileStorage.Shutdown()
+
+	uc := export.NewUseCase(log, filestorage.NewTaskStorage(fileStorage))
+	write, unlock, err := uc.Export(ctx, export.Query{TaskID: taskID})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "exporter error:", err)
+		return 1
+	}
+	defer unlock()
+
+	f, err := os.Create(out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create package file:", err)
+		return 1
+	}
+	if err = write(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(out)
+		fmt.Fprintln(os.Stderr, "exporter error:", err)
+		return 1
+	}
+	if err = f.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to close package file:", err)
+		return 1
+	}
+
+	fmt.Printf("Package: %s\n", out)
+	return 0
+}
@@ -10,10 +10,12 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	exportAPI "taski/internal/api/task/export"
 	getFileAPI "taski/internal/api/task/file"
 	getAPI "taski/internal/api/task/get"
 	listAPI "taski/internal/api/task/list"
 	randomTaskAPI "taski/internal/api/task/random"
+	taskStatsAPI "taski/internal/api/task/stats"
 	taskTopicsAPI "taski/internal/api/task/topics"
 	"taski/internal/api/testing/execute"
 	messagesAPI "taski/internal/api/testing/messages"
@@ -24,10 +26,12 @@ import (
 	"taski/internal/metrics"
 	"taski/internal/storage/filestorage"
 	"taski/internal/storage/postgres"
+	exportUC "taski/internal/usecase/task/usecase/export"
 	getFileUC "taski/internal/usecase/task/usecase/file"
 	getUC "taski/internal/usecase/task/usecase/get"
 	listUC "taski/internal/usecase/task/usecase/list"
 	randomTaskUC "taski/internal/usecase/task/usecase/random"
+	taskStatsUC "taski/internal/usecase/task/usecase/stats"
 	taskTopicsUC "taski/internal/usecase/task/usecase/topics"
 	messagesUC "taski/internal/usecase/testing/usecase/messages"
 	testUC "taski/internal/usecase/testing/usecase/test"
@@ -77,32 +81,38 @@ func main() {
 	}
 	defer fileStorage.Shutdown()
 
-	unitOfWork, solutionStorage, outboxStorage, messageStorage, err := setupDb(log, cfg.Db)
+	unitOfWork, solutionStorage, statsStorage, outboxStorage, messageStorage, err := setupDb(log, cfg.Db)
 	if err != nil {
 		log.Error("failed to setup db", slog.String("error", err.Error()))
 		return
 	}
 
-	executeClient := execute.NewExecuteClient(log, cfg.Execute.Endpoint)
+	executeClient := execute.NewExecuteClient(log, cfg.Execute)
 
 	taskStorage := filestorage.NewTaskStorage(fileStorage)
 
-	getTaskUseCase := getUC.NewUseCase(log, taskStorage)
+	getTaskUseCase := getUC.NewUseCase(log, taskStorage, unitOfWork, statsStorage)
 	getAPI.NewHandler(log, getTaskUseCase).Register(mux)
 
-	taskListUseCase := listUC.NewUseCase(log, taskStorage)
+	taskListUseCase := listUC.NewUseCase(log, taskStorage, unitOfWork, statsStorage)
 	listAPI.NewHandler(log, taskListUseCase).Register(mux)
 
 	taskTopicsUseCase := taskTopicsUC.NewUseCase(log, cfg.TaskTopics)
 	taskTopicsAPI.NewHandler(log, taskTopicsUseCase).Register(mux)
 
-	randomTaskUseCase := randomTaskUC.NewUseCase(log, taskStorage)
+	randomTaskUseCase := randomTaskUC.NewUseCase(log, taskStorage, unitOfWork, statsStorage)
 	randomTaskAPI.NewHandler(log, randomTaskUseCase).Register(mux)
 
+	taskStatsUseCase := taskStatsUC.NewUseCase(log, taskStorage, unitOfWork, statsStorage)
+	taskStatsAPI.NewHandler(log, taskStatsUseCase).Register(mux)
+
+	exportTaskUseCase := exportUC.NewUseCase(log, taskStorage)
+	exportAPI.NewHandler(log, exportTaskUseCase).Register(mux)
+
 	getTaskFileUseCase := getFileUC.NewUseCase(log, taskStorage)
 	getFileAPI.NewHandler(log, getTaskFileUseCase).Register(mux)
 
-	testUseCase := testUC.NewUseCase(log, taskStorage, unitOfWork, solutionStorage, executeClient, cfg.Execute.DownloadTaskEndpoint)
+	testUseCase := testUC.NewUseCase(log, taskStorage, unitOfWork, solutionStorage, statsStorage, executeClient, cfg.Execute.DownloadTaskEndpoint)
 	testAPI.NewHandler(log, testUseCase).Register(mux)
 
 	messagesUseCase := messagesUC.NewUseCase(log, unitOfWork, messageStorage)
@@ -111,7 +121,7 @@ func main() {
 	messageDispatcher := dispatcher.NewMessageDispatcher(log, cfg.MessageDispatcher, unitOfWork, outboxStorage, messageStorage)
 	messageDispatcher.Start(ctx)
 
-	updateTestingUseCase := update.NewUseCase(log, solutionStorage, unitOfWork, messageDispatcher)
+	updateTestingUseCase := update.NewUseCase(log, solutionStorage, statsStorage, unitOfWork, messageDispatcher)
 	eventHandler := handler.NewEventHandler(log, cfg, unitOfWork, solutionStorage, updateTestingUseCase)
 	eventHandler.Start(ctx)
 	defer func() { _ = eventHandler.Close() }()
@@ -123,7 +133,7 @@ func main() {
 	)
 	promRegisterer := prometheus.WrapRegistererWithPrefix("coduels_taski_", promRegistry)
 
-	metricsCollector := metrics.NewMetricsCollector(log, cfg.MetricsCollector, taskStorage, unitOfWork, solutionStorage)
+	metricsCollector := metrics.NewMetricsCollector(log, cfg.MetricsCollector, taskStorage, unitOfWork, statsStorage)
 	err = metricsCollector.RegisterMetrics(promRegisterer)
 	if err != nil {
 		log.Error("could not register metrics", slog.Any("err", err))
@@ -187,6 +197,7 @@ func setupLogger(env string) (log *slog.Logger, err error) {
 func setupDb(log *slog.Logger, cfg config.DbConfig) (
 	unitOfWork *postgres.UnitOfWork,
 	solutionStorage *postgres.SolutionStorage,
+	statsStorage *postgres.TaskStatsStorage,
 	outboxStorage *postgres.OutboxStorage,
 	messageStorage *postgres.MessageStorage,
 	err error,
@@ -204,6 +215,9 @@ func setupDb(log *slog.Logger, cfg config.DbConfig) (
 		if solutionStorage, err = postgres.NewSolutionStorage(ctx, log); err != nil {
 			return fmt.Errorf("failed to create solution storage: %w", err)
 		}
+		if statsStorage, err = postgres.NewTaskStatsStorage(ctx, log); err != nil {
+			return fmt.Errorf("failed to create task stats storage: %w", err)
+		}
 		if outboxStorage, err = postgres.NewOutboxStorage(ctx, log); err != nil {
 			return fmt.Errorf("failed to create outbox storage: %w", err)
 		}
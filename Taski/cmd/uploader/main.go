@@ -9,7 +9,10 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"taski/internal/api/testing/execute"
 	"taski/internal/config"
+	"taski/internal/uploader"
+	"taski/internal/usecase/task/usecase/batch"
 	"taski/internal/usecase/task/usecase/upload"
 
 	fs "github.com/DIvanCode/filestorage/pkg/filestorage"
@@ -47,14 +50,44 @@ func run() int {
 	}
 	defer fileStorage.Shutdown()
 
-	uc := upload.NewUseCase(log, fileStorage)
+	executeClient := execute.NewExecuteClient(log, cfg.Execute)
 
-	var command upload.Command
-	flag.StringVar(&command.Format, "format", upload.FormatPolygon, "source task format")
-	flag.StringVar(&command.SrcPath, "src", "", "path to polygon package directory or zip archive")
+	topics, err := uploader.NewTopics(cfg.TaskTopics, cfg.TaskTopicTags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid task topic tags:", err)
+		return 1
+	}
+
+	uc := upload.NewUseCase(log, fileStorage, executeClient, topics)
+
+	var (
+		command    upload.Command
+		batchSrc   string
+		workers    int
+		reportPath string
+	)
+	flag.StringVar(&command.Format, "format", upload.FormatPolygon, "source task format: polygon, taski, icpc or kattis")
+	flag.StringVar(&command.SrcPath, "src", "", "path to package directory or zip archive")
 	flag.IntVar(&command.Level, "level", 1, "task level [1..10]")
+	flag.StringVar(&batchSrc, "batch", "", "directory of packages or manifest (.csv, .yaml) to upload in a batch instead of -src")
+	flag.IntVar(&workers, "workers", 4, "packages uploaded at a time in a batch")
+	flag.StringVar(&reportPath, "report", "upload-report.json", "batch report, read to resume and rewritten after every package")
 	flag.Parse()
 
+	if batchSrc != "" {
+		if command.SrcPath != "" {
+			fmt.Fprintln(os.Stderr, "uploader error: -src and -batch are mutually exclusive")
+			return 1
+		}
+		return runBatch(ctx, batch.NewUseCase(log, uc), batch.Command{
+			Src:        batchSrc,
+			Format:     command.Format,
+			Level:      command.Level,
+			Workers:    workers,
+			ReportPath: reportPath,
+		})
+	}
+
 	taskID, err := uc.Upload(ctx, command)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "uploader error:", err)
@@ -64,3 +97,19 @@ func run() int {
 	fmt.Printf("Task ID: %s\n", taskID.String())
 	return 0
 }
+
+func runBatch(ctx context.Context, uc *batch.UseCase, command batch.Command) int {
+	report, err := uc.Upload(ctx, command)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "uploader error:", err)
+	}
+
+	s := report.Summary
+	fmt.Printf("Packages: %d, uploaded: %d, skipped: %d, exists: %d, failed: %d\n",
+		s.Total, s.Uploaded, s.Skipped, s.Exists, s.Failed)
+	fmt.Printf("Report: %s\n", command.ReportPath)
+	if err != nil || s.Failed > 0 || s.Uploaded+s.Skipped+s.Exists+s.Failed < s.Total {
+		return 1
+	}
+	return 0
+}
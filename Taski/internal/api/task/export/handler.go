@@ -0,0 +1,69 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"taski/internal/api"
+	"taski/internal/domain/task"
+	"taski/internal/usecase/task/usecase/export"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log *slog.Logger
+	uc  *export.UseCase
+}
+
+func NewHandler(log *slog.Logger, useCase *export.UseCase) *Handler {
+	return &Handler{
+		log: log,
+		uc:  useCase,
+	}
+}
+
+func (h *Handler) Register(r chi.Router) {
+	r.Get("/task/{id:[a-z0-9]{40}}/export", h.Handle)
+}
+
+func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
+	const op = "task.export"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id := chi.URLParam(r, "id")
+	var taskID task.ID
+	if err := taskID.FromString(id); err != nil {
+		log.Info("invalid id", slog.String("id", id), slog.Any("error", err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, api.Error("invalid task id"))
+		return
+	}
+
+	write, unlock, err := h.uc.Export(r.Context(), export.Query{TaskID: taskID})
+	if err != nil {
+		if errors.Is(err, task.ErrNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, api.Error("task not found"))
+			return
+		}
+		log.Error("failed to export task", slog.Any("error", err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, api.Error("internal server error"))
+		return
+	}
+	defer unlock()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", taskID.String()+".zip"))
+	if err = write(w); err != nil {
+		log.Error("failed to stream task package", slog.Any("error", err))
+	}
+}
@@ -24,7 +24,7 @@ func TestHandlerReturnsStableFileResponses(t *testing.T) {
 
 	const validID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
 	publicTask := &tasks.WriteCodeTask{
-		Details:  task.Details{Type: task.WriteCode, Statement: "statement.html"},
+		Details:  task.Details{Type: task.WriteCode, Statement: task.Statements{task.DefaultStatementLang: {Path: "statement.html"}}},
 		Checker:  task.Code{Path: "checker.cpp"},
 		Solution: task.Code{Path: "solution.cpp"},
 		Tests: []task.Test{
@@ -33,13 +33,13 @@ func TestHandlerReturnsStableFileResponses(t *testing.T) {
 		},
 	}
 	predictOutputTask := &tasks.PredictOutputTask{
-		Details: task.Details{Type: task.PredictOutput, Statement: "statement.html"},
+		Details: task.Details{Type: task.PredictOutput, Statement: task.Statements{task.DefaultStatementLang: {Path: "statement.html"}}},
 		Code:    task.Code{Path: "program/main.cpp"},
 		Checker: task.Code{Path: "private/checker.cpp"},
 		Test:    task.Test{Input: "tests/input.txt", Output: "tests/answer.txt"},
 	}
 	findTestTask := &tasks.FindTestTask{
-		Details:  task.Details{Type: task.FindTest, Statement: "statement.html"},
+		Details:  task.Details{Type: task.FindTest, Statement: task.Statements{task.DefaultStatementLang: {Path: "statement.html"}}},
 		Code:     task.Code{Path: "program/buggy.cpp"},
 		Solution: task.Code{Path: "private/solution.cpp"},
 		Checker:  task.Code{Path: "private/checker.cpp"},
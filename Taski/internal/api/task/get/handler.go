@@ -3,6 +3,9 @@ package get
 import (
 	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"taski/internal/api"
 	"taski/internal/domain/task"
 	"taski/internal/usecase/task/dto"
@@ -53,7 +56,7 @@ func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := get.Query{TaskID: taskID}
+	query := get.Query{TaskID: taskID, Langs: preferredLangs(r)}
 	taskDto, err := h.uc.Get(r.Context(), query)
 	if err != nil {
 		render.Status(r, http.StatusInternalServerError)
@@ -66,6 +69,44 @@ func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// preferredLangs returns the languages of the statement asked for by the lang parameter
+// and then by the Accept-Language header in the order of their weights.
+func preferredLangs(r *http.Request) []string {
+	langs := make([]string, 0)
+	if lang := strings.TrimSpace(r.URL.Query().Get("lang")); lang != "" {
+		langs = append(langs, lang)
+	}
+
+	type weightedLang struct {
+		lang   string
+		weight float64
+	}
+	weighted := make([]weightedLang, 0)
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.TrimSpace(lang)
+		if lang == "" || lang == "*" {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			weight = parsed
+		}
+		weighted = append(weighted, weightedLang{lang: lang, weight: weight})
+	}
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].weight > weighted[j].weight
+	})
+	for _, w := range weighted {
+		langs = append(langs, w.lang)
+	}
+	return langs
+}
+
 func okResponse(task dto.TaskDto) GetTaskResponse {
 	return GetTaskResponse{
 		Response: api.OK(),
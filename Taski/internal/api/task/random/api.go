@@ -8,4 +8,6 @@ import (
 type RandomTaskResponse struct {
 	api.Response
 	TaskID task.ID `json:"task_id,omitempty"`
+	// Constraint names the constraint no task was left after when there is no task to return.
+	Constraint string `json:"constraint,omitempty"`
 }
@@ -1,8 +1,13 @@
 package random
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"taski/internal/api"
 	"taski/internal/domain/task"
 	"taski/internal/usecase/task/usecase/random"
@@ -36,19 +41,88 @@ func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
 
-	query := random.Query{}
-	taskID, err := h.uc.Random(r.Context(), query)
+	query, err := parseQuery(r.URL.Query())
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		h.log.Info("invalid query", slog.Any("error", err))
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, errorResponse(err.Error()))
 		return
 	}
 
+	taskID, err := h.uc.Random(r.Context(), query)
+	if err != nil {
+		var constraintErr *random.ConstraintError
+		switch {
+		case errors.Is(err, random.ErrInvalidQuery):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, errorResponse(err.Error()))
+		case errors.As(err, &constraintErr):
+			h.log.Info("no task meets the constraints", slog.String("constraint", constraintErr.Constraint))
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, constraintResponse(constraintErr))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, errorResponse(err.Error()))
+		}
+		return
+	}
+
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, okResponse(taskID))
 	return
 }
 
+// parseQuery reads the constraints from the query parameters: rating, min_level, max_level, seed,
+// and the repeated topic, exclude_topic, and seen, the last one also comma-separated.
+func parseQuery(values url.Values) (random.Query, error) {
+	query := random.Query{
+		Topics:         values["topic"],
+		ExcludedTopics: values["exclude_topic"],
+	}
+
+	if s := values.Get("rating"); s != "" {
+		rating, err := strconv.Atoi(s)
+		if err != nil {
+			return random.Query{}, fmt.Errorf("invalid rating %q", s)
+		}
+		query.Rating = &rating
+	}
+	for _, param := range []struct {
+		name  string
+		level *task.Level
+	}{{"min_level", &query.MinLevel}, {"max_level", &query.MaxLevel}} {
+		if s := values.Get(param.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return random.Query{}, fmt.Errorf("invalid %s %q", param.name, s)
+			}
+			*param.level = task.Level(n)
+		}
+	}
+	if s := values.Get("seed"); s != "" {
+		seed, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return random.Query{}, fmt.Errorf("invalid seed %q", s)
+		}
+		query.Seed = &seed
+	}
+	for _, value := range values["seen"] {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			var taskID task.ID
+			if err := taskID.FromString(id); err != nil {
+				return random.Query{}, fmt.Errorf("invalid seen task id %q", id)
+			}
+			query.Seen = append(query.Seen, taskID)
+		}
+	}
+
+	return query, nil
+}
+
 func okResponse(taskID task.ID) RandomTaskResponse {
 	return RandomTaskResponse{
 		Response: api.OK(),
@@ -56,6 +130,13 @@ func okResponse(taskID task.ID) RandomTaskResponse {
 	}
 }
 
+func constraintResponse(err *random.ConstraintError) RandomTaskResponse {
+	return RandomTaskResponse{
+		Response:   api.Error(err.Error()),
+		Constraint: err.Constraint,
+	}
+}
+
 func errorResponse(msg string) RandomTaskResponse {
 	return RandomTaskResponse{
 		Response: api.Error(msg),
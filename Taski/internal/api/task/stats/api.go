@@ -0,0 +1,11 @@
+package stats
+
+import (
+	"taski/internal/api"
+	"taski/internal/usecase/task/dto"
+)
+
+type TaskStatsResponse struct {
+	api.Response
+	Stats *dto.TaskStatsDto `json:"stats,omitempty"`
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"taski/internal/api"
+	"taski/internal/domain/task"
+	"taski/internal/usecase/task/dto"
+	"taski/internal/usecase/task/usecase/stats"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log *slog.Logger
+	uc  *stats.UseCase
+}
+
+func NewHandler(log *slog.Logger, useCase *stats.UseCase) *Handler {
+	return &Handler{
+		log: log,
+		uc:  useCase,
+	}
+}
+
+func (h *Handler) Register(r chi.Router) {
+	r.Get("/task/{id:[a-z0-9]{40}}/stats", h.Handle)
+}
+
+func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
+	const op = "task.stats"
+
+	log := h.log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id := chi.URLParam(r, "id")
+	var taskID task.ID
+	if err := taskID.FromString(id); err != nil {
+		log.Info("invalid id", slog.String("id", id), slog.Any("error", err))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, errorResponse("invalid task id"))
+		return
+	}
+
+	statsDto, err := h.uc.Get(r.Context(), stats.Query{TaskID: taskID})
+	if err != nil {
+		if errors.Is(err, task.ErrNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, errorResponse("task not found"))
+			return
+		}
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, errorResponse(err.Error()))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, okResponse(statsDto))
+}
+
+func okResponse(stats dto.TaskStatsDto) TaskStatsResponse {
+	return TaskStatsResponse{
+		Response: api.OK(),
+		Stats:    &stats,
+	}
+}
+
+func errorResponse(msg string) TaskStatsResponse {
+	return TaskStatsResponse{
+		Response: api.Error(msg),
+	}
+}
@@ -1,6 +1,7 @@
 package execute
 
 import (
+	"taski/internal/domain/testing/event/events"
 	"taski/internal/domain/testing/execution"
 	"taski/internal/domain/testing/source/sources"
 )
@@ -9,9 +10,22 @@ type (
 	Request struct {
 		Stages  execution.Stages `json:"stages"`
 		Sources sources.Sources  `json:"sources"`
+		Tenant  string           `json:"tenant,omitempty"`
 	}
 
 	Response struct {
 		ExecutionID execution.ID `json:"execution_id"`
 	}
 )
+
+type (
+	MessagesResponse struct {
+		Status   string          `json:"status"`
+		Messages []MessageRecord `json:"messages"`
+	}
+
+	MessageRecord struct {
+		MessageID int64        `json:"message_id"`
+		Message   events.Event `json:"message"`
+	}
+)
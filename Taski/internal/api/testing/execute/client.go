@@ -8,6 +8,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"taski/internal/config"
 	"taski/internal/domain/testing/execution"
 	"taski/internal/domain/testing/source/sources"
 )
@@ -15,12 +17,18 @@ import (
 type ExecuteClient struct {
 	log      *slog.Logger
 	endpoint string
+	tenant   string
+	apiKey   string
 }
 
-func NewExecuteClient(log *slog.Logger, endpoint string) *ExecuteClient {
+const apiKeyHeader = "X-Api-Key"
+
+func NewExecuteClient(log *slog.Logger, cfg config.ExecuteConfig) *ExecuteClient {
 	return &ExecuteClient{
 		log:      log,
-		endpoint: endpoint,
+		endpoint: cfg.Endpoint,
+		tenant:   cfg.Tenant,
+		apiKey:   cfg.APIKey,
 	}
 }
 
@@ -29,7 +37,7 @@ func (c *ExecuteClient) Execute(
 	stages execution.Stages,
 	sources sources.Sources,
 ) (executionID execution.ID, err error) {
-	req := Request{Stages: stages, Sources: sources}
+	req := Request{Stages: stages, Sources: sources, Tenant: c.tenant}
 	jsonReq, err := json.Marshal(req)
 	if err != nil {
 		err = fmt.Errorf("failed to marshal execute request: %w", err)
@@ -44,6 +52,7 @@ func (c *ExecuteClient) Execute(
 		err = fmt.Errorf("failed to create execute request: %w", err)
 		return
 	}
+	c.authorize(httpReq)
 
 	httpClient := http.Client{}
 	httpResp, err := httpClient.Do(httpReq)
@@ -72,3 +81,57 @@ func (c *ExecuteClient) Execute(
 
 	return resp.ExecutionID, nil
 }
+
+// Messages returns at most count messages of the execution starting from the message startID.
+func (c *ExecuteClient) Messages(
+	ctx context.Context,
+	executionID execution.ID,
+	startID int64,
+	count int,
+) (resp MessagesResponse, err error) {
+	url := fmt.Sprintf(
+		"%s/executions/%s/messages?start_id=%d&count=%d",
+		strings.TrimRight(c.endpoint, "/"),
+		executionID,
+		startID,
+		count,
+	)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		err = fmt.Errorf("failed to create messages request: %w", err)
+		return
+	}
+	c.authorize(httpReq)
+
+	httpClient := http.Client{}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		err = fmt.Errorf("failed to send messages request: %w", err)
+		return
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var content []byte
+		content, err = io.ReadAll(httpResp.Body)
+		if err != nil {
+			err = fmt.Errorf("failed to read messages response: %w", err)
+			return
+		}
+		err = fmt.Errorf("messages got response error (status %d): %s", httpResp.StatusCode, string(content))
+		return
+	}
+
+	if err = json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		err = fmt.Errorf("failed to decode messages response: %w", err)
+		return
+	}
+
+	return resp, nil
+}
+
+func (c *ExecuteClient) authorize(httpReq *http.Request) {
+	if c.apiKey != "" {
+		httpReq.Header.Set(apiKeyHeader, c.apiKey)
+	}
+}
@@ -20,6 +20,7 @@ type (
 		MessageDispatcher MessageDispatcherConfig `yaml:"message_dispatcher" env-prefix:"MESSAGE_DISPATCHER_"`
 		MetricsCollector  MetricsCollectorConfig  `yaml:"metrics_collector" env-prefix:"METRICS_COLLECTOR_"`
 		TaskTopics        TaskTopicsList          `yaml:"task_topics" env:"TASK_TOPICS" env-separator:","`
+		TaskTopicTags     TaskTopicTagsMap        `yaml:"task_topic_tags"`
 	}
 
 	HttpServerConfig struct {
@@ -35,6 +36,10 @@ type (
 	ExecuteConfig struct {
 		Endpoint             string `yaml:"endpoint" env:"ENDPOINT"`
 		DownloadTaskEndpoint string `yaml:"download_task_endpoint" env:"DOWNLOAD_TASK_ENDPOINT"`
+		// Tenant is the Exesh tenant executions are queued under when APIKey is empty,
+		// otherwise Exesh takes the tenant of the api key.
+		Tenant string `yaml:"tenant" env:"TENANT"`
+		APIKey string `yaml:"api_key" env:"API_KEY"`
 	}
 
 	EventConsumerConfig struct {
@@ -65,6 +70,9 @@ type (
 	}
 
 	TaskTopicsList []string
+
+	// TaskTopicTagsMap lists the package tags, e.g. of Polygon, that map onto a task topic, by topic.
+	TaskTopicTagsMap map[string][]string
 )
 
 func MustLoad() (cfg *Config) {
@@ -4,6 +4,12 @@ type (
 	Code struct {
 		Path string   `json:"path"`
 		Lang Language `json:"lang"`
+		CompileOptions
+	}
+
+	CompileOptions struct {
+		Toolchain string   `json:"toolchain,omitempty"`
+		Flags     []string `json:"flags,omitempty"`
 	}
 
 	Language string
@@ -1,12 +1,12 @@
 package task
 
 type Details struct {
-	ID        ID       `json:"id"`
-	Title     string   `json:"title"`
-	Type      Type     `json:"type"`
-	Level     Level    `json:"level"`
-	Topics    []string `json:"topics"`
-	Statement string   `json:"statement"`
+	ID        ID         `json:"id"`
+	Title     string     `json:"title"`
+	Type      Type       `json:"type"`
+	Level     Level      `json:"level"`
+	Topics    []string   `json:"topics"`
+	Statement Statements `json:"statement"`
 }
 
 func (d Details) GetID() ID {
@@ -29,6 +29,6 @@ func (d Details) GetTopics() []string {
 	return d.Topics
 }
 
-func (d Details) GetStatement() string {
+func (d Details) GetStatement() Statements {
 	return d.Statement
 }
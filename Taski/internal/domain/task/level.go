@@ -1,3 +1,66 @@
 package task
 
+import "math"
+
 type Level int
+
+const (
+	MinLevel Level = 1
+	MaxLevel Level = 10
+)
+
+// Outcomes are the finished solutions of a task that say how hard it turned out to be.
+type Outcomes struct {
+	// Attempts are the solutions judged on the tests, that is all of them but compilation errors
+	// and testing failures, which say nothing about the task.
+	Attempts int
+	Accepted int
+}
+
+const (
+	// outcomesPrior is how many attempts the authored level weighs as, so that the computed level
+	// of a task with few attempts stays close to the authored one.
+	outcomesPrior = 20
+
+	// the acceptance rate expected of a task of the minimal level and its decrease per level
+	easiestAcceptanceRate  = 0.9
+	acceptanceRatePerLevel = 0.08
+)
+
+// LevelForRating returns the level of the tasks for players of the rating, the way Duely maps
+// ratings onto levels by default: level 1 below 600 and one more level every 300 points up to level 10.
+func LevelForRating(rating int) Level {
+	if rating < 600 {
+		return MinLevel
+	}
+	return min(MinLevel+Level((rating-300)/300), MaxLevel)
+}
+
+// AcceptanceRate returns the share of the accepted attempts, 0 when there are none.
+func (o Outcomes) AcceptanceRate() float64 {
+	if o.Attempts == 0 {
+		return 0
+	}
+	return float64(o.Accepted) / float64(o.Attempts)
+}
+
+// AttemptsBeforeAccepted returns the mean number of rejected attempts per accepted one, 0 when none
+// is accepted. Solutions are not tied to players, so this is taken over all the attempts of the task.
+func (o Outcomes) AttemptsBeforeAccepted() float64 {
+	if o.Accepted == 0 {
+		return 0
+	}
+	return float64(o.Attempts-o.Accepted) / float64(o.Accepted)
+}
+
+// ComputedLevel returns the level the outcomes point to. The acceptance rate is smoothed towards the rate
+// expected of the authored level with the weight of outcomesPrior attempts, so a task nobody has solved yet
+// keeps its authored level, and is then mapped back onto the levels.
+func (o Outcomes) ComputedLevel(authored Level) Level {
+	authored = min(max(authored, MinLevel), MaxLevel)
+	expected := easiestAcceptanceRate - acceptanceRatePerLevel*float64(authored-MinLevel)
+	rate := (float64(o.Accepted) + outcomesPrior*expected) / float64(o.Attempts+outcomesPrior)
+
+	level := Level(math.Round(float64(MinLevel) + (easiestAcceptanceRate-rate)/acceptanceRatePerLevel))
+	return min(max(level, MinLevel), MaxLevel)
+}
@@ -0,0 +1,51 @@
+package task
+
+import "testing"
+
+func TestOutcomesComputedLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		authored Level
+		outcomes Outcomes
+		want     Level
+	}{
+		{name: "no attempts", authored: 5, want: 5},
+		{name: "few failed attempts", authored: 5, outcomes: Outcomes{Attempts: 5}, want: 6},
+		{name: "rarely accepted", authored: 5, outcomes: Outcomes{Attempts: 100, Accepted: 10}, want: 10},
+		{name: "mostly accepted", authored: 5, outcomes: Outcomes{Attempts: 100, Accepted: 90}, want: 2},
+		{name: "level out of range", authored: 0, want: MinLevel},
+	}
+
+	for _, tt := range tests {
+		if got := tt.outcomes.ComputedLevel(tt.authored); got != tt.want {
+			t.Fatalf("%s: ComputedLevel(%d) = %d, want %d", tt.name, tt.authored, got, tt.want)
+		}
+	}
+}
+
+func TestOutcomesRates(t *testing.T) {
+	t.Parallel()
+
+	outcomes := Outcomes{Attempts: 8, Accepted: 2}
+	if got := outcomes.AcceptanceRate(); got != 0.25 {
+		t.Fatalf("AcceptanceRate() = %v, want 0.25", got)
+	}
+	if got := outcomes.AttemptsBeforeAccepted(); got != 3 {
+		t.Fatalf("AttemptsBeforeAccepted() = %v, want 3", got)
+	}
+	if got := (Outcomes{Attempts: 3}).AttemptsBeforeAccepted(); got != 0 {
+		t.Fatalf("AttemptsBeforeAccepted() without accepted = %v, want 0", got)
+	}
+}
+
+func TestLevelForRating(t *testing.T) {
+	t.Parallel()
+
+	for rating, want := range map[int]Level{-50: 1, 0: 1, 599: 1, 600: 2, 899: 2, 1500: 5, 2999: 9, 3000: 10, 9999: 10} {
+		if got := LevelForRating(rating); got != want {
+			t.Fatalf("LevelForRating(%d) = %d, want %d", rating, got, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// Statement is the statement of a task in one language: the HTML file
+	// and the files it refers to, like images. Both are paths in the task bucket.
+	Statement struct {
+		Path     string             `json:"path"`
+		Assets   []string           `json:"assets,omitempty"`
+		Sections *StatementSections `json:"sections,omitempty"`
+	}
+
+	// StatementSections are the parts of the statement rendered into sanitised HTML,
+	// for the clients that lay the statement out on their own. The samples are plain text.
+	StatementSections struct {
+		Legend      string            `json:"legend,omitempty"`
+		Input       string            `json:"input,omitempty"`
+		Output      string            `json:"output,omitempty"`
+		Interaction string            `json:"interaction,omitempty"`
+		Notes       string            `json:"notes,omitempty"`
+		Samples     []StatementSample `json:"samples,omitempty"`
+	}
+
+	StatementSample struct {
+		Input  string `json:"input"`
+		Output string `json:"output"`
+	}
+
+	// Statements are the statements of a task by language code, e.g. "ru" or "en".
+	Statements map[string]Statement
+)
+
+// DefaultStatementLang is the language of the statements stored as a single path
+// and the language picked when none of the requested ones is available.
+const DefaultStatementLang = "ru"
+
+// UnmarshalJSON accepts a plain statement path as well, the way the tasks were stored before
+// the statements got languages.
+func (s *Statements) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*s = Statements{DefaultStatementLang: {Path: path}}
+		return nil
+	}
+
+	var statements map[string]Statement
+	if err := json.Unmarshal(data, &statements); err != nil {
+		return fmt.Errorf("failed to unmarshal statements: %w", err)
+	}
+	*s = statements
+	return nil
+}
+
+// Langs returns the languages of the statements in alphabetical order.
+func (s Statements) Langs() []string {
+	langs := make([]string, 0, len(s))
+	for lang := range s {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Pick returns the statement in the first of the preferred languages it is available in.
+// A regional language like "en-US" matches "en" as well. When none of the languages is available
+// the statement falls back to DefaultStatementLang and then to the first language there is.
+func (s Statements) Pick(preferred ...string) (string, Statement, bool) {
+	for _, lang := range preferred {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if st, ok := s[lang]; ok {
+			return lang, st, true
+		}
+		if base, _, ok := strings.Cut(lang, "-"); ok {
+			if st, ok := s[base]; ok {
+				return base, st, true
+			}
+		}
+	}
+
+	if st, ok := s[DefaultStatementLang]; ok {
+		return DefaultStatementLang, st, true
+	}
+	if langs := s.Langs(); len(langs) > 0 {
+		return langs[0], s[langs[0]], true
+	}
+	return "", Statement{}, false
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStatementsUnmarshalPlainPath(t *testing.T) {
+	t.Parallel()
+
+	var details Details
+	if err := json.Unmarshal([]byte(`{"statement":"statement.html"}`), &details); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	statement, ok := details.Statement[DefaultStatementLang]
+	if !ok || statement.Path != "statement.html" || len(details.Statement) != 1 {
+		t.Fatalf("Statement = %v, want statement.html in %q", details.Statement, DefaultStatementLang)
+	}
+}
+
+func TestStatementsPick(t *testing.T) {
+	t.Parallel()
+
+	statements := Statements{
+		"ru": {Path: "statements/ru/statement.html"},
+		"en": {Path: "statements/en/statement.html"},
+	}
+
+	tests := []struct {
+		name       string
+		statements Statements
+		preferred  []string
+		wantLang   string
+	}{
+		{name: "preferred", statements: statements, preferred: []string{"en"}, wantLang: "en"},
+		{name: "first available preferred", statements: statements, preferred: []string{"de", "EN"}, wantLang: "en"},
+		{name: "regional", statements: statements, preferred: []string{"en-US"}, wantLang: "en"},
+		{name: "default", statements: statements, preferred: []string{"de"}, wantLang: "ru"},
+		{name: "no default", statements: Statements{"fr": {}, "en": {}}, wantLang: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lang, _, ok := tt.statements.Pick(tt.preferred...)
+			if !ok || lang != tt.wantLang {
+				t.Fatalf("Pick(%q) = %q, %v, want %q", tt.preferred, lang, ok, tt.wantLang)
+			}
+		})
+	}
+
+	if _, _, ok := (Statements{}).Pick("en"); ok {
+		t.Fatal("Pick() found a statement among none")
+	}
+}
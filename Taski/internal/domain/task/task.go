@@ -6,5 +6,5 @@ type Task interface {
 	GetType() Type
 	GetLevel() Level
 	GetTopics() []string
-	GetStatement() string
+	GetStatement() Statements
 }
@@ -10,4 +10,6 @@ type WriteCodeTask struct {
 	Checker     task.Code   `json:"checker"`
 	Solution    task.Code   `json:"solution"`
 	Tests       []task.Test `json:"tests"`
+
+	Compile map[task.Language]task.CompileOptions `json:"compile,omitempty"`
 }
@@ -5,9 +5,20 @@ import (
 	"taski/internal/domain/testing/job"
 )
 
-type RunJobEvent struct {
-	event.Details
-	JobName   job.Name   `json:"job"`
-	RunStatus job.Status `json:"status"`
-	Output    *string    `json:"output,omitempty"`
-}
+type (
+	RunJobEvent struct {
+		event.Details
+		JobName   job.Name   `json:"job"`
+		RunStatus job.Status `json:"status"`
+		Output    *string    `json:"output,omitempty"`
+		Artifact  *Artifact  `json:"artifact,omitempty"`
+	}
+
+	// Artifact is where the output of the run job can be downloaded from:
+	// the file of the bucket in the filestorage served at Endpoint.
+	Artifact struct {
+		Endpoint string `json:"endpoint"`
+		BucketID string `json:"bucket_id"`
+		File     string `json:"file"`
+	}
+)
@@ -8,12 +8,23 @@ type (
 	ID string
 
 	Stage struct {
-		Name StageName   `json:"name"`
-		Deps []StageName `json:"deps"`
-		Jobs []jobs.Job  `json:"jobs"`
+		Name   StageName   `json:"name"`
+		Deps   []StageName `json:"deps"`
+		Policy StagePolicy `json:"policy,omitempty"`
+		Jobs   []jobs.Job  `json:"jobs"`
 	}
 
 	StageName string
 
+	// StagePolicy tells exesh how to proceed after a job of the stage fails,
+	// an empty policy means StagePolicyRunAll.
+	StagePolicy string
+
 	Stages []Stage
 )
+
+const (
+	StagePolicyFailFast          StagePolicy = "fail_fast"
+	StagePolicyRunAll            StagePolicy = "run_all"
+	StagePolicyContinueOnFailure StagePolicy = "continue_on_failure"
+)
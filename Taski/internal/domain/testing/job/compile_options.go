@@ -0,0 +1,6 @@
+package job
+
+type CompileOptions struct {
+	Toolchain string   `json:"toolchain,omitempty"`
+	Flags     []string `json:"flags,omitempty"`
+}
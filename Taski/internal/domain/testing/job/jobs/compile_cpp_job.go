@@ -7,10 +7,13 @@ import (
 
 type CompileCppJob struct {
 	job.Details
+	job.CompileOptions
 	Code inputs.Input `json:"code"`
 }
 
-func NewCompileCppJob(name job.Name, categoryName string, timeLimit int, memoryLimit int, code inputs.Input) Job {
+func NewCompileCppJob(name job.Name, categoryName string, timeLimit int, memoryLimit int,
+	code inputs.Input, compileOptions job.CompileOptions,
+) Job {
 	return Job{IJob: &CompileCppJob{
 		Details: job.Details{
 			Type:          job.CompileCpp,
@@ -20,6 +23,7 @@ func NewCompileCppJob(name job.Name, categoryName string, timeLimit int, memoryL
 			TimeLimit:     timeLimit,
 			MemoryLimit:   memoryLimit,
 		},
-		Code: code,
+		CompileOptions: compileOptions,
+		Code:           code,
 	}}
 }
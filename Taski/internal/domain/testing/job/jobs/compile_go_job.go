@@ -7,10 +7,13 @@ import (
 
 type CompileGoJob struct {
 	job.Details
+	job.CompileOptions
 	Code inputs.Input `json:"code"`
 }
 
-func NewCompileGoJob(name job.Name, categoryName string, timeLimit int, memoryLimit int, code inputs.Input) Job {
+func NewCompileGoJob(name job.Name, categoryName string, timeLimit int, memoryLimit int,
+	code inputs.Input, compileOptions job.CompileOptions,
+) Job {
 	return Job{IJob: &CompileGoJob{
 		Details: job.Details{
 			Type:          job.CompileGo,
@@ -20,6 +23,7 @@ func NewCompileGoJob(name job.Name, categoryName string, timeLimit int, memoryLi
 			TimeLimit:     timeLimit,
 			MemoryLimit:   memoryLimit,
 		},
-		Code: code,
+		CompileOptions: compileOptions,
+		Code:           code,
 	}}
 }
@@ -0,0 +1,224 @@
+package testing
+
+import (
+	"math"
+	"sort"
+	"taski/internal/domain/task"
+	"taski/internal/domain/testing/strategy"
+	"time"
+)
+
+type (
+	// Result is what a finished solution adds to the statistics of its task.
+	Result struct {
+		TaskID task.ID
+		Lang   task.Language
+		// Verdict is the verdict without the test it names.
+		Verdict string
+		// FailedTest is the first test the solution failed, zero when the verdict names none.
+		FailedTest int
+		// ProcessTime is the time from the start of testing to the verdict, nil when testing never started.
+		ProcessTime *time.Duration
+	}
+
+	// TaskStats are the statistics of the finished solutions of a task.
+	TaskStats struct {
+		// Verdicts count the solutions by language and verdict.
+		Verdicts map[task.Language]map[string]int
+		// FailedTests count the solutions by the first test they failed.
+		FailedTests map[int]int
+		// SolveTimes count the accepted solutions by SolveTimeBucket of their process time.
+		SolveTimes map[int]int
+	}
+
+	// LanguageStats are the counters of the solutions of a task in a language.
+	LanguageStats struct {
+		TaskID task.ID
+		Lang   task.Language
+		// Created and Finished count the solutions created and finished.
+		Created  int
+		Finished int
+		// TimedFinished count the finished solutions whose testing started, TotalProcessTime sums their process times.
+		TimedFinished    int
+		TotalProcessTime time.Duration
+	}
+
+	// StatsCounts are the counters of the statistics summed over many solutions, keyed the way they are stored.
+	StatsCounts struct {
+		Created     map[LanguageKey]int
+		Verdicts    map[VerdictKey]VerdictCounts
+		FailedTests map[FailedTestKey]int
+		SolveTimes  map[SolveTimeKey]int
+	}
+
+	LanguageKey struct {
+		TaskID task.ID
+		Lang   task.Language
+	}
+
+	VerdictKey struct {
+		TaskID  task.ID
+		Lang    task.Language
+		Verdict string
+	}
+
+	// VerdictCounts count the solutions with a verdict, and the ones of them whose testing started with their process times.
+	VerdictCounts struct {
+		Solutions      int
+		TimedSolutions int
+		ProcessTime    time.Duration
+	}
+
+	FailedTestKey struct {
+		TaskID task.ID
+		TestID int
+	}
+
+	SolveTimeKey struct {
+		TaskID task.ID
+		Bucket int
+	}
+)
+
+// solveTimeBucketsPerDoubling is how many buckets of the solve times histogram a doubling of the time spans,
+// so a median taken from it is off by less than 10%.
+const solveTimeBucketsPerDoubling = 4
+
+// Result returns what the solution adds to the statistics of its task once it is finished.
+func (sol *Solution) Result() Result {
+	result := Result{
+		TaskID:      sol.TaskID,
+		Lang:        sol.Lang,
+		Verdict:     strategy.VerdictKind(sol.TestingStrategy.GetVerdict()),
+		ProcessTime: sol.ProcessTime(),
+	}
+	if testID, ok := sol.TestingStrategy.GetFailedTest(); ok {
+		result.FailedTest = testID
+	}
+	return result
+}
+
+func NewStatsCounts() StatsCounts {
+	return StatsCounts{
+		Created:     make(map[LanguageKey]int),
+		Verdicts:    make(map[VerdictKey]VerdictCounts),
+		FailedTests: make(map[FailedTestKey]int),
+		SolveTimes:  make(map[SolveTimeKey]int),
+	}
+}
+
+// AddCreated counts a created solution of the task in the language.
+func (c StatsCounts) AddCreated(taskID task.ID, lang task.Language) {
+	c.Created[LanguageKey{TaskID: taskID, Lang: lang}]++
+}
+
+// Add counts the result of a finished solution the way the statistics of its task count it.
+func (c StatsCounts) Add(result Result) {
+	key := VerdictKey{TaskID: result.TaskID, Lang: result.Lang, Verdict: result.Verdict}
+	counts := c.Verdicts[key]
+	counts.Solutions++
+	if result.ProcessTime != nil {
+		counts.TimedSolutions++
+		counts.ProcessTime += result.ProcessTime.Truncate(time.Millisecond)
+	}
+	c.Verdicts[key] = counts
+
+	if result.FailedTest > 0 {
+		c.FailedTests[FailedTestKey{TaskID: result.TaskID, TestID: result.FailedTest}]++
+	}
+	if result.Verdict == strategy.AcceptedVerdict && result.ProcessTime != nil {
+		c.SolveTimes[SolveTimeKey{TaskID: result.TaskID, Bucket: SolveTimeBucket(*result.ProcessTime)}]++
+	}
+}
+
+// SolveTimeBucket returns the bucket of the solve times histogram the time falls into. The buckets are
+// spaced evenly on the log scale from 1ms.
+func SolveTimeBucket(d time.Duration) int {
+	ms := max(float64(d)/float64(time.Millisecond), 1)
+	return int(math.Floor(solveTimeBucketsPerDoubling * math.Log2(ms)))
+}
+
+func solveTimeBucketBound(bucket int) float64 {
+	return math.Exp2(float64(bucket)/solveTimeBucketsPerDoubling) * float64(time.Millisecond)
+}
+
+// Outcomes returns the attempts and the accepted solutions of the task in every language.
+func (s TaskStats) Outcomes() task.Outcomes {
+	var outcomes task.Outcomes
+	for lang := range s.Verdicts {
+		o := s.LanguageOutcomes(lang)
+		outcomes.Attempts += o.Attempts
+		outcomes.Accepted += o.Accepted
+	}
+	return outcomes
+}
+
+// LanguageOutcomes returns the attempts and the accepted solutions of the task in the language.
+// Compilation errors and testing failures are not attempts.
+func (s TaskStats) LanguageOutcomes(lang task.Language) task.Outcomes {
+	var outcomes task.Outcomes
+	for verdict, count := range s.Verdicts[lang] {
+		switch verdict {
+		case strategy.CompilationErrorVerdict, strategy.TestingFailedVerdict:
+			continue
+		case strategy.AcceptedVerdict:
+			outcomes.Accepted += count
+		}
+		outcomes.Attempts += count
+	}
+	return outcomes
+}
+
+// MedianSolveTime returns the median process time of the accepted solutions, interpolated within
+// its bucket of the histogram, and false when none is accepted.
+func (s TaskStats) MedianSolveTime() (time.Duration, bool) {
+	buckets := make([]int, 0, len(s.SolveTimes))
+	total := 0
+	for bucket, count := range s.SolveTimes {
+		buckets = append(buckets, bucket)
+		total += count
+	}
+	if total == 0 {
+		return 0, false
+	}
+	sort.Ints(buckets)
+
+	half := float64(total) / 2
+	seen := 0
+	for _, bucket := range buckets {
+		count := s.SolveTimes[bucket]
+		if float64(seen+count) >= half {
+			lo, hi := solveTimeBucketBound(bucket), solveTimeBucketBound(bucket+1)
+			share := (half - float64(seen)) / float64(count)
+			return time.Duration(lo + (hi-lo)*share), true
+		}
+		seen += count
+	}
+	return 0, false
+}
+
+// AvgProcessTime returns the average process time of the finished solutions whose testing started,
+// and false when there are none.
+func (s LanguageStats) AvgProcessTime() (time.Duration, bool) {
+	if s.TimedFinished == 0 {
+		return 0, false
+	}
+	return s.TotalProcessTime / time.Duration(s.TimedFinished), true
+}
+
+// HardestTests returns up to limit tests that solutions failed first most often, the most failed first.
+func (s TaskStats) HardestTests(limit int) []int {
+	tests := make([]int, 0, len(s.FailedTests))
+	for testID, count := range s.FailedTests {
+		if count > 0 {
+			tests = append(tests, testID)
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if s.FailedTests[tests[i]] != s.FailedTests[tests[j]] {
+			return s.FailedTests[tests[i]] > s.FailedTests[tests[j]]
+		}
+		return tests[i] < tests[j]
+	})
+	return tests[:min(limit, len(tests))]
+}
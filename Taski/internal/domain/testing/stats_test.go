@@ -0,0 +1,101 @@
+package testing
+
+import (
+	"reflect"
+	"taski/internal/domain/task"
+	"taski/internal/domain/testing/job"
+	"taski/internal/domain/testing/strategy"
+	"taski/internal/domain/testing/strategy/strategies"
+	"testing"
+	"time"
+)
+
+func TestSolutionResult(t *testing.T) {
+	t.Parallel()
+
+	verdict := "Wrong Answer on test 3"
+	started := time.Now()
+	finished := started.Add(1500 * time.Millisecond)
+	sol := Solution{
+		Lang: task.LanguageCpp,
+		TestingStrategy: strategies.TestingStrategy{ITestingStrategy: &strategies.WriteCodeTaskTestingStrategy{
+			Details:    strategy.Details{Verdict: &verdict},
+			TestsCount: 4,
+			TestStatus: map[int]job.Status{1: job.StatusOK, 2: job.StatusOK, 3: job.StatusWA, 4: job.StatusTL},
+		}},
+		StartedAt:  &started,
+		FinishedAt: &finished,
+	}
+
+	result := sol.Result()
+	if result.Verdict != strategy.WrongAnswerVerdict || result.FailedTest != 3 {
+		t.Fatalf("Result() = %+v, want Wrong Answer failed on test 3", result)
+	}
+	if result.ProcessTime == nil || *result.ProcessTime != 1500*time.Millisecond {
+		t.Fatalf("Result() process time = %v, want 1.5s", result.ProcessTime)
+	}
+
+	compilationError := strategy.CompilationErrorVerdict
+	sol.TestingStrategy.ITestingStrategy = &strategies.WriteCodeTaskTestingStrategy{
+		Details:    strategy.Details{Verdict: &compilationError},
+		TestsCount: 4,
+		TestStatus: map[int]job.Status{},
+	}
+	if result = sol.Result(); result.FailedTest != 0 {
+		t.Fatalf("Result() of a compilation error failed test = %d, want none", result.FailedTest)
+	}
+}
+
+func TestTaskStats(t *testing.T) {
+	t.Parallel()
+
+	stats := TaskStats{
+		Verdicts: map[task.Language]map[string]int{
+			task.LanguageCpp: {
+				strategy.AcceptedVerdict:         3,
+				strategy.WrongAnswerVerdict:      4,
+				strategy.CompilationErrorVerdict: 5,
+			},
+			task.LanguagePython: {
+				strategy.AcceptedVerdict:      1,
+				strategy.TestingFailedVerdict: 2,
+			},
+		},
+		FailedTests: map[int]int{2: 1, 5: 3, 7: 3, 9: 0},
+		SolveTimes: map[int]int{
+			SolveTimeBucket(100 * time.Millisecond): 1,
+			SolveTimeBucket(time.Second):            2,
+			SolveTimeBucket(10 * time.Second):       1,
+		},
+	}
+
+	if got, want := stats.Outcomes(), (task.Outcomes{Attempts: 8, Accepted: 4}); got != want {
+		t.Fatalf("Outcomes() = %+v, want %+v", got, want)
+	}
+	if got, want := stats.LanguageOutcomes(task.LanguagePython), (task.Outcomes{Attempts: 1, Accepted: 1}); got != want {
+		t.Fatalf("LanguageOutcomes(Python) = %+v, want %+v", got, want)
+	}
+	if got, want := stats.HardestTests(2), []int{5, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("HardestTests(2) = %v, want %v", got, want)
+	}
+
+	median, ok := stats.MedianSolveTime()
+	if !ok || median < 900*time.Millisecond || median > 1100*time.Millisecond {
+		t.Fatalf("MedianSolveTime() = %v, %v, want about 1s", median, ok)
+	}
+	if _, ok = (TaskStats{}).MedianSolveTime(); ok {
+		t.Fatal("MedianSolveTime() without accepted solutions returned a time")
+	}
+}
+
+func TestLanguageStatsAvgProcessTime(t *testing.T) {
+	t.Parallel()
+
+	stats := LanguageStats{Created: 5, Finished: 4, TimedFinished: 3, TotalProcessTime: 6 * time.Second}
+	if avg, ok := stats.AvgProcessTime(); !ok || avg != 2*time.Second {
+		t.Fatalf("AvgProcessTime() = %v, %v, want 2s", avg, ok)
+	}
+	if _, ok := (LanguageStats{Created: 1}).AvgProcessTime(); ok {
+		t.Fatal("AvgProcessTime() without timed solutions returned a time")
+	}
+}
@@ -41,7 +41,7 @@ func NewFindTestTaskTestingStrategy(
 	checkerDef := typedTask.Checker
 	checker := inputs.NewFilestorageBucketInput(taskSource.GetName(), checkerDef.Path)
 	prepareCheckerJobName := strategy.FormatJobName(strategy.PrepareJobFormat, strategy.CheckerCode)
-	prepareCheckerJob, err := strategy.NewPrepareJob(t.GetID(), prepareCheckerJobName, checker, checkerDef.Lang)
+	prepareCheckerJob, err := strategy.NewPrepareJob(t.GetID(), prepareCheckerJobName, checker, checkerDef.Lang, checkerDef.CompileOptions)
 	if err != nil {
 		return ts, fmt.Errorf("failed to prepare checker: %w", err)
 	}
@@ -53,7 +53,7 @@ func NewFindTestTaskTestingStrategy(
 	sourceCodeDef := typedTask.Code
 	sourceCode := inputs.NewFilestorageBucketInput(taskSource.GetName(), sourceCodeDef.Path)
 	prepareSourceCodeJobName := strategy.FormatJobName(strategy.PrepareJobFormat, strategy.SourceCode)
-	prepareSourceCodeJob, err := strategy.NewPrepareJob(t.GetID(), prepareSourceCodeJobName, sourceCode, sourceCodeDef.Lang)
+	prepareSourceCodeJob, err := strategy.NewPrepareJob(t.GetID(), prepareSourceCodeJobName, sourceCode, sourceCodeDef.Lang, sourceCodeDef.CompileOptions)
 	if err != nil {
 		return ts, fmt.Errorf("failed to prepare source code: %w", err)
 	}
@@ -65,7 +65,7 @@ func NewFindTestTaskTestingStrategy(
 	solutionCodeDef := typedTask.Solution
 	solutionCode := inputs.NewFilestorageBucketInput(taskSource.GetName(), solutionCodeDef.Path)
 	prepareSolutionCodeJobName := strategy.FormatJobName(strategy.PrepareJobFormat, strategy.SolutionCode)
-	prepareSolutionCodeJob, err := strategy.NewPrepareJob(t.GetID(), prepareSolutionCodeJobName, solutionCode, solutionCodeDef.Lang)
+	prepareSolutionCodeJob, err := strategy.NewPrepareJob(t.GetID(), prepareSolutionCodeJobName, solutionCode, solutionCodeDef.Lang, solutionCodeDef.CompileOptions)
 	if err != nil {
 		return ts, fmt.Errorf("failed to prepare solution code: %w", err)
 	}
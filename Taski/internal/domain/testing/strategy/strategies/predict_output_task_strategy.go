@@ -41,7 +41,7 @@ func NewPredictOutputTaskTestingStrategy(
 	checkerDef := typedTask.Checker
 	checker := inputs.NewFilestorageBucketInput(taskSource.GetName(), checkerDef.Path)
 	prepareCheckerJobName := strategy.FormatJobName(strategy.PrepareJobFormat, strategy.CheckerCode)
-	prepareCheckerJob, err := strategy.NewPrepareJob(t.GetID(), prepareCheckerJobName, checker, checkerDef.Lang)
+	prepareCheckerJob, err := strategy.NewPrepareJob(t.GetID(), prepareCheckerJobName, checker, checkerDef.Lang, checkerDef.CompileOptions)
 	if err != nil {
 		return ts, fmt.Errorf("failed to prepare checker: %w", err)
 	}
@@ -68,7 +68,7 @@ func NewWriteCodeTaskTestingStrategy(
 	checkerDef := typedTask.Checker
 	checker := inputs.NewFilestorageBucketInput(taskSource.GetName(), checkerDef.Path)
 	prepareCheckerJobName := strategy.FormatJobName(strategy.PrepareJobFormat, strategy.CheckerCode)
-	prepareCheckerJob, err := strategy.NewPrepareJob(t.GetID(), prepareCheckerJobName, checker, checkerDef.Lang)
+	prepareCheckerJob, err := strategy.NewPrepareJob(t.GetID(), prepareCheckerJobName, checker, checkerDef.Lang, checkerDef.CompileOptions)
 	if err != nil {
 		return ts, fmt.Errorf("failed to prepare checker: %w", err)
 	}
@@ -79,7 +79,7 @@ func NewWriteCodeTaskTestingStrategy(
 
 	suspectCode := inputs.NewInlineInput(suspectCodeSource.GetName())
 	prepareSuspectCodeJobName := strategy.FormatJobName(strategy.PrepareJobFormat, strategy.SuspectCode)
-	prepareSuspectCodeJob, err := strategy.NewPrepareJob(t.GetID(), prepareSuspectCodeJobName, suspectCode, lang)
+	prepareSuspectCodeJob, err := strategy.NewPrepareJob(t.GetID(), prepareSuspectCodeJobName, suspectCode, lang, typedTask.Compile[lang])
 	if err != nil {
 		return ts, fmt.Errorf("failed to prepare suspect code: %w", err)
 	}
@@ -104,10 +104,13 @@ func NewWriteCodeTaskTestingStrategy(
 		for _, dep := range stages {
 			deps = append(deps, dep.Name)
 		}
+		// checks of earlier tests wait for their runs, so the batch must not be cut short
+		// by a failure of a later test
 		batchStage := execution.Stage{
-			Name: strategy.FormatStageName(testsStageFormat, from, to),
-			Deps: deps,
-			Jobs: []jobs.Job{},
+			Name:   strategy.FormatStageName(testsStageFormat, from, to),
+			Deps:   deps,
+			Policy: execution.StagePolicyRunAll,
+			Jobs:   []jobs.Job{},
 		}
 
 		for id := from; id <= to; id++ {
@@ -227,6 +230,29 @@ func (ts *WriteCodeTaskTestingStrategy) GetTestingStatus() string {
 	return ts.Details.GetTestingStatus()
 }
 
+// GetFailedTest returns the first test the solution failed once the verdict names it:
+// the tests before it are all checked and passed.
+func (ts *WriteCodeTaskTestingStrategy) GetFailedTest() (int, bool) {
+	if ts.Verdict == nil {
+		return 0, false
+	}
+	switch *ts.Verdict {
+	case strategy.AcceptedVerdict, strategy.CompilationErrorVerdict, strategy.TestingFailedVerdict:
+		return 0, false
+	}
+
+	for testID := 1; testID <= ts.TestsCount; testID++ {
+		status, ok := ts.TestStatus[testID]
+		if !ok {
+			return 0, false
+		}
+		if status != job.StatusOK {
+			return testID, true
+		}
+	}
+	return 0, false
+}
+
 func (ts *WriteCodeTaskTestingStrategy) parseTestID(name job.Name) (int, bool) {
 	matches := testRegex.FindStringSubmatch(strings.ToLower(string(name)))
 	if len(matches) != 2 {
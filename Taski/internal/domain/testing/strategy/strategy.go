@@ -22,6 +22,7 @@ type (
 		GetMessage() *string
 		UpdateJobStatus(name job.Name, status job.Status, msg *string)
 		GetTestingStatus() string
+		GetFailedTest() (int, bool)
 	}
 
 	Details struct {
@@ -66,9 +67,15 @@ const (
 )
 
 var (
-	suspectRegex = regexp.MustCompile(`\s*suspect\s*`)
+	suspectRegex     = regexp.MustCompile(`\s*suspect\s*`)
+	verdictTestRegex = regexp.MustCompile(`\s+on test \d+$`)
 )
 
+// VerdictKind returns the verdict without the test it names, e.g. "Wrong Answer" for "Wrong Answer on test 3".
+func VerdictKind(verdict string) string {
+	return verdictTestRegex.ReplaceAllString(verdict, "")
+}
+
 func (ts *Details) GetTaskType() task.Type {
 	return ts.TaskType
 }
@@ -92,6 +99,11 @@ func (ts *Details) GetVerdict() string {
 	return TestingFailedVerdict
 }
 
+// GetFailedTest returns the test the verdict names, the strategies without tests have none.
+func (ts *Details) GetFailedTest() (int, bool) {
+	return 0, false
+}
+
 func (ts *Details) GetMessage() *string {
 	return ts.Message
 }
@@ -175,20 +187,23 @@ func IsSuspectJob(name job.Name) bool {
 	return suspectRegex.MatchString(strings.ToLower(string(name)))
 }
 
-func NewPrepareJob(taskID task.ID, name job.Name, code inputs.Input, lang task.Language) (*jobs.Job, error) {
+func NewPrepareJob(taskID task.ID, name job.Name,
+	code inputs.Input, lang task.Language, opts task.CompileOptions,
+) (*jobs.Job, error) {
 	compileTimeLimitMs := DefaultCompileTimeLimitMs
 	if name == FormatJobName(PrepareJobFormat, CheckerCode) {
 		compileTimeLimitMs = DefaultCheckerCompileTimeLimitMs
 	}
+	compileOptions := job.CompileOptions{Toolchain: opts.Toolchain, Flags: opts.Flags}
 
 	switch lang {
 	case task.LanguageCpp:
 		categoryName := makeCategoryName(taskID, name, job.CompileCpp)
-		jb := jobs.NewCompileCppJob(name, categoryName, compileTimeLimitMs, DefaultCompileMemoryLimitMb, code)
+		jb := jobs.NewCompileCppJob(name, categoryName, compileTimeLimitMs, DefaultCompileMemoryLimitMb, code, compileOptions)
 		return &jb, nil
 	case task.LanguageGo:
 		categoryName := makeCategoryName(taskID, name, job.CompileGo)
-		jb := jobs.NewCompileGoJob(name, categoryName, compileTimeLimitMs, DefaultCompileMemoryLimitMb, code)
+		jb := jobs.NewCompileGoJob(name, categoryName, compileTimeLimitMs, DefaultCompileMemoryLimitMb, code, compileOptions)
 		return &jb, nil
 	case task.LanguagePython:
 		return nil, nil
@@ -0,0 +1,164 @@
+package markup
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// The placeholders of the formulas are private use characters, which Markdown leaves as they are.
+const (
+	formulaOpen  = "\uE000"
+	formulaClose = "\uE001"
+)
+
+type formula struct {
+	src     string
+	display bool
+}
+
+var (
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		// the raw HTML of the statement is kept, Sanitize drops whatever is unsafe in it
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+
+	formulaPlaceholderRe = regexp.MustCompile(`\x{E000}([0-9]+)\x{E001}`)
+)
+
+// Markdown renders the Markdown statement with LaTeX math in $...$ and $$...$$ into sanitised HTML.
+func Markdown(src string) (string, error) {
+	text, formulas := extractFormulas(src)
+
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(text), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return Sanitize(restoreFormulas(buf.String(), formulas)), nil
+}
+
+// extractFormulas replaces the formulas outside the code with placeholders,
+// so that Markdown does not take their underscores and asterisks for emphasis.
+func extractFormulas(src string) (string, []formula) {
+	formulas := make([]formula, 0)
+	var b strings.Builder
+
+	lines := strings.SplitAfter(src, "\n")
+	fence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			b.WriteString(line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			b.WriteString(line)
+			continue
+		}
+
+		// a paragraph is handled at once, since a formula may take several of its lines
+		paragraph := line
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && strings.TrimSpace(line) != "" &&
+			!strings.HasPrefix(strings.TrimSpace(lines[i+1]), "```") && !strings.HasPrefix(strings.TrimSpace(lines[i+1]), "~~~") {
+			i++
+			paragraph += lines[i]
+		}
+		b.WriteString(replaceFormulas(paragraph, &formulas))
+	}
+	return b.String(), formulas
+}
+
+func replaceFormulas(text string, formulas *[]formula) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '\\' && i+1 < len(text):
+			b.WriteString(text[i : i+2])
+			i += 2
+		case text[i] == '`':
+			// a code span is kept as it is up to the closing run of the same length
+			run := 0
+			for i+run < len(text) && text[i+run] == '`' {
+				run++
+			}
+			end := strings.Index(text[i+run:], strings.Repeat("`", run))
+			if end < 0 {
+				b.WriteString(text[i : i+run])
+				i += run
+				continue
+			}
+			b.WriteString(text[i : i+run+end+run])
+			i += run + end + run
+		case strings.HasPrefix(text[i:], "$$"):
+			end := strings.Index(text[i+2:], "$$")
+			if end < 0 {
+				b.WriteString("$$")
+				i += 2
+				continue
+			}
+			b.WriteString(addFormula(formulas, text[i+2:i+2+end], true))
+			i += 2 + end + 2
+		case text[i] == '$':
+			end := closingDollar(text, i+1)
+			if end < 0 {
+				b.WriteByte('$')
+				i++
+				continue
+			}
+			b.WriteString(addFormula(formulas, text[i+1:end], false))
+			i = end + 1
+		default:
+			b.WriteByte(text[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// closingDollar finds the end of an inline formula starting at from. Like in pandoc, the formula
+// may not start or end with a space and the closing dollar may not be followed by a digit,
+// so that the prices like $5 and $10 stay text.
+func closingDollar(text string, from int) int {
+	if from >= len(text) || text[from] == ' ' || text[from] == '\n' {
+		return -1
+	}
+	for i := from; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '$':
+			if text[i-1] == ' ' || (i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9') {
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func addFormula(formulas *[]formula, src string, display bool) string {
+	*formulas = append(*formulas, formula{src: src, display: display})
+	return formulaOpen + strconv.Itoa(len(*formulas)-1) + formulaClose
+}
+
+func restoreFormulas(rendered string, formulas []formula) string {
+	return formulaPlaceholderRe.ReplaceAllStringFunc(rendered, func(placeholder string) string {
+		i, err := strconv.Atoi(formulaPlaceholderRe.FindStringSubmatch(placeholder)[1])
+		if err != nil || i >= len(formulas) {
+			return ""
+		}
+		return Math(formulas[i].src, formulas[i].display)
+	})
+}
@@ -0,0 +1,133 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		display bool
+		want    string
+	}{
+		{name: "identifier", src: "n", want: "<math><mi>n</mi></math>"},
+		{name: "subscript", src: "a_1", want: "<math><msub><mi>a</mi><mn>1</mn></msub></math>"},
+		{
+			name: "fraction",
+			src:  `\frac{x}{2}`,
+			want: "<math><mfrac><mi>x</mi><mn>2</mn></mfrac></math>",
+		},
+		{
+			name:    "display",
+			src:     `1 \le n`,
+			display: true,
+			want:    `<math display="block"><mrow><mn>1</mn><mo>≤</mo><mi>n</mi></mrow></math>`,
+		},
+		{name: "escaped text", src: "a < b", want: "<math><mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow></math>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Math(tt.src, tt.display); got != tt.want {
+				t.Fatalf("Math(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "formula with underscores",
+			src:  "Print $a_i * b_i$ for *every* $i$.",
+			want: []string{"<msub><mi>a</mi><mi>i</mi></msub>", "<em>every</em>", "<math><mi>i</mi></math>"},
+		},
+		{
+			name:    "formula in code",
+			src:     "Call `f($x$)`.",
+			want:    []string{"<code>f($x$)</code>"},
+			notWant: []string{"<math>"},
+		},
+		{
+			name:    "prices",
+			src:     "It costs $5 or $10.",
+			want:    []string{"$5 or $10"},
+			notWant: []string{"<math>"},
+		},
+		{
+			name:    "script",
+			src:     "Hello <script>alert(1)</script><a href=\"javascript:alert(1)\">link</a>",
+			notWant: []string{"<script", "alert", "javascript:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Markdown(tt.src)
+			if err != nil {
+				t.Fatalf("Markdown(%q) returned error: %v", tt.src, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Fatalf("Markdown(%q) = %q, want it to contain %q", tt.src, got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Fatalf("Markdown(%q) = %q, want it not to contain %q", tt.src, got, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestTeX(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "paragraphs", src: "First.\n\nSecond\nline.", want: "<p>First.</p>\n<p>Second line.</p>"},
+		{name: "formatting", src: `\textbf{bold} and \emph{em}`, want: "<p><b>bold</b> and <em>em</em></p>"},
+		{name: "math", src: `Given $n$.`, want: "<p>Given <math><mi>n</mi></math>.</p>"},
+		{name: "dashes and ties", src: "a---b~c", want: "<p>a—b\u00a0c</p>"},
+		{
+			name: "itemize",
+			src:  "\\begin{itemize}\n\\item one\n\\item two\n\\end{itemize}",
+			want: "<ul><li>one</li><li>two</li></ul>",
+		},
+		{name: "escaped text", src: `a < b`, want: "<p>a &lt; b</p>"},
+		{
+			name: "illustration",
+			src:  `\illustration{0.3}{pic.png}{A picture}`,
+			want: `<div class="illustration"><img src="pic.png"><br>A picture</div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := TeX(tt.src); got != tt.want {
+				t.Fatalf("TeX(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
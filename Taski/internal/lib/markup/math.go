@@ -0,0 +1,596 @@
+package markup
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+type (
+	// mathParser converts the LaTeX math used in statements to MathML, which browsers render without scripts.
+	// It covers the formulas of programming problems; an unknown command is rendered as an error
+	// rather than failing the whole statement.
+	mathParser struct {
+		src []rune
+		pos int
+	}
+
+	mathSymbol struct {
+		text string
+		tag  string
+	}
+)
+
+const (
+	mathIdentifier = "mi"
+	mathOperator   = "mo"
+)
+
+var mathSymbols = map[string]mathSymbol{
+	"alpha": {"α", mathIdentifier}, "beta": {"β", mathIdentifier}, "gamma": {"γ", mathIdentifier},
+	"delta": {"δ", mathIdentifier}, "epsilon": {"ϵ", mathIdentifier}, "varepsilon": {"ε", mathIdentifier},
+	"zeta": {"ζ", mathIdentifier}, "eta": {"η", mathIdentifier}, "theta": {"θ", mathIdentifier},
+	"iota": {"ι", mathIdentifier}, "kappa": {"κ", mathIdentifier}, "lambda": {"λ", mathIdentifier},
+	"mu": {"μ", mathIdentifier}, "nu": {"ν", mathIdentifier}, "xi": {"ξ", mathIdentifier},
+	"pi": {"π", mathIdentifier}, "rho": {"ρ", mathIdentifier}, "sigma": {"σ", mathIdentifier},
+	"tau": {"τ", mathIdentifier}, "upsilon": {"υ", mathIdentifier}, "phi": {"ϕ", mathIdentifier},
+	"varphi": {"φ", mathIdentifier}, "chi": {"χ", mathIdentifier}, "psi": {"ψ", mathIdentifier},
+	"omega": {"ω", mathIdentifier}, "Gamma": {"Γ", mathIdentifier}, "Delta": {"Δ", mathIdentifier},
+	"Theta": {"Θ", mathIdentifier}, "Lambda": {"Λ", mathIdentifier}, "Xi": {"Ξ", mathIdentifier},
+	"Pi": {"Π", mathIdentifier}, "Sigma": {"Σ", mathIdentifier}, "Phi": {"Φ", mathIdentifier},
+	"Psi": {"Ψ", mathIdentifier}, "Omega": {"Ω", mathIdentifier},
+	"infty": {"∞", mathIdentifier}, "emptyset": {"∅", mathIdentifier}, "varnothing": {"∅", mathIdentifier},
+	"partial": {"∂", mathIdentifier}, "nabla": {"∇", mathIdentifier}, "ell": {"ℓ", mathIdentifier},
+
+	"le": {"≤", mathOperator}, "leq": {"≤", mathOperator}, "leqslant": {"⩽", mathOperator},
+	"ge": {"≥", mathOperator}, "geq": {"≥", mathOperator}, "geqslant": {"⩾", mathOperator},
+	"ne": {"≠", mathOperator}, "neq": {"≠", mathOperator}, "approx": {"≈", mathOperator},
+	"equiv": {"≡", mathOperator}, "sim": {"∼", mathOperator}, "simeq": {"≃", mathOperator},
+	"ll": {"≪", mathOperator}, "gg": {"≫", mathOperator}, "lt": {"<", mathOperator}, "gt": {">", mathOperator},
+	"cdot": {"⋅", mathOperator}, "times": {"×", mathOperator}, "div": {"÷", mathOperator},
+	"pm": {"±", mathOperator}, "mp": {"∓", mathOperator}, "ast": {"∗", mathOperator}, "star": {"⋆", mathOperator},
+	"circ": {"∘", mathOperator}, "bullet": {"∙", mathOperator},
+	"oplus": {"⊕", mathOperator}, "otimes": {"⊗", mathOperator}, "ominus": {"⊖", mathOperator},
+	"land": {"∧", mathOperator}, "wedge": {"∧", mathOperator}, "lor": {"∨", mathOperator}, "vee": {"∨", mathOperator},
+	"neg": {"¬", mathOperator}, "lnot": {"¬", mathOperator},
+	"in": {"∈", mathOperator}, "notin": {"∉", mathOperator}, "ni": {"∋", mathOperator},
+	"subset": {"⊂", mathOperator}, "subseteq": {"⊆", mathOperator}, "supset": {"⊃", mathOperator},
+	"supseteq": {"⊇", mathOperator}, "cup": {"∪", mathOperator}, "cap": {"∩", mathOperator},
+	"setminus": {"∖", mathOperator}, "backslash": {"\\", mathOperator},
+	"to": {"→", mathOperator}, "rightarrow": {"→", mathOperator}, "leftarrow": {"←", mathOperator},
+	"gets": {"←", mathOperator}, "leftrightarrow": {"↔", mathOperator}, "Rightarrow": {"⇒", mathOperator},
+	"Leftarrow": {"⇐", mathOperator}, "Leftrightarrow": {"⇔", mathOperator}, "implies": {"⟹", mathOperator},
+	"iff": {"⟺", mathOperator}, "mapsto": {"↦", mathOperator},
+	"forall": {"∀", mathOperator}, "exists": {"∃", mathOperator}, "mid": {"∣", mathOperator},
+	"nmid": {"∤", mathOperator}, "parallel": {"∥", mathOperator}, "perp": {"⊥", mathOperator},
+	"angle": {"∠", mathOperator}, "prime": {"′", mathOperator},
+	"ldots": {"…", mathOperator}, "dots": {"…", mathOperator}, "cdots": {"⋯", mathOperator},
+	"vdots": {"⋮", mathOperator}, "ddots": {"⋱", mathOperator},
+	"lfloor": {"⌊", mathOperator}, "rfloor": {"⌋", mathOperator}, "lceil": {"⌈", mathOperator},
+	"rceil": {"⌉", mathOperator}, "langle": {"⟨", mathOperator}, "rangle": {"⟩", mathOperator},
+	"vert": {"|", mathOperator}, "Vert": {"‖", mathOperator},
+	"{": {"{", mathOperator}, "}": {"}", mathOperator}, "|": {"‖", mathOperator},
+	"%": {"%", mathOperator}, "#": {"#", mathOperator}, "&": {"&", mathOperator},
+	"$": {"$", mathOperator}, "_": {"_", mathOperator},
+	"sum": {"∑", mathOperator}, "prod": {"∏", mathOperator}, "coprod": {"∐", mathOperator},
+	"int": {"∫", mathOperator}, "oint": {"∮", mathOperator}, "bigcup": {"⋃", mathOperator},
+	"bigcap": {"⋂", mathOperator}, "bigoplus": {"⨁", mathOperator}, "bigotimes": {"⨂", mathOperator},
+	"bigvee": {"⋁", mathOperator}, "bigwedge": {"⋀", mathOperator},
+}
+
+// mathLimitOperators take their scripts under and over them in display math.
+var mathLimitOperators = map[string]bool{
+	"sum": true, "prod": true, "coprod": true, "bigcup": true, "bigcap": true,
+	"bigoplus": true, "bigotimes": true, "bigvee": true, "bigwedge": true,
+	"lim": true, "max": true, "min": true, "sup": true, "inf": true,
+	"limsup": true, "liminf": true, "gcd": true, "det": true, "Pr": true,
+}
+
+var mathFunctions = map[string]string{
+	"log": "log", "ln": "ln", "lg": "lg", "exp": "exp", "sin": "sin", "cos": "cos", "tan": "tan",
+	"cot": "cot", "arcsin": "arcsin", "arccos": "arccos", "arctan": "arctan", "sinh": "sinh", "cosh": "cosh",
+	"deg": "deg", "dim": "dim", "ker": "ker", "arg": "arg",
+	"lim": "lim", "max": "max", "min": "min", "sup": "sup", "inf": "inf", "limsup": "lim sup",
+	"liminf": "lim inf", "gcd": "gcd", "det": "det", "Pr": "Pr", "bmod": "mod",
+}
+
+var mathSpaces = map[string]string{
+	",": "0.167em", ":": "0.222em", ">": "0.222em", ";": "0.278em", " ": "0.25em",
+	"quad": "1em", "qquad": "2em", "enspace": "0.5em", "thinspace": "0.167em",
+}
+
+var mathAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "¯", "overline": "¯", "tilde": "~", "widetilde": "~",
+	"vec": "→", "overrightarrow": "→", "dot": "˙", "ddot": "¨",
+}
+
+var mathDoubleStruck = map[rune]string{
+	'N': "ℕ", 'Z': "ℤ", 'Q': "ℚ", 'R': "ℝ", 'C': "ℂ", 'P': "ℙ",
+}
+
+var mathVariants = map[string]string{
+	"mathrm": "normal", "operatorname": "normal", "mathbf": "bold", "mathit": "italic",
+	"mathcal": "script", "mathscr": "script", "mathsf": "sans-serif", "mathtt": "monospace",
+	"mathbb": "double-struck", "boldsymbol": "bold",
+}
+
+var mathTextCommands = map[string]bool{
+	"text": true, "textrm": true, "textit": true, "textbf": true, "texttt": true, "mbox": true, "textnormal": true,
+}
+
+// mathIgnored are the commands changing the look of the formula in ways the browser decides on its own.
+var mathIgnored = map[string]bool{
+	"displaystyle": true, "textstyle": true, "scriptstyle": true, "limits": true, "nolimits": true,
+	"big": true, "Big": true, "bigg": true, "Bigg": true,
+	"bigl": true, "bigr": true, "Bigl": true, "Bigr": true, "!": true,
+}
+
+// Math renders the LaTeX math formula as MathML, in a block of its own when display is set.
+func Math(src string, display bool) string {
+	p := &mathParser{src: []rune(src)}
+	body := p.parseRow("")
+	for !p.eof() {
+		// an unbalanced closing brace or \right, the rest of the formula still goes on
+		if p.peekCommand("right") {
+			p.pos++
+			p.readCommandName()
+			p.readDelimiter()
+		} else {
+			p.pos++
+		}
+		body = append(body, p.parseRow("")...)
+	}
+
+	var b strings.Builder
+	if display {
+		b.WriteString(`<math display="block">`)
+	} else {
+		b.WriteString(`<math>`)
+	}
+	b.WriteString(wrapRow(body))
+	b.WriteString(`</math>`)
+	return b.String()
+}
+
+// parseRow parses the items up to the closing brace, the end of the environment or the end of the formula.
+// The terminator itself is not consumed.
+func (p *mathParser) parseRow(env string) []string {
+	items := make([]string, 0)
+	for {
+		p.skipSpaces()
+		if p.eof() || p.peek() == '}' {
+			return items
+		}
+		if env != "" && (p.peekCommand("end") || p.peek() == '&' || p.peekString(`\\`)) {
+			return items
+		}
+		if p.peekCommand("right") {
+			return items
+		}
+
+		item, limits := p.parseAtom(env)
+		if item == "" {
+			continue
+		}
+		items = append(items, p.parseScripts(item, limits))
+	}
+}
+
+// parseScripts attaches the sub- and superscripts following the item.
+func (p *mathParser) parseScripts(item string, limits bool) string {
+	var sub, sup string
+	for {
+		p.skipSpaces()
+		switch {
+		case p.peek() == '_' && sub == "":
+			p.pos++
+			sub = p.parseArgument()
+		case p.peek() == '^' && sup == "":
+			p.pos++
+			sup = p.parseArgument()
+		case p.peek() == '\'' && sup == "":
+			primes := ""
+			for p.peek() == '\'' {
+				primes += "′"
+				p.pos++
+			}
+			sup = "<mo>" + primes + "</mo>"
+		default:
+			switch {
+			case sub == "" && sup == "":
+				return item
+			case limits && sup == "":
+				return "<munder>" + item + sub + "</munder>"
+			case limits && sub == "":
+				return "<mover>" + item + sup + "</mover>"
+			case limits:
+				return "<munderover>" + item + sub + sup + "</munderover>"
+			case sup == "":
+				return "<msub>" + item + sub + "</msub>"
+			case sub == "":
+				return "<msup>" + item + sup + "</msup>"
+			default:
+				return "<msubsup>" + item + sub + sup + "</msubsup>"
+			}
+		}
+	}
+}
+
+// parseArgument parses a braced group or a single atom as one MathML element.
+func (p *mathParser) parseArgument() string {
+	p.skipSpaces()
+	if p.eof() {
+		return "<mrow></mrow>"
+	}
+	if p.peek() == '{' {
+		p.pos++
+		items := p.parseRow("")
+		p.expect('}')
+		return wrapRow(items)
+	}
+	item, _ := p.parseAtom("")
+	if item == "" {
+		return "<mrow></mrow>"
+	}
+	return item
+}
+
+// parseAtom parses one item and tells whether its scripts go under and over it.
+func (p *mathParser) parseAtom(env string) (string, bool) {
+	r := p.next()
+	switch {
+	case r == '{':
+		items := p.parseRow("")
+		p.expect('}')
+		return wrapRow(items), false
+	case r == '\\':
+		return p.parseCommand(env)
+	case unicode.IsDigit(r) || (r == '.' && unicode.IsDigit(p.peek())):
+		start := p.pos - 1
+		for unicode.IsDigit(p.peek()) || (p.peek() == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(p.src[p.pos+1])) {
+			p.pos++
+		}
+		return element("mn", string(p.src[start:p.pos])), false
+	case unicode.IsLetter(r):
+		return element("mi", string(r)), false
+	case r == '~':
+		return `<mspace width="0.25em"></mspace>`, false
+	case r == '-':
+		return element("mo", "−"), false
+	case r == '*':
+		return element("mo", "∗"), false
+	case r == '^' || r == '_':
+		// a script without a base
+		return p.parseScripts("<mrow></mrow>", false), false
+	default:
+		return element("mo", string(r)), false
+	}
+}
+
+func (p *mathParser) parseCommand(env string) (string, bool) {
+	name := p.readCommandName()
+	if mathIgnored[name] {
+		return "", false
+	}
+
+	if symbol, ok := mathSymbols[name]; ok {
+		return element(symbol.tag, symbol.text), mathLimitOperators[name]
+	}
+	if text, ok := mathFunctions[name]; ok {
+		if mathLimitOperators[name] {
+			return `<mo movablelimits="true">` + html.EscapeString(text) + `</mo>`, true
+		}
+		return element("mi", text), false
+	}
+	if width, ok := mathSpaces[name]; ok {
+		return `<mspace width="` + width + `"></mspace>`, false
+	}
+	if accent, ok := mathAccents[name]; ok {
+		return `<mover accent="true">` + p.parseArgument() + element("mo", accent) + `</mover>`, false
+	}
+	if variant, ok := mathVariants[name]; ok {
+		return p.parseVariant(variant), false
+	}
+	if mathTextCommands[name] {
+		return element("mtext", p.readRawGroup()), false
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num := p.parseArgument()
+		den := p.parseArgument()
+		return "<mfrac>" + num + den + "</mfrac>", false
+	case "binom", "dbinom", "tbinom":
+		top := p.parseArgument()
+		bottom := p.parseArgument()
+		return `<mrow><mo>(</mo><mfrac linethickness="0">` + top + bottom + `</mfrac><mo>)</mo></mrow>`, false
+	case "sqrt":
+		p.skipSpaces()
+		if p.peek() == '[' {
+			p.pos++
+			index := p.readUntil(']')
+			radicand := p.parseArgument()
+			return "<mroot>" + radicand + (&mathParser{src: []rune(index)}).rootIndex() + "</mroot>", false
+		}
+		return "<msqrt>" + p.parseArgument() + "</msqrt>", false
+	case "underline":
+		return `<munder accentunder="true">` + p.parseArgument() + element("mo", "_") + `</munder>`, false
+	case "pmod":
+		return "<mrow><mo>(</mo><mi>mod</mi><mspace width=\"0.333em\"></mspace>" + p.parseArgument() + "<mo>)</mo></mrow>", false
+	case "left":
+		return p.parseFenced(), false
+	case "begin":
+		return p.parseEnvironment(), false
+	case "color", "textcolor", "label":
+		p.readRawGroup()
+		return "", false
+	}
+
+	if name == "\\" {
+		return "", false
+	}
+	return "<merror><mtext>\\" + html.EscapeString(name) + "</mtext></merror>", false
+}
+
+func (p *mathParser) parseVariant(variant string) string {
+	raw := strings.TrimSpace(p.readRawGroup())
+	if variant == "double-struck" {
+		var b strings.Builder
+		for _, r := range raw {
+			if ds, ok := mathDoubleStruck[r]; ok {
+				b.WriteString(ds)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+		return element("mi", b.String())
+	}
+	if isPlainWord(raw) {
+		return `<mi mathvariant="` + variant + `">` + html.EscapeString(raw) + `</mi>`
+	}
+	items := (&mathParser{src: []rune(raw)}).parseRow("")
+	return wrapRow(items)
+}
+
+func (p *mathParser) parseFenced() string {
+	open := p.readDelimiter()
+	items := p.parseRow("")
+	closing := ""
+	if p.peekCommand("right") {
+		p.pos++
+		p.readCommandName()
+		closing = p.readDelimiter()
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	if open != "" {
+		b.WriteString(`<mo fence="true" stretchy="true">` + html.EscapeString(open) + `</mo>`)
+	}
+	b.WriteString(strings.Join(items, ""))
+	if closing != "" {
+		b.WriteString(`<mo fence="true" stretchy="true">` + html.EscapeString(closing) + `</mo>`)
+	}
+	b.WriteString("</mrow>")
+	return b.String()
+}
+
+func (p *mathParser) readDelimiter() string {
+	p.skipSpaces()
+	if p.eof() {
+		return ""
+	}
+	r := p.next()
+	switch r {
+	case '.':
+		return ""
+	case '\\':
+		name := p.readCommandName()
+		if symbol, ok := mathSymbols[name]; ok {
+			return symbol.text
+		}
+		return ""
+	default:
+		return string(r)
+	}
+}
+
+// parseEnvironment renders the tables: matrices, cases and aligned formulas.
+func (p *mathParser) parseEnvironment() string {
+	name := strings.TrimSuffix(p.readRawGroup(), "*")
+	if name == "array" {
+		p.readRawGroup()
+	}
+
+	rows := make([][]string, 0)
+	row := make([]string, 0)
+	for {
+		cell := p.parseRow(name)
+		row = append(row, wrapRow(cell))
+		switch {
+		case p.peek() == '&':
+			p.pos++
+		case p.peekString(`\\`):
+			p.pos += 2
+			rows = append(rows, row)
+			row = make([]string, 0)
+		default:
+			if p.peekCommand("end") {
+				p.pos++
+				p.readCommandName()
+				p.readRawGroup()
+			}
+			if len(row) > 1 || row[0] != "<mrow></mrow>" {
+				rows = append(rows, row)
+			}
+			return renderEnvironment(name, rows)
+		}
+	}
+}
+
+func renderEnvironment(name string, rows [][]string) string {
+	columnAlign := ""
+	switch name {
+	case "cases":
+		columnAlign = ` columnalign="left"`
+	case "aligned", "align", "split", "gathered":
+		columnAlign = ` columnalign="right left"`
+	}
+
+	var b strings.Builder
+	b.WriteString("<mtable" + columnAlign + ">")
+	for _, row := range rows {
+		b.WriteString("<mtr>")
+		for _, cell := range row {
+			b.WriteString("<mtd>" + cell + "</mtd>")
+		}
+		b.WriteString("</mtr>")
+	}
+	b.WriteString("</mtable>")
+	table := b.String()
+
+	switch name {
+	case "cases":
+		return `<mrow><mo fence="true" stretchy="true">{</mo>` + table + `</mrow>`
+	case "pmatrix":
+		return `<mrow><mo fence="true">(</mo>` + table + `<mo fence="true">)</mo></mrow>`
+	case "bmatrix":
+		return `<mrow><mo fence="true">[</mo>` + table + `<mo fence="true">]</mo></mrow>`
+	case "vmatrix":
+		return `<mrow><mo fence="true">|</mo>` + table + `<mo fence="true">|</mo></mrow>`
+	default:
+		return table
+	}
+}
+
+func (p *mathParser) rootIndex() string {
+	return wrapRow(p.parseRow(""))
+}
+
+func (p *mathParser) readCommandName() string {
+	if p.eof() {
+		return ""
+	}
+	start := p.pos
+	if !unicode.IsLetter(p.peek()) {
+		p.pos++
+		return string(p.src[start:p.pos])
+	}
+	for !p.eof() && unicode.IsLetter(p.peek()) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// readRawGroup returns the text of the braced group as it is, or of the next character without braces.
+func (p *mathParser) readRawGroup() string {
+	p.skipSpaces()
+	if p.eof() {
+		return ""
+	}
+	if p.peek() != '{' {
+		return string(p.next())
+	}
+	p.pos++
+	start := p.pos
+	depth := 1
+	for !p.eof() {
+		switch p.next() {
+		case '\\':
+			if !p.eof() {
+				p.pos++
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return string(p.src[start : p.pos-1])
+			}
+		}
+	}
+	return string(p.src[start:])
+}
+
+func (p *mathParser) readUntil(end rune) string {
+	start := p.pos
+	for !p.eof() && p.peek() != end {
+		p.pos++
+	}
+	text := string(p.src[start:p.pos])
+	p.expect(end)
+	return text
+}
+
+func (p *mathParser) expect(r rune) {
+	if !p.eof() && p.peek() == r {
+		p.pos++
+	}
+}
+
+func (p *mathParser) skipSpaces() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *mathParser) peekCommand(name string) bool {
+	if !p.peekString(`\` + name) {
+		return false
+	}
+	end := p.pos + 1 + len([]rune(name))
+	return end >= len(p.src) || !unicode.IsLetter(p.src[end])
+}
+
+func (p *mathParser) peekString(s string) bool {
+	runes := []rune(s)
+	if p.pos+len(runes) > len(p.src) {
+		return false
+	}
+	for i, r := range runes {
+		if p.src[p.pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *mathParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *mathParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	return r
+}
+
+func (p *mathParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func element(tag, text string) string {
+	return "<" + tag + ">" + html.EscapeString(text) + "</" + tag + ">"
+}
+
+func wrapRow(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return "<mrow>" + strings.Join(items, "") + "</mrow>"
+}
+
+func isPlainWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' {
+			return false
+		}
+	}
+	return true
+}
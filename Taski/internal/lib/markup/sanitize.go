@@ -0,0 +1,45 @@
+package markup
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+var mathElements = []string{
+	"math", "mrow", "mi", "mn", "mo", "mtext", "mspace", "msub", "msup", "msubsup",
+	"mfrac", "msqrt", "mroot", "mover", "munder", "munderover", "mtable", "mtr", "mtd", "merror",
+}
+
+var (
+	policyOnce sync.Once
+	policy     *bluemonday.Policy
+)
+
+// Sanitize keeps the markup a statement may have: the formatting, links, images, tables and math,
+// and drops the rest, so that a statement cannot run scripts in the browser of the reader.
+func Sanitize(html string) string {
+	policyOnce.Do(func() {
+		policy = newPolicy()
+	})
+	return policy.Sanitize(html)
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowElements("div", "span", "section", "center")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9 _-]+$`)).OnElements("div", "span", "pre", "code", "p", "h1")
+
+	// the elements without attributes are dropped unless they are allowed to have none
+	p.AllowNoAttrs().OnElements(mathElements...)
+	p.AllowAttrs("display").Matching(regexp.MustCompile(`^(block|inline)$`)).OnElements("math")
+	p.AllowAttrs("mathvariant").Matching(regexp.MustCompile(`^[a-z-]+$`)).OnElements("mi")
+	p.AllowAttrs("fence", "stretchy", "movablelimits").Matching(regexp.MustCompile(`^(true|false)$`)).OnElements("mo")
+	p.AllowAttrs("accent").Matching(regexp.MustCompile(`^(true|false)$`)).OnElements("mover")
+	p.AllowAttrs("accentunder").Matching(regexp.MustCompile(`^(true|false)$`)).OnElements("munder")
+	p.AllowAttrs("width").Matching(regexp.MustCompile(`^[0-9.]+em$`)).OnElements("mspace")
+	p.AllowAttrs("linethickness").Matching(regexp.MustCompile(`^[0-9.]+$`)).OnElements("mfrac")
+	p.AllowAttrs("columnalign").Matching(regexp.MustCompile(`^(left|right|center)( (left|right|center))*$`)).OnElements("mtable")
+	return p
+}
@@ -0,0 +1,413 @@
+package markup
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+type texConverter struct {
+	src []rune
+	pos int
+}
+
+// The markers are put in place of the paragraph breaks and the list items while converting
+// and are turned into the tags once the enclosing block is known.
+const (
+	texParagraph = "\uE002"
+	texItem      = "\uE003"
+)
+
+var texSymbols = map[string]string{
+	"%": "%", "$": "$", "&": "&", "#": "#", "_": "_", "{": "{", "}": "}", " ": " ", ",": "\u2009",
+	"ldots": "…", "dots": "…", "LaTeX": "LaTeX", "TeX": "TeX", "textless": "<", "textgreater": ">",
+	"textbackslash": "\\", "quad": "\u2003", "qquad": "\u2003\u2003", "ge": "≥", "le": "≤",
+	"textasciitilde": "~", "textasciicircum": "^", "textbar": "|", "copyright": "©", "S": "§",
+	"glqq": "„", "grqq": "“", "guillemotleft": "«", "guillemotright": "»", "-": "",
+}
+
+var texInlineTags = map[string]string{
+	"textbf": "b", "bf": "b", "textit": "i", "it": "i", "emph": "em", "textsl": "i",
+	"texttt": "code", "tt": "code", "t": "code", "underline": "u", "sout": "s",
+}
+
+var texHeadings = map[string]string{
+	"section": "h3", "subsection": "h4", "subsubsection": "h5", "paragraph": "b",
+}
+
+// texIgnored are the commands without output along with the number of their braced arguments.
+var texIgnored = map[string]int{
+	"noindent": 0, "smallskip": 0, "medskip": 0, "bigskip": 0, "hfill": 0, "vfill": 0, "centering": 0,
+	"small": 0, "large": 0, "Large": 0, "normalsize": 0, "footnotesize": 0, "hline": 0, "clearpage": 0,
+	"newpage": 0, "label": 1, "vspace": 1, "hspace": 1, "exmp": 2, "exmpfile": 2, "epigraph": 2,
+}
+
+// TeX renders the LaTeX text of a statement section, like the Polygon statement sections are written in,
+// into sanitised HTML. The math goes to MathML, the text formatting, lists, tables and images go to HTML.
+func TeX(src string) string {
+	c := &texConverter{src: []rune(src)}
+	return Sanitize(paragraphs(c.convert("")))
+}
+
+// convert converts the text up to the terminator: "}" for a group, "end" for an environment or "" for the whole text.
+func (c *texConverter) convert(until string) string {
+	var b strings.Builder
+	for !c.eof() {
+		r := c.peek()
+		switch {
+		case until == "}" && r == '}':
+			return b.String()
+		case until == "end" && c.peekCommand("end"):
+			return b.String()
+		case r == '%':
+			for !c.eof() && c.peek() != '\n' {
+				c.pos++
+			}
+		case r == '\n':
+			c.pos++
+			blank := false
+			for !c.eof() && (c.peek() == '\n' || c.peek() == ' ' || c.peek() == '\t') {
+				if c.next() == '\n' {
+					blank = true
+				}
+			}
+			if blank {
+				b.WriteString(texParagraph)
+			} else {
+				b.WriteByte(' ')
+			}
+		case c.peekString("$$"):
+			c.pos += 2
+			b.WriteString(Math(c.readUntil("$$"), true))
+		case r == '$':
+			c.pos++
+			b.WriteString(Math(c.readUntil("$"), false))
+		case c.peekString(`\[`):
+			c.pos += 2
+			b.WriteString(Math(c.readUntil(`\]`), true))
+		case c.peekString(`\(`):
+			c.pos += 2
+			b.WriteString(Math(c.readUntil(`\)`), false))
+		case c.peekString(`\\`):
+			c.pos += 2
+			b.WriteString("<br>")
+		case r == '\\':
+			c.pos++
+			b.WriteString(c.convertCommand())
+		case r == '{':
+			c.pos++
+			b.WriteString(c.convert("}"))
+			c.expect('}')
+		case r == '}':
+			c.pos++
+		case r == '~':
+			c.pos++
+			b.WriteString("\u00a0")
+		case c.peekString("---"):
+			c.pos += 3
+			b.WriteString("—")
+		case c.peekString("--"):
+			c.pos += 2
+			b.WriteString("–")
+		case c.peekString("``"):
+			c.pos += 2
+			b.WriteString("“")
+		case c.peekString("''"):
+			c.pos += 2
+			b.WriteString("”")
+		case c.peekString("<<"):
+			c.pos += 2
+			b.WriteString("«")
+		case c.peekString(">>"):
+			c.pos += 2
+			b.WriteString("»")
+		default:
+			c.pos++
+			b.WriteString(html.EscapeString(string(r)))
+		}
+	}
+	return b.String()
+}
+
+func (c *texConverter) convertCommand() string {
+	name := c.readCommandName()
+	if symbol, ok := texSymbols[name]; ok {
+		return html.EscapeString(symbol)
+	}
+	if tag, ok := texInlineTags[name]; ok {
+		c.skipSpaces()
+		if c.peek() != '{' {
+			// a switch like {\bf text}, the formatting is left out
+			return ""
+		}
+		return "<" + tag + ">" + c.convertArgument() + "</" + tag + ">"
+	}
+	if tag, ok := texHeadings[name]; ok {
+		c.skipStar()
+		return texParagraph + "<" + tag + ">" + c.convertArgument() + "</" + tag + ">" + texParagraph
+	}
+	if args, ok := texIgnored[name]; ok {
+		for range args {
+			c.readRawGroup()
+		}
+		return ""
+	}
+
+	switch name {
+	case "par", "newline", "linebreak":
+		if name == "par" {
+			return texParagraph
+		}
+		return "<br>"
+	case "item":
+		c.skipSpaces()
+		if c.peek() == '[' {
+			c.pos++
+			return texItem + "<b>" + html.EscapeString(c.readUntil("]")) + "</b> "
+		}
+		return texItem
+	case "url":
+		url := strings.TrimSpace(c.readRawGroup())
+		return `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(url) + `</a>`
+	case "href":
+		url := strings.TrimSpace(c.readRawGroup())
+		return `<a href="` + html.EscapeString(url) + `">` + c.convertArgument() + `</a>`
+	case "includegraphics":
+		c.skipSpaces()
+		if c.peek() == '[' {
+			c.pos++
+			c.readUntil("]")
+		}
+		return `<img src="` + html.EscapeString(strings.TrimSpace(c.readRawGroup())) + `">`
+	case "illustration":
+		// \illustration{width}{file}{caption} of the Kattis statements
+		c.readRawGroup()
+		src := strings.TrimSpace(c.readRawGroup())
+		caption := c.convertArgument()
+		return texParagraph + `<div class="illustration"><img src="` + html.EscapeString(src) + `"><br>` + caption + "</div>" + texParagraph
+	case "begin":
+		return c.convertEnvironment(strings.TrimSpace(c.readRawGroup()))
+	case "end":
+		c.readRawGroup()
+		return ""
+	}
+
+	// an unknown command leaves the text of its argument
+	c.skipSpaces()
+	if c.peek() == '{' {
+		return c.convertArgument()
+	}
+	return ""
+}
+
+func (c *texConverter) convertEnvironment(name string) string {
+	switch name {
+	case "verbatim", "lstlisting", "minted":
+		body := c.readUntil(`\end{` + name + `}`)
+		if name != "verbatim" && strings.HasPrefix(body, "[") {
+			if end := strings.Index(body, "]"); end >= 0 {
+				body = body[end+1:]
+			}
+		}
+		return texParagraph + "<pre>" + html.EscapeString(strings.Trim(body, "\n")) + "</pre>" + texParagraph
+	case "tabular", "tabular*", "array":
+		c.readRawGroup()
+		body := c.readUntil(`\end{` + name + `}`)
+		return texParagraph + convertTable(body) + texParagraph
+	}
+
+	body := c.convert("end")
+	if c.peekCommand("end") {
+		c.pos++
+		c.readCommandName()
+		c.readRawGroup()
+	}
+
+	switch name {
+	case "itemize", "enumerate", "description":
+		tag := "ul"
+		if name == "enumerate" {
+			tag = "ol"
+		}
+		items := strings.Split(body, texItem)
+		var b strings.Builder
+		b.WriteString(texParagraph + "<" + tag + ">")
+		for i, item := range items {
+			if i == 0 && strings.TrimSpace(item) == "" {
+				continue
+			}
+			b.WriteString("<li>" + strings.TrimSpace(strings.ReplaceAll(item, texParagraph, "<br>")) + "</li>")
+		}
+		b.WriteString("</" + tag + ">" + texParagraph)
+		return b.String()
+	case "center", "flushleft", "flushright", "quote", "quotation":
+		return texParagraph + `<div class="` + name + `">` + paragraphs(body) + "</div>" + texParagraph
+	default:
+		return body
+	}
+}
+
+// convertTable converts the body of a tabular, the rows are separated by \\ and the cells by &.
+func convertTable(body string) string {
+	var b strings.Builder
+	b.WriteString("<table>")
+	for _, row := range splitTopLevel(body, `\\`) {
+		row = strings.TrimSpace(strings.ReplaceAll(row, `\hline`, ""))
+		if row == "" {
+			continue
+		}
+		b.WriteString("<tr>")
+		for _, cell := range splitTopLevel(row, "&") {
+			b.WriteString("<td>" + strings.TrimSpace((&texConverter{src: []rune(cell)}).convert("")) + "</td>")
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
+
+// splitTopLevel splits the text by the separator outside the groups and the formulas.
+func splitTopLevel(text, sep string) []string {
+	parts := make([]string, 0)
+	depth, math := 0, false
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && !strings.HasPrefix(text[i:], sep):
+			i++
+		case text[i] == '{':
+			depth++
+		case text[i] == '}':
+			depth--
+		case text[i] == '$':
+			math = !math
+		case depth == 0 && !math && strings.HasPrefix(text[i:], sep):
+			parts = append(parts, text[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// paragraphs wraps the text between the paragraph breaks into paragraphs, leaving the blocks as they are.
+func paragraphs(text string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(text, texParagraph) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if isBlock(part) {
+			b.WriteString(part)
+		} else {
+			b.WriteString("<p>" + part + "</p>")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func isBlock(part string) bool {
+	for _, tag := range []string{"<ul>", "<ol>", "<pre>", "<table>", "<div", "<h3>", "<h4>", "<h5>", `<math display="block">`} {
+		if strings.HasPrefix(part, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *texConverter) convertArgument() string {
+	c.skipSpaces()
+	if c.peek() != '{' {
+		return ""
+	}
+	c.pos++
+	text := c.convert("}")
+	c.expect('}')
+	return text
+}
+
+func (c *texConverter) readCommandName() string {
+	if c.eof() {
+		return ""
+	}
+	start := c.pos
+	if !unicode.IsLetter(c.peek()) {
+		c.pos++
+		return string(c.src[start:c.pos])
+	}
+	for !c.eof() && unicode.IsLetter(c.peek()) {
+		c.pos++
+	}
+	name := string(c.src[start:c.pos])
+	// the space after a command name only ends the name
+	if !c.eof() && c.peek() == ' ' {
+		c.pos++
+	}
+	return name
+}
+
+func (c *texConverter) readRawGroup() string {
+	p := &mathParser{src: c.src, pos: c.pos}
+	group := p.readRawGroup()
+	c.pos = p.pos
+	return group
+}
+
+// readUntil returns the text up to the end and skips the end itself.
+func (c *texConverter) readUntil(end string) string {
+	rest := string(c.src[c.pos:])
+	i := strings.Index(rest, end)
+	if i < 0 {
+		c.pos = len(c.src)
+		return rest
+	}
+	c.pos += len([]rune(rest[:i])) + len([]rune(end))
+	return rest[:i]
+}
+
+func (c *texConverter) skipStar() {
+	if c.peek() == '*' {
+		c.pos++
+	}
+}
+
+func (c *texConverter) skipSpaces() {
+	for !c.eof() && unicode.IsSpace(c.peek()) {
+		c.pos++
+	}
+}
+
+func (c *texConverter) expect(r rune) {
+	if c.peek() == r {
+		c.pos++
+	}
+}
+
+func (c *texConverter) peekCommand(name string) bool {
+	p := mathParser{src: c.src, pos: c.pos}
+	return p.peekCommand(name)
+}
+
+func (c *texConverter) peekString(s string) bool {
+	p := mathParser{src: c.src, pos: c.pos}
+	return p.peekString(s)
+}
+
+func (c *texConverter) peek() rune {
+	if c.eof() {
+		return 0
+	}
+	return c.src[c.pos]
+}
+
+func (c *texConverter) next() rune {
+	r := c.src[c.pos]
+	c.pos++
+	return r
+}
+
+func (c *texConverter) eof() bool {
+	return c.pos >= len(c.src)
+}
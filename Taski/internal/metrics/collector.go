@@ -20,24 +20,28 @@ type (
 		cfg config.MetricsCollectorConfig
 
 		taskStorage taskStorage
+		// tasks are the labels of the collected tasks; a bucket does not change, so a task is read once
+		tasks map[task.ID]taskLabelsKey
 
-		unitOfWork      unitOfWork
-		solutionStorage solutionStorage
+		unitOfWork   unitOfWork
+		statsStorage statsStorage
 
-		tasksGauge     *prometheus.GaugeVec
-		solutionsGauge *prometheus.GaugeVec
+		tasksGauge          *prometheus.GaugeVec
+		solutionsGauge      *prometheus.GaugeVec
+		solutionsCountGauge *prometheus.GaugeVec
 	}
 
 	taskStorage interface {
-		GetList(context.Context) ([]task.Task, error)
+		GetTaskIDs(context.Context) ([]task.ID, error)
+		Get(context.Context, task.ID) (task.Task, func(), error)
 	}
 
 	unitOfWork interface {
 		Do(context.Context, func(ctx context.Context) error) error
 	}
 
-	solutionStorage interface {
-		GetAll(context.Context) ([]testing.Solution, error)
+	statsStorage interface {
+		GetLanguageStats(context.Context) ([]testing.LanguageStats, error)
 	}
 
 	taskLabels string
@@ -63,6 +67,10 @@ const (
 
 	solutionTaskIdLabel   solutionLabels = "task_id"
 	solutionLanguageLabel solutionLabels = "language"
+	solutionStateLabel    solutionLabels = "state"
+
+	solutionStateCreated  = "created"
+	solutionStateFinished = "finished"
 )
 
 func (key taskLabelsKey) values() []string {
@@ -85,16 +93,17 @@ func NewMetricsCollector(
 	cfg config.MetricsCollectorConfig,
 	taskStorage taskStorage,
 	unitOfWork unitOfWork,
-	solutionStorage solutionStorage,
+	statsStorage statsStorage,
 ) *Collector {
 	return &Collector{
 		log: log,
 		cfg: cfg,
 
 		taskStorage: taskStorage,
+		tasks:       make(map[task.ID]taskLabelsKey),
 
-		unitOfWork:      unitOfWork,
-		solutionStorage: solutionStorage,
+		unitOfWork:   unitOfWork,
+		statsStorage: statsStorage,
 
 		tasksGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -116,6 +125,17 @@ func NewMetricsCollector(
 				string(solutionTaskIdLabel),
 				string(solutionLanguageLabel),
 			}),
+
+		solutionsCountGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "solutions_total",
+				Help: "Count of solutions created and finished",
+			},
+			[]string{
+				string(solutionTaskIdLabel),
+				string(solutionLanguageLabel),
+				string(solutionStateLabel),
+			}),
 	}
 }
 
@@ -123,6 +143,7 @@ func (c *Collector) RegisterMetrics(r prometheus.Registerer) error {
 	return errors.Join(
 		r.Register(c.tasksGauge),
 		r.Register(c.solutionsGauge),
+		r.Register(c.solutionsCountGauge),
 	)
 }
 
@@ -151,85 +172,68 @@ func (c *Collector) run(ctx context.Context) {
 	}
 }
 
+// collectTasks reads only the tasks it has not seen and forgets the removed ones. A task that fails to be
+// read is skipped until the next collection.
 func (c *Collector) collectTasks(ctx context.Context) error {
-	tasks, err := c.taskStorage.GetList(ctx)
+	taskIDs, err := c.taskStorage.GetTaskIDs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get all tasks: %w", err)
+		return fmt.Errorf("failed to get task ids: %w", err)
 	}
 
-	groups := make(map[taskLabelsKey]int)
-	for _, t := range tasks {
+	present := make(map[task.ID]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		present[id] = true
+		if _, ok := c.tasks[id]; ok {
+			continue
+		}
+
+		t, unlock, err := c.taskStorage.Get(ctx, id)
+		if err != nil {
+			c.log.Warn("failed to get task", slog.String("task_id", id.String()), slog.Any("err", err))
+			continue
+		}
+		unlock()
+
 		labels := taskLabelsKey{
 			Id:    t.GetID(),
 			Name:  t.GetTitle(),
 			Level: t.GetLevel(),
 		}
-
-		val, ok := groups[labels]
-		if !ok {
-			val = 0
-		}
-		val++
-
-		groups[labels] = val
+		c.tasks[id] = labels
+		c.tasksGauge.WithLabelValues(labels.values()...).Set(1)
 	}
 
-	for key, val := range groups {
-		c.tasksGauge.WithLabelValues(key.values()...).Set(float64(val))
+	for id, labels := range c.tasks {
+		if !present[id] {
+			c.tasksGauge.DeleteLabelValues(labels.values()...)
+			delete(c.tasks, id)
+		}
 	}
 
 	return nil
 }
 
 func (c *Collector) collectSolutions(ctx context.Context) error {
-	err := c.unitOfWork.Do(ctx, func(ctx context.Context) error {
-		solutions, err := c.solutionStorage.GetAll(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to get all solutions: %w", err)
-		}
+	var stats []testing.LanguageStats
+	err := c.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		stats, err = c.statsStorage.GetLanguageStats(ctx)
+		return
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get language stats: %w", err)
+	}
 
-		groupsTotalDuration := make(map[solutionLabelsKey]time.Duration)
-		groupsCountSubmissions := make(map[solutionLabelsKey]int)
-		for _, s := range solutions {
-			if s.StartedAt == nil || s.FinishedAt == nil {
-				continue
-			}
-
-			labels := solutionLabelsKey{
-				TaskId:   s.TaskID,
-				Language: s.Lang,
-			}
-
-			dur, ok := groupsTotalDuration[labels]
-			if !ok {
-				dur = time.Duration(0)
-			}
-
-			processTime := s.ProcessTime()
-			if processTime != nil {
-				dur += *processTime
-			}
-
-			cnt, ok := groupsCountSubmissions[labels]
-			if !ok {
-				cnt = 0
-			}
-			cnt += 1
-
-			groupsTotalDuration[labels] = dur
-			groupsCountSubmissions[labels] = cnt
+	for _, s := range stats {
+		labels := solutionLabelsKey{
+			TaskId:   s.TaskID,
+			Language: s.Lang,
 		}
 
-		for key, dur := range groupsTotalDuration {
-			cnt := groupsCountSubmissions[key]
-			c.solutionsGauge.WithLabelValues(key.values()...).Set(dur.Seconds() / float64(cnt))
+		if avg, ok := s.AvgProcessTime(); ok {
+			c.solutionsGauge.WithLabelValues(labels.values()...).Set(avg.Seconds())
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to collect solutions: %w", err)
+		c.solutionsCountGauge.WithLabelValues(append(labels.values(), solutionStateCreated)...).Set(float64(s.Created))
+		c.solutionsCountGauge.WithLabelValues(append(labels.values(), solutionStateFinished)...).Set(float64(s.Finished))
 	}
 
 	return nil
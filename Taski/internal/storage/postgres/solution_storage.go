@@ -88,20 +88,18 @@ const (
 		FOR UPDATE;
 	`
 
-	selectAllSolutionsQuery = `
-		SELECT id, 
-		       external_id, 
-		       task_id, 
-		       execution_id, 
-		       solution, 
-		       lang, 
-		       testing_strategy, 
-		       last_testing_status, 
-		       handled_events_count,
-		       created_at, 
-		       started_at, 
+	selectSolutionsBatchQuery = `
+		SELECT id,
+		       task_id,
+		       lang,
+		       testing_strategy,
+		       created_at,
+		       started_at,
 		       finished_at
 		FROM Solutions
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2;
 	`
 
 	selectInProgressSolutionsQuery = `
@@ -234,13 +232,15 @@ func (s *SolutionStorage) GetByExecutionID(ctx context.Context, executionID exec
 	return
 }
 
-func (s *SolutionStorage) GetAll(ctx context.Context) (solutions []testing.Solution, err error) {
+// GetBatch returns up to limit solutions with IDs greater than afterID in the order of IDs. Only the fields
+// the task statistics are built from are read: neither the source code nor the testing progress.
+func (s *SolutionStorage) GetBatch(ctx context.Context, afterID int64, limit int) (solutions []testing.Solution, err error) {
 	tx := extractTx(ctx)
 
 	var rows *sql.Rows
-	rows, err = tx.QueryContext(ctx, selectAllSolutionsQuery)
+	rows, err = tx.QueryContext(ctx, selectSolutionsBatchQuery, afterID, limit)
 	if err != nil {
-		err = fmt.Errorf("failed to do select query: %w", err)
+		err = fmt.Errorf("failed to do select batch query: %w", err)
 		return
 	}
 	defer func() { _ = rows.Close() }()
@@ -251,19 +251,14 @@ func (s *SolutionStorage) GetAll(ctx context.Context) (solutions []testing.Solut
 		var testingStrategy json.RawMessage
 		if err = rows.Scan(
 			&sol.ID,
-			&sol.ExternalID,
 			&taskID,
-			&sol.ExecutionID,
-			&sol.Solution,
 			&sol.Lang,
 			&testingStrategy,
-			&sol.LastTestingStatus,
-			&sol.HandledEventsCount,
 			&sol.CreatedAt,
 			&sol.StartedAt,
 			&sol.FinishedAt,
 		); err != nil {
-			err = fmt.Errorf("failed to do select query: %w", err)
+			err = fmt.Errorf("failed to do select batch query: %w", err)
 			return
 		}
 		if err = sol.TaskID.FromString(taskID); err != nil {
@@ -276,6 +271,10 @@ func (s *SolutionStorage) GetAll(ctx context.Context) (solutions []testing.Solut
 		}
 		solutions = append(solutions, sol)
 	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("failed to do select batch query: %w", err)
+		return
+	}
 
 	return
 }
@@ -0,0 +1,607 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"taski/internal/domain/task"
+	"taski/internal/domain/testing"
+	"taski/internal/domain/testing/strategy"
+	"time"
+)
+
+type TaskStatsStorage struct {
+	log *slog.Logger
+}
+
+const (
+	createTaskCreatedStatsTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskCreatedStats(
+			task_id text NOT NULL,
+			lang varchar(16) NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, lang)
+		);
+	`
+
+	createTaskVerdictStatsTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskVerdictStats(
+			task_id text NOT NULL,
+			lang varchar(16) NOT NULL,
+			verdict text NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			timed_solutions bigint NOT NULL DEFAULT 0,
+			process_time_ms bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, lang, verdict)
+		);
+	`
+
+	createTaskFailedTestStatsTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskFailedTestStats(
+			task_id text NOT NULL,
+			test_id int NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, test_id)
+		);
+	`
+
+	createTaskSolveTimeStatsTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskSolveTimeStats(
+			task_id text NOT NULL,
+			bucket int NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, bucket)
+		);
+	`
+
+	// the rebuild tables keep the rebuilt counters less the counters of the snapshot they are rebuilt from,
+	// so that adding them to the counters keeps the updates made during the rebuild
+	createTaskCreatedStatsRebuildTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskCreatedStatsRebuild(
+			task_id text NOT NULL,
+			lang varchar(16) NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, lang)
+		);
+	`
+
+	createTaskVerdictStatsRebuildTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskVerdictStatsRebuild(
+			task_id text NOT NULL,
+			lang varchar(16) NOT NULL,
+			verdict text NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			timed_solutions bigint NOT NULL DEFAULT 0,
+			process_time_ms bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, lang, verdict)
+		);
+	`
+
+	createTaskFailedTestStatsRebuildTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskFailedTestStatsRebuild(
+			task_id text NOT NULL,
+			test_id int NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, test_id)
+		);
+	`
+
+	createTaskSolveTimeStatsRebuildTableQuery = `
+		CREATE TABLE IF NOT EXISTS TaskSolveTimeStatsRebuild(
+			task_id text NOT NULL,
+			bucket int NOT NULL,
+			solutions bigint NOT NULL DEFAULT 0,
+			PRIMARY KEY (task_id, bucket)
+		);
+	`
+
+	upsertTaskCreatedStatsQuery = `
+		INSERT INTO TaskCreatedStats(task_id, lang, solutions)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (task_id, lang) DO UPDATE
+		SET solutions = TaskCreatedStats.solutions + 1;
+	`
+
+	upsertTaskVerdictStatsQuery = `
+		INSERT INTO TaskVerdictStats(task_id, lang, verdict, solutions, timed_solutions, process_time_ms)
+		VALUES ($1, $2, $3, 1, $4, $5)
+		ON CONFLICT (task_id, lang, verdict) DO UPDATE
+		SET solutions = TaskVerdictStats.solutions + 1,
+		    timed_solutions = TaskVerdictStats.timed_solutions + EXCLUDED.timed_solutions,
+		    process_time_ms = TaskVerdictStats.process_time_ms + EXCLUDED.process_time_ms;
+	`
+
+	upsertTaskFailedTestStatsQuery = `
+		INSERT INTO TaskFailedTestStats(task_id, test_id, solutions)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (task_id, test_id) DO UPDATE
+		SET solutions = TaskFailedTestStats.solutions + 1;
+	`
+
+	upsertTaskSolveTimeStatsQuery = `
+		INSERT INTO TaskSolveTimeStats(task_id, bucket, solutions)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (task_id, bucket) DO UPDATE
+		SET solutions = TaskSolveTimeStats.solutions + 1;
+	`
+
+	selectTaskVerdictStatsQuery = `
+		SELECT lang, verdict, solutions
+		FROM TaskVerdictStats
+		WHERE task_id = $1;
+	`
+
+	selectAllTaskVerdictStatsQuery = `
+		SELECT task_id, lang, verdict, solutions
+		FROM TaskVerdictStats;
+	`
+
+	selectLanguageStatsQuery = `
+		SELECT task_id,
+		       lang,
+		       COALESCE(c.solutions, 0),
+		       COALESCE(v.solutions, 0),
+		       COALESCE(v.timed_solutions, 0),
+		       COALESCE(v.process_time_ms, 0)
+		FROM TaskCreatedStats c
+		FULL JOIN (
+			SELECT task_id,
+			       lang,
+			       SUM(solutions)::bigint AS solutions,
+			       SUM(timed_solutions)::bigint AS timed_solutions,
+			       SUM(process_time_ms)::bigint AS process_time_ms
+			FROM TaskVerdictStats
+			GROUP BY task_id, lang
+		) v USING (task_id, lang);
+	`
+
+	selectTaskFailedTestStatsQuery = `
+		SELECT test_id, solutions
+		FROM TaskFailedTestStats
+		WHERE task_id = $1;
+	`
+
+	selectTaskSolveTimeStatsQuery = `
+		SELECT bucket, solutions
+		FROM TaskSolveTimeStats
+		WHERE task_id = $1;
+	`
+
+	// one rebuild at a time: a rebuild replaces the rows of the previous one, which are applied at most once
+	lockTaskStatsRebuildTablesQuery = `
+		LOCK TABLE TaskCreatedStatsRebuild, TaskVerdictStatsRebuild, TaskFailedTestStatsRebuild, TaskSolveTimeStatsRebuild
+		IN EXCLUSIVE MODE;
+	`
+
+	deleteTaskCreatedStatsRebuildQuery    = `DELETE FROM TaskCreatedStatsRebuild;`
+	deleteTaskVerdictStatsRebuildQuery    = `DELETE FROM TaskVerdictStatsRebuild;`
+	deleteTaskFailedTestStatsRebuildQuery = `DELETE FROM TaskFailedTestStatsRebuild;`
+	deleteTaskSolveTimeStatsRebuildQuery  = `DELETE FROM TaskSolveTimeStatsRebuild;`
+
+	subtractTaskCreatedStatsQuery = `
+		INSERT INTO TaskCreatedStatsRebuild(task_id, lang, solutions)
+		SELECT task_id, lang, -solutions FROM TaskCreatedStats;
+	`
+
+	subtractTaskVerdictStatsQuery = `
+		INSERT INTO TaskVerdictStatsRebuild(task_id, lang, verdict, solutions, timed_solutions, process_time_ms)
+		SELECT task_id, lang, verdict, -solutions, -timed_solutions, -process_time_ms FROM TaskVerdictStats;
+	`
+
+	subtractTaskFailedTestStatsQuery = `
+		INSERT INTO TaskFailedTestStatsRebuild(task_id, test_id, solutions)
+		SELECT task_id, test_id, -solutions FROM TaskFailedTestStats;
+	`
+
+	subtractTaskSolveTimeStatsQuery = `
+		INSERT INTO TaskSolveTimeStatsRebuild(task_id, bucket, solutions)
+		SELECT task_id, bucket, -solutions FROM TaskSolveTimeStats;
+	`
+
+	addTaskCreatedStatsRebuildQuery = `
+		INSERT INTO TaskCreatedStatsRebuild(task_id, lang, solutions)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, lang) DO UPDATE
+		SET solutions = TaskCreatedStatsRebuild.solutions + EXCLUDED.solutions;
+	`
+
+	addTaskVerdictStatsRebuildQuery = `
+		INSERT INTO TaskVerdictStatsRebuild(task_id, lang, verdict, solutions, timed_solutions, process_time_ms)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (task_id, lang, verdict) DO UPDATE
+		SET solutions = TaskVerdictStatsRebuild.solutions + EXCLUDED.solutions,
+		    timed_solutions = TaskVerdictStatsRebuild.timed_solutions + EXCLUDED.timed_solutions,
+		    process_time_ms = TaskVerdictStatsRebuild.process_time_ms + EXCLUDED.process_time_ms;
+	`
+
+	addTaskFailedTestStatsRebuildQuery = `
+		INSERT INTO TaskFailedTestStatsRebuild(task_id, test_id, solutions)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, test_id) DO UPDATE
+		SET solutions = TaskFailedTestStatsRebuild.solutions + EXCLUDED.solutions;
+	`
+
+	addTaskSolveTimeStatsRebuildQuery = `
+		INSERT INTO TaskSolveTimeStatsRebuild(task_id, bucket, solutions)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, bucket) DO UPDATE
+		SET solutions = TaskSolveTimeStatsRebuild.solutions + EXCLUDED.solutions;
+	`
+
+	mergeTaskCreatedStatsQuery = `
+		INSERT INTO TaskCreatedStats(task_id, lang, solutions)
+		SELECT task_id, lang, solutions FROM TaskCreatedStatsRebuild
+		WHERE solutions <> 0
+		ON CONFLICT (task_id, lang) DO UPDATE
+		SET solutions = TaskCreatedStats.solutions + EXCLUDED.solutions;
+	`
+
+	mergeTaskVerdictStatsQuery = `
+		INSERT INTO TaskVerdictStats(task_id, lang, verdict, solutions, timed_solutions, process_time_ms)
+		SELECT task_id, lang, verdict, solutions, timed_solutions, process_time_ms FROM TaskVerdictStatsRebuild
+		WHERE solutions <> 0 OR timed_solutions <> 0 OR process_time_ms <> 0
+		ON CONFLICT (task_id, lang, verdict) DO UPDATE
+		SET solutions = TaskVerdictStats.solutions + EXCLUDED.solutions,
+		    timed_solutions = TaskVerdictStats.timed_solutions + EXCLUDED.timed_solutions,
+		    process_time_ms = TaskVerdictStats.process_time_ms + EXCLUDED.process_time_ms;
+	`
+
+	mergeTaskFailedTestStatsQuery = `
+		INSERT INTO TaskFailedTestStats(task_id, test_id, solutions)
+		SELECT task_id, test_id, solutions FROM TaskFailedTestStatsRebuild
+		WHERE solutions <> 0
+		ON CONFLICT (task_id, test_id) DO UPDATE
+		SET solutions = TaskFailedTestStats.solutions + EXCLUDED.solutions;
+	`
+
+	mergeTaskSolveTimeStatsQuery = `
+		INSERT INTO TaskSolveTimeStats(task_id, bucket, solutions)
+		SELECT task_id, bucket, solutions FROM TaskSolveTimeStatsRebuild
+		WHERE solutions <> 0
+		ON CONFLICT (task_id, bucket) DO UPDATE
+		SET solutions = TaskSolveTimeStats.solutions + EXCLUDED.solutions;
+	`
+
+	deleteEmptyTaskCreatedStatsQuery    = `DELETE FROM TaskCreatedStats WHERE solutions = 0;`
+	deleteEmptyTaskVerdictStatsQuery    = `DELETE FROM TaskVerdictStats WHERE solutions = 0;`
+	deleteEmptyTaskFailedTestStatsQuery = `DELETE FROM TaskFailedTestStats WHERE solutions = 0;`
+	deleteEmptyTaskSolveTimeStatsQuery  = `DELETE FROM TaskSolveTimeStats WHERE solutions = 0;`
+)
+
+func NewTaskStatsStorage(ctx context.Context, log *slog.Logger) (*TaskStatsStorage, error) {
+	tx := extractTx(ctx)
+
+	for _, query := range []string{
+		createTaskCreatedStatsTableQuery,
+		createTaskVerdictStatsTableQuery,
+		createTaskFailedTestStatsTableQuery,
+		createTaskSolveTimeStatsTableQuery,
+		createTaskCreatedStatsRebuildTableQuery,
+		createTaskVerdictStatsRebuildTableQuery,
+		createTaskFailedTestStatsRebuildTableQuery,
+		createTaskSolveTimeStatsRebuildTableQuery,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return nil, fmt.Errorf("failed to create task stats table: %w", err)
+		}
+	}
+
+	return &TaskStatsStorage{log: log}, nil
+}
+
+// AddCreated counts a created solution of the task in the language.
+func (s *TaskStatsStorage) AddCreated(ctx context.Context, taskID task.ID, lang task.Language) error {
+	tx := extractTx(ctx)
+
+	if _, err := tx.ExecContext(ctx, upsertTaskCreatedStatsQuery, taskID.String(), lang); err != nil {
+		return fmt.Errorf("failed to do upsert task created stats query: %w", err)
+	}
+
+	return nil
+}
+
+// Add adds the result of a finished solution to the statistics of its task. It must be called
+// once per solution, in the transaction that finishes it.
+func (s *TaskStatsStorage) Add(ctx context.Context, result testing.Result) error {
+	tx := extractTx(ctx)
+
+	var timedSolutions, processTimeMs int64
+	if result.ProcessTime != nil {
+		timedSolutions = 1
+		processTimeMs = result.ProcessTime.Milliseconds()
+	}
+	if _, err := tx.ExecContext(ctx, upsertTaskVerdictStatsQuery,
+		result.TaskID.String(),
+		result.Lang,
+		result.Verdict,
+		timedSolutions,
+		processTimeMs,
+	); err != nil {
+		return fmt.Errorf("failed to do upsert task verdict stats query: %w", err)
+	}
+
+	if result.FailedTest > 0 {
+		if _, err := tx.ExecContext(ctx, upsertTaskFailedTestStatsQuery, result.TaskID.String(), result.FailedTest); err != nil {
+			return fmt.Errorf("failed to do upsert task failed test stats query: %w", err)
+		}
+	}
+
+	if result.Verdict == strategy.AcceptedVerdict && result.ProcessTime != nil {
+		bucket := testing.SolveTimeBucket(*result.ProcessTime)
+		if _, err := tx.ExecContext(ctx, upsertTaskSolveTimeStatsQuery, result.TaskID.String(), bucket); err != nil {
+			return fmt.Errorf("failed to do upsert task solve time stats query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the statistics of the task, empty ones when it has no finished solutions.
+func (s *TaskStatsStorage) Get(ctx context.Context, taskID task.ID) (stats testing.TaskStats, err error) {
+	tx := extractTx(ctx)
+
+	stats = testing.TaskStats{
+		Verdicts:    make(map[task.Language]map[string]int),
+		FailedTests: make(map[int]int),
+		SolveTimes:  make(map[int]int),
+	}
+
+	var rows *sql.Rows
+	rows, err = tx.QueryContext(ctx, selectTaskVerdictStatsQuery, taskID.String())
+	if err != nil {
+		err = fmt.Errorf("failed to do select task verdict stats query: %w", err)
+		return
+	}
+	for rows.Next() {
+		var lang task.Language
+		var verdict string
+		var count int
+		if err = rows.Scan(&lang, &verdict, &count); err != nil {
+			_ = rows.Close()
+			err = fmt.Errorf("failed to do select task verdict stats query: %w", err)
+			return
+		}
+		if stats.Verdicts[lang] == nil {
+			stats.Verdicts[lang] = make(map[string]int)
+		}
+		stats.Verdicts[lang][verdict] = count
+	}
+	err = rows.Err()
+	_ = rows.Close()
+	if err != nil {
+		err = fmt.Errorf("failed to do select task verdict stats query: %w", err)
+		return
+	}
+
+	if err = scanCounts(ctx, tx, selectTaskFailedTestStatsQuery, taskID, stats.FailedTests); err != nil {
+		err = fmt.Errorf("failed to do select task failed test stats query: %w", err)
+		return
+	}
+	if err = scanCounts(ctx, tx, selectTaskSolveTimeStatsQuery, taskID, stats.SolveTimes); err != nil {
+		err = fmt.Errorf("failed to do select task solve time stats query: %w", err)
+		return
+	}
+
+	return
+}
+
+// GetTaskOutcomes returns the outcomes of the finished solutions of every task that has any.
+func (s *TaskStatsStorage) GetTaskOutcomes(ctx context.Context) (outcomes map[task.ID]task.Outcomes, err error) {
+	tx := extractTx(ctx)
+
+	var rows *sql.Rows
+	rows, err = tx.QueryContext(ctx, selectAllTaskVerdictStatsQuery)
+	if err != nil {
+		err = fmt.Errorf("failed to do select task verdict stats query: %w", err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := make(map[task.ID]testing.TaskStats)
+	for rows.Next() {
+		var taskID string
+		var lang task.Language
+		var verdict string
+		var count int
+		if err = rows.Scan(&taskID, &lang, &verdict, &count); err != nil {
+			err = fmt.Errorf("failed to do select task verdict stats query: %w", err)
+			return
+		}
+		var id task.ID
+		if err = id.FromString(taskID); err != nil {
+			err = fmt.Errorf("failed to unmarshal task id: %w", err)
+			return
+		}
+		if _, ok := stats[id]; !ok {
+			stats[id] = testing.TaskStats{Verdicts: make(map[task.Language]map[string]int)}
+		}
+		if stats[id].Verdicts[lang] == nil {
+			stats[id].Verdicts[lang] = make(map[string]int)
+		}
+		stats[id].Verdicts[lang][verdict] = count
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("failed to do select task verdict stats query: %w", err)
+		return
+	}
+
+	outcomes = make(map[task.ID]task.Outcomes, len(stats))
+	for id, taskStats := range stats {
+		outcomes[id] = taskStats.Outcomes()
+	}
+	return
+}
+
+// GetTaskOutcomesByTask returns the outcomes of the finished solutions of the task.
+func (s *TaskStatsStorage) GetTaskOutcomesByTask(ctx context.Context, taskID task.ID) (task.Outcomes, error) {
+	stats, err := s.Get(ctx, taskID)
+	if err != nil {
+		return task.Outcomes{}, err
+	}
+	return stats.Outcomes(), nil
+}
+
+// GetLanguageStats returns the counters of the solutions of every task in every language it has any in.
+func (s *TaskStatsStorage) GetLanguageStats(ctx context.Context) (stats []testing.LanguageStats, err error) {
+	tx := extractTx(ctx)
+
+	var rows *sql.Rows
+	rows, err = tx.QueryContext(ctx, selectLanguageStatsQuery)
+	if err != nil {
+		err = fmt.Errorf("failed to do select language stats query: %w", err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var langStats testing.LanguageStats
+		var taskID string
+		var processTimeMs int64
+		if err = rows.Scan(
+			&taskID,
+			&langStats.Lang,
+			&langStats.Created,
+			&langStats.Finished,
+			&langStats.TimedFinished,
+			&processTimeMs,
+		); err != nil {
+			err = fmt.Errorf("failed to do select language stats query: %w", err)
+			return
+		}
+		if err = langStats.TaskID.FromString(taskID); err != nil {
+			err = fmt.Errorf("failed to unmarshal task id: %w", err)
+			return
+		}
+		langStats.TotalProcessTime = time.Duration(processTimeMs) * time.Millisecond
+		stats = append(stats, langStats)
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("failed to do select language stats query: %w", err)
+		return
+	}
+
+	return
+}
+
+// StartRebuild replaces the rows of the previous rebuild with the counters less, and must be the first
+// statement of a repeatable read transaction: the counters subtracted are then the ones of the snapshot
+// the solutions are read from. It locks the rebuild, not the statistics.
+func (s *TaskStatsStorage) StartRebuild(ctx context.Context) error {
+	tx := extractTx(ctx)
+
+	if _, err := tx.ExecContext(ctx, lockTaskStatsRebuildTablesQuery); err != nil {
+		return fmt.Errorf("failed to do lock task stats rebuild tables query: %w", err)
+	}
+	for _, query := range []string{
+		deleteTaskCreatedStatsRebuildQuery,
+		deleteTaskVerdictStatsRebuildQuery,
+		deleteTaskFailedTestStatsRebuildQuery,
+		deleteTaskSolveTimeStatsRebuildQuery,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to do delete task stats rebuild query: %w", err)
+		}
+	}
+	for _, query := range []string{
+		subtractTaskCreatedStatsQuery,
+		subtractTaskVerdictStatsQuery,
+		subtractTaskFailedTestStatsQuery,
+		subtractTaskSolveTimeStatsQuery,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to do subtract task stats query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// AddRebuilt adds the counters of the solutions read to the rebuild.
+func (s *TaskStatsStorage) AddRebuilt(ctx context.Context, counts testing.StatsCounts) error {
+	tx := extractTx(ctx)
+
+	for key, solutions := range counts.Created {
+		if _, err := tx.ExecContext(ctx, addTaskCreatedStatsRebuildQuery, key.TaskID.String(), key.Lang, solutions); err != nil {
+			return fmt.Errorf("failed to do add task created stats rebuild query: %w", err)
+		}
+	}
+	for key, verdictCounts := range counts.Verdicts {
+		if _, err := tx.ExecContext(ctx, addTaskVerdictStatsRebuildQuery,
+			key.TaskID.String(),
+			key.Lang,
+			key.Verdict,
+			verdictCounts.Solutions,
+			verdictCounts.TimedSolutions,
+			verdictCounts.ProcessTime.Milliseconds(),
+		); err != nil {
+			return fmt.Errorf("failed to do add task verdict stats rebuild query: %w", err)
+		}
+	}
+	for key, solutions := range counts.FailedTests {
+		if _, err := tx.ExecContext(ctx, addTaskFailedTestStatsRebuildQuery, key.TaskID.String(), key.TestID, solutions); err != nil {
+			return fmt.Errorf("failed to do add task failed test stats rebuild query: %w", err)
+		}
+	}
+	for key, solutions := range counts.SolveTimes {
+		if _, err := tx.ExecContext(ctx, addTaskSolveTimeStatsRebuildQuery, key.TaskID.String(), key.Bucket, solutions); err != nil {
+			return fmt.Errorf("failed to do add task solve time stats rebuild query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ApplyRebuild adds the rebuild to the statistics and deletes it. The counters are updated in place,
+// so AddCreated and Add of the other transactions only wait for the rows they share with it.
+func (s *TaskStatsStorage) ApplyRebuild(ctx context.Context) error {
+	tx := extractTx(ctx)
+
+	// the rebuild is locked first, so that a rebuild still being read is waited for before the statistics are touched
+	if _, err := tx.ExecContext(ctx, lockTaskStatsRebuildTablesQuery); err != nil {
+		return fmt.Errorf("failed to do lock task stats rebuild tables query: %w", err)
+	}
+	for _, query := range []string{
+		mergeTaskCreatedStatsQuery,
+		mergeTaskVerdictStatsQuery,
+		mergeTaskFailedTestStatsQuery,
+		mergeTaskSolveTimeStatsQuery,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to do merge task stats query: %w", err)
+		}
+	}
+	for _, query := range []string{
+		deleteEmptyTaskCreatedStatsQuery,
+		deleteEmptyTaskVerdictStatsQuery,
+		deleteEmptyTaskFailedTestStatsQuery,
+		deleteEmptyTaskSolveTimeStatsQuery,
+		deleteTaskCreatedStatsRebuildQuery,
+		deleteTaskVerdictStatsRebuildQuery,
+		deleteTaskFailedTestStatsRebuildQuery,
+		deleteTaskSolveTimeStatsRebuildQuery,
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to do delete task stats query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func scanCounts(ctx context.Context, tx *sql.Tx, query string, taskID task.ID, counts map[int]int) error {
+	rows, err := tx.QueryContext(ctx, query, taskID.String())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var key, count int
+		if err = rows.Scan(&key, &count); err != nil {
+			return err
+		}
+		counts[key] = count
+	}
+	return rows.Err()
+}
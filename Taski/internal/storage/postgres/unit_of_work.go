@@ -23,7 +23,16 @@ func NewUnitOfWork(cfg config.DbConfig) (*UnitOfWork, error) {
 }
 
 func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
-	tx, err := u.db.BeginTx(ctx, nil)
+	return u.do(ctx, nil, fn)
+}
+
+// DoRepeatableRead runs fn in a transaction which reads every table as of the snapshot of its first query.
+func (u *UnitOfWork) DoRepeatableRead(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.do(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead}, fn)
+}
+
+func (u *UnitOfWork) do(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := u.db.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
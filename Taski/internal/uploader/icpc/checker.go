@@ -0,0 +1,190 @@
+package icpc
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"taski/internal/lib/safepath"
+)
+
+// checkerFileName is the checker in the task bucket, always C++: either the default validator of the format
+// or the validator of the package with an adapter to the checker interface.
+const checkerFileName = "checker.cpp"
+
+var (
+	//go:embed checkers/default_validator.cpp
+	defaultValidator string
+
+	//go:embed checkers/validator_adapter.cpp
+	validatorAdapter string
+
+	localIncludeRe = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*include[ \t]*"([^"]+)"[ \t]*\r?$`)
+	pragmaOnceRe   = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*pragma[ \t]+once[ \t]*\r?\n?`)
+)
+
+// validatorDirs are the directories of the output validator: output_validators/<name> of the legacy format
+// and output_validator of the 2023-07 one.
+var validatorDirs = []string{"output_validators", "output_validator"}
+
+var cppExts = map[string]bool{".cpp": true, ".cc": true, ".cxx": true, ".c++": true}
+
+// buildChecker returns the source of the checker. Taski runs a checker as checker <input> <output> <answer>
+// and reads the verdict from its stderr, so the validator of the package is not used as is:
+// its main is renamed and called by an adapter the way the format calls the validator.
+// The flags of the validator are compiled into the checker, since the checker gets no arguments of its own.
+func buildChecker(pkgDir string, p problem) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(validatorFlags(p.ValidatorFlags))
+	if !p.CustomChecker {
+		b.WriteString(defaultValidator)
+		return []byte(b.String()), nil
+	}
+
+	source, err := findValidator(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	code, err := inlineIncludes(pkgDir, source, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	b.WriteString("#define main icpc_validator_main\n")
+	b.WriteString(code)
+	if !strings.HasSuffix(code, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(validatorAdapter)
+	return []byte(b.String()), nil
+}
+
+func validatorFlags(flags []string) string {
+	var b strings.Builder
+	b.WriteString("static const char* const icpc_validator_flags[] = {")
+	for _, flag := range flags {
+		b.WriteString(strconv.Quote(flag) + ", ")
+	}
+	b.WriteString("nullptr};\n")
+	return b.String()
+}
+
+// findValidator returns the C++ source of the output validator. A validator written in another language
+// cannot become a checker and fails the import.
+func findValidator(pkgDir string) (string, error) {
+	for _, name := range validatorDirs {
+		dir := filepath.Join(pkgDir, name)
+		if !isDir(dir) {
+			continue
+		}
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 1 && entries[0].IsDir() {
+			dir = filepath.Join(dir, entries[0].Name())
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", err
+		}
+		var sources, others []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			switch {
+			case cppExts[ext]:
+				sources = append(sources, filepath.Join(dir, entry.Name()))
+			case ext == ".h" || ext == ".hpp" || ext == ".hh":
+			default:
+				others = append(others, entry.Name())
+			}
+		}
+		switch {
+		case len(sources) == 1:
+			return sources[0], nil
+		case len(sources) > 1:
+			return "", fmt.Errorf("output validator in %s has %d C++ sources, only a single one is supported", name, len(sources))
+		case len(others) > 0:
+			return "", fmt.Errorf("output validator %s is not supported, only C++ validators are", strings.Join(others, ", "))
+		}
+	}
+	return "", errors.New("custom validation without an output validator")
+}
+
+// inlineIncludes puts the headers included by a relative path into the source, so that the checker
+// is a single file like every other checker. A header is put in once, the next includes of it are dropped.
+// A header outside the package fails the import, so that a package cannot read the files of the server.
+func inlineIncludes(pkgDir, path string, seen map[string]bool) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	seen[path] = true
+
+	var inlineErr error
+	code := localIncludeRe.ReplaceAllStringFunc(pragmaOnceRe.ReplaceAllString(string(data), ""), func(line string) string {
+		name := localIncludeRe.FindStringSubmatch(line)[1]
+		header := filepath.Join(filepath.Dir(path), name)
+		if !insidePackage(pkgDir, header) {
+			if inlineErr == nil {
+				inlineErr = fmt.Errorf("included header %q is outside the package", name)
+			}
+			return line
+		}
+		if seen[header] {
+			return ""
+		}
+		if !fileExists(header) {
+			return line
+		}
+		included, err := inlineIncludes(pkgDir, header, seen)
+		if err != nil && inlineErr == nil {
+			inlineErr = err
+		}
+		return included
+	})
+	if inlineErr != nil {
+		return "", inlineErr
+	}
+	return code, nil
+}
+
+// insidePackage reports whether the path stays in the package directory, with the symbolic links followed.
+func insidePackage(pkgDir, path string) bool {
+	if !isRelativeTo(pkgDir, path) {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		// a missing header is left to the compiler
+		return errors.Is(err, fs.ErrNotExist)
+	}
+	root, err := filepath.EvalSymlinks(pkgDir)
+	if err != nil {
+		return false
+	}
+	return isRelativeTo(root, resolved)
+}
+
+func isRelativeTo(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	_, err = safepath.Clean(filepath.ToSlash(rel))
+	return err == nil
+}
+
+func isDir(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
+
+func fileExists(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && !stat.IsDir()
+}
@@ -0,0 +1,143 @@
+// The default output validator of the ICPC problem package format, built as a Taski checker:
+//
+//     checker <input> <output> <answer>
+//
+// The output is compared with the answer token by token. The verdict goes to stderr,
+// "ok" or "wrong answer" followed by the reason. The flags of the validator
+// (case_sensitive, space_change_sensitive, float_tolerance, float_absolute_tolerance
+// and float_relative_tolerance) come in icpc_validator_flags, put before this file on import.
+
+#include <cctype>
+#include <cmath>
+#include <cstdio>
+#include <cstdlib>
+#include <fstream>
+#include <sstream>
+#include <string>
+
+namespace {
+
+bool case_sensitive = false;
+bool space_change_sensitive = false;
+double absolute_tolerance = -1;
+double relative_tolerance = -1;
+
+[[noreturn]] void verdict(bool ok, const std::string& message) {
+    std::fprintf(stderr, "%s %s\n", ok ? "ok" : "wrong answer", message.c_str());
+    std::exit(ok ? 0 : 1);
+}
+
+std::string read_file(const char* path) {
+    std::ifstream in(path, std::ios::binary);
+    if (!in) {
+        std::fprintf(stderr, "fail cannot open %s\n", path);
+        std::exit(3);
+    }
+    std::ostringstream content;
+    content << in.rdbuf();
+    return content.str();
+}
+
+struct tokenizer {
+    const std::string& text;
+    size_t pos = 0;
+
+    // next reads the whitespace before the next token and the token itself, it returns false at the end of the text.
+    bool next(std::string& space, std::string& token) {
+        size_t start = pos;
+        while (pos < text.size() && std::isspace(static_cast<unsigned char>(text[pos]))) {
+            pos++;
+        }
+        space = text.substr(start, pos - start);
+        start = pos;
+        while (pos < text.size() && !std::isspace(static_cast<unsigned char>(text[pos]))) {
+            pos++;
+        }
+        token = text.substr(start, pos - start);
+        return !token.empty();
+    }
+};
+
+bool parse_double(const std::string& token, double& value) {
+    char* end = nullptr;
+    value = std::strtod(token.c_str(), &end);
+    return !token.empty() && *end == '\0';
+}
+
+std::string lower(std::string s) {
+    for (char& c : s) {
+        c = static_cast<char>(std::tolower(static_cast<unsigned char>(c)));
+    }
+    return s;
+}
+
+bool equal_tokens(const std::string& judge, const std::string& team) {
+    double expected = 0;
+    if ((absolute_tolerance >= 0 || relative_tolerance >= 0) && parse_double(judge, expected)) {
+        double found = 0;
+        if (!parse_double(team, found)) {
+            return false;
+        }
+        double diff = std::fabs(expected - found);
+        return expected == found || (absolute_tolerance >= 0 && diff <= absolute_tolerance) ||
+               (relative_tolerance >= 0 && diff <= relative_tolerance * std::fabs(expected));
+    }
+    return case_sensitive ? judge == team : lower(judge) == lower(team);
+}
+
+void parse_flags() {
+    for (int i = 0; icpc_validator_flags[i] != nullptr; i++) {
+        std::string flag = icpc_validator_flags[i];
+        const char* value = icpc_validator_flags[i + 1];
+        if (flag == "case_sensitive") {
+            case_sensitive = true;
+        } else if (flag == "space_change_sensitive") {
+            space_change_sensitive = true;
+        } else if (flag == "float_tolerance" && value != nullptr) {
+            absolute_tolerance = relative_tolerance = std::atof(value);
+            i++;
+        } else if (flag == "float_absolute_tolerance" && value != nullptr) {
+            absolute_tolerance = std::atof(value);
+            i++;
+        } else if (flag == "float_relative_tolerance" && value != nullptr) {
+            relative_tolerance = std::atof(value);
+            i++;
+        }
+    }
+}
+
+}  // namespace
+
+int main(int argc, char** argv) {
+    if (argc < 4) {
+        std::fprintf(stderr, "fail usage: checker <input> <output> <answer>\n");
+        return 3;
+    }
+    parse_flags();
+
+    std::string team = read_file(argv[2]);
+    std::string judge = read_file(argv[3]);
+    tokenizer judge_tokens{judge};
+    tokenizer team_tokens{team};
+
+    std::string judge_space, judge_token, team_space, team_token;
+    for (int n = 1;; n++) {
+        bool judge_more = judge_tokens.next(judge_space, judge_token);
+        bool team_more = team_tokens.next(team_space, team_token);
+        if (space_change_sensitive && judge_space != team_space) {
+            verdict(false, "whitespace differs before token " + std::to_string(n));
+        }
+        if (!judge_more && !team_more) {
+            verdict(true, std::to_string(n - 1) + " tokens");
+        }
+        if (!judge_more) {
+            verdict(false, "extra output: " + team_token);
+        }
+        if (!team_more) {
+            verdict(false, "output ended before token " + std::to_string(n) + ", expected " + judge_token);
+        }
+        if (!equal_tokens(judge_token, team_token)) {
+            verdict(false, "token " + std::to_string(n) + " differs: expected " + judge_token + ", found " + team_token);
+        }
+    }
+}
@@ -0,0 +1,75 @@
+// The adapter running the output validator of the package as a Taski checker:
+//
+//     checker <input> <output> <answer>
+//
+// The validator is compiled above with its main renamed to icpc_validator_main and is called
+// the way the ICPC format runs it: with the input, the answer and the feedback directory as arguments,
+// the flags after them and the output of the solution on stdin. It reports the verdict by exiting
+// with 42 (accepted) or 43 (wrong answer), which is turned into "ok" or "wrong answer" on stderr.
+// Whatever the validator writes to stderr itself is dropped, so that the verdict comes first.
+// The sandbox allows a single process, so the exit status is caught by on_exit instead of a child process.
+
+#undef main
+
+#include <cstdio>
+#include <cstdlib>
+#include <cstring>
+#include <fcntl.h>
+#include <tuple>
+#include <unistd.h>
+#include <vector>
+
+namespace icpc_adapter {
+
+int verdict_fd = 2;
+
+void report(int status, void*) {
+    const char* verdict = "fail output validator exited with an unexpected status\n";
+    if (status == 42) {
+        verdict = "ok\n";
+    } else if (status == 43) {
+        verdict = "wrong answer\n";
+    }
+    if (write(verdict_fd, verdict, std::strlen(verdict)) < 0) {
+        std::_Exit(3);
+    }
+}
+
+template <typename... Args>
+int call(int (*validator_main)(Args...), int argc, char** argv) {
+    if constexpr (sizeof...(Args) == 0) {
+        return validator_main();
+    } else {
+        using argv_type = typename std::tuple_element<1, std::tuple<Args...>>::type;
+        return validator_main(argc, (argv_type)argv);
+    }
+}
+
+}  // namespace icpc_adapter
+
+int main(int argc, char** argv) {
+    if (argc < 4) {
+        std::fprintf(stderr, "fail usage: checker <input> <output> <answer>\n");
+        return 3;
+    }
+    if (std::freopen(argv[2], "r", stdin) == nullptr) {
+        std::fprintf(stderr, "fail cannot open %s\n", argv[2]);
+        return 3;
+    }
+
+    icpc_adapter::verdict_fd = dup(2);
+    int null_fd = open("/dev/null", O_WRONLY);
+    if (icpc_adapter::verdict_fd >= 0 && null_fd >= 0) {
+        dup2(null_fd, 2);
+    } else {
+        icpc_adapter::verdict_fd = 2;
+    }
+    on_exit(icpc_adapter::report, nullptr);
+
+    std::vector<char*> args = {argv[0], argv[1], argv[3], const_cast<char*>(".")};
+    for (int i = 0; icpc_validator_flags[i] != nullptr; i++) {
+        args.push_back(const_cast<char*>(icpc_validator_flags[i]));
+    }
+    args.push_back(nullptr);
+    return icpc_adapter::call(icpc_validator_main, static_cast<int>(args.size()) - 1, args.data());
+}
@@ -0,0 +1,219 @@
+package icpc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ProblemFileName is the file at the root of a package describing the problem.
+	ProblemFileName = "problem.yaml"
+
+	// defaultTimeLimit is used when the package has no time limit of its own: the legacy format leaves it
+	// to the judge, which derives it from the running time of the accepted submissions.
+	defaultTimeLimit = 1000
+
+	// defaultMemoryLimit is the memory limit in MiB the format has when problem.yaml gives none.
+	defaultMemoryLimit = 2048
+)
+
+type problemYAML struct {
+	Name           problemName  `yaml:"name"`
+	Type           stringList   `yaml:"type"`
+	Validation     string       `yaml:"validation"`
+	ValidatorFlags string       `yaml:"validator_flags"`
+	Limits         problemLimit `yaml:"limits"`
+}
+
+type problemLimit struct {
+	TimeLimit float64 `yaml:"time_limit"`
+	Memory    int     `yaml:"memory"`
+}
+
+type testdataYAML struct {
+	OutputValidatorFlags stringList `yaml:"output_validator_flags"`
+	OutputValidatorArgs  stringList `yaml:"output_validator_args"`
+}
+
+// problemName is the name of the problem by language. The legacy format allows a plain string,
+// which is taken as the English name.
+type problemName map[string]string
+
+func (n *problemName) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*n = problemName{"en": value.Value}
+		return nil
+	}
+	var names map[string]string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
+// stringList is a list of strings that may also be given as a single string of space separated items.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = strings.Fields(value.Value)
+		return nil
+	}
+	var items []string
+	if err := value.Decode(&items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+// problem is what the importer takes from the package besides the tests and the statements.
+type problem struct {
+	ShortName      string
+	Names          map[string]string
+	TimeLimit      int
+	MemoryLimit    int
+	CustomChecker  bool
+	ValidatorFlags []string
+}
+
+func loadProblem(pkgDir, shortName string) (problem, error) {
+	data, err := os.ReadFile(filepath.Join(pkgDir, ProblemFileName))
+	if err != nil {
+		return problem{}, fmt.Errorf("failed to read %s: %w", ProblemFileName, err)
+	}
+	var py problemYAML
+	if err = yaml.Unmarshal(data, &py); err != nil {
+		return problem{}, fmt.Errorf("failed to parse %s: %w", ProblemFileName, err)
+	}
+
+	p := problem{
+		ShortName:      shortName,
+		Names:          py.Name,
+		MemoryLimit:    py.Limits.Memory,
+		ValidatorFlags: strings.Fields(py.ValidatorFlags),
+	}
+	if p.MemoryLimit <= 0 {
+		p.MemoryLimit = defaultMemoryLimit
+	}
+
+	validation := strings.Fields(strings.ToLower(py.Validation))
+	// a scoring problem is judged pass-fail, the score of a test is not kept
+	if slices.Contains(validation, "interactive") {
+		return problem{}, errors.New("interactive validation is not supported")
+	}
+	p.CustomChecker = len(validation) > 0 && validation[0] == "custom"
+	if len(validation) == 0 && isDir(filepath.Join(pkgDir, "output_validator")) {
+		// the 2023-07 format has no validation field, a package with its own validator just has one
+		p.CustomChecker = true
+	}
+	for _, t := range py.Type {
+		switch strings.ToLower(t) {
+		case "pass-fail", "scoring":
+		default:
+			return problem{}, fmt.Errorf("problem type %s is not supported", t)
+		}
+	}
+
+	if p.TimeLimit, err = loadTimeLimit(pkgDir, py.Limits.TimeLimit); err != nil {
+		return problem{}, err
+	}
+
+	testdata, err := loadTestdata(pkgDir)
+	if err != nil {
+		return problem{}, err
+	}
+	p.ValidatorFlags = append(p.ValidatorFlags, testdata.OutputValidatorFlags...)
+	p.ValidatorFlags = append(p.ValidatorFlags, testdata.OutputValidatorArgs...)
+	return p, nil
+}
+
+// title returns the name of the problem in the language, or in English, or in any language it has.
+func (p problem) title(lang string) string {
+	for _, l := range []string{lang, "en"} {
+		if name := strings.TrimSpace(p.Names[l]); name != "" {
+			return name
+		}
+	}
+	for _, name := range p.Names {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return p.ShortName
+}
+
+// loadTimeLimit returns the time limit in milliseconds. It is given in problem.yaml since the 2023-07 version
+// of the format. Older packages exported from a judge carry it in .timelimit (problemtools and Kattis)
+// or in domjudge-problem.ini (DOMjudge), both in seconds.
+func loadTimeLimit(pkgDir string, seconds float64) (int, error) {
+	if seconds > 0 {
+		return secondsToMillis(seconds), nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(pkgDir, ".timelimit"))
+	if err == nil {
+		seconds, err = strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil || seconds <= 0 {
+			return 0, fmt.Errorf("invalid time limit in .timelimit: %q", strings.TrimSpace(string(data)))
+		}
+		return secondsToMillis(seconds), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+
+	file, err := os.Open(filepath.Join(pkgDir, "domjudge-problem.ini"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || strings.TrimSpace(key) != "timelimit" {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `'"`)
+		seconds, err = strconv.ParseFloat(value, 64)
+		if err != nil || seconds <= 0 {
+			return 0, fmt.Errorf("invalid time limit in domjudge-problem.ini: %q", value)
+		}
+		return secondsToMillis(seconds), nil
+	}
+	return 0, scanner.Err()
+}
+
+func loadTestdata(pkgDir string) (testdataYAML, error) {
+	var testdata testdataYAML
+	data, err := os.ReadFile(filepath.Join(pkgDir, "data", "testdata.yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return testdata, nil
+	}
+	if err != nil {
+		return testdata, err
+	}
+	if err = yaml.Unmarshal(data, &testdata); err != nil {
+		return testdata, fmt.Errorf("failed to parse data/testdata.yaml: %w", err)
+	}
+	return testdata, nil
+}
+
+func secondsToMillis(seconds float64) int {
+	return int(math.Ceil(seconds * 1000))
+}
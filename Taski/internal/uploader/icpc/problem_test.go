@@ -0,0 +1,224 @@
+package icpc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writePackage(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content)); err != nil {
+			t.Fatalf("writeFile(%s) returned error: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoadProblem(t *testing.T) {
+	t.Parallel()
+
+	pkgDir := writePackage(t, map[string]string{
+		"problem.yaml":       "name:\n  en: Hello\n  ru: Привет\nvalidation: custom\nvalidator_flags: case_sensitive\nlimits:\n  memory: 512\n",
+		".timelimit":         "2.5\n",
+		"data/testdata.yaml": "output_validator_flags: float_tolerance 1e-6\n",
+	})
+
+	p, err := loadProblem(pkgDir, "hello")
+	if err != nil {
+		t.Fatalf("loadProblem() returned error: %v", err)
+	}
+	if p.TimeLimit != 2500 || p.MemoryLimit != 512 {
+		t.Fatalf("limits = %d ms, %d MiB, want 2500 ms, 512 MiB", p.TimeLimit, p.MemoryLimit)
+	}
+	if !p.CustomChecker {
+		t.Fatal("CustomChecker = false, want true")
+	}
+	if want := []string{"case_sensitive", "float_tolerance", "1e-6"}; !reflect.DeepEqual(p.ValidatorFlags, want) {
+		t.Fatalf("ValidatorFlags = %q, want %q", p.ValidatorFlags, want)
+	}
+	if p.title("ru") != "Привет" || p.title("de") != "Hello" {
+		t.Fatalf("title() = %q and %q, want the Russian and the English names", p.title("ru"), p.title("de"))
+	}
+}
+
+func TestLoadProblem2023(t *testing.T) {
+	t.Parallel()
+
+	pkgDir := writePackage(t, map[string]string{
+		"problem.yaml":                   "problem_format_version: 2023-07-draft\nname: Hello\ntype: pass-fail\nlimits:\n  time_limit: 1.5\n",
+		"output_validator/validator.cpp": "int main() { return 42; }\n",
+	})
+
+	p, err := loadProblem(pkgDir, "hello")
+	if err != nil {
+		t.Fatalf("loadProblem() returned error: %v", err)
+	}
+	if p.TimeLimit != 1500 || p.MemoryLimit != defaultMemoryLimit {
+		t.Fatalf("limits = %d ms, %d MiB, want 1500 ms, %d MiB", p.TimeLimit, p.MemoryLimit, defaultMemoryLimit)
+	}
+	if !p.CustomChecker {
+		t.Fatal("CustomChecker = false, want true for a package with output_validator")
+	}
+	if p.title("en") != "Hello" {
+		t.Fatalf("title() = %q, want Hello", p.title("en"))
+	}
+}
+
+func TestLoadProblemRejectsInteractive(t *testing.T) {
+	t.Parallel()
+
+	problems := map[string]string{
+		"legacy": "validation: custom interactive\n",
+		"2023":   "type: [pass-fail, interactive]\n",
+	}
+
+	for name, problemYAML := range problems {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			pkgDir := writePackage(t, map[string]string{"problem.yaml": problemYAML})
+			if _, err := loadProblem(pkgDir, "hello"); err == nil {
+				t.Fatalf("loadProblem(%q) returned no error", problemYAML)
+			}
+		})
+	}
+}
+
+func TestCollectTests(t *testing.T) {
+	t.Parallel()
+
+	pkgDir := writePackage(t, map[string]string{
+		"data/sample/1.in":         "",
+		"data/sample/1.ans":        "",
+		"data/secret/b/10.in":      "",
+		"data/secret/b/10.ans":     "",
+		"data/secret/b/2.in":       "",
+		"data/secret/b/2.ans":      "",
+		"data/secret/a/large.in":   "",
+		"data/secret/a/large.ans":  "",
+		"data/secret/a/readme.txt": "",
+	})
+
+	tests, err := collectTests(pkgDir)
+	if err != nil {
+		t.Fatalf("collectTests() returned error: %v", err)
+	}
+	var got []string
+	for _, test := range tests {
+		rel, _ := filepath.Rel(pkgDir, test.Input)
+		got = append(got, filepath.ToSlash(rel))
+		if test.Sample != strings.HasPrefix(rel, filepath.Join("data", "sample")) {
+			t.Fatalf("Sample = %v for %s", test.Sample, rel)
+		}
+	}
+	want := []string{"data/sample/1.in", "data/secret/a/large.in", "data/secret/b/2.in", "data/secret/b/10.in"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("collectTests() = %q, want %q", got, want)
+	}
+
+	if err = os.Remove(filepath.Join(pkgDir, "data", "secret", "b", "2.ans")); err != nil {
+		t.Fatalf("Remove() returned error: %v", err)
+	}
+	if _, err = collectTests(pkgDir); err == nil {
+		t.Fatal("collectTests() returned no error for a test without an answer")
+	}
+}
+
+func TestSplitSections(t *testing.T) {
+	t.Parallel()
+
+	text := "Legend.\n\\subsection*{Details}\nMore.\n\\section*{Input}\nIn.\n\\section*{ Output }\nOut.\n"
+	got := splitSections(text, texSectionRe)
+	want := map[string]string{
+		"legend": "Legend.\n\\subsection*{Details}\nMore.\n",
+		"input":  "\nIn.\n",
+		"output": "\nOut.\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitSections() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildChecker(t *testing.T) {
+	t.Parallel()
+
+	pkgDir := writePackage(t, map[string]string{
+		"output_validators/check/check.cpp": "#include \"lib.h\"\nint main() { return ok(); }\n",
+		"output_validators/check/lib.h":     "#pragma once\ninline int ok() { return 42; }\n",
+	})
+
+	checker, err := buildChecker(pkgDir, problem{CustomChecker: true, ValidatorFlags: []string{"case_sensitive"}})
+	if err != nil {
+		t.Fatalf("buildChecker() returned error: %v", err)
+	}
+	code := string(checker)
+	wantPrefix := "static const char* const icpc_validator_flags[] = {\"case_sensitive\", nullptr};\n" +
+		"#define main icpc_validator_main\ninline int ok() { return 42; }\n\nint main() { return ok(); }\n"
+	if !strings.HasPrefix(code, wantPrefix) || !strings.HasSuffix(code, validatorAdapter) {
+		t.Fatalf("buildChecker() = %s, want the flags, the validator with lib.h inlined and the adapter", code)
+	}
+
+	if _, err = buildChecker(t.TempDir(), problem{CustomChecker: true}); err == nil {
+		t.Fatal("buildChecker() returned no error without an output validator")
+	}
+}
+
+func TestBuildCheckerRejectsHeadersOutsidePackage(t *testing.T) {
+	t.Parallel()
+
+	outside := filepath.Join(t.TempDir(), "secret.h")
+	if err := writeFile(outside, []byte("int secret = 1;\n")); err != nil {
+		t.Fatalf("writeFile() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		include func(pkgDir string) string
+		link    bool
+	}{
+		{
+			name: "parent path",
+			include: func(pkgDir string) string {
+				rel, err := filepath.Rel(filepath.Join(pkgDir, "output_validators", "check"), outside)
+				if err != nil {
+					t.Fatalf("Rel() returned error: %v", err)
+				}
+				return filepath.ToSlash(rel)
+			},
+		},
+		{
+			name:    "symbolic link",
+			include: func(string) string { return "linked.h" },
+			link:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pkgDir := writePackage(t, map[string]string{"output_validators/check/check.cpp": "int main() {}\n"})
+			validator := filepath.Join(pkgDir, "output_validators", "check")
+			if tt.link {
+				if err := os.Symlink(outside, filepath.Join(validator, "linked.h")); err != nil {
+					t.Fatalf("Symlink() returned error: %v", err)
+				}
+			}
+			source := "#include \"" + tt.include(pkgDir) + "\"\n" + "int main() {}\n"
+			if err := os.WriteFile(filepath.Join(validator, "check.cpp"), []byte(source), 0o666); err != nil {
+				t.Fatalf("WriteFile() returned error: %v", err)
+			}
+
+			checker, err := buildChecker(pkgDir, problem{CustomChecker: true})
+			if err == nil {
+				t.Fatalf("buildChecker() = %s, want an error for the header outside the package", checker)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package icpc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"taski/internal/domain/task"
+	"taski/internal/lib/markup"
+	"taski/internal/uploader/statement"
+)
+
+// statementDirs are the directories of the statements: statement of the 2023-07 format
+// and problem_statement of the legacy one and of Kattis.
+var statementDirs = []string{"statement", "problem_statement"}
+
+var (
+	statementFileRe = regexp.MustCompile(`^problem(?:\.([A-Za-z]{2,3}(?:-[A-Za-z]+)?))?\.(tex|md)$`)
+
+	texDocumentRe = regexp.MustCompile(`(?s)\\begin\{document\}(.*?)(?:\\end\{document\}|$)`)
+	texNameRe     = regexp.MustCompile(`\\problemname\s*\{([^{}]*)\}`)
+	texSectionRe  = regexp.MustCompile(`\\(?:sub)?section\*?\s*\{\s*([^{}]*?)\s*\}`)
+	mdTitleRe     = regexp.MustCompile(`\A\s*#[ \t]+([^\n]+)\n`)
+	mdSectionRe   = regexp.MustCompile(`(?m)^#{1,6}[ \t]+(.+?)[ \t]*#*[ \t]*$`)
+)
+
+// sectionNames are the headings that start a section of the statement page, the rest of the headings
+// stay in the text of the section they are in.
+var sectionNames = map[string]string{
+	"input":       "input",
+	"output":      "output",
+	"interaction": "interaction",
+	"note":        "notes",
+	"notes":       "notes",
+}
+
+type statementSource struct {
+	Lang string
+	Path string
+}
+
+// findStatements returns the directory of the statements and the statement in every language it has,
+// problem.<lang>.tex or problem.<lang>.md. A statement without the language is English.
+func findStatements(pkgDir string) (string, []statementSource, error) {
+	for _, name := range statementDirs {
+		dir := filepath.Join(pkgDir, name)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		byLang := make(map[string]statementSource)
+		for _, entry := range entries {
+			m := statementFileRe.FindStringSubmatch(entry.Name())
+			if entry.IsDir() || m == nil {
+				continue
+			}
+			lang := strings.ToLower(m[1])
+			if lang == "" {
+				lang = "en"
+			}
+			// a LaTeX statement wins over a Markdown one in the same language, being the original in the legacy format
+			if existing, ok := byLang[lang]; ok && filepath.Ext(existing.Path) == ".tex" {
+				continue
+			}
+			byLang[lang] = statementSource{Lang: lang, Path: filepath.Join(dir, entry.Name())}
+		}
+		if len(byLang) == 0 {
+			continue
+		}
+
+		sources := make([]statementSource, 0, len(byLang))
+		for _, source := range byLang {
+			sources = append(sources, source)
+		}
+		sort.Slice(sources, func(i, j int) bool { return sources[i].Lang < sources[j].Lang })
+		return dir, sources, nil
+	}
+	return "", nil, errors.New("no problem statement in statement or problem_statement")
+}
+
+// loadStatement renders the statement into sections. The title is \problemname of a LaTeX statement
+// or the leading heading of a Markdown one. The text is split into the sections at the Input, Output,
+// Interaction and Notes headings, the text before them is the legend.
+func loadStatement(path string) (statement.Rendered, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return statement.Rendered{}, err
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	var (
+		rendered statement.Rendered
+		render   func(string) (string, error)
+		headings *regexp.Regexp
+	)
+	if filepath.Ext(path) == ".tex" {
+		if m := texDocumentRe.FindStringSubmatch(text); m != nil {
+			text = m[1]
+		}
+		if m := texNameRe.FindStringSubmatch(text); m != nil {
+			rendered.Title = strings.TrimSpace(m[1])
+			text = strings.Replace(text, m[0], "", 1)
+		}
+		render = func(src string) (string, error) { return markup.TeX(src), nil }
+		headings = texSectionRe
+	} else {
+		if m := mdTitleRe.FindStringSubmatch(text); m != nil {
+			rendered.Title = strings.TrimSpace(m[1])
+			text = text[len(m[0]):]
+		}
+		render = markup.Markdown
+		headings = mdSectionRe
+	}
+
+	parts := splitSections(text, headings)
+	sections := map[string]*string{
+		"legend":      &rendered.Sections.Legend,
+		"input":       &rendered.Sections.Input,
+		"output":      &rendered.Sections.Output,
+		"interaction": &rendered.Sections.Interaction,
+		"notes":       &rendered.Sections.Notes,
+	}
+	for name, src := range parts {
+		html, err := render(src)
+		if err != nil {
+			return statement.Rendered{}, fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		*sections[name] = html
+	}
+	if strings.TrimSpace(rendered.Sections.Legend) == "" {
+		return statement.Rendered{}, fmt.Errorf("no legend in %s", filepath.Base(path))
+	}
+	return rendered, nil
+}
+
+// splitSections cuts the text at the headings of the sections. A section given twice keeps both parts.
+func splitSections(text string, headings *regexp.Regexp) map[string]string {
+	parts := make(map[string]string)
+	current, start := "legend", 0
+	for _, m := range headings.FindAllStringSubmatchIndex(text, -1) {
+		name, ok := sectionNames[strings.ToLower(strings.TrimSpace(text[m[2]:m[3]]))]
+		if !ok {
+			continue
+		}
+		parts[current] += text[start:m[0]]
+		current, start = name, m[1]
+	}
+	parts[current] += text[start:]
+
+	for name, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			delete(parts, name)
+		}
+	}
+	return parts
+}
+
+// sampleSections returns the samples of the statement, the tests of data/sample.
+func sampleSections(tests []testCase) ([]task.StatementSample, error) {
+	var samples []task.StatementSample
+	for _, test := range tests {
+		if !test.Sample {
+			continue
+		}
+		input, err := os.ReadFile(test.Input)
+		if err != nil {
+			return nil, err
+		}
+		output, err := os.ReadFile(test.Answer)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, task.StatementSample{
+			Input:  string(input),
+			Output: string(output),
+		})
+	}
+	return samples, nil
+}
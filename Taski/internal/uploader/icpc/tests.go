@@ -0,0 +1,142 @@
+package icpc
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"taski/internal/domain/task"
+)
+
+// testCase is a test of the package: its input and answer files and whether it is a sample.
+type testCase struct {
+	Input  string
+	Answer string
+	Sample bool
+}
+
+// collectTests returns the samples from data/sample followed by the secret tests from data/secret
+// and its test groups, each in the natural order of their paths. Every .in file needs an .ans next to it.
+func collectTests(pkgDir string) ([]testCase, error) {
+	samples, err := findTests(filepath.Join(pkgDir, "data", "sample"), true)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := findTests(filepath.Join(pkgDir, "data", "secret"), false)
+	if err != nil {
+		return nil, err
+	}
+	tests := append(samples, secret...)
+	if len(tests) == 0 {
+		return nil, errors.New("no tests in data/sample and data/secret")
+	}
+	return tests, nil
+}
+
+func findTests(dir string, sample bool) ([]testCase, error) {
+	var inputs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".in") {
+			inputs = append(inputs, path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(inputs, naturalCompare)
+
+	tests := make([]testCase, 0, len(inputs))
+	for _, input := range inputs {
+		answer := strings.TrimSuffix(input, ".in") + ".ans"
+		if stat, err := os.Stat(answer); err != nil || stat.IsDir() {
+			rel, _ := filepath.Rel(filepath.Dir(filepath.Dir(dir)), input)
+			return nil, fmt.Errorf("test %s has no answer", filepath.ToSlash(rel))
+		}
+		tests = append(tests, testCase{
+			Input:  input,
+			Answer: answer,
+			Sample: sample,
+		})
+	}
+	return tests, nil
+}
+
+// copyTests copies the tests into tests/NN.in and tests/NN.out of the bucket, the samples are visible.
+func copyTests(tests []testCase, outDir string) ([]task.Test, error) {
+	taskTests := make([]task.Test, 0, len(tests))
+	for i, test := range tests {
+		id := i + 1
+		taskTest := task.Test{
+			ID:      id,
+			Input:   fmt.Sprintf("tests/%02d.in", id),
+			Output:  fmt.Sprintf("tests/%02d.out", id),
+			Visible: test.Sample,
+		}
+		if err := copyFile(test.Input, filepath.Join(outDir, filepath.FromSlash(taskTest.Input))); err != nil {
+			return nil, fmt.Errorf("test %d input: %w", id, err)
+		}
+		if err := copyFile(test.Answer, filepath.Join(outDir, filepath.FromSlash(taskTest.Output))); err != nil {
+			return nil, fmt.Errorf("test %d output: %w", id, err)
+		}
+		taskTests = append(taskTests, taskTest)
+	}
+	return taskTests, nil
+}
+
+// naturalCompare compares the strings with the runs of digits in them compared as numbers,
+// so that 2.in goes before 10.in.
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return cmp.Compare(int(a[0]), int(b[0]))
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, data)
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o666)
+}
@@ -0,0 +1,287 @@
+package icpc
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"taski/internal/domain/task"
+	"taski/internal/domain/task/tasks"
+	"taski/internal/uploader"
+	"taski/internal/uploader/statement"
+	"time"
+
+	"github.com/DIvanCode/filestorage/pkg/bucket"
+)
+
+type fileStorage interface {
+	ReserveBucket(ctx context.Context, id bucket.ID, ttl *time.Duration) (path string, commit, abort func() error, err error)
+}
+
+// solutionLanguages are the languages of the accepted submissions a solution is taken from.
+var solutionLanguages = map[string]task.Language{
+	".cpp": task.LanguageCpp,
+	".cc":  task.LanguageCpp,
+	".cxx": task.LanguageCpp,
+	".py":  task.LanguagePython,
+	".go":  task.LanguageGo,
+}
+
+// icpcUploader imports a problem package of the ICPC format. The Kattis layout is the legacy version
+// of the format, so the same uploader serves both and only the name of the format differs.
+type icpcUploader struct {
+	format string
+	fs     fileStorage
+	log    *slog.Logger
+}
+
+func NewUploader(fs fileStorage, log *slog.Logger) uploader.Uploader {
+	return icpcUploader{
+		format: uploader.FormatICPC,
+		fs:     fs,
+		log:    log,
+	}
+}
+
+func NewKattisUploader(fs fileStorage, log *slog.Logger) uploader.Uploader {
+	return icpcUploader{
+		format: uploader.FormatKattis,
+		fs:     fs,
+		log:    log,
+	}
+}
+
+func (u icpcUploader) SupportsFormat(format string) bool {
+	return strings.EqualFold(format, u.format)
+}
+
+func (u icpcUploader) Upload(ctx context.Context, cfg uploader.Config) (task.ID, error) {
+	u.info(u.format+" package upload started", slog.String("src", cfg.SrcPath))
+
+	if cfg.SrcPath == "" {
+		return task.ID{}, errors.New("missing source path")
+	}
+	if u.fs == nil {
+		return task.ID{}, errors.New("file storage is not configured")
+	}
+	if cfg.Level < 1 || cfg.Level > 10 {
+		return task.ID{}, errors.New("level must be in range [1..10]")
+	}
+
+	tempPrefix := u.format + "_pkg_"
+	pkgDir, cleanup, err := uploader.PrepareSource(cfg.SrcPath, ProblemFileName, tempPrefix+"*")
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to prepare source: %w", err)
+	}
+	defer cleanup()
+
+	shortName := packageShortName(cfg.SrcPath, pkgDir, tempPrefix)
+	p, err := loadProblem(pkgDir, shortName)
+	if err != nil {
+		return task.ID{}, err
+	}
+	if p.TimeLimit == 0 {
+		p.TimeLimit = defaultTimeLimit
+		u.warn("package has no time limit, the default one is used", slog.Int("time_limit", p.TimeLimit))
+	}
+	u.info("problem.yaml parsed",
+		slog.String("short_name", shortName),
+		slog.Int("time_limit", p.TimeLimit),
+		slog.Int("memory_limit", p.MemoryLimit),
+		slog.Bool("custom_checker", p.CustomChecker),
+	)
+
+	tests, err := collectTests(pkgDir)
+	if err != nil {
+		return task.ID{}, err
+	}
+	checker, err := buildChecker(pkgDir, p)
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to build checker: %w", err)
+	}
+	statementDir, sources, err := findStatements(pkgDir)
+	if err != nil {
+		return task.ID{}, err
+	}
+	samples, err := sampleSections(tests)
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	taskID, err := taskIDFromShortName(shortName)
+	if err != nil {
+		return task.ID{}, err
+	}
+	var bucketID bucket.ID
+	if err = bucketID.FromString(taskID.String()); err != nil {
+		return task.ID{}, fmt.Errorf("failed to parse bucket id: %w", err)
+	}
+	outDir, commit, abort, err := u.fs.ReserveBucket(ctx, bucketID, nil)
+	if err != nil {
+		return task.ID{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = abort()
+		}
+	}()
+
+	statements := make(task.Statements, len(sources))
+	title := ""
+	for _, source := range sources {
+		rendered, err := loadStatement(source.Path)
+		if err != nil {
+			return task.ID{}, fmt.Errorf("failed to convert %s statement: %w", source.Lang, err)
+		}
+		if rendered.Title == "" {
+			rendered.Title = p.title(source.Lang)
+		}
+		rendered.Sections.Samples = samples
+		page := statement.Render(rendered.Title, source.Lang, rendered.Sections)
+		saved, err := statement.Save(statementDir, outDir, source.Lang, page, &rendered.Sections)
+		if err != nil {
+			return task.ID{}, fmt.Errorf("failed to save %s statement: %w", source.Lang, err)
+		}
+		statements[source.Lang] = saved
+		if title == "" || source.Lang == "en" {
+			title = rendered.Title
+		}
+		u.info("statement saved",
+			slog.String("lang", source.Lang),
+			slog.Int("assets", len(saved.Assets)),
+		)
+	}
+
+	if err = writeFile(filepath.Join(outDir, checkerFileName), checker); err != nil {
+		return task.ID{}, fmt.Errorf("failed to write %s: %w", checkerFileName, err)
+	}
+	u.info("checker saved", slog.String("path", checkerFileName))
+
+	solution, err := copySolution(pkgDir, outDir)
+	if err != nil {
+		return task.ID{}, err
+	}
+	if solution.Path == "" {
+		u.warn("package has no accepted submission in a supported language, the task has no solution")
+	}
+
+	taskTests, err := copyTests(tests, outDir)
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to copy tests: %w", err)
+	}
+	u.info("tests copied", slog.Int("count", len(taskTests)))
+
+	taskModel := tasks.WriteCodeTask{
+		Details: task.Details{
+			ID:        taskID,
+			Title:     title,
+			Type:      task.WriteCode,
+			Level:     task.Level(cfg.Level),
+			Topics:    cfg.TaskTopics(),
+			Statement: statements,
+		},
+		TimeLimit:   p.TimeLimit,
+		MemoryLimit: p.MemoryLimit,
+		Tests:       taskTests,
+		Checker: task.Code{
+			Path: checkerFileName,
+			Lang: task.LanguageCpp,
+		},
+		Solution: solution,
+	}
+	taskBytes, err := json.MarshalIndent(taskModel, "", "    ")
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to marshal task.json: %w", err)
+	}
+	if err = writeFile(filepath.Join(outDir, "task.json"), taskBytes); err != nil {
+		return task.ID{}, fmt.Errorf("failed to write task.json: %w", err)
+	}
+	u.info("task.json saved")
+
+	if err = commit(); err != nil {
+		return task.ID{}, fmt.Errorf("failed to commit bucket: %w", err)
+	}
+	committed = true
+	u.info("bucket committed", slog.String("task_id", taskID.String()))
+
+	return taskID, nil
+}
+
+func (u icpcUploader) info(msg string, attrs ...any) {
+	if u.log == nil {
+		return
+	}
+	u.log.Info(msg, attrs...)
+}
+
+func (u icpcUploader) warn(msg string, attrs ...any) {
+	if u.log == nil {
+		return
+	}
+	u.log.Warn(msg, attrs...)
+}
+
+// packageShortName returns the short name of the problem, which the format defines as the name
+// of the package directory. An archive without a directory inside is named after the archive.
+func packageShortName(src, pkgDir, tempPrefix string) string {
+	if abs, err := filepath.Abs(pkgDir); err == nil {
+		pkgDir = abs
+	}
+	name := filepath.Base(pkgDir)
+	if strings.EqualFold(filepath.Ext(src), ".zip") && strings.HasPrefix(name, tempPrefix) {
+		return strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	}
+	return name
+}
+
+func taskIDFromShortName(shortName string) (task.ID, error) {
+	shortName = strings.TrimSpace(shortName)
+	if shortName == "" {
+		return task.ID{}, errors.New("problem short name is empty")
+	}
+	hash := sha1.Sum([]byte(shortName))
+	var taskID task.ID
+	if err := taskID.FromString(hex.EncodeToString(hash[:])); err != nil {
+		return task.ID{}, fmt.Errorf("failed to derive task id: %w", err)
+	}
+	return taskID, nil
+}
+
+// copySolution copies the first accepted submission in a supported language as the solution of the task.
+// The testing does not run the solution, so a package without one is still imported.
+func copySolution(pkgDir, outDir string) (task.Code, error) {
+	dir := filepath.Join(pkgDir, "submissions", "accepted")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return task.Code{}, nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ext := strings.ToLower(filepath.Ext(name))
+		lang, ok := solutionLanguages[ext]
+		if !ok {
+			continue
+		}
+		solution := task.Code{Path: "solution" + ext, Lang: lang}
+		if err = copyFile(filepath.Join(dir, name), filepath.Join(outDir, solution.Path)); err != nil {
+			return task.Code{}, fmt.Errorf("failed to copy solution %s: %w", name, err)
+		}
+		return solution, nil
+	}
+	return task.Code{}, nil
+}
@@ -0,0 +1,129 @@
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"taski/internal/domain/task"
+	"taski/internal/domain/task/tasks"
+	"taski/internal/uploader"
+)
+
+const (
+	// ManifestFileName is the file at the root of a package describing the task.
+	ManifestFileName = "manifest.json"
+
+	// ManifestVersion is the version of the package format written by MarshalManifest.
+	// A package of a newer version is rejected, since its manifest may mean something the importer does not know.
+	ManifestVersion = 1
+)
+
+// The fields the manifest adds to task.json.
+const (
+	formatField  = "format"
+	versionField = "version"
+	nameField    = "name"
+)
+
+type manifestHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+}
+
+// MarshalManifest returns the manifest of the task: its task.json with the format and the version
+// of the package put first.
+func MarshalManifest(t task.Task) ([]byte, error) {
+	data, err := json.MarshalIndent(t, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task: %w", err)
+	}
+	fields, ok := bytes.CutPrefix(data, []byte("{\n"))
+	if !ok {
+		return nil, fmt.Errorf("task is marshalled into %q instead of an object", data)
+	}
+
+	var manifest bytes.Buffer
+	fmt.Fprintf(&manifest, "{\n    %q: %q,\n    %q: %d,\n", formatField, uploader.FormatTaski, versionField, ManifestVersion)
+	manifest.Write(fields)
+	manifest.WriteByte('\n')
+	return manifest.Bytes(), nil
+}
+
+// unmarshalManifest splits the manifest into its header and the fields of task.json.
+func unmarshalManifest(data []byte) (manifestHeader, map[string]json.RawMessage, error) {
+	var header manifestHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return manifestHeader{}, nil, fmt.Errorf("failed to parse %s: %w", ManifestFileName, err)
+	}
+	if header.Format != uploader.FormatTaski {
+		return manifestHeader{}, nil, fmt.Errorf("%s has format %q, want %q", ManifestFileName, header.Format, uploader.FormatTaski)
+	}
+	if header.Version < 1 || header.Version > ManifestVersion {
+		return manifestHeader{}, nil, fmt.Errorf("unsupported package version %d (supported up to %d)", header.Version, ManifestVersion)
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return manifestHeader{}, nil, fmt.Errorf("failed to parse %s: %w", ManifestFileName, err)
+	}
+	delete(fields, formatField)
+	delete(fields, versionField)
+	delete(fields, nameField)
+	return header, fields, nil
+}
+
+// Files returns the files of the bucket the task refers to in alphabetical order:
+// the statements with their assets, the code and the tests.
+func Files(t task.Task) ([]string, error) {
+	if t == nil {
+		return nil, errors.New("nil task")
+	}
+
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if file != "" {
+			seen[file] = true
+		}
+	}
+
+	for _, statement := range t.GetStatement() {
+		add(statement.Path)
+		for _, asset := range statement.Assets {
+			add(asset)
+		}
+	}
+
+	switch typedTask := t.(type) {
+	case *tasks.WriteCodeTask:
+		if typedTask.SourceCode != nil {
+			add(typedTask.SourceCode.Path)
+		}
+		add(typedTask.Checker.Path)
+		add(typedTask.Solution.Path)
+		for _, test := range typedTask.Tests {
+			add(test.Input)
+			add(test.Output)
+		}
+	case *tasks.FindTestTask:
+		add(typedTask.Code.Path)
+		add(typedTask.Solution.Path)
+		add(typedTask.Checker.Path)
+	case *tasks.PredictOutputTask:
+		add(typedTask.Code.Path)
+		add(typedTask.Checker.Path)
+		add(typedTask.Test.Input)
+		add(typedTask.Test.Output)
+	default:
+		return nil, fmt.Errorf("unsupported task type %q", t.GetType())
+	}
+
+	files := make([]string, 0, len(seen))
+	for file := range seen {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
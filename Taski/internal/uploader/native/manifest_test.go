@@ -0,0 +1,135 @@
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"taski/internal/domain/task"
+	"taski/internal/domain/task/tasks"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var id task.ID
+	if err := id.FromString("0123456789abcdef0123456789abcdef01234567"); err != nil {
+		t.Fatalf("FromString() returned error: %v", err)
+	}
+	want := &tasks.PredictOutputTask{
+		Details: task.Details{
+			ID:        id,
+			Title:     "Predict",
+			Type:      task.PredictOutput,
+			Level:     3,
+			Topics:    []string{"loops"},
+			Statement: task.Statements{"en": {Path: "statements/en/statement.html"}},
+		},
+		Code:    task.Code{Path: "code.py", Lang: task.LanguagePython},
+		Checker: task.Code{Path: "checker.cpp", Lang: task.LanguageCpp},
+		Test:    task.Test{ID: 1, Input: "tests/01.in", Output: "tests/01.out"},
+	}
+
+	manifest, err := MarshalManifest(want)
+	if err != nil {
+		t.Fatalf("MarshalManifest() returned error: %v", err)
+	}
+	if !bytes.HasPrefix(manifest, []byte("{\n    \"format\": \"taski\",\n    \"version\": 1,\n")) {
+		t.Fatalf("MarshalManifest() = %s, want the format and the version first", manifest)
+	}
+
+	header, fields, err := unmarshalManifest(manifest)
+	if err != nil {
+		t.Fatalf("unmarshalManifest() returned error: %v", err)
+	}
+	if header.Version != ManifestVersion {
+		t.Fatalf("Version = %d, want %d", header.Version, ManifestVersion)
+	}
+	got, err := unmarshalTask(fields)
+	if err != nil {
+		t.Fatalf("unmarshalTask() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("task = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalManifestRejectsUnknownPackages(t *testing.T) {
+	t.Parallel()
+
+	manifests := map[string]string{
+		"other format":  `{"format": "polygon", "version": 1}`,
+		"newer version": `{"format": "taski", "version": 2}`,
+		"no version":    `{"format": "taski"}`,
+	}
+
+	for name, manifest := range manifests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, _, err := unmarshalManifest([]byte(manifest)); err == nil {
+				t.Fatalf("unmarshalManifest(%s) returned no error", manifest)
+			}
+		})
+	}
+}
+
+func TestManifestTaskIDFromName(t *testing.T) {
+	t.Parallel()
+
+	id, err := manifestTaskID(manifestHeader{Name: "a-plus-b"}, map[string]json.RawMessage{})
+	if err != nil {
+		t.Fatalf("manifestTaskID() returned error: %v", err)
+	}
+	again, err := manifestTaskID(manifestHeader{Name: " a-plus-b "}, map[string]json.RawMessage{})
+	if err != nil {
+		t.Fatalf("manifestTaskID() returned error: %v", err)
+	}
+	if id != again {
+		t.Fatalf("manifestTaskID() = %s and %s for the same name", id, again)
+	}
+
+	if _, err = manifestTaskID(manifestHeader{}, map[string]json.RawMessage{}); err == nil {
+		t.Fatal("manifestTaskID() returned no error without id and name")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	source := &task.Code{Path: "starter/main.cpp", Lang: task.LanguageCpp}
+	writeCode := &tasks.WriteCodeTask{
+		Details: task.Details{
+			Type: task.WriteCode,
+			Statement: task.Statements{
+				"ru": {Path: "statements/ru/statement.html", Assets: []string{"statements/ru/pic.png"}},
+			},
+		},
+		SourceCode: source,
+		Checker:    task.Code{Path: "checker.cpp"},
+		Solution:   task.Code{Path: "solution.cpp"},
+		Tests: []task.Test{
+			{ID: 1, Input: "tests/01.in", Output: "tests/01.out"},
+			{ID: 2, Input: "tests/02.in", Output: "tests/02.out"},
+		},
+	}
+
+	got, err := Files(writeCode)
+	if err != nil {
+		t.Fatalf("Files() returned error: %v", err)
+	}
+	want := []string{
+		"checker.cpp",
+		"solution.cpp",
+		"starter/main.cpp",
+		"statements/ru/pic.png",
+		"statements/ru/statement.html",
+		"tests/01.in",
+		"tests/01.out",
+		"tests/02.in",
+		"tests/02.out",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Files() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,275 @@
+package native
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"taski/internal/domain/task"
+	"taski/internal/domain/task/tasks"
+	"taski/internal/lib/markup"
+	"taski/internal/lib/safepath"
+	"taski/internal/uploader"
+	"taski/internal/uploader/statement"
+	"time"
+
+	"github.com/DIvanCode/filestorage/pkg/bucket"
+)
+
+type fileStorage interface {
+	ReserveBucket(ctx context.Context, id bucket.ID, ttl *time.Duration) (path string, commit, abort func() error, err error)
+}
+
+type nativeUploader struct {
+	fs  fileStorage
+	log *slog.Logger
+}
+
+func NewUploader(fs fileStorage, log *slog.Logger) uploader.Uploader {
+	return nativeUploader{
+		fs:  fs,
+		log: log,
+	}
+}
+
+func (u nativeUploader) SupportsFormat(format string) bool {
+	return strings.EqualFold(format, uploader.FormatTaski)
+}
+
+func (u nativeUploader) Upload(ctx context.Context, cfg uploader.Config) (task.ID, error) {
+	u.info("taski package upload started", slog.String("src", cfg.SrcPath))
+
+	if cfg.SrcPath == "" {
+		return task.ID{}, errors.New("missing source path")
+	}
+	if u.fs == nil {
+		return task.ID{}, errors.New("file storage is not configured")
+	}
+
+	pkgDir, cleanup, err := uploader.PrepareSource(cfg.SrcPath, ManifestFileName, "taski_pkg_*")
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to prepare source: %w", err)
+	}
+	defer cleanup()
+
+	data, err := os.ReadFile(filepath.Join(pkgDir, ManifestFileName))
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to read %s: %w", ManifestFileName, err)
+	}
+	header, fields, err := unmarshalManifest(data)
+	if err != nil {
+		return task.ID{}, err
+	}
+
+	taskID, err := manifestTaskID(header, fields)
+	if err != nil {
+		return task.ID{}, err
+	}
+	fields["id"], _ = json.Marshal(taskID)
+	if level, ok := fields["level"]; !ok || string(level) == "0" {
+		fields["level"], _ = json.Marshal(cfg.Level)
+	}
+	if len(cfg.Topics) > 0 {
+		fields["topics"], _ = json.Marshal(cfg.Topics)
+	}
+
+	t, err := unmarshalTask(fields)
+	if err != nil {
+		return task.ID{}, err
+	}
+	if t.GetLevel() < 1 || t.GetLevel() > 10 {
+		return task.ID{}, errors.New("level must be in range [1..10]")
+	}
+	if strings.TrimSpace(t.GetTitle()) == "" {
+		return task.ID{}, errors.New("task title is empty")
+	}
+	u.info("manifest parsed",
+		slog.String("task_id", taskID.String()),
+		slog.String("type", string(t.GetType())),
+		slog.Int("version", header.Version),
+	)
+
+	var bucketID bucket.ID
+	if err = bucketID.FromString(taskID.String()); err != nil {
+		return task.ID{}, fmt.Errorf("failed to parse bucket id: %w", err)
+	}
+	outDir, commit, abort, err := u.fs.ReserveBucket(ctx, bucketID, nil)
+	if err != nil {
+		return task.ID{}, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = abort()
+		}
+	}()
+
+	statements, written, err := saveStatements(pkgDir, outDir, t)
+	if err != nil {
+		return task.ID{}, err
+	}
+	fields["statement"], _ = json.Marshal(statements)
+	if t, err = unmarshalTask(fields); err != nil {
+		return task.ID{}, err
+	}
+
+	files, err := Files(t)
+	if err != nil {
+		return task.ID{}, err
+	}
+	for _, file := range files {
+		if written[file] {
+			continue
+		}
+		if err = copyPackageFile(pkgDir, outDir, file); err != nil {
+			return task.ID{}, err
+		}
+	}
+	u.info("files copied", slog.Int("count", len(files)))
+
+	taskBytes, err := json.MarshalIndent(t, "", "    ")
+	if err != nil {
+		return task.ID{}, fmt.Errorf("failed to marshal task.json: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(outDir, "task.json"), append(taskBytes, '\n'), 0o666); err != nil {
+		return task.ID{}, fmt.Errorf("failed to write task.json: %w", err)
+	}
+
+	if err = commit(); err != nil {
+		return task.ID{}, fmt.Errorf("failed to commit bucket: %w", err)
+	}
+	committed = true
+	u.info("bucket committed", slog.String("task_id", taskID.String()))
+
+	return taskID, nil
+}
+
+func (u nativeUploader) info(msg string, attrs ...any) {
+	if u.log == nil {
+		return
+	}
+	u.log.Info(msg, attrs...)
+}
+
+// manifestTaskID returns the ID the manifest has, which keeps the task under the same ID in every environment.
+// A package written by hand may have a name instead, the ID is then derived from it the way Polygon short names are.
+func manifestTaskID(header manifestHeader, fields map[string]json.RawMessage) (task.ID, error) {
+	var taskID task.ID
+	if raw, ok := fields["id"]; ok {
+		if err := json.Unmarshal(raw, &taskID); err != nil {
+			return task.ID{}, fmt.Errorf("invalid task id in %s: %w", ManifestFileName, err)
+		}
+		return taskID, nil
+	}
+
+	name := strings.TrimSpace(header.Name)
+	if name == "" {
+		return task.ID{}, fmt.Errorf("%s has neither id nor name", ManifestFileName)
+	}
+	hash := sha1.Sum([]byte(name))
+	if err := taskID.FromString(hex.EncodeToString(hash[:])); err != nil {
+		return task.ID{}, fmt.Errorf("failed to derive task id: %w", err)
+	}
+	return taskID, nil
+}
+
+func unmarshalTask(fields map[string]json.RawMessage) (task.Task, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task fields: %w", err)
+	}
+	t, err := tasks.UnmarshalTaskJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task in %s: %w", ManifestFileName, err)
+	}
+	return t, nil
+}
+
+// saveStatements writes the statements into the bucket. A statement whose path is a directory is rendered
+// from the sources in it, an HTML statement is sanitised and its assets are left to be copied along with the rest.
+// The files written are returned, so that they are not copied again.
+func saveStatements(pkgDir, outDir string, t task.Task) (task.Statements, map[string]bool, error) {
+	statements := make(task.Statements, len(t.GetStatement()))
+	written := make(map[string]bool)
+	for lang, st := range t.GetStatement() {
+		clean, err := safepath.Clean(st.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s statement path %q: %w", lang, st.Path, err)
+		}
+		src := filepath.Join(pkgDir, filepath.FromSlash(clean))
+
+		if stat, err := os.Stat(src); err == nil && stat.IsDir() {
+			rendered, err := statement.Load(src)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to render %s statement: %w", lang, err)
+			}
+			title := rendered.Title
+			if title == "" {
+				title = t.GetTitle()
+			}
+			saved, err := statement.Save(src, outDir, lang, statement.Render(title, lang, rendered.Sections), &rendered.Sections)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to save %s statement: %w", lang, err)
+			}
+			for _, asset := range saved.Assets {
+				written[asset] = true
+			}
+			st = saved
+		} else {
+			page, err := os.ReadFile(src)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read %s statement: %w", lang, err)
+			}
+			if err = writeFile(filepath.Join(outDir, filepath.FromSlash(clean)), []byte(markup.Sanitize(string(page)))); err != nil {
+				return nil, nil, fmt.Errorf("failed to write %s statement: %w", lang, err)
+			}
+			st.Path = clean
+			st.Sections = sanitizeSections(st.Sections)
+		}
+
+		statements[lang] = st
+		written[st.Path] = true
+	}
+	return statements, written, nil
+}
+
+// sanitizeSections sanitises the HTML of the sections given in the manifest, the way the statement page is.
+// The samples are plain text and are escaped where they are shown.
+func sanitizeSections(sections *task.StatementSections) *task.StatementSections {
+	if sections == nil {
+		return nil
+	}
+	clean := *sections
+	for _, field := range []*string{&clean.Legend, &clean.Input, &clean.Output, &clean.Interaction, &clean.Notes} {
+		*field = markup.Sanitize(*field)
+	}
+	return &clean
+}
+
+func copyPackageFile(pkgDir, outDir, file string) error {
+	clean, err := safepath.Clean(file)
+	if err != nil {
+		return fmt.Errorf("invalid file path %q: %w", file, err)
+	}
+	data, err := os.ReadFile(filepath.Join(pkgDir, filepath.FromSlash(clean)))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", clean, err)
+	}
+	if err = writeFile(filepath.Join(outDir, filepath.FromSlash(clean)), data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", clean, err)
+	}
+	return nil
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o666)
+}
@@ -0,0 +1,82 @@
+package native
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"taski/internal/domain/task"
+	"taski/internal/domain/task/tasks"
+	"testing"
+)
+
+func TestSaveStatementsSanitizesSections(t *testing.T) {
+	t.Parallel()
+
+	pkgDir := t.TempDir()
+	outDir := t.TempDir()
+	page := `<p>Add two numbers.</p><script>alert(1)</script>`
+	if err := writeFile(filepath.Join(pkgDir, "statements", "en", "statement.html"), []byte(page)); err != nil {
+		t.Fatalf("writeFile() returned error: %v", err)
+	}
+
+	samples := []task.StatementSample{{Input: "1 2\n", Output: "<3>\n"}}
+	tsk := &tasks.PredictOutputTask{
+		Details: task.Details{
+			Statement: task.Statements{"en": {
+				Path: "statements/en/statement.html",
+				Sections: &task.StatementSections{
+					Legend:      `<p>Add two numbers.</p><script>alert(1)</script>`,
+					Input:       `<img src="x.png" onerror="alert(1)">`,
+					Output:      `<a href="javascript:alert(1)">sum</a>`,
+					Interaction: `<iframe src="https://example.com"></iframe>`,
+					Notes:       `<p onclick="alert(1)">note</p>`,
+					Samples:     samples,
+				},
+			}},
+		},
+	}
+
+	statements, written, err := saveStatements(pkgDir, outDir, tsk)
+	if err != nil {
+		t.Fatalf("saveStatements() returned error: %v", err)
+	}
+	if !written["statements/en/statement.html"] {
+		t.Fatalf("written = %v, want the statement page", written)
+	}
+
+	saved, err := os.ReadFile(filepath.Join(outDir, "statements", "en", "statement.html"))
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %v", err)
+	}
+	if strings.Contains(string(saved), "script") {
+		t.Fatalf("statement page = %s, want the script dropped", saved)
+	}
+
+	sections := statements["en"].Sections
+	if sections == nil {
+		t.Fatal("saveStatements() dropped the sections")
+	}
+	for name, field := range map[string]string{
+		"legend":      sections.Legend,
+		"input":       sections.Input,
+		"output":      sections.Output,
+		"interaction": sections.Interaction,
+		"notes":       sections.Notes,
+	} {
+		for _, unsafe := range []string{"<script", "onerror", "javascript:", "<iframe", "onclick"} {
+			if strings.Contains(field, unsafe) {
+				t.Fatalf("%s section = %s, want %s dropped", name, field, unsafe)
+			}
+		}
+	}
+	if sections.Legend != "<p>Add two numbers.</p>" {
+		t.Fatalf("legend section = %s, want the paragraph kept", sections.Legend)
+	}
+	if !reflect.DeepEqual(sections.Samples, samples) {
+		t.Fatalf("samples = %v, want %v", sections.Samples, samples)
+	}
+	if tsk.Statement["en"].Sections.Input != `<img src="x.png" onerror="alert(1)">` {
+		t.Fatal("saveStatements() changed the sections of the task")
+	}
+}
@@ -0,0 +1,254 @@
+package polygon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"taski/internal/api/testing/execute"
+	"taski/internal/domain/task"
+	"taski/internal/domain/testing/event"
+	"taski/internal/domain/testing/event/events"
+	"taski/internal/domain/testing/execution"
+	"taski/internal/domain/testing/input/inputs"
+	"taski/internal/domain/testing/job"
+	"taski/internal/domain/testing/job/jobs"
+	"taski/internal/domain/testing/source"
+	"taski/internal/domain/testing/source/sources"
+	"taski/internal/domain/testing/strategy"
+	"time"
+
+	"github.com/DIvanCode/filestorage/pkg/bucket"
+)
+
+type (
+	executeClient interface {
+		Execute(ctx context.Context, stages execution.Stages, sources sources.Sources) (execution.ID, error)
+		Messages(ctx context.Context, executionID execution.ID, startID int64, count int) (execute.MessagesResponse, error)
+	}
+
+	// AnswerGenerationError tells why the main solution failed to produce the answer to a test.
+	// Test is zero when the solution failed before running on any test, e.g. did not compile.
+	AnswerGenerationError struct {
+		Test    int
+		Status  job.Status
+		Message string
+	}
+
+	// answersRequest is the main solution run on the tests missing answers
+	// with the time and memory limits of the package.
+	answersRequest struct {
+		TaskID       task.ID
+		SolutionCode string
+		SolutionLang task.Language
+		TestsDir     string
+		Tests        []int
+		TimeLimit    int
+		MemoryLimit  int
+	}
+)
+
+const (
+	answersStageName        = "answers"
+	solutionSourceName      = "solution"
+	testSourceFormat        = "test %d"
+	runSolutionOnTestFormat = "run solution on test %d"
+
+	answersPollInterval  = 250 * time.Millisecond
+	answersMessagesCount = 100
+	// defaultAnswersTimeout bounds the whole answers execution, from the submission to the last answer.
+	defaultAnswersTimeout = 10 * time.Minute
+	// answersArtifactTTL keeps the downloaded outputs in the filestorage until they are copied to the tests.
+	answersArtifactTTL = 10 * time.Minute
+)
+
+func (e *AnswerGenerationError) Error() string {
+	if e.Test == 0 {
+		return fmt.Sprintf("main solution failed with %s: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("main solution failed on test %d with %s: %s", e.Test, e.Status, e.Message)
+}
+
+// generateAnswers runs the main solution on the tests in exesh and writes its outputs as the test answers,
+// so that the code of the package never runs on the upload machine. The outputs are downloaded
+// from the workers as artifacts, since they may be too large for the execution messages.
+func (u polygonUploader) generateAnswers(ctx context.Context, req answersRequest) error {
+	if u.executeClient == nil {
+		return errors.New("execute client is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, u.answersTimeout)
+	defer cancel()
+
+	stages, srcs, runJobs, err := buildAnswersExecution(req)
+	if err != nil {
+		return err
+	}
+
+	executionID, err := u.executeClient.Execute(ctx, stages, srcs)
+	if err != nil {
+		return fmt.Errorf("failed to start answers execution: %w", err)
+	}
+	u.info("answers execution started", slog.String("execution_id", string(executionID)))
+
+	answered := make(map[int]bool, len(req.Tests))
+	startID := int64(1)
+	ticker := time.NewTicker(answersPollInterval)
+	defer ticker.Stop()
+
+	for {
+		resp, err := u.executeClient.Messages(ctx, executionID, startID, answersMessagesCount)
+		if err != nil {
+			return fmt.Errorf("failed to get answers execution messages: %w", err)
+		}
+
+		for _, record := range resp.Messages {
+			startID = record.MessageID + 1
+
+			switch record.Message.GetType() {
+			case event.CompileJob:
+				evt := record.Message.AsCompileJobEvent()
+				if evt.CompileStatus != job.StatusOK {
+					return &AnswerGenerationError{Status: evt.CompileStatus, Message: derefOr(evt.CompilationError, "compilation failed")}
+				}
+			case event.RunJob:
+				evt := record.Message.AsRunJobEvent()
+				test, ok := runJobs[evt.JobName]
+				if !ok {
+					continue
+				}
+				if evt.RunStatus != job.StatusOK {
+					return &AnswerGenerationError{Test: test, Status: evt.RunStatus, Message: "solution did not finish successfully"}
+				}
+				if evt.Artifact == nil {
+					return &AnswerGenerationError{Test: test, Status: job.StatusRE, Message: "no output artifact reported"}
+				}
+				outPath := filepath.Join(req.TestsDir, fmt.Sprintf("%02d.out", test))
+				if err = u.downloadAnswer(ctx, *evt.Artifact, outPath); err != nil {
+					return fmt.Errorf("test %d output download after generation: %w", test, err)
+				}
+				answered[test] = true
+			case event.FinishExecution:
+				evt := record.Message.AsFinishExecutionEvent()
+				if evt.Error != nil {
+					return fmt.Errorf("answers execution failed: %s", *evt.Error)
+				}
+				for _, test := range req.Tests {
+					if !answered[test] {
+						return &AnswerGenerationError{Test: test, Status: job.StatusRE, Message: "no output reported"}
+					}
+				}
+				return nil
+			}
+		}
+
+		if len(resp.Messages) == answersMessagesCount {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("answers execution %s is not finished: %w", executionID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// downloadAnswer downloads the output artifact to the filestorage and writes it as the answer.
+func (u polygonUploader) downloadAnswer(ctx context.Context, artifact events.Artifact, outPath string) error {
+	var bucketID bucket.ID
+	if err := bucketID.FromString(artifact.BucketID); err != nil {
+		return fmt.Errorf("invalid artifact bucket %q: %w", artifact.BucketID, err)
+	}
+
+	if err := u.fs.DownloadFile(ctx, artifact.Endpoint, bucketID, artifact.File); err != nil {
+		// the bucket does not exist yet, so create it
+		ttl := answersArtifactTTL
+		_, commit, _, reserveErr := u.fs.ReserveBucket(ctx, bucketID, &ttl)
+		if reserveErr != nil {
+			return fmt.Errorf("failed to reserve artifact bucket: %w", reserveErr)
+		}
+		if err = commit(); err != nil {
+			return fmt.Errorf("failed to commit artifact bucket: %w", err)
+		}
+		if err = u.fs.DownloadFile(ctx, artifact.Endpoint, bucketID, artifact.File); err != nil {
+			return fmt.Errorf("failed to download artifact: %w", err)
+		}
+	}
+
+	path, unlock, err := u.fs.GetFile(ctx, bucketID, artifact.File, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get artifact: %w", err)
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return writeFile(outPath, data)
+}
+
+// buildAnswersExecution returns the stages and the sources of the execution
+// along with the tests the run jobs of the solution are for.
+func buildAnswersExecution(req answersRequest) (execution.Stages, sources.Sources, map[job.Name]int, error) {
+	solutionSource := sources.NewInlineSource(solutionSourceName, req.SolutionCode)
+	srcs := sources.Sources{solutionSource}
+	stages := make(execution.Stages, 0, 2)
+
+	solution := inputs.NewInlineInput(solutionSource.GetName())
+	prepareJobName := strategy.FormatJobName(strategy.PrepareJobFormat, strategy.SolutionCode)
+	prepareJob, err := strategy.NewPrepareJob(req.TaskID, prepareJobName, solution, req.SolutionLang, task.CompileOptions{})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to prepare solution: %w", err)
+	}
+	deps := []execution.StageName{}
+	if prepareJob != nil {
+		prepareStage := execution.Stage{
+			Name:   strategy.FormatStageName(strategy.PrepareStageFormat),
+			Deps:   []execution.StageName{},
+			Policy: execution.StagePolicyFailFast,
+			Jobs:   []jobs.Job{*prepareJob},
+		}
+		stages = append(stages, prepareStage)
+		deps = append(deps, prepareStage.Name)
+		solution = inputs.NewArtifactInput(prepareJob.GetName())
+	}
+
+	answersStage := execution.Stage{
+		Name:   strategy.FormatStageName(answersStageName),
+		Deps:   deps,
+		Policy: execution.StagePolicyFailFast,
+		Jobs:   make([]jobs.Job, 0, len(req.Tests)),
+	}
+	runJobs := make(map[job.Name]int, len(req.Tests))
+	for _, test := range req.Tests {
+		inData, err := os.ReadFile(filepath.Join(req.TestsDir, fmt.Sprintf("%02d.in", test)))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("test %d input read for generation: %w", test, err)
+		}
+		testSource := sources.NewInlineSource(source.Name(fmt.Sprintf(testSourceFormat, test)), string(inData))
+		srcs = append(srcs, testSource)
+
+		runJobName := strategy.FormatJobName(runSolutionOnTestFormat, test)
+		runJob, err := strategy.NewRunJob(req.TaskID, runJobName,
+			req.SolutionLang, solution, inputs.NewInlineInput(testSource.GetName()),
+			req.TimeLimit, req.MemoryLimit, false)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to run solution on test %d: %w", test, err)
+		}
+		answersStage.Jobs = append(answersStage.Jobs, runJob)
+		runJobs[runJobName] = test
+	}
+	stages = append(stages, answersStage)
+
+	return stages, srcs, runJobs, nil
+}
+
+func derefOr(s *string, fallback string) string {
+	if s == nil {
+		return fallback
+	}
+	return *s
+}
@@ -0,0 +1,321 @@
+package polygon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"taski/internal/api/testing/execute"
+	"taski/internal/domain/task"
+	"taski/internal/domain/testing/event"
+	"taski/internal/domain/testing/event/events"
+	"taski/internal/domain/testing/execution"
+	"taski/internal/domain/testing/job"
+	"taski/internal/domain/testing/job/jobs"
+	"taski/internal/domain/testing/source/sources"
+	"testing"
+	"time"
+
+	"github.com/DIvanCode/filestorage/pkg/bucket"
+)
+
+const testWorkerEndpoint = "http://worker-1:5254"
+
+// fakeExecuteClient starts one execution and returns its messages in pages.
+type fakeExecuteClient struct {
+	messages []events.Event
+}
+
+func (c *fakeExecuteClient) Execute(context.Context, execution.Stages, sources.Sources) (execution.ID, error) {
+	return "execution-1", nil
+}
+
+func (c *fakeExecuteClient) Messages(_ context.Context, _ execution.ID, startID int64, count int) (execute.MessagesResponse, error) {
+	resp := execute.MessagesResponse{Status: "OK"}
+	for i := startID; i <= int64(len(c.messages)) && len(resp.Messages) < count; i++ {
+		resp.Messages = append(resp.Messages, execute.MessageRecord{MessageID: i, Message: c.messages[i-1]})
+	}
+	return resp, nil
+}
+
+// fakeFileStorage downloads the files the workers serve into its directory.
+type fakeFileStorage struct {
+	dir     string
+	served  map[string]string
+	buckets map[bucket.ID]bool
+}
+
+func newFakeFileStorage(t *testing.T, served map[string]string) *fakeFileStorage {
+	t.Helper()
+
+	return &fakeFileStorage{
+		dir:     t.TempDir(),
+		served:  served,
+		buckets: make(map[bucket.ID]bool),
+	}
+}
+
+func (fs *fakeFileStorage) ReserveBucket(_ context.Context, id bucket.ID, _ *time.Duration) (string, func() error, func() error, error) {
+	if fs.buckets[id] {
+		return "", nil, nil, errors.New("bucket already exists")
+	}
+	fs.buckets[id] = true
+	path := filepath.Join(fs.dir, id.String())
+	return path, func() error { return os.MkdirAll(path, 0o777) }, func() error { return os.RemoveAll(path) }, nil
+}
+
+func (fs *fakeFileStorage) DownloadFile(_ context.Context, endpoint string, id bucket.ID, file string) error {
+	if !fs.buckets[id] {
+		return errors.New("bucket not found")
+	}
+	content, ok := fs.served[endpoint+"/"+id.String()+"/"+file]
+	if !ok {
+		return errors.New("file not found")
+	}
+	return os.WriteFile(filepath.Join(fs.dir, id.String(), file), []byte(content), 0o666)
+}
+
+func (fs *fakeFileStorage) GetFile(_ context.Context, id bucket.ID, file string, _ *time.Duration) (string, func(), error) {
+	return filepath.Join(fs.dir, id.String(), file), func() {}, nil
+}
+
+func artifactBucket(test int) string {
+	return strings.Repeat(fmt.Sprint(test), 40)
+}
+
+func runEvent(test int, status job.Status, withArtifact bool) events.Event {
+	evt := &events.RunJobEvent{
+		Details:   event.Details{Type: event.RunJob, ExecutionID: "execution-1"},
+		JobName:   job.Name(fmt.Sprintf(runSolutionOnTestFormat, test)),
+		RunStatus: status,
+	}
+	if withArtifact {
+		evt.Artifact = &events.Artifact{Endpoint: testWorkerEndpoint, BucketID: artifactBucket(test), File: "output"}
+	}
+	return events.Event{IEvent: evt}
+}
+
+func compileEvent(status job.Status, compilationError string) events.Event {
+	return events.Event{IEvent: &events.CompileJobEvent{
+		Details:          event.Details{Type: event.CompileJob, ExecutionID: "execution-1"},
+		JobName:          "prepare solution",
+		CompileStatus:    status,
+		CompilationError: &compilationError,
+	}}
+}
+
+func finishEvent(errMessage *string) events.Event {
+	return events.Event{IEvent: &events.FinishExecutionEvent{
+		Details: event.Details{Type: event.FinishExecution, ExecutionID: "execution-1"},
+		Error:   errMessage,
+	}}
+}
+
+func writeTests(t *testing.T, tests ...int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, test := range tests {
+		if err := writeFile(filepath.Join(dir, fmt.Sprintf("%02d.in", test)), []byte(fmt.Sprintf("input %d", test))); err != nil {
+			t.Fatalf("writeFile() returned error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestBuildAnswersExecution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		lang       task.Language
+		wantStages []execution.StageName
+	}{
+		{name: "compiled", lang: task.LanguageCpp, wantStages: []execution.StageName{"prepare", "answers"}},
+		{name: "interpreted", lang: task.LanguagePython, wantStages: []execution.StageName{"answers"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := answersRequest{
+				SolutionCode: "int main() {}",
+				SolutionLang: tt.lang,
+				TestsDir:     writeTests(t, 1, 3),
+				Tests:        []int{1, 3},
+				TimeLimit:    1000,
+				MemoryLimit:  256,
+			}
+
+			stages, srcs, runJobs, err := buildAnswersExecution(req)
+			if err != nil {
+				t.Fatalf("buildAnswersExecution() returned error: %v", err)
+			}
+
+			names := make([]execution.StageName, 0, len(stages))
+			for _, stage := range stages {
+				names = append(names, stage.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantStages) {
+				t.Fatalf("stages = %v, want %v", names, tt.wantStages)
+			}
+			if len(srcs) != 3 {
+				t.Fatalf("sources = %d, want the solution and two tests", len(srcs))
+			}
+			wantRunJobs := map[job.Name]int{"run solution on test 1": 1, "run solution on test 3": 3}
+			if !reflect.DeepEqual(runJobs, wantRunJobs) {
+				t.Fatalf("run jobs = %v, want %v", runJobs, wantRunJobs)
+			}
+
+			answers := stages[len(stages)-1]
+			if !reflect.DeepEqual(answers.Deps, names[:len(names)-1]) {
+				t.Fatalf("answers stage deps = %v, want %v", answers.Deps, names[:len(names)-1])
+			}
+			for _, jb := range answers.Jobs {
+				if showsOutput(jb) {
+					t.Fatalf("job %s shows its output, want it kept as an artifact", jb.GetName())
+				}
+			}
+		})
+	}
+}
+
+func TestBuildAnswersExecutionMissingInput(t *testing.T) {
+	t.Parallel()
+
+	req := answersRequest{SolutionLang: task.LanguagePython, TestsDir: writeTests(t, 1), Tests: []int{1, 2}}
+	if _, _, _, err := buildAnswersExecution(req); err == nil {
+		t.Fatal("buildAnswersExecution() returned no error for a missing test input")
+	}
+}
+
+func TestGenerateAnswers(t *testing.T) {
+	t.Parallel()
+
+	failure := "worker lost"
+	tests := []struct {
+		name         string
+		messages     []events.Event
+		wantErr      *AnswerGenerationError
+		wantFail     bool
+		wantDeadline bool
+		wantFiles    map[string]string
+	}{
+		{
+			name: "answers downloaded",
+			messages: []events.Event{
+				compileEvent(job.StatusOK, ""),
+				runEvent(1, job.StatusOK, true),
+				runEvent(3, job.StatusOK, true),
+				finishEvent(nil),
+			},
+			wantFiles: map[string]string{"01.out": "answer 1\n", "03.out": "answer 3\n"},
+		},
+		{
+			name:     "compilation error",
+			messages: []events.Event{compileEvent(job.StatusCE, "syntax error")},
+			wantErr:  &AnswerGenerationError{Status: job.StatusCE, Message: "syntax error"},
+		},
+		{
+			name:     "time limit",
+			messages: []events.Event{runEvent(1, job.StatusOK, true), runEvent(3, job.StatusTL, false)},
+			wantErr:  &AnswerGenerationError{Test: 3, Status: job.StatusTL, Message: "solution did not finish successfully"},
+		},
+		{
+			name:     "no artifact",
+			messages: []events.Event{runEvent(1, job.StatusOK, false)},
+			wantErr:  &AnswerGenerationError{Test: 1, Status: job.StatusRE, Message: "no output artifact reported"},
+		},
+		{
+			name:     "no answer before the finish",
+			messages: []events.Event{runEvent(1, job.StatusOK, true), finishEvent(nil)},
+			wantErr:  &AnswerGenerationError{Test: 3, Status: job.StatusRE, Message: "no output reported"},
+		},
+		{
+			name:     "execution failed",
+			messages: []events.Event{finishEvent(&failure)},
+			wantFail: true,
+		},
+		{
+			name:         "execution never finishes",
+			messages:     []events.Event{runEvent(1, job.StatusOK, true)},
+			wantFail:     true,
+			wantDeadline: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			testsDir := writeTests(t, 1, 3)
+			client := &fakeExecuteClient{messages: tt.messages}
+			fs := newFakeFileStorage(t, map[string]string{
+				testWorkerEndpoint + "/" + artifactBucket(1) + "/output": "answer 1",
+				testWorkerEndpoint + "/" + artifactBucket(3) + "/output": "answer 3",
+			})
+			u := polygonUploader{
+				fs:             fs,
+				executeClient:  client,
+				log:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+				answersTimeout: 300 * time.Millisecond,
+			}
+
+			err := u.generateAnswers(context.Background(), answersRequest{
+				SolutionLang: task.LanguagePython,
+				TestsDir:     testsDir,
+				Tests:        []int{1, 3},
+				TimeLimit:    1000,
+				MemoryLimit:  256,
+			})
+
+			var genErr *AnswerGenerationError
+			switch {
+			case tt.wantErr != nil:
+				if !errors.As(err, &genErr) || *genErr != *tt.wantErr {
+					t.Fatalf("generateAnswers() returned %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantFail:
+				if err == nil || errors.As(err, &genErr) {
+					t.Fatalf("generateAnswers() returned %v, want an execution error", err)
+				}
+				if tt.wantDeadline && !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("generateAnswers() returned %v, want the answers timeout", err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("generateAnswers() returned error: %v", err)
+			}
+
+			for name, want := range tt.wantFiles {
+				got, err := os.ReadFile(filepath.Join(testsDir, name))
+				if err != nil {
+					t.Fatalf("ReadFile(%s) returned error: %v", name, err)
+				}
+				if string(got) != want {
+					t.Fatalf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func showsOutput(jb jobs.Job) bool {
+	switch typed := jb.IJob.(type) {
+	case *jobs.RunCppJob:
+		return typed.ShowOutput
+	case *jobs.RunGoJob:
+		return typed.ShowOutput
+	case *jobs.RunPyJob:
+		return typed.ShowOutput
+	default:
+		return false
+	}
+}
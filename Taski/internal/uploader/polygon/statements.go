@@ -0,0 +1,227 @@
+package polygon
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"taski/internal/domain/task"
+	"taski/internal/lib/markup"
+	"taski/internal/uploader/statement"
+)
+
+// polygonStatement is the statement of the package in one language. The statement is rendered from
+// SourcesDir when the package has the LaTeX sources of it, otherwise it is taken from the HTML at Path.
+type polygonStatement struct {
+	Lang       string
+	Path       string
+	SourcesDir string
+	Title      string
+}
+
+// statementSections are the classes of the statement divs kept in the order they go in.
+var statementSections = []string{
+	"legend",
+	"input-specification",
+	"output-specification",
+	"interaction",
+	"sample-tests",
+	"note",
+}
+
+// statementLangs maps the languages of Polygon to the codes the statements are stored by.
+var statementLangs = map[string]string{
+	"russian":     "ru",
+	"english":     "en",
+	"ukrainian":   "uk",
+	"belarusian":  "be",
+	"kazakh":      "kk",
+	"uzbek":       "uz",
+	"armenian":    "hy",
+	"georgian":    "ka",
+	"azerbaijani": "az",
+	"german":      "de",
+	"french":      "fr",
+	"spanish":     "es",
+	"portuguese":  "pt",
+	"polish":      "pl",
+	"chinese":     "zh",
+	"japanese":    "ja",
+	"korean":      "ko",
+}
+
+var (
+	// Polygon leaves the formulas of the HTML statements to MathJax: $$$...$$$ inline and $$$$$$...$$$$$$ on a line of their own.
+	polygonDisplayMathRe = regexp.MustCompile(`(?s)\${6}(.+?)\${6}`)
+	polygonInlineMathRe  = regexp.MustCompile(`(?s)\${3}(.+?)\${3}`)
+
+	sampleBlockRe  = regexp.MustCompile(`(?is)<div class="(input|output)">.*?<pre[^>]*>(.*?)</pre>`)
+	sectionTitleRe = regexp.MustCompile(`(?is)^\s*<div class="section-title">.*?</div>`)
+	tagRe          = regexp.MustCompile(`(?s)<[^>]*>`)
+	brRe           = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// pickStatements returns a statement for every language the package has one in. The LaTeX sources are preferred
+// over the HTML: statement-sections/<language> of the full package or the problem-properties.json
+// next to the .tex statement.
+func pickStatements(pkgDir string, p polygonProblem) []polygonStatement {
+	titles := make(map[string]string, len(p.Names.Names))
+	for _, n := range p.Names.Names {
+		titles[statementLang(n.Language)] = strings.TrimSpace(n.Value)
+	}
+
+	statements := make([]polygonStatement, 0, len(p.Statements.Statements))
+	byLang := make(map[string]int)
+	for _, s := range p.Statements.Statements {
+		lang := statementLang(s.Language)
+		i, ok := byLang[lang]
+		if !ok {
+			i = len(statements)
+			byLang[lang] = i
+			statements = append(statements, polygonStatement{Lang: lang, Title: titles[lang]})
+			sectionsDir := filepath.Join("statement-sections", strings.ToLower(strings.TrimSpace(s.Language)))
+			if statement.HasSources(filepath.Join(pkgDir, sectionsDir)) {
+				statements[i].SourcesDir = sectionsDir
+			}
+		}
+
+		switch strings.ToLower(filepath.Ext(s.Path)) {
+		case ".html":
+			if statements[i].Path == "" {
+				statements[i].Path = s.Path
+			}
+		case ".tex":
+			texDir := filepath.Dir(filepath.Clean(s.Path))
+			if statements[i].SourcesDir == "" && fileExists(filepath.Join(pkgDir, texDir, statement.PropertiesFileName)) {
+				statements[i].SourcesDir = texDir
+			}
+		}
+	}
+	statements = slices.DeleteFunc(statements, func(s polygonStatement) bool {
+		return s.SourcesDir == "" && s.Path == ""
+	})
+
+	if len(statements) == 0 {
+		statements = append(statements, polygonStatement{
+			Lang:  task.DefaultStatementLang,
+			Path:  filepath.Join("statements", ".html", "russian", "problem.html"),
+			Title: titles[task.DefaultStatementLang],
+		})
+	}
+	return statements
+}
+
+func statementLang(polygonLang string) string {
+	polygonLang = strings.ToLower(strings.TrimSpace(polygonLang))
+	if lang, ok := statementLangs[polygonLang]; ok {
+		return lang
+	}
+	return polygonLang
+}
+
+// saveStatement renders the statement and writes it along with its images into statements/<lang> of the bucket.
+func saveStatement(pkgDir, outDir string, s polygonStatement, title string) (task.Statement, error) {
+	if s.Title != "" {
+		title = s.Title
+	}
+
+	if s.SourcesDir != "" {
+		srcDir := filepath.Join(pkgDir, filepath.Clean(s.SourcesDir))
+		rendered, err := statement.Load(srcDir)
+		if err != nil {
+			return task.Statement{}, err
+		}
+		if s.Title == "" && rendered.Title != "" {
+			title = rendered.Title
+		}
+		return statement.Save(srcDir, outDir, s.Lang, statement.Render(title, s.Lang, rendered.Sections), &rendered.Sections)
+	}
+
+	statementAbs := filepath.Join(pkgDir, filepath.Clean(s.Path))
+	page, err := buildStatementHTML(statementAbs, title)
+	if err != nil {
+		return task.Statement{}, err
+	}
+	sections := htmlSections(page)
+	return statement.Save(filepath.Dir(statementAbs), outDir, s.Lang, page, &sections)
+}
+
+func buildStatementHTML(statementHTMLPath, title string) (string, error) {
+	data, err := os.ReadFile(statementHTMLPath)
+	if err != nil {
+		return "", err
+	}
+	src := string(data)
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = markup.Sanitize(renderPolygonMath(src))
+
+	sections := make([]string, 0, len(statementSections))
+	for _, className := range statementSections {
+		if section := extractDivByClass(src, className); section != "" {
+			sections = append(sections, strings.TrimSpace(section))
+		}
+	}
+	if len(sections) == 0 {
+		return "", fmt.Errorf("failed to find statement sections in %s", statementHTMLPath)
+	}
+
+	var b strings.Builder
+	if strings.TrimSpace(title) != "" {
+		b.WriteString(`<h1 class="title">`)
+		b.WriteString(escapeHTMLText(strings.TrimSpace(title)))
+		b.WriteString("</h1>\n")
+	}
+	for _, section := range sections {
+		b.WriteString(section)
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String()) + "\n", nil
+}
+
+// renderPolygonMath renders the MathJax formulas of the Polygon HTML into MathML.
+func renderPolygonMath(src string) string {
+	render := func(re *regexp.Regexp, display bool) func(string) string {
+		return func(match string) string {
+			return markup.Math(html.UnescapeString(re.FindStringSubmatch(match)[1]), display)
+		}
+	}
+	src = polygonDisplayMathRe.ReplaceAllStringFunc(src, render(polygonDisplayMathRe, true))
+	return polygonInlineMathRe.ReplaceAllStringFunc(src, render(polygonInlineMathRe, false))
+}
+
+// htmlSections takes the sections out of the sanitised Polygon statement.
+func htmlSections(page string) task.StatementSections {
+	sections := task.StatementSections{
+		Legend:      sectionBody(page, "legend"),
+		Input:       sectionBody(page, "input-specification"),
+		Output:      sectionBody(page, "output-specification"),
+		Interaction: sectionBody(page, "interaction"),
+		Notes:       sectionBody(page, "note"),
+	}
+
+	var sample task.StatementSample
+	for _, match := range sampleBlockRe.FindAllStringSubmatch(extractDivByClass(page, "sample-tests"), -1) {
+		text := html.UnescapeString(tagRe.ReplaceAllString(brRe.ReplaceAllString(match[2], "\n"), ""))
+		if match[1] == "input" {
+			sample = task.StatementSample{Input: text}
+			continue
+		}
+		sample.Output = text
+		sections.Samples = append(sections.Samples, sample)
+		sample = task.StatementSample{}
+	}
+	return sections
+}
+
+// sectionBody returns the inner HTML of the section div without its title.
+func sectionBody(page, className string) string {
+	div := extractDivByClass(page, className)
+	if div == "" {
+		return ""
+	}
+	body := div[strings.Index(div, ">")+1 : strings.LastIndex(div, "<")]
+	return strings.TrimSpace(sectionTitleRe.ReplaceAllString(body, ""))
+}
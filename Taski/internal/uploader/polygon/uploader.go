@@ -1,8 +1,6 @@
 package polygon
 
 import (
-	"archive/zip"
-	"bytes"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
@@ -10,9 +8,7 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
@@ -49,6 +45,12 @@ type polygonProblem struct {
 		Testsets []polygonTestset `xml:"testset"`
 	} `xml:"judging"`
 
+	Tags struct {
+		Tags []struct {
+			Value string `xml:"value,attr"`
+		} `xml:"tag"`
+	} `xml:"tags"`
+
 	Assets struct {
 		Checker struct {
 			Source polygonSource `xml:"source"`
@@ -83,6 +85,8 @@ type polygonTestset struct {
 
 type fileStorage interface {
 	ReserveBucket(ctx context.Context, id bucket.ID, ttl *time.Duration) (path string, commit, abort func() error, err error)
+	DownloadFile(ctx context.Context, endpoint string, id bucket.ID, file string) error
+	GetFile(ctx context.Context, id bucket.ID, file string, ttl *time.Duration) (path string, unlock func(), err error)
 }
 
 var (
@@ -92,14 +96,24 @@ var (
 )
 
 type polygonUploader struct {
-	fs  fileStorage
-	log *slog.Logger
+	fs            fileStorage
+	executeClient executeClient
+	topics        uploader.Topics
+	log           *slog.Logger
+
+	// answersTimeout bounds the generation of the missing answers.
+	answersTimeout time.Duration
 }
 
-func NewUploader(fs fileStorage, log *slog.Logger) uploader.Uploader {
+// NewUploader returns the Polygon importer, topics map the tags of the problem onto the task topics.
+func NewUploader(fs fileStorage, executeClient executeClient, topics uploader.Topics, log *slog.Logger) uploader.Uploader {
 	return polygonUploader{
-		fs:  fs,
-		log: log,
+		fs:            fs,
+		executeClient: executeClient,
+		topics:        topics,
+		log:           log,
+
+		answersTimeout: defaultAnswersTimeout,
 	}
 }
 
@@ -123,7 +137,7 @@ func (u polygonUploader) Upload(ctx context.Context, cfg uploader.Config) (task.
 		return task.ID{}, errors.New("file storage is not configured")
 	}
 
-	pkgDir, cleanup, err := prepareSourceDir(cfg.SrcPath)
+	pkgDir, cleanup, err := uploader.PrepareSource(cfg.SrcPath, "problem.xml", "polygon_pkg_*")
 	if err != nil {
 		return task.ID{}, fmt.Errorf("failed to prepare source: %w", err)
 	}
@@ -150,18 +164,14 @@ func (u polygonUploader) Upload(ctx context.Context, cfg uploader.Config) (task.
 	if title == "" {
 		return task.ID{}, errors.New("failed to extract task title from problem.xml")
 	}
+	topics := u.pickTopics(problem, cfg)
 	u.info("metadata extracted",
 		slog.String("title", title),
 		slog.Int("time_limit_ms", testset.TimeLimit),
 		slog.Int("memory_limit_bytes", testset.MemoryLimit),
+		slog.Any("topics", topics),
 	)
 
-	statementPath := pickStatementPath(problem)
-	if statementPath == "" {
-		statementPath = filepath.Join("statements", ".html", "russian", "problem.html")
-	}
-	statementAbs := filepath.Join(pkgDir, filepath.Clean(statementPath))
-
 	solutionSource := pickSolutionSource(problem)
 	solutionRel := strings.TrimSpace(solutionSource.Path)
 	if solutionRel == "" {
@@ -203,14 +213,18 @@ func (u polygonUploader) Upload(ctx context.Context, cfg uploader.Config) (task.
 		}
 	}()
 
-	statementHTML, err := buildStatementHTML(statementAbs, title)
-	if err != nil {
-		return task.ID{}, fmt.Errorf("failed to convert statement: %w", err)
-	}
-	if err = writeFile(filepath.Join(outDir, "statement.html"), []byte(statementHTML)); err != nil {
-		return task.ID{}, fmt.Errorf("failed to write statement.html: %w", err)
+	statements := make(task.Statements)
+	for _, s := range pickStatements(pkgDir, problem) {
+		statement, err := saveStatement(pkgDir, outDir, s, title)
+		if err != nil {
+			return task.ID{}, fmt.Errorf("failed to convert %s statement: %w", s.Lang, err)
+		}
+		statements[s.Lang] = statement
+		u.info("statement saved",
+			slog.String("lang", s.Lang),
+			slog.Int("assets", len(statement.Assets)),
+		)
 	}
-	u.info("statement saved")
 
 	solutionAbs := filepath.Join(pkgDir, filepath.Clean(solutionRel))
 	solutionCode, err := os.ReadFile(solutionAbs)
@@ -252,25 +266,33 @@ func (u polygonUploader) Upload(ctx context.Context, cfg uploader.Config) (task.
 		slog.Int("missing_outputs", len(missingOutputs)),
 	)
 
+	var taskID task.ID
+	if err = taskID.FromString(bucketID); err != nil {
+		return task.ID{}, fmt.Errorf("failed to convert bucket id to task id: %w", err)
+	}
+
 	if len(missingOutputs) > 0 {
-		if err = generateMissingOutputs(solutionAbs, solutionLang, testsDir, missingOutputs); err != nil {
+		if err = u.generateAnswers(ctx, answersRequest{
+			TaskID:       taskID,
+			SolutionCode: string(solutionCode),
+			SolutionLang: solutionLang,
+			TestsDir:     testsDir,
+			Tests:        missingOutputs,
+			TimeLimit:    testset.TimeLimit,
+			MemoryLimit:  memoryBytesToMB(testset.MemoryLimit),
+		}); err != nil {
 			return task.ID{}, fmt.Errorf("failed to generate missing outputs: %w", err)
 		}
 		u.info("missing outputs generated", slog.Int("count", len(missingOutputs)))
 	}
-
-	var taskID task.ID
-	if err = taskID.FromString(bucketID); err != nil {
-		return task.ID{}, fmt.Errorf("failed to convert bucket id to task id: %w", err)
-	}
 	taskModel := tasks.WriteCodeTask{
 		Details: task.Details{
 			ID:        taskID,
 			Title:     title,
 			Type:      task.WriteCode,
 			Level:     task.Level(cfg.Level),
-			Topics:    []string{},
-			Statement: "statement.html",
+			Topics:    topics,
+			Statement: statements,
 		},
 		TimeLimit:   testset.TimeLimit,
 		MemoryLimit: memoryBytesToMB(testset.MemoryLimit),
@@ -309,82 +331,21 @@ func (u polygonUploader) info(msg string, attrs ...any) {
 	u.log.Info(msg, attrs...)
 }
 
-func prepareSourceDir(src string) (string, func(), error) {
-	stat, err := os.Stat(src)
-	if err != nil {
-		return "", nil, err
-	}
-	if stat.IsDir() {
-		return src, func() {}, nil
-	}
-
-	if strings.EqualFold(filepath.Ext(src), ".zip") {
-		tempDir, err := os.MkdirTemp("", "polygon_pkg_*")
-		if err != nil {
-			return "", nil, err
-		}
-		if err = unzip(src, tempDir); err != nil {
-			_ = os.RemoveAll(tempDir)
-			return "", nil, err
-		}
-		root := pickZipRoot(tempDir)
-		return root, func() { _ = os.RemoveAll(tempDir) }, nil
-	}
-
-	return "", nil, fmt.Errorf("unsupported source type: %s (expected directory or .zip)", src)
-}
-
-func unzip(zipPath, dest string) error {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = r.Close() }()
-
-	for _, f := range r.File {
-		target := filepath.Join(dest, f.Name)
-		if !strings.HasPrefix(filepath.Clean(target), filepath.Clean(dest)+string(os.PathSeparator)) &&
-			filepath.Clean(target) != filepath.Clean(dest) {
-			return fmt.Errorf("zip contains unsafe path: %s", f.Name)
-		}
-
-		if f.FileInfo().IsDir() {
-			if err := os.MkdirAll(target, 0o777); err != nil {
-				return err
-			}
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(target), 0o777); err != nil {
-			return err
-		}
-		rc, err := f.Open()
-		if err != nil {
-			return err
-		}
-		data, err := io.ReadAll(rc)
-		_ = rc.Close()
-		if err != nil {
-			return err
-		}
-		if err := os.WriteFile(target, data, 0o666); err != nil {
-			return err
-		}
+// pickTopics returns the topics given with the upload, or else the ones the tags of the problem map onto.
+func (u polygonUploader) pickTopics(p polygonProblem, cfg uploader.Config) []string {
+	if len(cfg.Topics) > 0 {
+		return cfg.TaskTopics()
 	}
 
-	return nil
-}
-
-func pickZipRoot(dir string) string {
-	entries, err := os.ReadDir(dir)
-	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
-		return dir
+	tags := make([]string, 0, len(p.Tags.Tags))
+	for _, tag := range p.Tags.Tags {
+		tags = append(tags, tag.Value)
 	}
-	candidate := filepath.Join(dir, entries[0].Name())
-	if _, err := os.Stat(filepath.Join(candidate, "problem.xml")); err == nil {
-		return candidate
+	topics, unmatched := u.topics.Match(tags)
+	if len(unmatched) > 0 {
+		u.info("tags not mapped onto topics", slog.Any("tags", unmatched))
 	}
-	return dir
+	return topics
 }
 
 func loadProblem(path string) (polygonProblem, error) {
@@ -411,20 +372,6 @@ func pickTitle(p polygonProblem) string {
 	return ""
 }
 
-func pickStatementPath(p polygonProblem) string {
-	for _, s := range p.Statements.Statements {
-		if strings.EqualFold(s.Language, "russian") && strings.HasSuffix(strings.ToLower(s.Path), ".html") {
-			return s.Path
-		}
-	}
-	for _, s := range p.Statements.Statements {
-		if strings.HasSuffix(strings.ToLower(s.Path), ".html") {
-			return s.Path
-		}
-	}
-	return ""
-}
-
 func pickSolutionSource(p polygonProblem) polygonSource {
 	for _, s := range p.Assets.Solutions.Solutions {
 		if strings.EqualFold(s.Tag, "main") {
@@ -548,103 +495,6 @@ func resolveTestOutputPath(pkgDir, pattern string, i int) (string, bool, error)
 	return "", false, nil
 }
 
-func generateMissingOutputs(solutionPath string, solutionLang task.Language, testsDir string, missing []int) error {
-	run := func(_ context.Context, _ []byte) ([]byte, string, error) {
-		return nil, "", nil
-	}
-	cleanup := func() {}
-
-	switch solutionLang {
-	case task.LanguageCpp:
-		bin, done, err := compileCppSolution(solutionPath)
-		if err != nil {
-			return err
-		}
-		cleanup = done
-		run = func(ctx context.Context, in []byte) ([]byte, string, error) {
-			cmd := exec.CommandContext(ctx, bin)
-			cmd.Stdin = bytes.NewReader(in)
-			var stdout, stderr bytes.Buffer
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
-			err := cmd.Run()
-			return stdout.Bytes(), strings.TrimSpace(stderr.String()), err
-		}
-	case task.LanguageGo:
-		bin, done, err := compileGoSolution(solutionPath)
-		if err != nil {
-			return err
-		}
-		cleanup = done
-		run = func(ctx context.Context, in []byte) ([]byte, string, error) {
-			cmd := exec.CommandContext(ctx, bin)
-			cmd.Stdin = bytes.NewReader(in)
-			var stdout, stderr bytes.Buffer
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
-			err := cmd.Run()
-			return stdout.Bytes(), strings.TrimSpace(stderr.String()), err
-		}
-	case task.LanguagePython:
-		run = func(ctx context.Context, in []byte) ([]byte, string, error) {
-			cmd := exec.CommandContext(ctx, "python3", solutionPath)
-			cmd.Stdin = bytes.NewReader(in)
-			var stdout, stderr bytes.Buffer
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
-			err := cmd.Run()
-			return stdout.Bytes(), strings.TrimSpace(stderr.String()), err
-		}
-	default:
-		return fmt.Errorf("missing outputs generation is not supported for language %s", solutionLang)
-	}
-	defer cleanup()
-
-	for _, i := range missing {
-		inPath := filepath.Join(testsDir, fmt.Sprintf("%02d.in", i))
-		outPath := filepath.Join(testsDir, fmt.Sprintf("%02d.out", i))
-
-		inData, err := os.ReadFile(inPath)
-		if err != nil {
-			return fmt.Errorf("test %d input read for generation: %w", i, err)
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		stdout, stderr, err := run(ctx, inData)
-		cancel()
-		if err != nil {
-			return fmt.Errorf("test %d output generation failed: %w; stderr: %s", i, err, stderr)
-		}
-		if err = writeFile(outPath, stdout); err != nil {
-			return fmt.Errorf("test %d output write after generation: %w", i, err)
-		}
-	}
-
-	return nil
-}
-
-func compileCppSolution(src string) (string, func(), error) {
-	binPath := filepath.Join(os.TempDir(), fmt.Sprintf("polygon_solution_%d", time.Now().UnixNano()))
-	cmd := exec.Command("g++", "-std=c++17", "-O2", src, "-o", binPath)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return "", nil, fmt.Errorf("failed to compile solution with g++: %w; stderr: %s", err, strings.TrimSpace(stderr.String()))
-	}
-	return binPath, func() { _ = os.Remove(binPath) }, nil
-}
-
-func compileGoSolution(src string) (string, func(), error) {
-	binPath := filepath.Join(os.TempDir(), fmt.Sprintf("polygon_solution_%d", time.Now().UnixNano()))
-	cmd := exec.Command("go", "build", "-o", binPath, src)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return "", nil, fmt.Errorf("failed to compile solution with go build: %w; stderr: %s", err, strings.TrimSpace(stderr.String()))
-	}
-	return binPath, func() { _ = os.Remove(binPath) }, nil
-}
-
 func inlineTestlibIfNeeded(pkgDir, checkerAbs string, checkerCode []byte) ([]byte, error) {
 	code := string(checkerCode)
 	includeRe := regexp.MustCompile(`(?m)^\s*#\s*include\s*[<"]testlib\.h[>"]\s*$`)
@@ -676,42 +526,6 @@ func inlineTestlibIfNeeded(pkgDir, checkerAbs string, checkerCode []byte) ([]byt
 	return []byte(out), nil
 }
 
-func buildStatementHTML(statementHTMLPath, title string) (string, error) {
-	data, err := os.ReadFile(statementHTMLPath)
-	if err != nil {
-		return "", err
-	}
-	src := string(data)
-	src = strings.ReplaceAll(src, "\r\n", "\n")
-
-	legend := extractDivByClass(src, "legend")
-	input := extractDivByClass(src, "input-specification")
-	output := extractDivByClass(src, "output-specification")
-	if legend == "" && input == "" && output == "" {
-		return "", fmt.Errorf("failed to find statement sections in %s", statementHTMLPath)
-	}
-
-	var b strings.Builder
-	if strings.TrimSpace(title) != "" {
-		b.WriteString(`<h1 class="title">`)
-		b.WriteString(escapeHTMLText(strings.TrimSpace(title)))
-		b.WriteString("</h1>\n")
-	}
-	if legend != "" {
-		b.WriteString(strings.TrimSpace(legend))
-		b.WriteString("\n")
-	}
-	if input != "" {
-		b.WriteString(strings.TrimSpace(input))
-		b.WriteString("\n")
-	}
-	if output != "" {
-		b.WriteString(strings.TrimSpace(output))
-		b.WriteString("\n")
-	}
-	return strings.TrimSpace(b.String()) + "\n", nil
-}
-
 func extractDivByClass(src, className string) string {
 	openDivRe := regexp.MustCompile(`(?is)<div\b[^>]*>`)
 	divTagRe := regexp.MustCompile(`(?is)</?div\b[^>]*>`)
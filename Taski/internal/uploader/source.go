@@ -0,0 +1,91 @@
+package uploader
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PrepareSource returns the directory of the package at src, which is either a directory or a ZIP archive.
+// An archive is extracted into a temporary directory named by tempPattern and removed by cleanup.
+// When the archive holds a single directory with rootFile in it, that directory is the package.
+func PrepareSource(src, rootFile, tempPattern string) (dir string, cleanup func(), err error) {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return "", nil, err
+	}
+	if stat.IsDir() {
+		return src, func() {}, nil
+	}
+
+	if strings.EqualFold(filepath.Ext(src), ".zip") {
+		tempDir, err := os.MkdirTemp("", tempPattern)
+		if err != nil {
+			return "", nil, err
+		}
+		if err = unzip(src, tempDir); err != nil {
+			_ = os.RemoveAll(tempDir)
+			return "", nil, err
+		}
+		root := pickZipRoot(tempDir, rootFile)
+		return root, func() { _ = os.RemoveAll(tempDir) }, nil
+	}
+
+	return "", nil, fmt.Errorf("unsupported source type: %s (expected directory or .zip)", src)
+}
+
+func unzip(zipPath, dest string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	for _, f := range r.File {
+		target := filepath.Join(dest, f.Name)
+		if !strings.HasPrefix(filepath.Clean(target), filepath.Clean(dest)+string(os.PathSeparator)) &&
+			filepath.Clean(target) != filepath.Clean(dest) {
+			return fmt.Errorf("zip contains unsafe path: %s", f.Name)
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o777); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o777); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o666); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pickZipRoot(dir, rootFile string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return dir
+	}
+	candidate := filepath.Join(dir, entries[0].Name())
+	if _, err := os.Stat(filepath.Join(candidate, rootFile)); err == nil {
+		return candidate
+	}
+	return dir
+}
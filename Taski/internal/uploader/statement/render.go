@@ -0,0 +1,79 @@
+package statement
+
+import (
+	"html"
+	"strings"
+	"taski/internal/domain/task"
+)
+
+type sectionTitles struct {
+	Input       string
+	Output      string
+	Interaction string
+	Samples     string
+	Notes       string
+}
+
+// titles are the headings of the sections by the language of the statement, English is used for the rest.
+var titles = map[string]sectionTitles{
+	"ru": {
+		Input:       "Входные данные",
+		Output:      "Выходные данные",
+		Interaction: "Протокол взаимодействия",
+		Samples:     "Примеры",
+		Notes:       "Примечание",
+	},
+	"en": {
+		Input:       "Input",
+		Output:      "Output",
+		Interaction: "Interaction",
+		Samples:     "Examples",
+		Notes:       "Note",
+	},
+}
+
+// Render builds the statement page out of the sections. The page is laid out with the same classes
+// Polygon uses in its HTML statements, so the pages look the same whichever way the statement came in.
+func Render(title, lang string, s task.StatementSections) string {
+	t, ok := titles[lang]
+	if !ok {
+		t = titles["en"]
+	}
+
+	var b strings.Builder
+	if title = strings.TrimSpace(title); title != "" {
+		b.WriteString(`<h1 class="title">` + html.EscapeString(title) + "</h1>\n")
+	}
+	writeSection(&b, "legend", "", s.Legend)
+	writeSection(&b, "input-specification", t.Input, s.Input)
+	writeSection(&b, "output-specification", t.Output, s.Output)
+	writeSection(&b, "interaction", t.Interaction, s.Interaction)
+	if len(s.Samples) > 0 {
+		b.WriteString(`<div class="sample-tests"><div class="section-title">` + html.EscapeString(t.Samples) + "</div>\n")
+		for _, sample := range s.Samples {
+			b.WriteString(`<div class="sample-test">`)
+			writeSample(&b, "input", t.Input, sample.Input)
+			writeSample(&b, "output", t.Output, sample.Output)
+			b.WriteString("</div>\n")
+		}
+		b.WriteString("</div>\n")
+	}
+	writeSection(&b, "note", t.Notes, s.Notes)
+	return b.String()
+}
+
+func writeSection(b *strings.Builder, class, title, body string) {
+	if strings.TrimSpace(body) == "" {
+		return
+	}
+	b.WriteString(`<div class="` + class + `">`)
+	if title != "" {
+		b.WriteString(`<div class="section-title">` + html.EscapeString(title) + "</div>")
+	}
+	b.WriteString("\n" + strings.TrimSpace(body) + "\n</div>\n")
+}
+
+func writeSample(b *strings.Builder, class, title, text string) {
+	b.WriteString(`<div class="` + class + `"><div class="title">` + html.EscapeString(title) + "</div>")
+	b.WriteString(`<pre class="content">` + html.EscapeString(strings.TrimRight(text, "\r\n")) + "</pre></div>")
+}
@@ -0,0 +1,91 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"taski/internal/domain/task"
+	"taski/internal/lib/safepath"
+)
+
+// FileName is the name of the statement page in the statements/<lang> directory of the task bucket.
+const FileName = "statement.html"
+
+var imgSrcRe = regexp.MustCompile(`(?is)<img\b[^>]*?\bsrc\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+// Save writes the statement page along with the images it refers to into statements/<lang> of the bucket.
+// The images are looked for relative to srcDir and keep their relative paths,
+// so the page shows them when read from the bucket.
+func Save(srcDir, outDir, lang, page string, sections *task.StatementSections) (task.Statement, error) {
+	dir := path.Join("statements", lang)
+	statement := task.Statement{
+		Path:     path.Join(dir, FileName),
+		Assets:   make([]string, 0),
+		Sections: sections,
+	}
+	seen := make(map[string]bool)
+	for _, src := range Images(page) {
+		clean, err := safepath.Clean(src)
+		if err != nil || seen[clean] {
+			continue
+		}
+		seen[clean] = true
+
+		asset := path.Join(dir, clean)
+		if err = copyFile(filepath.Join(srcDir, filepath.FromSlash(clean)), filepath.Join(outDir, filepath.FromSlash(asset))); err != nil {
+			return task.Statement{}, fmt.Errorf("failed to copy statement image %s: %w", src, err)
+		}
+		statement.Assets = append(statement.Assets, asset)
+	}
+
+	if !strings.HasSuffix(page, "\n") {
+		page += "\n"
+	}
+	target := filepath.Join(outDir, filepath.FromSlash(statement.Path))
+	if err := os.MkdirAll(filepath.Dir(target), 0o777); err != nil {
+		return task.Statement{}, err
+	}
+	if err := os.WriteFile(target, []byte(page), 0o666); err != nil {
+		return task.Statement{}, fmt.Errorf("failed to write %s: %w", statement.Path, err)
+	}
+	return statement, nil
+}
+
+// Images returns the sources of the images the page refers to by a relative path,
+// the images referred to by a URL are left as they are.
+func Images(page string) []string {
+	images := make([]string, 0)
+	for _, match := range imgSrcRe.FindAllStringSubmatch(page, -1) {
+		src := strings.TrimSpace(match[1] + match[2])
+		if src == "" || strings.HasPrefix(src, "/") || strings.Contains(src, ":") {
+			continue
+		}
+		images = append(images, src)
+	}
+	return images
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0o777); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
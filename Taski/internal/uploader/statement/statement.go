@@ -0,0 +1,201 @@
+package statement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"taski/internal/domain/task"
+	"taski/internal/lib/markup"
+)
+
+// Rendered is a statement rendered from the sources of its sections.
+type Rendered struct {
+	Title    string
+	Sections task.StatementSections
+}
+
+// PropertiesFileName is the file Polygon puts the LaTeX sections of a statement into,
+// next to the .tex statement.
+const PropertiesFileName = "problem-properties.json"
+
+type problemProperties struct {
+	Name        string `json:"name"`
+	Legend      string `json:"legend"`
+	Input       string `json:"input"`
+	Output      string `json:"output"`
+	Interaction string `json:"interaction"`
+	Notes       string `json:"notes"`
+	SampleTests []struct {
+		Input  string `json:"input"`
+		Output string `json:"output"`
+	} `json:"sampleTests"`
+}
+
+// sectionExts are the extensions of the section files and the renderers of them, in the order they are looked for.
+var sectionExts = []struct {
+	ext    string
+	render func(string) (string, error)
+}{
+	{ext: ".md", render: markup.Markdown},
+	{ext: ".tex", render: func(src string) (string, error) { return markup.TeX(src), nil }},
+}
+
+// HasSources reports whether the directory has the sources of a statement:
+// either problem-properties.json or at least the legend.
+func HasSources(dir string) bool {
+	if fileExists(filepath.Join(dir, PropertiesFileName)) {
+		return true
+	}
+	_, ok := findSection(dir, "legend")
+	return ok
+}
+
+// Load renders the statement from the directory. The sections are taken from problem-properties.json
+// if there is one, otherwise every section is a file of its own: legend, input, output, interaction
+// and notes, each either in Markdown (.md) or in LaTeX (.tex), and the name of the task in name.md or name.tex.
+// The samples are the example.NN files with the answers in example.NN.a, the way Polygon lays them out
+// in statement-sections.
+func Load(dir string) (Rendered, error) {
+	data, err := os.ReadFile(filepath.Join(dir, PropertiesFileName))
+	if err == nil {
+		return loadProperties(data)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return Rendered{}, err
+	}
+	return loadSections(dir)
+}
+
+func loadProperties(data []byte) (Rendered, error) {
+	var props problemProperties
+	if err := json.Unmarshal(data, &props); err != nil {
+		return Rendered{}, fmt.Errorf("failed to parse %s: %w", PropertiesFileName, err)
+	}
+
+	rendered := Rendered{
+		Title: strings.TrimSpace(props.Name),
+		Sections: task.StatementSections{
+			Legend:      markup.TeX(props.Legend),
+			Input:       markup.TeX(props.Input),
+			Output:      markup.TeX(props.Output),
+			Interaction: markup.TeX(props.Interaction),
+			Notes:       markup.TeX(props.Notes),
+		},
+	}
+	for _, sample := range props.SampleTests {
+		rendered.Sections.Samples = append(rendered.Sections.Samples, task.StatementSample{
+			Input:  sample.Input,
+			Output: sample.Output,
+		})
+	}
+	if rendered.Sections.Legend == "" {
+		return Rendered{}, fmt.Errorf("%s has no legend", PropertiesFileName)
+	}
+	return rendered, nil
+}
+
+func loadSections(dir string) (Rendered, error) {
+	var rendered Rendered
+	if path, ok := findSection(dir, "name"); ok {
+		name, err := os.ReadFile(path)
+		if err != nil {
+			return Rendered{}, err
+		}
+		rendered.Title = strings.TrimSpace(string(name))
+	}
+
+	sections := []struct {
+		name string
+		html *string
+	}{
+		{name: "legend", html: &rendered.Sections.Legend},
+		{name: "input", html: &rendered.Sections.Input},
+		{name: "output", html: &rendered.Sections.Output},
+		{name: "interaction", html: &rendered.Sections.Interaction},
+		{name: "notes", html: &rendered.Sections.Notes},
+	}
+	for _, section := range sections {
+		path, ok := findSection(dir, section.name)
+		if !ok {
+			continue
+		}
+		html, err := renderSection(path)
+		if err != nil {
+			return Rendered{}, fmt.Errorf("failed to render %s: %w", filepath.Base(path), err)
+		}
+		*section.html = html
+	}
+	if rendered.Sections.Legend == "" {
+		return Rendered{}, fmt.Errorf("no legend in %s", dir)
+	}
+
+	samples, err := loadSamples(dir)
+	if err != nil {
+		return Rendered{}, err
+	}
+	rendered.Sections.Samples = samples
+	return rendered, nil
+}
+
+func findSection(dir, name string) (string, bool) {
+	for _, e := range sectionExts {
+		path := filepath.Join(dir, name+e.ext)
+		if fileExists(path) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func renderSection(path string) (string, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	for _, e := range sectionExts {
+		if strings.EqualFold(filepath.Ext(path), e.ext) {
+			return e.render(text)
+		}
+	}
+	return "", fmt.Errorf("unsupported section format %s", filepath.Ext(path))
+}
+
+// loadSamples reads the example.NN files in the order of their numbers. The answer of a sample
+// may be missing, e.g. for an interactive task, and is left empty then.
+func loadSamples(dir string) ([]task.StatementSample, error) {
+	inputs, err := filepath.Glob(filepath.Join(dir, "example.[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	inputs = slices.DeleteFunc(inputs, func(path string) bool { return strings.HasSuffix(path, ".a") })
+	sort.Strings(inputs)
+
+	samples := make([]task.StatementSample, 0, len(inputs))
+	for _, inputPath := range inputs {
+		input, err := os.ReadFile(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		output, err := os.ReadFile(inputPath + ".a")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		samples = append(samples, task.StatementSample{
+			Input:  string(input),
+			Output: string(output),
+		})
+	}
+	return samples, nil
+}
+
+func fileExists(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && !stat.IsDir()
+}
@@ -0,0 +1,70 @@
+package uploader
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Topics maps the tags of a package onto the configured task topics. A tag maps onto a topic when it is
+// the name of the topic or one of the tags configured for it, ignoring case and surrounding spaces.
+type Topics struct {
+	names []string
+	byTag map[string][]string
+}
+
+// NewTopics returns the mapping of the tags onto the topics, tags being the tags of each topic.
+// A topic with tags must be one of the topics.
+func NewTopics(topics []string, tags map[string][]string) (Topics, error) {
+	t := Topics{
+		names: topics,
+		byTag: make(map[string][]string),
+	}
+	for _, topic := range topics {
+		t.add(topic, topic)
+	}
+	for topic, topicTags := range tags {
+		if !slices.Contains(topics, topic) {
+			return Topics{}, fmt.Errorf("tags of unknown topic %q", topic)
+		}
+		for _, tag := range topicTags {
+			t.add(tag, topic)
+		}
+	}
+	return t, nil
+}
+
+func (t Topics) add(tag, topic string) {
+	key := normalizeTag(tag)
+	if key != "" && !slices.Contains(t.byTag[key], topic) {
+		t.byTag[key] = append(t.byTag[key], topic)
+	}
+}
+
+// Match returns the topics the tags map onto in the order they are configured in, and the tags
+// that map onto none of them.
+func (t Topics) Match(tags []string) (topics, unmatched []string) {
+	matched := make(map[string]bool)
+	for _, tag := range tags {
+		mapped, ok := t.byTag[normalizeTag(tag)]
+		if !ok {
+			unmatched = append(unmatched, tag)
+			continue
+		}
+		for _, topic := range mapped {
+			matched[topic] = true
+		}
+	}
+
+	topics = make([]string, 0, len(matched))
+	for _, topic := range t.names {
+		if matched[topic] {
+			topics = append(topics, topic)
+		}
+	}
+	return topics, unmatched
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
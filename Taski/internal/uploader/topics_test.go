@@ -0,0 +1,41 @@
+package uploader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopicsMatch(t *testing.T) {
+	t.Parallel()
+
+	topics, err := NewTopics(
+		[]string{"структуры данных", "дерево отрезков", "графы"},
+		map[string][]string{
+			"структуры данных": {"data structures", "dsu"},
+			"дерево отрезков":  {"segment tree", "data structures"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewTopics() returned error: %v", err)
+	}
+
+	got, unmatched := topics.Match([]string{" Data Structures", "greedy", "Графы"})
+	if want := []string{"структуры данных", "дерево отрезков", "графы"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Match() topics = %q, want %q", got, want)
+	}
+	if want := []string{"greedy"}; !reflect.DeepEqual(unmatched, want) {
+		t.Fatalf("Match() unmatched = %q, want %q", unmatched, want)
+	}
+
+	if got, _ = topics.Match(nil); got == nil || len(got) != 0 {
+		t.Fatalf("Match(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestNewTopicsUnknownTopic(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewTopics([]string{"графы"}, map[string][]string{"dp": {"dp"}}); err == nil {
+		t.Fatal("NewTopics() with tags of an unknown topic returned no error")
+	}
+}
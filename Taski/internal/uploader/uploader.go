@@ -8,12 +8,24 @@ import (
 
 const (
 	FormatPolygon = "polygon"
+	FormatTaski   = "taski"
+	FormatICPC    = "icpc"
+	FormatKattis  = "kattis"
 )
 
 type Config struct {
 	Format  string
 	SrcPath string
 	Level   int
+	Topics  []string
+}
+
+// TaskTopics returns the topics the task is uploaded with, never nil so that task.json has a list.
+func (c Config) TaskTopics() []string {
+	if c.Topics == nil {
+		return []string{}
+	}
+	return c.Topics
 }
 
 type Uploader interface {
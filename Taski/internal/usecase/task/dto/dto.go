@@ -7,16 +7,21 @@ import (
 )
 
 type TaskDto interface {
-	setDetails(t task.Task)
+	setDetails(t task.Task, outcomes task.Outcomes, langs []string)
 }
 
 type taskDetailsDto struct {
-	ID        task.ID    `json:"id"`
-	Title     string     `json:"title"`
-	Type      task.Type  `json:"type"`
-	Level     task.Level `json:"level"`
-	Topics    []string   `json:"topics"`
-	Statement string     `json:"statement"`
+	ID                task.ID                 `json:"id"`
+	Title             string                  `json:"title"`
+	Type              task.Type               `json:"type"`
+	Level             task.Level              `json:"level"`
+	ComputedLevel     task.Level              `json:"computed_level"`
+	Difficulty        DifficultyDto           `json:"difficulty"`
+	Topics            []string                `json:"topics"`
+	Statement         string                  `json:"statement"`
+	StatementLang     string                  `json:"statement_lang"`
+	StatementLangs    []string                `json:"statement_langs"`
+	StatementSections *task.StatementSections `json:"statement_sections,omitempty"`
 }
 
 type WriteCodeTaskDto struct {
@@ -40,19 +45,40 @@ type PredictOutputTaskDto struct {
 	Input string    `json:"input"`
 }
 
+// DifficultyDto is what the computed level of the task is based on.
+type DifficultyDto struct {
+	Attempts               int     `json:"attempts"`
+	Accepted               int     `json:"accepted"`
+	AcceptanceRate         float64 `json:"acceptance_rate"`
+	AttemptsBeforeAccepted float64 `json:"attempts_before_accepted"`
+}
+
 type TestDto struct {
 	Order  int    `json:"order"`
 	Input  string `json:"input"`
 	Output string `json:"output"`
 }
 
-func (d *taskDetailsDto) setDetails(t task.Task) {
+func (d *taskDetailsDto) setDetails(t task.Task, outcomes task.Outcomes, langs []string) {
 	d.ID = t.GetID()
 	d.Title = t.GetTitle()
 	d.Type = t.GetType()
 	d.Level = t.GetLevel()
+	d.ComputedLevel = outcomes.ComputedLevel(t.GetLevel())
+	d.Difficulty = DifficultyDto{
+		Attempts:               outcomes.Attempts,
+		Accepted:               outcomes.Accepted,
+		AcceptanceRate:         outcomes.AcceptanceRate(),
+		AttemptsBeforeAccepted: outcomes.AttemptsBeforeAccepted(),
+	}
 	d.Topics = t.GetTopics()
-	d.Statement = t.GetStatement()
+
+	statements := t.GetStatement()
+	lang, statement, _ := statements.Pick(langs...)
+	d.Statement = statement.Path
+	d.StatementLang = lang
+	d.StatementLangs = statements.Langs()
+	d.StatementSections = statement.Sections
 }
 
 func convertTests(tests []task.Test) []TestDto {
@@ -71,11 +97,13 @@ func convertTests(tests []task.Test) []TestDto {
 	return testsDto
 }
 
-func ConvertTask(t task.Task) (TaskDto, error) {
+// ConvertTask converts the task with the statement in the first of the languages it is available in
+// and the level computed from the outcomes of its solutions next to the authored one.
+func ConvertTask(t task.Task, outcomes task.Outcomes, langs ...string) (TaskDto, error) {
 	switch t.GetType() {
 	case task.WriteCode:
 		taskDto := &WriteCodeTaskDto{}
-		taskDto.setDetails(t)
+		taskDto.setDetails(t, outcomes, langs)
 
 		typedTask := t.(*tasks.WriteCodeTask)
 		taskDto.SourceCode = typedTask.SourceCode
@@ -86,7 +114,7 @@ func ConvertTask(t task.Task) (TaskDto, error) {
 		return taskDto, nil
 	case task.FindTest:
 		taskDto := &FindTestTaskDto{}
-		taskDto.setDetails(t)
+		taskDto.setDetails(t, outcomes, langs)
 
 		typedTask := t.(*tasks.FindTestTask)
 		taskDto.Code = typedTask.Code
@@ -96,7 +124,7 @@ func ConvertTask(t task.Task) (TaskDto, error) {
 		return taskDto, nil
 	case task.PredictOutput:
 		taskDto := &PredictOutputTaskDto{}
-		taskDto.setDetails(t)
+		taskDto.setDetails(t, outcomes, langs)
 
 		typedTask := t.(*tasks.PredictOutputTask)
 		taskDto.Code = typedTask.Code
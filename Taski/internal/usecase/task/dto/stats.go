@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"sort"
+	"taski/internal/domain/task"
+	"taski/internal/domain/testing"
+)
+
+// hardestTestsLimit is how many of the hardest tests the statistics list.
+const hardestTestsLimit = 5
+
+type TaskStatsDto struct {
+	TaskID task.ID `json:"task_id"`
+	// Solutions are all the finished solutions, Attempts the ones judged on the tests.
+	Solutions         int                `json:"solutions"`
+	Attempts          int                `json:"attempts"`
+	Accepted          int                `json:"accepted"`
+	AcceptanceRate    float64            `json:"acceptance_rate"`
+	Verdicts          map[string]int     `json:"verdicts"`
+	Languages         []LanguageStatsDto `json:"languages"`
+	MedianSolveTimeMs *int64             `json:"median_solve_time_ms"`
+	HardestTests      []FailedTestDto    `json:"hardest_tests"`
+}
+
+type LanguageStatsDto struct {
+	Lang           task.Language  `json:"lang"`
+	Solutions      int            `json:"solutions"`
+	Attempts       int            `json:"attempts"`
+	Accepted       int            `json:"accepted"`
+	AcceptanceRate float64        `json:"acceptance_rate"`
+	Verdicts       map[string]int `json:"verdicts"`
+}
+
+type FailedTestDto struct {
+	Test int `json:"test"`
+	// Failures are the solutions that failed the test first.
+	Failures int `json:"failures"`
+}
+
+func ConvertTaskStats(taskID task.ID, stats testing.TaskStats) TaskStatsDto {
+	outcomes := stats.Outcomes()
+	statsDto := TaskStatsDto{
+		TaskID:         taskID,
+		Attempts:       outcomes.Attempts,
+		Accepted:       outcomes.Accepted,
+		AcceptanceRate: outcomes.AcceptanceRate(),
+		Verdicts:       make(map[string]int),
+		Languages:      make([]LanguageStatsDto, 0, len(stats.Verdicts)),
+		HardestTests:   make([]FailedTestDto, 0, hardestTestsLimit),
+	}
+
+	for lang, verdicts := range stats.Verdicts {
+		langOutcomes := stats.LanguageOutcomes(lang)
+		langDto := LanguageStatsDto{
+			Lang:           lang,
+			Attempts:       langOutcomes.Attempts,
+			Accepted:       langOutcomes.Accepted,
+			AcceptanceRate: langOutcomes.AcceptanceRate(),
+			Verdicts:       verdicts,
+		}
+		for verdict, count := range verdicts {
+			langDto.Solutions += count
+			statsDto.Verdicts[verdict] += count
+		}
+		statsDto.Solutions += langDto.Solutions
+		statsDto.Languages = append(statsDto.Languages, langDto)
+	}
+	sort.Slice(statsDto.Languages, func(i, j int) bool {
+		return statsDto.Languages[i].Lang < statsDto.Languages[j].Lang
+	})
+
+	if median, ok := stats.MedianSolveTime(); ok {
+		ms := median.Milliseconds()
+		statsDto.MedianSolveTimeMs = &ms
+	}
+	for _, testID := range stats.HardestTests(hardestTestsLimit) {
+		statsDto.HardestTests = append(statsDto.HardestTests, FailedTestDto{
+			Test:     testID,
+			Failures: stats.FailedTests[testID],
+		})
+	}
+
+	return statsDto
+}
@@ -0,0 +1,113 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Status is what happened to a package of the batch.
+type Status string
+
+const (
+	// StatusUploaded is a package uploaded by the run.
+	StatusUploaded Status = "uploaded"
+	// StatusSkipped is a package uploaded before with the same content.
+	StatusSkipped Status = "skipped"
+	// StatusExists is a package whose task is already stored although the report has no upload of it,
+	// e.g. when the previous run stopped before writing the report. The task is left as it is.
+	StatusExists Status = "exists"
+	// StatusFailed is a package that could not be uploaded.
+	StatusFailed Status = "failed"
+)
+
+// Report is the outcome of a batch upload, written as JSON after every package.
+type Report struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Summary    Summary   `json:"summary"`
+	Packages   []Entry   `json:"packages"`
+}
+
+type Summary struct {
+	Total    int `json:"total"`
+	Uploaded int `json:"uploaded"`
+	Skipped  int `json:"skipped"`
+	Exists   int `json:"exists"`
+	Failed   int `json:"failed"`
+}
+
+// Entry is the outcome of a package.
+type Entry struct {
+	Path       string   `json:"path"`
+	Format     string   `json:"format"`
+	Level      int      `json:"level"`
+	Topics     []string `json:"topics,omitempty"`
+	Hash       string   `json:"hash,omitempty"`
+	Status     Status   `json:"status"`
+	TaskID     string   `json:"task_id,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// readReport reads the report of the previous run, a missing report is an empty one.
+func readReport(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Report{}, nil
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	var report Report
+	if err = json.Unmarshal(data, &report); err != nil {
+		return Report{}, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// writeReport replaces the report through a temporary file, so that an interrupted run leaves
+// either the previous report or the new one.
+func writeReport(path string, report Report) error {
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (r *Report) summarize(total int) {
+	r.Summary = Summary{Total: total}
+	for _, entry := range r.Packages {
+		switch entry.Status {
+		case StatusUploaded:
+			r.Summary.Uploaded++
+		case StatusSkipped:
+			r.Summary.Skipped++
+		case StatusExists:
+			r.Summary.Exists++
+		case StatusFailed:
+			r.Summary.Failed++
+		}
+	}
+}
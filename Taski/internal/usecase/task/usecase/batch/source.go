@@ -0,0 +1,175 @@
+package batch
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Package is a package of the batch and the way it is uploaded.
+type Package struct {
+	Path   string   `yaml:"path"`
+	Format string   `yaml:"format"`
+	Level  int      `yaml:"level"`
+	Topics []string `yaml:"topics"`
+}
+
+// LoadPackages returns the packages of the batch. The source is either a directory, in which every
+// directory and ZIP archive is a package uploaded with the defaults, or a manifest listing the packages:
+// a CSV file with a header row or a YAML list, with the columns path, level, topics and format.
+// Relative paths of a manifest are taken from the directory of the manifest, the missing level and format
+// are the defaults. The topics of a CSV row are separated by semicolons.
+func LoadPackages(src string, defaults Package) ([]Package, error) {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []Package
+	switch ext := strings.ToLower(filepath.Ext(src)); {
+	case stat.IsDir():
+		packages, err = listDirectory(src)
+	case ext == ".csv":
+		packages, err = readCSV(src)
+	case ext == ".yaml" || ext == ".yml":
+		packages, err = readYAML(src)
+	default:
+		return nil, fmt.Errorf("unsupported batch source %s (expected directory, .csv or .yaml)", src)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(packages))
+	for i := range packages {
+		p := &packages[i]
+		if strings.TrimSpace(p.Path) == "" {
+			return nil, fmt.Errorf("package %d has no path", i+1)
+		}
+		if !stat.IsDir() && !filepath.IsAbs(p.Path) {
+			p.Path = filepath.Join(filepath.Dir(src), p.Path)
+		}
+		p.Path = filepath.Clean(p.Path)
+		if seen[p.Path] {
+			return nil, fmt.Errorf("package %s is listed twice", p.Path)
+		}
+		seen[p.Path] = true
+
+		if p.Format == "" {
+			p.Format = defaults.Format
+		}
+		if p.Level == 0 {
+			p.Level = defaults.Level
+		}
+		if p.Topics == nil {
+			p.Topics = defaults.Topics
+		}
+	}
+	return packages, nil
+}
+
+func listDirectory(dir string) ([]Package, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var packages []Package
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || !entry.IsDir() && !strings.EqualFold(filepath.Ext(name), ".zip") {
+			continue
+		}
+		packages = append(packages, Package{Path: filepath.Join(dir, name)})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Path < packages[j].Path })
+	return packages, nil
+}
+
+func readCSV(path string) ([]Package, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["path"]; !ok {
+		return nil, fmt.Errorf("%s has no path column", filepath.Base(path))
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var packages []Package
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return packages, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		line, _ := r.FieldPos(0)
+
+		p := Package{
+			Path:   field(record, "path"),
+			Format: field(record, "format"),
+		}
+		if level := field(record, "level"); level != "" {
+			if p.Level, err = strconv.Atoi(level); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid level %q", filepath.Base(path), line, level)
+			}
+		}
+		if topics := field(record, "topics"); topics != "" {
+			p.Topics = splitTopics(topics)
+		}
+		packages = append(packages, p)
+	}
+}
+
+func readYAML(path string) ([]Package, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var packages []Package
+	if err = yaml.Unmarshal(data, &packages); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return packages, nil
+}
+
+func splitTopics(s string) []string {
+	topics := make([]string, 0)
+	for _, topic := range strings.Split(s, ";") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
@@ -0,0 +1,200 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"taski/internal/domain/task"
+	"taski/internal/usecase/task/usecase/upload"
+	"time"
+
+	ferrs "github.com/DIvanCode/filestorage/pkg/errors"
+)
+
+type taskUploader interface {
+	Upload(ctx context.Context, command upload.Command) (task.ID, error)
+}
+
+type UseCase struct {
+	log      *slog.Logger
+	uploader taskUploader
+}
+
+type Command struct {
+	Src        string
+	Format     string
+	Level      int
+	Workers    int
+	ReportPath string
+}
+
+func NewUseCase(log *slog.Logger, uploader taskUploader) *UseCase {
+	return &UseCase{
+		log:      log,
+		uploader: uploader,
+	}
+}
+
+// Upload uploads the packages of the batch with at most command.Workers of them at a time. The report is
+// written after every package, and the report of the previous run at the same path is where the run resumes
+// from: a package uploaded by it is skipped as long as its content hash is the same.
+func (uc *UseCase) Upload(ctx context.Context, command Command) (Report, error) {
+	if command.ReportPath == "" {
+		return Report{}, errors.New("missing report path")
+	}
+	packages, err := LoadPackages(command.Src, Package{Format: command.Format, Level: command.Level})
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to load packages: %w", err)
+	}
+	previous, err := readReport(command.ReportPath)
+	if err != nil {
+		return Report{}, err
+	}
+	uploaded := make(map[string]Entry, len(previous.Packages))
+	for _, entry := range previous.Packages {
+		uploaded[entry.Path] = entry
+	}
+
+	uc.log.Info("batch upload started",
+		slog.String("src", command.Src),
+		slog.Int("packages", len(packages)),
+		slog.Int("workers", max(command.Workers, 1)),
+	)
+
+	// entries keep the previous outcome of the packages the run has not got to yet,
+	// so that a report of an interrupted run still has everything to resume from
+	entries := make([]Entry, len(packages))
+	for i, p := range packages {
+		entries[i] = uploaded[p.Path]
+	}
+	report := Report{StartedAt: time.Now().UTC()}
+	var (
+		mu       sync.Mutex
+		done     int
+		writeErr error
+	)
+	record := func(i int, entry Entry) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		entries[i] = entry
+		done++
+		report.Packages = collectEntries(entries)
+		report.summarize(len(packages))
+		if err := writeReport(command.ReportPath, report); err != nil && writeErr == nil {
+			writeErr = fmt.Errorf("failed to write report: %w", err)
+		}
+
+		attrs := []any{
+			slog.String("progress", fmt.Sprintf("%d/%d", done, len(packages))),
+			slog.String("path", entry.Path),
+			slog.String("status", string(entry.Status)),
+		}
+		if entry.TaskID != "" {
+			attrs = append(attrs, slog.String("task_id", entry.TaskID))
+		}
+		if entry.Status == StatusFailed {
+			uc.log.Error("package processed", append(attrs, slog.String("error", entry.Error))...)
+		} else {
+			uc.log.Info("package processed", attrs...)
+		}
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range max(command.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				record(i, uc.uploadPackage(ctx, packages[i], uploaded[packages[i].Path]))
+			}
+		}()
+	}
+feed:
+	for i := range packages {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	report.Packages = collectEntries(entries)
+	report.FinishedAt = time.Now().UTC()
+	report.summarize(len(packages))
+	if err = writeReport(command.ReportPath, report); err != nil && writeErr == nil {
+		writeErr = fmt.Errorf("failed to write report: %w", err)
+	}
+
+	uc.log.Info("batch upload finished",
+		slog.Int("uploaded", report.Summary.Uploaded),
+		slog.Int("skipped", report.Summary.Skipped),
+		slog.Int("exists", report.Summary.Exists),
+		slog.Int("failed", report.Summary.Failed),
+	)
+	if writeErr != nil {
+		return report, writeErr
+	}
+	return report, ctx.Err()
+}
+
+func (uc *UseCase) uploadPackage(ctx context.Context, p Package, previous Entry) Entry {
+	started := time.Now()
+	entry := Entry{
+		Path:   p.Path,
+		Format: p.Format,
+		Level:  p.Level,
+		Topics: p.Topics,
+	}
+	finish := func(status Status, err error) Entry {
+		entry.Status = status
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		entry.DurationMs = time.Since(started).Milliseconds()
+		return entry
+	}
+
+	hash, err := hashPackage(p.Path)
+	if err != nil {
+		return finish(StatusFailed, fmt.Errorf("failed to hash package: %w", err))
+	}
+	entry.Hash = hash
+	if previous.Hash == hash && (previous.Status == StatusUploaded || previous.Status == StatusSkipped) {
+		entry.TaskID = previous.TaskID
+		return finish(StatusSkipped, nil)
+	}
+	if err = ctx.Err(); err != nil {
+		return finish(StatusFailed, err)
+	}
+
+	taskID, err := uc.uploader.Upload(ctx, upload.Command{
+		Format:  p.Format,
+		SrcPath: p.Path,
+		Level:   p.Level,
+		Topics:  p.Topics,
+	})
+	if errors.Is(err, ferrs.ErrBucketAlreadyExists) {
+		return finish(StatusExists, nil)
+	}
+	if err != nil {
+		return finish(StatusFailed, err)
+	}
+	entry.TaskID = taskID.String()
+	return finish(StatusUploaded, nil)
+}
+
+func collectEntries(entries []Entry) []Entry {
+	collected := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Status != "" {
+			collected = append(collected, entry)
+		}
+	}
+	return collected
+}
@@ -0,0 +1,204 @@
+package batch
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"taski/internal/domain/task"
+	"taski/internal/usecase/task/usecase/upload"
+	"testing"
+
+	ferrs "github.com/DIvanCode/filestorage/pkg/errors"
+)
+
+type stubUploader struct {
+	mu       sync.Mutex
+	uploaded []string
+	errs     map[string]error
+}
+
+func (u *stubUploader) Upload(_ context.Context, command upload.Command) (task.ID, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	name := filepath.Base(command.SrcPath)
+	if err := u.errs[name]; err != nil {
+		return task.ID{}, err
+	}
+	u.uploaded = append(u.uploaded, name)
+
+	hash := sha1.Sum([]byte(name))
+	var id task.ID
+	if err := id.FromString(hex.EncodeToString(hash[:])); err != nil {
+		return task.ID{}, err
+	}
+	return id, nil
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+			t.Fatalf("MkdirAll() returned error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatalf("WriteFile() returned error: %v", err)
+		}
+	}
+}
+
+func statuses(report Report) map[string]Status {
+	got := make(map[string]Status, len(report.Packages))
+	for _, entry := range report.Packages {
+		got[filepath.Base(entry.Path)] = entry.Status
+	}
+	return got
+}
+
+func TestUploadResumes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "packages")
+	writeFiles(t, src, map[string]string{
+		"a/problem.xml":  "a",
+		"b/problem.xml":  "b",
+		"c.zip":          "c",
+		"notes.txt":      "not a package",
+		".hidden/x.yaml": "not a package",
+	})
+	command := Command{
+		Src:        src,
+		Format:     upload.FormatPolygon,
+		Level:      2,
+		Workers:    2,
+		ReportPath: filepath.Join(dir, "report.json"),
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	uploader := &stubUploader{errs: map[string]error{"b": errors.New("broken checker")}}
+	report, err := NewUseCase(log, uploader).Upload(context.Background(), command)
+	if err != nil {
+		t.Fatalf("Upload() returned error: %v", err)
+	}
+	want := map[string]Status{"a": StatusUploaded, "b": StatusFailed, "c.zip": StatusUploaded}
+	if got := statuses(report); !reflect.DeepEqual(got, want) {
+		t.Fatalf("first run statuses = %v, want %v", got, want)
+	}
+	if report.Summary != (Summary{Total: 3, Uploaded: 2, Failed: 1}) {
+		t.Fatalf("first run summary = %+v", report.Summary)
+	}
+
+	// the fixed package is uploaded, the changed one is tried again and is already stored
+	writeFiles(t, src, map[string]string{"c.zip": "c changed"})
+	uploader = &stubUploader{errs: map[string]error{"c.zip": ferrs.ErrBucketAlreadyExists}}
+	report, err = NewUseCase(log, uploader).Upload(context.Background(), command)
+	if err != nil {
+		t.Fatalf("Upload() returned error: %v", err)
+	}
+	want = map[string]Status{"a": StatusSkipped, "b": StatusUploaded, "c.zip": StatusExists}
+	if got := statuses(report); !reflect.DeepEqual(got, want) {
+		t.Fatalf("second run statuses = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(uploader.uploaded, []string{"b"}) {
+		t.Fatalf("second run uploaded %q, want only b", uploader.uploaded)
+	}
+	if report.Packages[0].TaskID == "" {
+		t.Fatal("skipped package lost its task id")
+	}
+
+	saved, err := readReport(command.ReportPath)
+	if err != nil {
+		t.Fatalf("readReport() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(statuses(saved), want) || saved.FinishedAt.IsZero() {
+		t.Fatalf("saved report = %+v, want the second run", saved)
+	}
+}
+
+func TestUploadStopsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"packages/a/problem.xml": "a"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	report, err := NewUseCase(log, &stubUploader{}).Upload(ctx, Command{
+		Src:        filepath.Join(dir, "packages"),
+		Format:     upload.FormatPolygon,
+		Level:      1,
+		ReportPath: filepath.Join(dir, "report.json"),
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Upload() error = %v, want context.Canceled", err)
+	}
+	if report.Summary.Uploaded != 0 {
+		t.Fatalf("Upload() uploaded %d packages after cancellation", report.Summary.Uploaded)
+	}
+}
+
+func TestLoadPackagesFromManifest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"tasks.csv": "path,level,topics,format\n" +
+			"a.zip,3,math; greedy,\n" +
+			"b,,,taski\n",
+		"tasks.yaml": "- path: a.zip\n  level: 3\n  topics: [math, greedy]\n" +
+			"- path: b\n  format: taski\n",
+	})
+
+	want := []Package{
+		{Path: filepath.Join(dir, "a.zip"), Format: upload.FormatPolygon, Level: 3, Topics: []string{"math", "greedy"}},
+		{Path: filepath.Join(dir, "b"), Format: upload.FormatTaski, Level: 5},
+	}
+	for _, manifest := range []string{"tasks.csv", "tasks.yaml"} {
+		got, err := LoadPackages(filepath.Join(dir, manifest), Package{Format: upload.FormatPolygon, Level: 5})
+		if err != nil {
+			t.Fatalf("LoadPackages(%s) returned error: %v", manifest, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("LoadPackages(%s) = %+v, want %+v", manifest, got, want)
+		}
+	}
+}
+
+func TestHashPackage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"one/problem.xml":     "x",
+		"one/tests/01":        "1",
+		"two/problem.xml":     "x",
+		"two/tests/01":        "1",
+		"three/problem.xml":   "x",
+		"three/tests/01.copy": "1",
+	})
+
+	hash := func(name string) string {
+		h, err := hashPackage(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("hashPackage(%s) returned error: %v", name, err)
+		}
+		return h
+	}
+	if hash("one") != hash("two") {
+		t.Fatal("hashPackage() differs for the same content")
+	}
+	if hash("one") == hash("three") {
+		t.Fatal("hashPackage() is the same for a renamed file")
+	}
+}
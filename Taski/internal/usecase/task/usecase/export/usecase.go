@@ -0,0 +1,108 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"taski/internal/domain/task"
+	"taski/internal/uploader/native"
+)
+
+type (
+	Query struct {
+		TaskID task.ID
+	}
+
+	UseCase struct {
+		log     *slog.Logger
+		storage taskStorage
+	}
+
+	taskStorage interface {
+		Get(context.Context, task.ID) (t task.Task, unlock func(), err error)
+		GetFile(context.Context, task.ID, string) (r io.ReadCloser, unlock func(), err error)
+	}
+)
+
+func NewUseCase(log *slog.Logger, storage taskStorage) *UseCase {
+	return &UseCase{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// Export locks the task and returns write, which writes the task as a native package ZIP:
+// the manifest and every file the task refers to. The caller calls unlock once the package is written.
+// The errors of finding the task are returned before anything is written.
+func (uc *UseCase) Export(ctx context.Context, query Query) (write func(io.Writer) error, unlock func(), err error) {
+	t, unlock, err := uc.storage.Get(ctx, query.TaskID)
+	if err != nil {
+		if errors.Is(err, task.ErrNotFound) {
+			return nil, nil, err
+		}
+		uc.log.Error("failed to get task from storage",
+			slog.Any("task_id", query.TaskID),
+			slog.Any("error", err))
+		return nil, nil, fmt.Errorf("get task from storage: %w", err)
+	}
+
+	manifest, err := native.MarshalManifest(t)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	files, err := native.Files(t)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+
+	write = func(w io.Writer) error {
+		return uc.writePackage(ctx, query.TaskID, manifest, files, w)
+	}
+	return write, unlock, nil
+}
+
+func (uc *UseCase) writePackage(ctx context.Context, taskID task.ID, manifest []byte, files []string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	mw, err := zw.Create(native.ManifestFileName)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", native.ManifestFileName, err)
+	}
+	if _, err = mw.Write(manifest); err != nil {
+		return fmt.Errorf("failed to write %s: %w", native.ManifestFileName, err)
+	}
+
+	for _, file := range files {
+		if err = uc.addFile(ctx, zw, taskID, file); err != nil {
+			return err
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish package: %w", err)
+	}
+	return nil
+}
+
+func (uc *UseCase) addFile(ctx context.Context, zw *zip.Writer, taskID task.ID, file string) error {
+	r, unlock, err := uc.storage.GetFile(ctx, taskID, file)
+	if err != nil {
+		return fmt.Errorf("failed to get task file %s: %w", file, err)
+	}
+	defer unlock()
+	defer func() { _ = r.Close() }()
+
+	fw, err := zw.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", file, err)
+	}
+	if _, err = io.Copy(fw, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return nil
+}
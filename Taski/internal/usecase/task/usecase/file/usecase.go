@@ -124,8 +124,15 @@ func publicFiles(t task.Task) (map[string]struct{}, error) {
 		return nil
 	}
 
-	if err := add("statement", t.GetStatement()); err != nil {
-		return nil, err
+	for _, statement := range t.GetStatement() {
+		if err := add("statement", statement.Path); err != nil {
+			return nil, err
+		}
+		for _, asset := range statement.Assets {
+			if err := add("statement asset", asset); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	switch t.GetType() {
@@ -17,8 +17,11 @@ func TestReadUsesExplicitPublicFileAllowlist(t *testing.T) {
 
 	writeCodeTask := &tasks.WriteCodeTask{
 		Details: task.Details{
-			Type:      task.WriteCode,
-			Statement: "statement.html",
+			Type: task.WriteCode,
+			Statement: task.Statements{
+				"ru": {Path: "statement.html"},
+				"en": {Path: "statements/en/statement.html", Assets: []string{"statements/en/picture.png"}},
+			},
 		},
 		SourceCode: &task.Code{Path: "starter/main.cpp"},
 		Checker:    task.Code{Path: "private/checker.cpp"},
@@ -31,7 +34,7 @@ func TestReadUsesExplicitPublicFileAllowlist(t *testing.T) {
 	predictOutputTask := &tasks.PredictOutputTask{
 		Details: task.Details{
 			Type:      task.PredictOutput,
-			Statement: "statement.html",
+			Statement: task.Statements{task.DefaultStatementLang: {Path: "statement.html"}},
 		},
 		Code:    task.Code{Path: "program/main.cpp"},
 		Checker: task.Code{Path: "private/checker.cpp"},
@@ -40,7 +43,7 @@ func TestReadUsesExplicitPublicFileAllowlist(t *testing.T) {
 	findTestTask := &tasks.FindTestTask{
 		Details: task.Details{
 			Type:      task.FindTest,
-			Statement: "statement.html",
+			Statement: task.Statements{task.DefaultStatementLang: {Path: "statement.html"}},
 		},
 		Code:     task.Code{Path: "program/buggy.cpp"},
 		Solution: task.Code{Path: "private/solution.cpp"},
@@ -56,6 +59,8 @@ func TestReadUsesExplicitPublicFileAllowlist(t *testing.T) {
 		wantGetCall bool
 	}{
 		{name: "write code statement", task: writeCodeTask, file: "statement.html", wantFile: "statement.html", wantGetCall: true},
+		{name: "write code english statement", task: writeCodeTask, file: "statements/en/statement.html", wantFile: "statements/en/statement.html", wantGetCall: true},
+		{name: "write code statement asset", task: writeCodeTask, file: "statements/en/picture.png", wantFile: "statements/en/picture.png", wantGetCall: true},
 		{name: "write code starter", task: writeCodeTask, file: "starter/main.cpp", wantFile: "starter/main.cpp", wantGetCall: true},
 		{name: "write code portable separator", task: writeCodeTask, file: `starter\main.cpp`, wantFile: "starter/main.cpp", wantGetCall: true},
 		{name: "write code visible input", task: writeCodeTask, file: "tests/01.in", wantFile: "tests/01.in", wantGetCall: true},
@@ -125,7 +130,7 @@ func TestReadReturnsClassifiedNotFoundErrors(t *testing.T) {
 	t.Parallel()
 
 	taskValue := &tasks.FindTestTask{
-		Details: task.Details{Type: task.FindTest, Statement: "statement.html"},
+		Details: task.Details{Type: task.FindTest, Statement: task.Statements{task.DefaultStatementLang: {Path: "statement.html"}}},
 		Code:    task.Code{Path: "buggy.cpp"},
 	}
 
@@ -167,7 +172,7 @@ func TestReadDoesNotPanicOnMismatchedTaskType(t *testing.T) {
 
 	storage := &stubTaskStorage{
 		storedTask: &tasks.PredictOutputTask{
-			Details: task.Details{Type: task.FindTest, Statement: "statement.html"},
+			Details: task.Details{Type: task.FindTest, Statement: task.Statements{task.DefaultStatementLang: {Path: "statement.html"}}},
 			Code:    task.Code{Path: "buggy.cpp"},
 		},
 	}
@@ -11,22 +11,36 @@ import (
 type (
 	Query struct {
 		TaskID task.ID
+		// Langs are the languages of the statement in the order of preference.
+		Langs []string
 	}
 
 	UseCase struct {
-		log     *slog.Logger
-		storage taskStorage
+		log          *slog.Logger
+		storage      taskStorage
+		unitOfWork   unitOfWork
+		statsStorage statsStorage
 	}
 
 	taskStorage interface {
 		Get(context.Context, task.ID) (t task.Task, unlock func(), err error)
 	}
+
+	unitOfWork interface {
+		Do(context.Context, func(ctx context.Context) error) error
+	}
+
+	statsStorage interface {
+		GetTaskOutcomesByTask(context.Context, task.ID) (task.Outcomes, error)
+	}
 )
 
-func NewUseCase(log *slog.Logger, storage taskStorage) *UseCase {
+func NewUseCase(log *slog.Logger, storage taskStorage, unitOfWork unitOfWork, statsStorage statsStorage) *UseCase {
 	return &UseCase{
-		log:     log,
-		storage: storage,
+		log:          log,
+		storage:      storage,
+		unitOfWork:   unitOfWork,
+		statsStorage: statsStorage,
 	}
 }
 
@@ -38,7 +52,17 @@ func (uc *UseCase) Get(ctx context.Context, query Query) (dto.TaskDto, error) {
 	}
 	defer unlock()
 
-	taskDto, err := dto.ConvertTask(t)
+	// the task is served without the outcomes rather than not at all, its computed level is then the authored one
+	var outcomes task.Outcomes
+	if err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		outcomes, err = uc.statsStorage.GetTaskOutcomesByTask(ctx, query.TaskID)
+		return err
+	}); err != nil {
+		uc.log.Warn("failed to get task outcomes", slog.Any("err", err))
+		outcomes = task.Outcomes{}
+	}
+
+	taskDto, err := dto.ConvertTask(t, outcomes, query.Langs...)
 	if err != nil {
 		uc.log.Error("failed to convert task", slog.Any("err", err))
 		return nil, fmt.Errorf("failed to convert task")
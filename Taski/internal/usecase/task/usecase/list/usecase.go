@@ -12,19 +12,31 @@ type (
 	Query struct{}
 
 	UseCase struct {
-		log     *slog.Logger
-		storage taskStorage
+		log          *slog.Logger
+		storage      taskStorage
+		unitOfWork   unitOfWork
+		statsStorage statsStorage
 	}
 
 	taskStorage interface {
 		GetList(context.Context) ([]task.Task, error)
 	}
+
+	unitOfWork interface {
+		Do(context.Context, func(ctx context.Context) error) error
+	}
+
+	statsStorage interface {
+		GetTaskOutcomes(context.Context) (map[task.ID]task.Outcomes, error)
+	}
 )
 
-func NewUseCase(log *slog.Logger, storage taskStorage) *UseCase {
+func NewUseCase(log *slog.Logger, storage taskStorage, unitOfWork unitOfWork, statsStorage statsStorage) *UseCase {
 	return &UseCase{
-		log:     log,
-		storage: storage,
+		log:          log,
+		storage:      storage,
+		unitOfWork:   unitOfWork,
+		statsStorage: statsStorage,
 	}
 }
 
@@ -35,9 +47,19 @@ func (uc *UseCase) Get(ctx context.Context, _ Query) ([]dto.TaskDto, error) {
 		return nil, err
 	}
 
+	// the tasks are listed without the outcomes rather than not at all, their computed levels are then the authored ones
+	var outcomes map[task.ID]task.Outcomes
+	if err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		outcomes, err = uc.statsStorage.GetTaskOutcomes(ctx)
+		return err
+	}); err != nil {
+		uc.log.Warn("failed to get task outcomes", slog.Any("err", err))
+		outcomes = nil
+	}
+
 	tasksDto := make([]dto.TaskDto, len(tasks), len(tasks))
 	for i := range tasks {
-		taskDto, err := dto.ConvertTask(tasks[i])
+		taskDto, err := dto.ConvertTask(tasks[i], outcomes[tasks[i].GetID()])
 		if err != nil {
 			uc.log.Error("failed to convert task to dto", slog.Any("err", err))
 			return nil, fmt.Errorf("failed to convert task to dto")
@@ -2,45 +2,272 @@ package random
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand/v2"
+	"slices"
+	"strings"
 	"taski/internal/domain/task"
 )
 
+var ErrInvalidQuery = errors.New("invalid random task query")
+
+// The constraints of the query a ConstraintError names.
+const (
+	ConstraintSeen           = "seen"
+	ConstraintLevel          = "level"
+	ConstraintTopics         = "topics"
+	ConstraintExcludedTopics = "exclude_topics"
+)
+
 type (
-	Query struct{}
+	// Query lists the constraints of the random task, the zero Query picks any task.
+	Query struct {
+		// Rating is the rating of the players the task is for. It is a preference rather than a constraint:
+		// the task is picked among the ones whose computed level is the closest to the level of the rating.
+		Rating *int
+		// MinLevel and MaxLevel bound the computed level of the task, zero is no bound.
+		MinLevel task.Level
+		MaxLevel task.Level
+		// Topics are the topics the task has at least one of, ExcludedTopics the ones it has none of.
+		Topics         []string
+		ExcludedTopics []string
+		// Seen are the tasks the players have already seen.
+		Seen []task.ID
+		// Seed makes the pick reproducible for the same tasks and their outcomes.
+		Seed *uint64
+	}
+
+	// ConstraintError is returned when no task meets the constraints, it names the first one no task
+	// was left after.
+	ConstraintError struct {
+		Constraint string
+		Message    string
+	}
 
 	UseCase struct {
-		log     *slog.Logger
-		storage taskStorage
+		log          *slog.Logger
+		storage      taskStorage
+		unitOfWork   unitOfWork
+		statsStorage statsStorage
 	}
 
 	taskStorage interface {
 		GetTaskIDs(context.Context) ([]task.ID, error)
+		GetList(context.Context) ([]task.Task, error)
+	}
+
+	unitOfWork interface {
+		Do(context.Context, func(ctx context.Context) error) error
+	}
+
+	statsStorage interface {
+		GetTaskOutcomes(context.Context) (map[task.ID]task.Outcomes, error)
+	}
+
+	candidate struct {
+		id     task.ID
+		level  task.Level
+		topics []string
 	}
 )
 
-func NewUseCase(log *slog.Logger, storage taskStorage) *UseCase {
+func (e *ConstraintError) Error() string {
+	return e.Message
+}
+
+func NewUseCase(log *slog.Logger, storage taskStorage, unitOfWork unitOfWork, statsStorage statsStorage) *UseCase {
 	return &UseCase{
-		log:     log,
-		storage: storage,
+		log:          log,
+		storage:      storage,
+		unitOfWork:   unitOfWork,
+		statsStorage: statsStorage,
 	}
 }
 
-func (uc *UseCase) Random(ctx context.Context, _ Query) (taskID task.ID, err error) {
-	taskIDs, err := uc.storage.GetTaskIDs(ctx)
-	if err != nil {
-		uc.log.Error("failed to get task ids", slog.Any("err", err))
-		err = fmt.Errorf("failed to get task ids")
+func (uc *UseCase) Random(ctx context.Context, query Query) (taskID task.ID, err error) {
+	if err = query.validate(); err != nil {
 		return
 	}
 
-	if len(taskIDs) == 0 {
+	candidates, err := uc.candidates(ctx, query.needsTasks())
+	if err != nil {
+		return
+	}
+	if len(candidates) == 0 {
 		err = fmt.Errorf("no tasks found")
 		return
 	}
 
-	taskID = taskIDs[rand.N(len(taskIDs))]
+	candidates, err = filterCandidates(candidates, query)
+	if err != nil {
+		return
+	}
+	if query.Rating != nil {
+		candidates = closestLevel(candidates, task.LevelForRating(*query.Rating))
+	}
+
+	// the candidates are put in a stable order for the seed to pick the same task
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return strings.Compare(a.id.String(), b.id.String())
+	})
+	if query.Seed != nil {
+		taskID = candidates[rand.New(rand.NewPCG(*query.Seed, 0)).IntN(len(candidates))].id
+	} else {
+		taskID = candidates[rand.N(len(candidates))].id
+	}
 	return
 }
+
+// candidates returns the tasks to pick from; only their IDs unless the levels or the topics are needed.
+func (uc *UseCase) candidates(ctx context.Context, withTasks bool) ([]candidate, error) {
+	if !withTasks {
+		taskIDs, err := uc.storage.GetTaskIDs(ctx)
+		if err != nil {
+			uc.log.Error("failed to get task ids", slog.Any("err", err))
+			return nil, fmt.Errorf("failed to get task ids")
+		}
+
+		candidates := make([]candidate, len(taskIDs))
+		for i, id := range taskIDs {
+			candidates[i] = candidate{id: id}
+		}
+		return candidates, nil
+	}
+
+	tasks, err := uc.storage.GetList(ctx)
+	if err != nil {
+		uc.log.Error("failed to get tasks list", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get tasks list")
+	}
+
+	// without the outcomes the computed levels are the authored ones, as in the task list
+	var outcomes map[task.ID]task.Outcomes
+	if err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		outcomes, err = uc.statsStorage.GetTaskOutcomes(ctx)
+		return err
+	}); err != nil {
+		uc.log.Warn("failed to get task outcomes", slog.Any("err", err))
+		outcomes = nil
+	}
+
+	candidates := make([]candidate, len(tasks))
+	for i, t := range tasks {
+		candidates[i] = candidate{
+			id:     t.GetID(),
+			level:  outcomes[t.GetID()].ComputedLevel(t.GetLevel()),
+			topics: t.GetTopics(),
+		}
+	}
+	return candidates, nil
+}
+
+func (q Query) validate() error {
+	if q.Rating != nil && *q.Rating < 0 {
+		return fmt.Errorf("%w: rating must not be negative", ErrInvalidQuery)
+	}
+	for _, level := range []task.Level{q.MinLevel, q.MaxLevel} {
+		if level != 0 && (level < task.MinLevel || level > task.MaxLevel) {
+			return fmt.Errorf("%w: level must be in range [%d..%d]", ErrInvalidQuery, task.MinLevel, task.MaxLevel)
+		}
+	}
+	if q.MinLevel != 0 && q.MaxLevel != 0 && q.MinLevel > q.MaxLevel {
+		return fmt.Errorf("%w: min level %d is greater than max level %d", ErrInvalidQuery, q.MinLevel, q.MaxLevel)
+	}
+	return nil
+}
+
+func (q Query) needsTasks() bool {
+	return q.Rating != nil || q.MinLevel != 0 || q.MaxLevel != 0 || len(q.Topics) > 0 || len(q.ExcludedTopics) > 0
+}
+
+// filterCandidates applies the constraints one by one, so that the error names the first one
+// that left no task.
+func filterCandidates(candidates []candidate, q Query) ([]candidate, error) {
+	seen := make(map[task.ID]bool, len(q.Seen))
+	for _, id := range q.Seen {
+		seen[id] = true
+	}
+	total := len(candidates)
+	candidates = slices.DeleteFunc(candidates, func(c candidate) bool {
+		return seen[c.id]
+	})
+	if len(candidates) == 0 {
+		return nil, &ConstraintError{
+			Constraint: ConstraintSeen,
+			Message:    fmt.Sprintf("all %d tasks have been seen", total),
+		}
+	}
+
+	if q.MinLevel != 0 || q.MaxLevel != 0 {
+		minLevel, maxLevel := max(q.MinLevel, task.MinLevel), q.MaxLevel
+		if maxLevel == 0 {
+			maxLevel = task.MaxLevel
+		}
+		left := len(candidates)
+		candidates = slices.DeleteFunc(candidates, func(c candidate) bool {
+			return c.level < minLevel || c.level > maxLevel
+		})
+		if len(candidates) == 0 {
+			return nil, &ConstraintError{
+				Constraint: ConstraintLevel,
+				Message:    fmt.Sprintf("none of the %d tasks left has level in range [%d..%d]", left, minLevel, maxLevel),
+			}
+		}
+	}
+
+	if len(q.Topics) > 0 {
+		left := len(candidates)
+		candidates = slices.DeleteFunc(candidates, func(c candidate) bool {
+			return !hasAnyTopic(c, q.Topics)
+		})
+		if len(candidates) == 0 {
+			return nil, &ConstraintError{
+				Constraint: ConstraintTopics,
+				Message:    fmt.Sprintf("none of the %d tasks left has any of topics %q", left, q.Topics),
+			}
+		}
+	}
+
+	if len(q.ExcludedTopics) > 0 {
+		left := len(candidates)
+		candidates = slices.DeleteFunc(candidates, func(c candidate) bool {
+			return hasAnyTopic(c, q.ExcludedTopics)
+		})
+		if len(candidates) == 0 {
+			return nil, &ConstraintError{
+				Constraint: ConstraintExcludedTopics,
+				Message:    fmt.Sprintf("all of the %d tasks left have some of excluded topics %q", left, q.ExcludedTopics),
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+func hasAnyTopic(c candidate, topics []string) bool {
+	for _, topic := range c.topics {
+		if slices.Contains(topics, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestLevel returns the candidates whose level is the closest to the target.
+func closestLevel(candidates []candidate, target task.Level) []candidate {
+	distance := func(c candidate) task.Level {
+		if c.level > target {
+			return c.level - target
+		}
+		return target - c.level
+	}
+
+	best := distance(slices.MinFunc(candidates, func(a, b candidate) int {
+		return int(distance(a) - distance(b))
+	}))
+	return slices.DeleteFunc(candidates, func(c candidate) bool {
+		return distance(c) != best
+	})
+}
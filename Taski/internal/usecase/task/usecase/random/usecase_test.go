@@ -0,0 +1,152 @@
+package random
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"taski/internal/domain/task"
+	"taski/internal/domain/task/tasks"
+)
+
+type stubStorage struct {
+	tasks []task.Task
+}
+
+func (s stubStorage) GetTaskIDs(context.Context) ([]task.ID, error) {
+	ids := make([]task.ID, len(s.tasks))
+	for i, t := range s.tasks {
+		ids[i] = t.GetID()
+	}
+	return ids, nil
+}
+
+func (s stubStorage) GetList(context.Context) ([]task.Task, error) {
+	return s.tasks, nil
+}
+
+type stubUnitOfWork struct{}
+
+func (stubUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type stubStatsStorage map[task.ID]task.Outcomes
+
+func (s stubStatsStorage) GetTaskOutcomes(context.Context) (map[task.ID]task.Outcomes, error) {
+	return s, nil
+}
+
+func taskID(t *testing.T, c byte) task.ID {
+	t.Helper()
+
+	var id task.ID
+	if err := id.FromString(strings.Repeat(string(c), len(id))); err != nil {
+		t.Fatalf("FromString() returned error: %v", err)
+	}
+	return id
+}
+
+func newTestUseCase(t *testing.T) *UseCase {
+	t.Helper()
+
+	newTask := func(c byte, level task.Level, topics ...string) task.Task {
+		return &tasks.WriteCodeTask{Details: task.Details{
+			ID:     taskID(t, c),
+			Type:   task.WriteCode,
+			Level:  level,
+			Topics: topics,
+		}}
+	}
+	storage := stubStorage{tasks: []task.Task{
+		newTask('a', 2, "greedy"),
+		newTask('b', 5, "graphs", "dp"),
+		newTask('c', 5, "dp"),
+		newTask('d', 8, "graphs"),
+	}}
+	// d is solved by nearly everyone, so its computed level is 1
+	outcomes := stubStatsStorage{taskID(t, 'd'): {Attempts: 200, Accepted: 200}}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewUseCase(log, storage, stubUnitOfWork{}, outcomes)
+}
+
+func TestRandomMeetsConstraints(t *testing.T) {
+	t.Parallel()
+
+	uc := newTestUseCase(t)
+	rating := 1500
+	tests := []struct {
+		name  string
+		query Query
+		want  []byte
+	}{
+		{name: "level range", query: Query{MinLevel: 4, MaxLevel: 6, Topics: []string{"graphs"}}, want: []byte{'b'}},
+		{name: "computed level", query: Query{MaxLevel: 1}, want: []byte{'d'}},
+		{name: "rating", query: Query{Rating: &rating, Seen: []task.ID{taskID(t, 'c')}}, want: []byte{'b'}},
+		{name: "excluded topics", query: Query{ExcludedTopics: []string{"dp", "graphs"}}, want: []byte{'a'}},
+		{name: "seen", query: Query{Seen: []task.ID{taskID(t, 'a'), taskID(t, 'b')}}, want: []byte{'c', 'd'}},
+	}
+
+	for _, tt := range tests {
+		for range 10 {
+			got, err := uc.Random(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("%s: Random() returned error: %v", tt.name, err)
+			}
+			if !strings.ContainsRune(string(tt.want), rune(got.String()[0])) {
+				t.Fatalf("%s: Random() = %s, want one of %q", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestRandomNamesUnmetConstraint(t *testing.T) {
+	t.Parallel()
+
+	uc := newTestUseCase(t)
+	tests := []struct {
+		query Query
+		want  string
+	}{
+		{query: Query{Seen: []task.ID{taskID(t, 'a'), taskID(t, 'b'), taskID(t, 'c'), taskID(t, 'd')}}, want: ConstraintSeen},
+		{query: Query{MinLevel: 9}, want: ConstraintLevel},
+		{query: Query{MinLevel: 5, Topics: []string{"greedy"}}, want: ConstraintTopics},
+		{query: Query{Topics: []string{"dp"}, ExcludedTopics: []string{"dp"}}, want: ConstraintExcludedTopics},
+	}
+
+	for _, tt := range tests {
+		_, err := uc.Random(context.Background(), tt.query)
+		var constraintErr *ConstraintError
+		if !errors.As(err, &constraintErr) || constraintErr.Constraint != tt.want {
+			t.Fatalf("Random(%+v) error = %v, want unmet constraint %s", tt.query, err, tt.want)
+		}
+	}
+
+	if _, err := uc.Random(context.Background(), Query{MinLevel: 6, MaxLevel: 3}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("Random() with an empty level range error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestRandomIsReproducibleWithSeed(t *testing.T) {
+	t.Parallel()
+
+	uc := newTestUseCase(t)
+	seed := uint64(42)
+	first, err := uc.Random(context.Background(), Query{Seed: &seed})
+	if err != nil {
+		t.Fatalf("Random() returned error: %v", err)
+	}
+	for range 10 {
+		got, err := uc.Random(context.Background(), Query{Seed: &seed})
+		if err != nil {
+			t.Fatalf("Random() returned error: %v", err)
+		}
+		if got != first {
+			t.Fatalf("Random() with the same seed = %s, then %s", first, got)
+		}
+	}
+}
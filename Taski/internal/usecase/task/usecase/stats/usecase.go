@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"taski/internal/domain/task"
+	"taski/internal/domain/testing"
+	"taski/internal/usecase/task/dto"
+)
+
+type (
+	Query struct {
+		TaskID task.ID
+	}
+
+	UseCase struct {
+		log          *slog.Logger
+		storage      taskStorage
+		unitOfWork   unitOfWork
+		statsStorage statsStorage
+	}
+
+	taskStorage interface {
+		Get(context.Context, task.ID) (t task.Task, unlock func(), err error)
+	}
+
+	unitOfWork interface {
+		Do(context.Context, func(ctx context.Context) error) error
+	}
+
+	statsStorage interface {
+		Get(context.Context, task.ID) (testing.TaskStats, error)
+	}
+)
+
+func NewUseCase(log *slog.Logger, storage taskStorage, unitOfWork unitOfWork, statsStorage statsStorage) *UseCase {
+	return &UseCase{
+		log:          log,
+		storage:      storage,
+		unitOfWork:   unitOfWork,
+		statsStorage: statsStorage,
+	}
+}
+
+// Get returns the statistics of the task, kept up to date as the verdicts of its solutions arrive.
+func (uc *UseCase) Get(ctx context.Context, query Query) (dto.TaskStatsDto, error) {
+	_, unlock, err := uc.storage.Get(ctx, query.TaskID)
+	if err != nil {
+		if errors.Is(err, task.ErrNotFound) {
+			return dto.TaskStatsDto{}, err
+		}
+		uc.log.Error("failed to get task from storage", slog.Any("err", err))
+		return dto.TaskStatsDto{}, fmt.Errorf("failed to get task from storage")
+	}
+	unlock()
+
+	var stats testing.TaskStats
+	if err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		stats, err = uc.statsStorage.Get(ctx, query.TaskID)
+		return err
+	}); err != nil {
+		uc.log.Error("failed to get task stats", slog.Any("err", err))
+		return dto.TaskStatsDto{}, fmt.Errorf("failed to get task stats")
+	}
+
+	return dto.ConvertTaskStats(query.TaskID, stats), nil
+}
@@ -3,8 +3,11 @@ package upload
 import (
 	"context"
 	"log/slog"
+	"taski/internal/api/testing/execute"
 	"taski/internal/domain/task"
 	"taski/internal/uploader"
+	"taski/internal/uploader/icpc"
+	"taski/internal/uploader/native"
 	"taski/internal/uploader/polygon"
 
 	fs "github.com/DIvanCode/filestorage/pkg/filestorage"
@@ -12,6 +15,9 @@ import (
 
 const (
 	FormatPolygon = uploader.FormatPolygon
+	FormatTaski   = uploader.FormatTaski
+	FormatICPC    = uploader.FormatICPC
+	FormatKattis  = uploader.FormatKattis
 )
 
 type UseCase struct {
@@ -23,12 +29,20 @@ type Command struct {
 	Format  string
 	SrcPath string
 	Level   int
+	Topics  []string
 }
 
-func NewUseCase(log *slog.Logger, fileStorage fs.FileStorage) *UseCase {
+// NewUseCase returns the upload use case with the importers of every format, topics map the tags
+// of the packages that have them onto the task topics.
+func NewUseCase(log *slog.Logger, fileStorage fs.FileStorage, executeClient *execute.ExecuteClient, topics uploader.Topics) *UseCase {
 	return &UseCase{
-		log:        log,
-		dispatcher: uploader.NewDispatcher(polygon.NewUploader(fileStorage, log)),
+		log: log,
+		dispatcher: uploader.NewDispatcher(
+			polygon.NewUploader(fileStorage, executeClient, topics, log),
+			native.NewUploader(fileStorage, log),
+			icpc.NewUploader(fileStorage, log),
+			icpc.NewKattisUploader(fileStorage, log),
+		),
 	}
 }
 
@@ -37,12 +51,14 @@ func (uc *UseCase) Upload(ctx context.Context, command Command) (task.ID, error)
 		Format:  command.Format,
 		SrcPath: command.SrcPath,
 		Level:   command.Level,
+		Topics:  command.Topics,
 	})
 	if err != nil {
 		return task.ID{}, err
 	}
 
-	uc.log.Info("polygon package converted",
+	uc.log.Info("task package uploaded",
+		slog.String("format", command.Format),
 		slog.String("task_id", taskID.String()),
 	)
 
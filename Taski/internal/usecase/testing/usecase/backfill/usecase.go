@@ -0,0 +1,125 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"taski/internal/domain/testing"
+)
+
+const defaultBatchSize = 1000
+
+type (
+	Command struct {
+		// BatchSize is how many solutions are read at once, defaultBatchSize when not positive.
+		BatchSize int
+	}
+
+	// Report counts the solutions the statistics were rebuilt from.
+	Report struct {
+		Solutions int
+		Finished  int
+	}
+
+	UseCase struct {
+		log             *slog.Logger
+		unitOfWork      unitOfWork
+		solutionStorage solutionStorage
+		statsStorage    statsStorage
+	}
+
+	unitOfWork interface {
+		Do(context.Context, func(ctx context.Context) error) error
+		DoRepeatableRead(context.Context, func(ctx context.Context) error) error
+	}
+
+	solutionStorage interface {
+		GetBatch(ctx context.Context, afterID int64, limit int) ([]testing.Solution, error)
+	}
+
+	statsStorage interface {
+		StartRebuild(context.Context) error
+		AddRebuilt(context.Context, testing.StatsCounts) error
+		ApplyRebuild(context.Context) error
+	}
+)
+
+func NewUseCase(
+	log *slog.Logger,
+	unitOfWork unitOfWork,
+	solutionStorage solutionStorage,
+	statsStorage statsStorage,
+) *UseCase {
+	return &UseCase{
+		log:             log,
+		unitOfWork:      unitOfWork,
+		solutionStorage: solutionStorage,
+		statsStorage:    statsStorage,
+	}
+}
+
+// Backfill rebuilds the task statistics from every solution without holding the statistics locked.
+// The solutions are read in one snapshot and summed into a rebuild, less the statistics of that snapshot;
+// a short transaction then adds the rebuild to the statistics. A solution created or finished meanwhile
+// is counted exactly once: by the backfill when it was committed before the snapshot, by itself otherwise.
+func (uc *UseCase) Backfill(ctx context.Context, command Command) (report Report, err error) {
+	batchSize := command.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	err = uc.unitOfWork.DoRepeatableRead(ctx, func(ctx context.Context) error {
+		report = Report{}
+		if err := uc.statsStorage.StartRebuild(ctx); err != nil {
+			return fmt.Errorf("failed to start task stats rebuild: %w", err)
+		}
+
+		counts := testing.NewStatsCounts()
+		var afterID int64
+		for {
+			solutions, err := uc.solutionStorage.GetBatch(ctx, afterID, batchSize)
+			if err != nil {
+				return fmt.Errorf("failed to get solutions after %d: %w", afterID, err)
+			}
+
+			for _, sol := range solutions {
+				add(counts, &sol, &report)
+				afterID = sol.ID
+			}
+			uc.log.Info("backfilled task stats",
+				slog.Int("solutions", report.Solutions),
+				slog.Int64("last_solution_id", afterID))
+
+			if len(solutions) < batchSize {
+				break
+			}
+		}
+
+		if err := uc.statsStorage.AddRebuilt(ctx, counts); err != nil {
+			return fmt.Errorf("failed to add task stats rebuild: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.statsStorage.ApplyRebuild(ctx); err != nil {
+			return fmt.Errorf("failed to apply task stats rebuild: %w", err)
+		}
+		return nil
+	})
+	return
+}
+
+func add(counts testing.StatsCounts, sol *testing.Solution, report *Report) {
+	counts.AddCreated(sol.TaskID, sol.Lang)
+	report.Solutions++
+
+	if sol.FinishedAt == nil {
+		return
+	}
+	counts.Add(sol.Result())
+	report.Finished++
+}
@@ -0,0 +1,158 @@
+package backfill
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"taski/internal/domain/task"
+	domain "taski/internal/domain/testing"
+	"taski/internal/domain/testing/job"
+	"taski/internal/domain/testing/strategy"
+	"taski/internal/domain/testing/strategy/strategies"
+)
+
+type stubSolutionStorage struct {
+	solutions []domain.Solution
+	batches   int
+}
+
+func (s *stubSolutionStorage) GetBatch(_ context.Context, afterID int64, limit int) ([]domain.Solution, error) {
+	s.batches++
+
+	var batch []domain.Solution
+	for _, sol := range s.solutions {
+		if sol.ID > afterID && len(batch) < limit {
+			batch = append(batch, sol)
+		}
+	}
+	return batch, nil
+}
+
+// stubStatsStorage keeps the rebuild and tells the transactions it was built and applied in.
+type stubStatsStorage struct {
+	starts  int
+	rebuilt domain.StatsCounts
+	applied int
+	calls   []string
+}
+
+func (s *stubStatsStorage) StartRebuild(ctx context.Context) error {
+	s.starts++
+	s.rebuilt = domain.NewStatsCounts()
+	s.calls = append(s.calls, "start in "+txKind(ctx))
+	return nil
+}
+
+func (s *stubStatsStorage) AddRebuilt(ctx context.Context, counts domain.StatsCounts) error {
+	s.rebuilt = counts
+	s.calls = append(s.calls, "add in "+txKind(ctx))
+	return nil
+}
+
+func (s *stubStatsStorage) ApplyRebuild(ctx context.Context) error {
+	s.applied++
+	s.calls = append(s.calls, "apply in "+txKind(ctx))
+	return nil
+}
+
+type txKindKey struct{}
+
+func txKind(ctx context.Context) string {
+	kind, _ := ctx.Value(txKindKey{}).(string)
+	return kind
+}
+
+type stubUnitOfWork struct{}
+
+func (stubUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txKindKey{}, "read committed"))
+}
+
+func (stubUnitOfWork) DoRepeatableRead(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txKindKey{}, "repeatable read"))
+}
+
+func newSolution(t *testing.T, id int64, lang task.Language, verdict *string) domain.Solution {
+	t.Helper()
+
+	var taskID task.ID
+	if err := taskID.FromString(strings.Repeat("a", len(taskID))); err != nil {
+		t.Fatalf("FromString() returned error: %v", err)
+	}
+	sol := domain.Solution{
+		ID:     id,
+		TaskID: taskID,
+		Lang:   lang,
+		TestingStrategy: strategies.TestingStrategy{ITestingStrategy: &strategies.WriteCodeTaskTestingStrategy{
+			Details:    strategy.Details{Verdict: verdict},
+			TestsCount: 2,
+			TestStatus: map[int]job.Status{1: job.StatusOK, 2: job.StatusWA},
+		}},
+	}
+	if verdict != nil {
+		started := time.Now()
+		finished := started.Add(time.Second)
+		sol.StartedAt, sol.FinishedAt = &started, &finished
+	}
+	return sol
+}
+
+func TestBackfillCountsEverySolutionOnce(t *testing.T) {
+	t.Parallel()
+
+	accepted, wrongAnswer := strategy.AcceptedVerdict, "Wrong Answer on test 2"
+	solutions := &stubSolutionStorage{solutions: []domain.Solution{
+		newSolution(t, 1, task.LanguageCpp, &accepted),
+		newSolution(t, 2, task.LanguageCpp, &wrongAnswer),
+		newSolution(t, 4, task.LanguagePython, nil),
+		newSolution(t, 7, task.LanguagePython, &accepted),
+	}}
+	stats := &stubStatsStorage{}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	uc := NewUseCase(log, stubUnitOfWork{}, solutions, stats)
+	report, err := uc.Backfill(context.Background(), Command{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Backfill() returned error: %v", err)
+	}
+
+	if want := (Report{Solutions: 4, Finished: 3}); report != want {
+		t.Fatalf("Backfill() = %+v, want %+v", report, want)
+	}
+	if stats.starts != 1 || stats.applied != 1 || solutions.batches != 3 {
+		t.Fatalf("Backfill() started %d and applied %d rebuilds in %d batches, want one in 3", stats.starts, stats.applied, solutions.batches)
+	}
+	wantCalls := []string{"start in repeatable read", "add in repeatable read", "apply in read committed"}
+	if !reflect.DeepEqual(stats.calls, wantCalls) {
+		t.Fatalf("Backfill() calls = %v, want %v", stats.calls, wantCalls)
+	}
+
+	taskID := solutions.solutions[0].TaskID
+	wantCreated := map[domain.LanguageKey]int{
+		{TaskID: taskID, Lang: task.LanguageCpp}:    2,
+		{TaskID: taskID, Lang: task.LanguagePython}: 2,
+	}
+	if !reflect.DeepEqual(stats.rebuilt.Created, wantCreated) {
+		t.Fatalf("Backfill() created = %v, want %v", stats.rebuilt.Created, wantCreated)
+	}
+	wantVerdicts := map[domain.VerdictKey]domain.VerdictCounts{
+		{TaskID: taskID, Lang: task.LanguageCpp, Verdict: strategy.AcceptedVerdict}:    {Solutions: 1, TimedSolutions: 1, ProcessTime: time.Second},
+		{TaskID: taskID, Lang: task.LanguageCpp, Verdict: strategy.WrongAnswerVerdict}: {Solutions: 1, TimedSolutions: 1, ProcessTime: time.Second},
+		{TaskID: taskID, Lang: task.LanguagePython, Verdict: strategy.AcceptedVerdict}: {Solutions: 1, TimedSolutions: 1, ProcessTime: time.Second},
+	}
+	if !reflect.DeepEqual(stats.rebuilt.Verdicts, wantVerdicts) {
+		t.Fatalf("Backfill() verdicts = %v, want %v", stats.rebuilt.Verdicts, wantVerdicts)
+	}
+	if want := map[domain.FailedTestKey]int{{TaskID: taskID, TestID: 2}: 1}; !reflect.DeepEqual(stats.rebuilt.FailedTests, want) {
+		t.Fatalf("Backfill() failed tests = %v, want %v", stats.rebuilt.FailedTests, want)
+	}
+	wantSolveTimes := map[domain.SolveTimeKey]int{{TaskID: taskID, Bucket: domain.SolveTimeBucket(time.Second)}: 2}
+	if !reflect.DeepEqual(stats.rebuilt.SolveTimes, wantSolveTimes) {
+		t.Fatalf("Backfill() solve times = %v, want %v", stats.rebuilt.SolveTimes, wantSolveTimes)
+	}
+}
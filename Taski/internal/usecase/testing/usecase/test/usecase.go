@@ -24,6 +24,7 @@ type (
 		taskStorage          taskStorage
 		unitOfWork           unitOfWork
 		solutionStorage      solutionStorage
+		statsStorage         statsStorage
 		executeClient        executeClient
 		downloadTaskEndpoint string
 	}
@@ -40,6 +41,10 @@ type (
 		Create(context.Context, testing.Solution) error
 	}
 
+	statsStorage interface {
+		AddCreated(context.Context, task.ID, task.Language) error
+	}
+
 	executeClient interface {
 		Execute(context.Context, execution.Stages, sources.Sources) (execution.ID, error)
 	}
@@ -50,6 +55,7 @@ func NewUseCase(
 	storage taskStorage,
 	unitOfWork unitOfWork,
 	solutionStorage solutionStorage,
+	statsStorage statsStorage,
 	executeClient executeClient,
 	downloadTaskEndpoint string,
 ) *UseCase {
@@ -58,6 +64,7 @@ func NewUseCase(
 		taskStorage:          storage,
 		unitOfWork:           unitOfWork,
 		solutionStorage:      solutionStorage,
+		statsStorage:         statsStorage,
 		executeClient:        executeClient,
 		downloadTaskEndpoint: downloadTaskEndpoint,
 	}
@@ -98,6 +105,12 @@ func (uc *UseCase) Test(ctx context.Context, command Command) error {
 				slog.Any("err", err))
 			return fmt.Errorf("failed to save solution to storage: %w", err)
 		}
+		if err := uc.statsStorage.AddCreated(ctx, sol.TaskID, sol.Lang); err != nil {
+			uc.log.Error("failed to count created solution",
+				slog.String("external_id", string(sol.ExternalID)),
+				slog.Any("err", err))
+			return fmt.Errorf("failed to count created solution: %w", err)
+		}
 
 		return nil
 	})
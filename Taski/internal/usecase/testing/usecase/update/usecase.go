@@ -26,6 +26,7 @@ type (
 
 		unitOfWork      unitOfWork
 		solutionStorage solutionStorage
+		statsStorage    statsStorage
 
 		messageDispatcher messageDispatcher
 	}
@@ -39,6 +40,10 @@ type (
 		Update(context.Context, testing.Solution) error
 	}
 
+	statsStorage interface {
+		Add(context.Context, testing.Result) error
+	}
+
 	messageDispatcher interface {
 		Send(ctx context.Context, msg messages.Message) error
 	}
@@ -47,6 +52,7 @@ type (
 func NewUseCase(
 	log *slog.Logger,
 	solutionStorage solutionStorage,
+	statsStorage statsStorage,
 	unitOfWork unitOfWork,
 	messageDispatcher messageDispatcher,
 ) *UseCase {
@@ -55,6 +61,7 @@ func NewUseCase(
 
 		unitOfWork:      unitOfWork,
 		solutionStorage: solutionStorage,
+		statsStorage:    statsStorage,
 
 		messageDispatcher: messageDispatcher,
 	}
@@ -75,10 +82,17 @@ func (uc *UseCase) Update(ctx context.Context, command Command) error {
 			}
 		}
 
+		wasFinished := sol.FinishedAt != nil
 		msg, hasMsg, err := uc.handleExecutionEvent(&sol, command.Event, time.Now())
 		if err != nil {
 			return err
 		}
+		// a solution finishes once, so its result is added to the task statistics once
+		if !wasFinished && sol.FinishedAt != nil {
+			if err = uc.statsStorage.Add(ctx, sol.Result()); err != nil {
+				return fmt.Errorf("failed to add solution result to task stats: %w", err)
+			}
+		}
 		if hasMsg {
 			if err = uc.messageDispatcher.Send(ctx, msg); err != nil {
 				return fmt.Errorf("failed to dispatch message: %w", err)
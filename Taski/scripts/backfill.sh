@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+
+if [[ $# -gt 1 ]]; then
+  echo "usage: $0 [batch_size]" >&2
+  exit 1
+fi
+
+BATCH_SIZE="${1:-1000}"
+
+go -C "$SCRIPT_DIR/.." run ./cmd/backfill \
+  -batch-size "$BATCH_SIZE"
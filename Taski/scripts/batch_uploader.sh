@@ -0,0 +1,43 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+
+if [[ $# -lt 3 || $# -gt 4 ]]; then
+  echo "usage: $0 <format> <packages_dir|manifest> <level> [report.json]" >&2
+  exit 1
+fi
+
+FORMAT="$1"
+BATCH_PATH="$2"
+LEVEL="$3"
+REPORT="${4:-$PWD/upload-report.json}"
+WORKERS="${WORKERS:-4}"
+
+if [[ -z "$FORMAT" ]]; then
+  echo "format must be non-empty" >&2
+  exit 1
+fi
+
+if [[ ! -d "$BATCH_PATH" && ! -f "$BATCH_PATH" ]]; then
+  echo "batch path does not exist: $BATCH_PATH" >&2
+  exit 1
+fi
+
+if ! [[ "$LEVEL" =~ ^[0-9]+$ ]] || (( LEVEL < 1 || LEVEL > 10 )); then
+  echo "level must be an integer in range [1..10], got: $LEVEL" >&2
+  exit 1
+fi
+
+if ! [[ "$WORKERS" =~ ^[0-9]+$ ]] || (( WORKERS < 1 )); then
+  echo "WORKERS must be a positive integer, got: $WORKERS" >&2
+  exit 1
+fi
+
+CONFIG_PATH="$SCRIPT_DIR/uploader_config.yml" FILESTORAGE_ROOT_DIR="$SCRIPT_DIR/../tasks" \
+  go -C "$SCRIPT_DIR/.." run ./cmd/uploader \
+    -format "$FORMAT" \
+    -batch "$(realpath "$BATCH_PATH")" \
+    -level "$LEVEL" \
+    -workers "$WORKERS" \
+    -report "$(realpath -m "$REPORT")"
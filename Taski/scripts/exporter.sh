@@ -0,0 +1,22 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+
+if [[ $# -lt 1 || $# -gt 2 ]]; then
+  echo "usage: $0 <task_id> [out.zip]" >&2
+  exit 1
+fi
+
+TASK_ID="$1"
+OUT="${2:-$PWD/$TASK_ID.zip}"
+
+if ! [[ "$TASK_ID" =~ ^[0-9a-f]{40}$ ]]; then
+  echo "task id must be 40 lowercase hex characters, got: $TASK_ID" >&2
+  exit 1
+fi
+
+CONFIG_PATH="$SCRIPT_DIR/uploader_config.yml" FILESTORAGE_ROOT_DIR="$SCRIPT_DIR/../tasks" \
+  go -C "$SCRIPT_DIR/.." run ./cmd/exporter \
+    -id "$TASK_ID" \
+    -out "$OUT"